@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/api/middleware"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/internal/core/ports"
+	"github.com/theblitlabs/parity-server/internal/core/services"
+)
+
+type ArtifactHandler struct {
+	taskService     *services.TaskService
+	artifactService *services.ArtifactService
+}
+
+func NewArtifactHandler(taskService *services.TaskService, artifactService *services.ArtifactService) *ArtifactHandler {
+	return &ArtifactHandler{
+		taskService:     taskService,
+		artifactService: artifactService,
+	}
+}
+
+type startArtifactUploadRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type"`
+}
+
+// runnerTask returns the task in the path if it is running on the calling
+// runner, which is the only time the runner may upload artifacts for it.
+func (h *ArtifactHandler) runnerTask(c *gin.Context) (*models.Task, string, bool) {
+	deviceID := c.GetHeader("X-Device-ID")
+	if deviceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Device ID is required"})
+		return nil, "", false
+	}
+
+	task, err := h.taskService.GetTask(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, services.ErrTaskNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			return nil, "", false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, "", false
+	}
+
+	if task.RunnerID != deviceID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "task is assigned to a different runner"})
+		return nil, "", false
+	}
+	if task.Status != models.TaskStatusRunning {
+		c.JSON(http.StatusConflict, gin.H{"error": "artifacts can only be uploaded while the task is running"})
+		return nil, "", false
+	}
+
+	return task, deviceID, true
+}
+
+func (h *ArtifactHandler) uploadResponse(c *gin.Context, status int, upload *models.ArtifactUpload) {
+	c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	c.JSON(status, upload)
+}
+
+func artifactErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidArtifact):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrUploadNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrUploadOffsetMismatch), errors.Is(err, services.ErrTooManyArtifacts):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrArtifactTooLarge):
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// StartUpload announces an artifact the runner is about to upload. Announcing
+// the same name and size again returns the unfinished upload to resume.
+func (h *ArtifactHandler) StartUpload(c *gin.Context) {
+	task, deviceID, ok := h.runnerTask(c)
+	if !ok {
+		return
+	}
+
+	var req startArtifactUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	upload, err := h.artifactService.StartUpload(c.Request.Context(), task.ID, deviceID, req.Name, req.ContentType, req.Size)
+	if err != nil {
+		c.JSON(artifactErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.uploadResponse(c, http.StatusCreated, upload)
+}
+
+// GetUpload reports how many bytes of an upload the server has, so a runner
+// knows where to resume.
+func (h *ArtifactHandler) GetUpload(c *gin.Context) {
+	task, deviceID, ok := h.runnerTask(c)
+	if !ok {
+		return
+	}
+
+	uploadID, err := uuid.Parse(c.Param("upload_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": services.ErrUploadNotFound.Error()})
+		return
+	}
+
+	upload, err := h.artifactService.GetUpload(c.Request.Context(), task.ID, uploadID, deviceID)
+	if err != nil {
+		c.JSON(artifactErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.uploadResponse(c, http.StatusOK, upload)
+}
+
+// AppendUpload appends the request body to an upload at the offset given in
+// the Upload-Offset header. A mismatched offset is rejected with the server's
+// offset so the runner can continue from there.
+func (h *ArtifactHandler) AppendUpload(c *gin.Context) {
+	task, deviceID, ok := h.runnerTask(c)
+	if !ok {
+		return
+	}
+
+	uploadID, err := uuid.Parse(c.Param("upload_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": services.ErrUploadNotFound.Error()})
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Offset header must be a non-negative integer"})
+		return
+	}
+
+	upload, artifact, err := h.artifactService.AppendUpload(c.Request.Context(), task.ID, uploadID, deviceID, offset, c.Request.Body)
+	if err != nil {
+		if upload != nil {
+			c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+		}
+		c.JSON(artifactErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if artifact != nil {
+		c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+		c.JSON(http.StatusOK, gin.H{"upload": upload, "artifact": artifact})
+		return
+	}
+	h.uploadResponse(c, http.StatusOK, upload)
+}
+
+// Download streams an artifact of a task's result to the task's creator.
+func (h *ArtifactHandler) Download(c *gin.Context) {
+	taskID := c.Param("id")
+	name := c.Param("name")
+
+	task, err := h.taskService.GetTask(c.Request.Context(), taskID)
+	if err != nil {
+		if errors.Is(err, services.ErrTaskNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if principal := middleware.PrincipalFrom(c); principal != nil && !principal.Owns(task.CreatorAddress) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "task belongs to another creator"})
+		return
+	}
+
+	result, err := h.taskService.GetTaskResult(c.Request.Context(), taskID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var artifact *models.TaskArtifact
+	if result != nil {
+		artifact = result.Artifacts.Find(name)
+	}
+	if artifact == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "artifact not found"})
+		return
+	}
+
+	reader, err := h.artifactService.Open(c.Request.Context(), artifact)
+	if errors.Is(err, ports.ErrObjectNotFound) {
+		c.JSON(http.StatusGone, gin.H{"error": "artifact is no longer stored"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Content-Type", artifact.ContentType)
+	c.Header("Content-Length", strconv.FormatInt(artifact.Size, 10))
+	c.Header("Content-Disposition", `attachment; filename="`+artifact.Name+`"`)
+	c.Header("ETag", `"`+artifact.Digest+`"`)
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, reader); err != nil {
+		log := gologger.WithComponent("artifacts")
+		log.Warn().Err(err).
+			Str("task_id", taskID).
+			Str("artifact", artifact.Name).
+			Msg("Artifact download interrupted")
+	}
+}
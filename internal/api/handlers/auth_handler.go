@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/api/middleware"
+	"github.com/theblitlabs/parity-server/internal/api/models"
+	coremodels "github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/internal/core/services"
+)
+
+type AuthHandler struct {
+	authService *services.AuthService
+	enforce     bool
+}
+
+func NewAuthHandler(authService *services.AuthService, enforce bool) *AuthHandler {
+	return &AuthHandler{
+		authService: authService,
+		enforce:     enforce,
+	}
+}
+
+// Middleware authenticates creators, LLM clients and admins.
+func (h *AuthHandler) Middleware() gin.HandlerFunc {
+	return middleware.Authenticate(h.authService, h.enforce)
+}
+
+func (h *AuthHandler) CreateChallenge(c *gin.Context) {
+	var req models.AuthChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	challenge, err := h.authService.CreateChallenge(c.Request.Context(), req.WalletAddress)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"challenge_id": challenge.ID,
+		"message":      challenge.Message,
+		"expires_at":   challenge.ExpiresAt,
+	})
+}
+
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req models.AuthLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	tokens, err := h.authService.Login(c.Request.Context(), req.ChallengeID, req.Signature)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidChallenge) || errors.Is(err, services.ErrInvalidSignature) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *AuthHandler) Logout(c *gin.Context) {
+	token := middleware.BearerToken(c)
+	if token == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	if err := h.authService.Logout(c.Request.Context(), token); err != nil {
+		if errors.Is(err, services.ErrInvalidCredentials) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Signed out"})
+}
+
+func (h *AuthHandler) Me(c *gin.Context) {
+	principal := middleware.PrincipalFrom(c)
+	if principal == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	c.JSON(http.StatusOK, principal)
+}
+
+func (h *AuthHandler) CreateAPIKey(c *gin.Context) {
+	log := gologger.WithComponent("auth_handler")
+
+	principal := middleware.PrincipalFrom(c)
+	if principal == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+	if principal.APIKeyID != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API keys cannot create other API keys"})
+		return
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	ttl := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	created, err := h.authService.CreateAPIKey(c.Request.Context(), principal, req.Name, coremodels.RoleList(req.Roles), ttl)
+	if err != nil {
+		if errors.Is(err, services.ErrRoleNotGranted) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error().Err(err).Str("account_id", principal.AccountID.String()).Msg("Failed to create API key")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *AuthHandler) ListAPIKeys(c *gin.Context) {
+	principal := middleware.PrincipalFrom(c)
+	if principal == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	keys, err := h.authService.ListAPIKeys(c.Request.Context(), principal)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+func (h *AuthHandler) RevokeAPIKey(c *gin.Context) {
+	principal := middleware.PrincipalFrom(c)
+	if principal == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+
+	if err := h.authService.RevokeAPIKey(c.Request.Context(), principal, keyID); err != nil {
+		if errors.Is(err, services.ErrAPIKeyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
+
+func (h *AuthHandler) SetAccountRoles(c *gin.Context) {
+	var req models.SetAccountRolesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	account, err := h.authService.SetAccountRoles(c.Request.Context(), c.Param("wallet"), coremodels.RoleList(req.Roles))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
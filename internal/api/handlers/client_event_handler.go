@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/theblitlabs/parity-server/internal/api/middleware"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/internal/core/services"
+)
+
+// clientEventKeepAlive is how often an idle event stream sends an SSE
+// comment, so proxies do not close it.
+const clientEventKeepAlive = 15 * time.Second
+
+type ClientEventHandler struct {
+	eventBus *services.EventBus
+}
+
+func NewClientEventHandler(eventBus *services.EventBus) *ClientEventHandler {
+	return &ClientEventHandler{eventBus: eventBus}
+}
+
+// Stream sends the caller's task, prompt and FL session state changes as
+// Server-Sent Events. kinds and ids narrow the stream; a client resumes a
+// dropped stream by sending Last-Event-ID.
+func (h *ClientEventHandler) Stream(c *gin.Context) {
+	principal := middleware.PrincipalFrom(c)
+	if principal == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	filter := models.ClientEventFilter{Principal: principal}
+	if kinds := c.Query("kinds"); kinds != "" {
+		filter.Kinds = make(map[models.ClientEventKind]bool)
+		for _, kind := range strings.Split(kinds, ",") {
+			kind := models.ClientEventKind(strings.TrimSpace(kind))
+			if !kind.Valid() {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown event kind %q", kind)})
+				return
+			}
+			filter.Kinds[kind] = true
+		}
+	}
+	if ids := c.Query("ids"); ids != "" {
+		filter.ResourceIDs = make(map[string]bool)
+		for _, id := range strings.Split(ids, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				filter.ResourceIDs[id] = true
+			}
+		}
+	}
+
+	after := c.Query("after")
+	if after == "" {
+		after = c.GetHeader("Last-Event-ID")
+	}
+	var afterID int64
+	if after != "" {
+		var err error
+		if afterID, err = strconv.ParseInt(after, 10, 64); err != nil || afterID < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "after must be a non-negative event ID"})
+			return
+		}
+	}
+
+	subscription := h.eventBus.Subscribe(filter, afterID)
+	defer subscription.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	// Tell a resuming client that some events are gone, so it refetches the
+	// state it cares about instead of trusting the stream to be complete.
+	if subscription.Missed {
+		data, _ := json.Marshal(gin.H{"last_event_id": h.eventBus.LastID()})
+		if _, err := fmt.Fprintf(c.Writer, "event: reset\ndata: %s\n\n", data); err != nil {
+			return
+		}
+	}
+	for i := range subscription.Backlog {
+		if !writeClientEvent(c, &subscription.Backlog[i]) {
+			return
+		}
+	}
+	if _, err := fmt.Fprint(c.Writer, ": connected\n\n"); err != nil {
+		return
+	}
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(clientEventKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-subscription.Events:
+			// A closed channel means this client fell behind; ending the
+			// stream makes it reconnect and resume from its last event ID.
+			if !ok {
+				return
+			}
+			if !writeClientEvent(c, &event) {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(c.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
+func writeClientEvent(c *gin.Context, event *models.ClientEvent) bool {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return false
+	}
+	_, err = fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err == nil
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/theblitlabs/parity-server/internal/api/middleware"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/internal/core/services"
+)
+
+type CreatorWebhookHandler struct {
+	creatorWebhookService *services.CreatorWebhookService
+}
+
+func NewCreatorWebhookHandler(creatorWebhookService *services.CreatorWebhookService) *CreatorWebhookHandler {
+	return &CreatorWebhookHandler{creatorWebhookService: creatorWebhookService}
+}
+
+// Register adds a callback URL for the caller's tasks. The response carries the
+// secret deliveries are signed with; it is not shown again.
+func (h *CreatorWebhookHandler) Register(c *gin.Context) {
+	creator := webhookCreator(c, false)
+	if creator == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "X-Creator-Address header is required"})
+		return
+	}
+
+	var req services.RegisterCreatorWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	webhook, secret, err := h.creatorWebhookService.Register(c.Request.Context(), creator, req)
+	if err != nil {
+		c.JSON(creatorWebhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"webhook": webhook,
+		"secret":  secret,
+	})
+}
+
+func (h *CreatorWebhookHandler) List(c *gin.Context) {
+	creator := webhookCreator(c, true)
+	if creator == "" && !middleware.PrincipalFrom(c).IsAdmin() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "X-Creator-Address header is required"})
+		return
+	}
+
+	webhooks, err := h.creatorWebhookService.ListWebhooks(c.Request.Context(), creator)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhooks": webhooks,
+		"count":    len(webhooks),
+	})
+}
+
+func (h *CreatorWebhookHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	principal := middleware.PrincipalFrom(c)
+	if principal == nil {
+		// Without authentication the creator header stands in for the session.
+		principal = &models.Principal{WalletAddress: c.GetHeader("X-Creator-Address")}
+	}
+
+	if err := h.creatorWebhookService.Delete(c.Request.Context(), principal, id); err != nil {
+		c.JSON(creatorWebhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries returns the delivery log of the caller's webhooks, newest
+// first, with every attempt.
+func (h *CreatorWebhookHandler) ListDeliveries(c *gin.Context) {
+	creator := webhookCreator(c, true)
+	if creator == "" && !middleware.PrincipalFrom(c).IsAdmin() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "X-Creator-Address header is required"})
+		return
+	}
+
+	var webhookID *uuid.UUID
+	if raw := c.Query("webhook_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+			return
+		}
+		webhookID = &id
+	}
+
+	status := models.WebhookDeliveryStatus(c.Query("status"))
+	switch status {
+	case "", models.WebhookDeliveryPending, models.WebhookDeliveryDelivered, models.WebhookDeliveryDead:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status filter"})
+		return
+	}
+
+	limit := 50
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+
+	deliveries, err := h.creatorWebhookService.ListDeliveries(c.Request.Context(), creator, webhookID, status, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// webhookCreator returns the creator the request acts for. Admins may pass
+// ?creator= to look at another creator's webhooks when listing.
+func webhookCreator(c *gin.Context, adminMayChoose bool) string {
+	principal := middleware.PrincipalFrom(c)
+	if principal == nil {
+		return c.GetHeader("X-Creator-Address")
+	}
+	if adminMayChoose && principal.IsAdmin() {
+		return c.Query("creator")
+	}
+	return principal.WalletAddress
+}
+
+func creatorWebhookErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidCreatorWebhook):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrCreatorWebhookNotFound), errors.Is(err, services.ErrTaskNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrCreatorWebhookForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrTooManyCreatorWebhooks):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -3,10 +3,12 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/api/middleware"
 	requestmodels "github.com/theblitlabs/parity-server/internal/api/models"
 	"github.com/theblitlabs/parity-server/internal/core/ports"
 )
@@ -31,6 +33,14 @@ func (h *FederatedLearningHandler) CreateSession(c *gin.Context) {
 		return
 	}
 
+	if principal := middleware.PrincipalFrom(c); principal != nil {
+		if req.CreatorAddress != "" && !strings.EqualFold(req.CreatorAddress, principal.WalletAddress) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Creator address does not match authenticated account"})
+			return
+		}
+		req.CreatorAddress = principal.WalletAddress
+	}
+
 	session, err := h.service.CreateSession(c.Request.Context(), &req)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to create FL session")
@@ -92,6 +102,11 @@ func (h *FederatedLearningHandler) GetSession(c *gin.Context) {
 		return
 	}
 
+	if principal := middleware.PrincipalFrom(c); principal != nil && !principal.Owns(session.CreatorAddress) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "session belongs to another creator"})
+		return
+	}
+
 	response := requestmodels.FLSessionResponse{
 		ID:              session.ID.String(),
 		Name:            session.Name,
@@ -132,6 +147,9 @@ func (h *FederatedLearningHandler) ListSessions(c *gin.Context) {
 	log := gologger.WithComponent("fl_handler")
 
 	creatorAddress := c.Query("creator")
+	if principal := middleware.PrincipalFrom(c); principal != nil && !principal.IsAdmin() {
+		creatorAddress = principal.WalletAddress
+	}
 
 	sessions, err := h.service.ListSessions(c.Request.Context(), creatorAddress)
 	if err != nil {
@@ -195,6 +213,10 @@ func (h *FederatedLearningHandler) StartSession(c *gin.Context) {
 		return
 	}
 
+	if !h.authorizeSession(c, sessionID) {
+		return
+	}
+
 	if err := h.service.StartSession(c.Request.Context(), sessionID); err != nil {
 		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to start FL session")
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -247,6 +269,10 @@ func (h *FederatedLearningHandler) GetRound(c *gin.Context) {
 		return
 	}
 
+	if !h.authorizeSession(c, sessionID) {
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"session_id":   sessionID.String(),
 		"round_number": roundNumber,
@@ -265,6 +291,10 @@ func (h *FederatedLearningHandler) GetModel(c *gin.Context) {
 		return
 	}
 
+	if !h.authorizeSession(c, sessionID) {
+		return
+	}
+
 	model, err := h.service.GetTrainedModel(c.Request.Context(), sessionID)
 	if err != nil {
 		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to get trained model")
@@ -274,3 +304,23 @@ func (h *FederatedLearningHandler) GetModel(c *gin.Context) {
 
 	c.JSON(http.StatusOK, model)
 }
+
+// authorizeSession checks the caller created the session. It writes the error
+// response itself and reports whether the handler may continue.
+func (h *FederatedLearningHandler) authorizeSession(c *gin.Context, sessionID uuid.UUID) bool {
+	principal := middleware.PrincipalFrom(c)
+	if principal == nil || principal.IsAdmin() {
+		return true
+	}
+
+	session, err := h.service.GetSession(c.Request.Context(), sessionID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return false
+	}
+	if !principal.Owns(session.CreatorAddress) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "session belongs to another creator"})
+		return false
+	}
+	return true
+}
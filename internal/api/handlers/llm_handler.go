@@ -4,11 +4,13 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/api/middleware"
 	requestmodels "github.com/theblitlabs/parity-server/internal/api/models"
 	"github.com/theblitlabs/parity-server/internal/core/services"
 )
@@ -33,15 +35,17 @@ func (h *LLMHandler) SubmitPrompt(c *gin.Context) {
 		return
 	}
 
-	clientID := c.GetHeader("X-Client-ID")
-	if clientID == "" {
-		log.Error().Msg("Client ID is required")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Client ID is required"})
+	clientID, ok := h.resolveClientID(c)
+	if !ok {
 		return
 	}
 
-	promptReq, err := h.llmService.CreatePrompt(c.Request.Context(), clientID, req.Prompt, req.ModelName, req.CreatorAddress)
+	promptReq, err := h.llmService.CreatePrompt(c.Request.Context(), clientID, req.Prompt, req.ModelName, req.CreatorAddress, req.SamplingParams)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidPromptRequest) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		log.Error().Err(err).Str("client_id", clientID).Str("model_name", req.ModelName).Msg("Failed to submit prompt")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -88,6 +92,11 @@ func (h *LLMHandler) GetPrompt(c *gin.Context) {
 		return
 	}
 
+	if principal := middleware.PrincipalFrom(c); principal != nil && !principal.Owns(promptReq.ClientID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "prompt belongs to another client"})
+		return
+	}
+
 	response := &requestmodels.PromptResponse{
 		ID:        promptReq.ID.String(),
 		Response:  promptReq.Response,
@@ -107,10 +116,8 @@ func (h *LLMHandler) GetPrompt(c *gin.Context) {
 func (h *LLMHandler) ListPrompts(c *gin.Context) {
 	log := gologger.WithComponent("llm_handler")
 
-	clientID := c.GetHeader("X-Client-ID")
-	if clientID == "" {
-		log.Error().Msg("Client ID is required")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Client ID is required"})
+	clientID, ok := h.resolveClientID(c)
+	if !ok {
 		return
 	}
 
@@ -254,10 +261,8 @@ func (h *LLMHandler) GetAvailableModels(c *gin.Context) {
 func (h *LLMHandler) GetBillingMetrics(c *gin.Context) {
 	log := gologger.WithComponent("llm_handler")
 
-	clientID := c.GetHeader("X-Client-ID")
-	if clientID == "" {
-		log.Error().Msg("Client ID is required")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Client ID is required"})
+	clientID, ok := h.resolveClientID(c)
+	if !ok {
 		return
 	}
 
@@ -275,3 +280,26 @@ func (h *LLMHandler) GetBillingMetrics(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// resolveClientID returns the client the request acts for. Authenticated callers
+// are pinned to their own wallet; anonymous callers still name themselves through
+// X-Client-ID while authentication is not enforced.
+func (h *LLMHandler) resolveClientID(c *gin.Context) (string, bool) {
+	clientID := c.GetHeader("X-Client-ID")
+
+	if principal := middleware.PrincipalFrom(c); principal != nil {
+		if clientID != "" && !strings.EqualFold(clientID, principal.WalletAddress) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Client ID does not match authenticated account"})
+			return "", false
+		}
+		return principal.WalletAddress, true
+	}
+
+	if clientID == "" {
+		log := gologger.WithComponent("llm_handler")
+		log.Error().Msg("Client ID is required")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Client ID is required"})
+		return "", false
+	}
+	return clientID, true
+}
@@ -194,3 +194,37 @@ func (h *ReputationHandler) ReportMaliciousBehavior(c *gin.Context) {
 		"runner_id": request.RunnerID,
 	})
 }
+
+// SlashRunnerStake slashes a runner's stake after an admin has reviewed the evidence
+func (h *ReputationHandler) SlashRunnerStake(c *gin.Context) {
+	log := gologger.WithComponent("reputation_handler")
+
+	runnerID := c.Param("runner_id")
+	if runnerID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Runner ID is required"})
+		return
+	}
+
+	var request struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.reputationService.SlashRunnerStake(c.Request.Context(), runnerID, request.Reason); err != nil {
+		log.Error().Err(err).
+			Str("runner_id", runnerID).
+			Str("reason", request.Reason).
+			Msg("Failed to slash runner stake")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to slash runner stake"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Runner stake slashed",
+		"runner_id": runnerID,
+	})
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	coremodels "github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/internal/core/services"
+)
+
+type RetentionHandler struct {
+	retentionService *services.RetentionService
+}
+
+func NewRetentionHandler(retentionService *services.RetentionService) *RetentionHandler {
+	return &RetentionHandler{retentionService: retentionService}
+}
+
+func (h *RetentionHandler) ListPolicies(c *gin.Context) {
+	overrides, err := h.retentionService.ListPolicies(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"global":    h.retentionService.GlobalPolicy(),
+		"overrides": overrides,
+	})
+}
+
+func (h *RetentionHandler) SetPolicy(c *gin.Context) {
+	var policy coremodels.RetentionPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	policy.CreatorAddress = c.Param("creator")
+
+	if err := h.retentionService.SetPolicy(c.Request.Context(), &policy); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrInvalidRetentionPolicy) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+func (h *RetentionHandler) DeletePolicy(c *gin.Context) {
+	if err := h.retentionService.DeletePolicy(c.Request.Context(), c.Param("creator")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Run triggers a collection. It defaults to a dry run; pass dry_run=false to
+// actually remove anything.
+func (h *RetentionHandler) Run(c *gin.Context) {
+	dryRun := true
+	if value := c.Query("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "dry_run must be true or false"})
+			return
+		}
+		dryRun = parsed
+	}
+
+	report, err := h.retentionService.Run(c.Request.Context(), dryRun)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrRetentionRunning) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/theblitlabs/parity-server/internal/api/models"
+	coremodels "github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/internal/core/services"
+)
+
+type RunnerAdminHandler struct {
+	adminService *services.RunnerAdminService
+}
+
+func NewRunnerAdminHandler(adminService *services.RunnerAdminService) *RunnerAdminHandler {
+	return &RunnerAdminHandler{adminService: adminService}
+}
+
+func (h *RunnerAdminHandler) ListRunners(c *gin.Context) {
+	filter := coremodels.RunnerFilter{
+		Status:        coremodels.RunnerStatus(c.Query("status")),
+		Model:         c.Query("model"),
+		WalletAddress: c.Query("wallet"),
+		Limit:         100,
+	}
+
+	switch filter.Status {
+	case "", coremodels.RunnerStatusOnline, coremodels.RunnerStatusOffline, coremodels.RunnerStatusBusy, coremodels.RunnerStatusDraining:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status filter"})
+		return
+	}
+
+	for param, target := range map[string]**time.Time{
+		"heartbeat_after":  &filter.HeartbeatAfter,
+		"heartbeat_before": &filter.HeartbeatBefore,
+	} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": param + " must be an RFC3339 timestamp"})
+			return
+		}
+		*target = &parsed
+	}
+
+	for _, label := range c.QueryArray("label") {
+		key, value, ok := strings.Cut(label, "=")
+		if !ok || key == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "label filters must be key=value"})
+			return
+		}
+		if filter.Labels == nil {
+			filter.Labels = make(map[string]string)
+		}
+		filter.Labels[key] = value
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		filter.Limit = parsed
+	}
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		parsed, err := strconv.Atoi(offsetStr)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset"})
+			return
+		}
+		filter.Offset = parsed
+	}
+
+	runners, err := h.adminService.ListRunners(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"runners": runners,
+		"count":   len(runners),
+	})
+}
+
+func (h *RunnerAdminHandler) GetVersionDistribution(c *gin.Context) {
+	distribution, err := h.adminService.VersionDistribution(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, distribution)
+}
+
+func (h *RunnerAdminHandler) GetRunner(c *gin.Context) {
+	details, err := h.adminService.GetRunnerDetails(c.Request.Context(), c.Param("device_id"))
+	if err != nil {
+		c.JSON(runnerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, details)
+}
+
+func (h *RunnerAdminHandler) ForceOffline(c *gin.Context) {
+	runner, err := h.adminService.ForceOffline(c.Request.Context(), c.Param("device_id"))
+	if err != nil {
+		c.JSON(runnerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, runner)
+}
+
+func (h *RunnerAdminHandler) RestoreRunner(c *gin.Context) {
+	runner, err := h.adminService.RestoreRunner(c.Request.Context(), c.Param("device_id"))
+	if err != nil {
+		c.JSON(runnerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, runner)
+}
+
+func (h *RunnerAdminHandler) BindWallet(c *gin.Context) {
+	var req models.BindRunnerWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	runner, err := h.adminService.BindWallet(c.Request.Context(), c.Param("device_id"), req.WalletAddress)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidWalletAddress) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(runnerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, runner)
+}
+
+func (h *RunnerAdminHandler) RequeueWork(c *gin.Context) {
+	result, err := h.adminService.RequeueWork(c.Request.Context(), c.Param("device_id"))
+	if err != nil {
+		c.JSON(runnerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *RunnerAdminHandler) DeregisterRunner(c *gin.Context) {
+	result, err := h.adminService.DeregisterRunner(c.Request.Context(), c.Param("device_id"))
+	if err != nil {
+		c.JSON(runnerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *RunnerAdminHandler) GetRunnerTelemetry(c *gin.Context) {
+	until := time.Now()
+	since := until.Add(-24 * time.Hour)
+
+	for param, target := range map[string]*time.Time{"since": &since, "until": &until} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": param + " must be an RFC3339 timestamp"})
+			return
+		}
+		*target = parsed
+	}
+	if !since.Before(until) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "since must be before until"})
+		return
+	}
+
+	samples, err := h.adminService.RunnerTelemetry(c.Request.Context(), c.Param("device_id"), since, until)
+	if err != nil {
+		c.JSON(telemetryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"device_id": c.Param("device_id"),
+		"since":     since,
+		"until":     until,
+		"samples":   samples,
+	})
+}
+
+func (h *RunnerAdminHandler) GetRunnerHealth(c *gin.Context) {
+	var window time.Duration
+	if windowStr := c.Query("window_hours"); windowStr != "" {
+		hours, err := strconv.Atoi(windowStr)
+		if err != nil || hours <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid window_hours"})
+			return
+		}
+		window = time.Duration(hours) * time.Hour
+	}
+
+	health, err := h.adminService.RunnerHealth(c.Request.Context(), c.Param("device_id"), window)
+	if err != nil {
+		c.JSON(telemetryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, health)
+}
+
+func (h *RunnerAdminHandler) GetCacheMetrics(c *gin.Context) {
+	metrics, err := h.adminService.CacheMetrics()
+	if err != nil {
+		c.JSON(telemetryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, metrics)
+}
+
+func (h *RunnerAdminHandler) GetRunnerCache(c *gin.Context) {
+	cache, err := h.adminService.RunnerCache(c.Request.Context(), c.Param("device_id"))
+	if err != nil {
+		c.JSON(telemetryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, cache)
+}
+
+func telemetryErrorStatus(err error) int {
+	if errors.Is(err, services.ErrTelemetryUnavailable) || errors.Is(err, services.ErrRunnerCacheUnavailable) {
+		return http.StatusServiceUnavailable
+	}
+	return runnerErrorStatus(err)
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/api/middleware"
+	"github.com/theblitlabs/parity-server/internal/api/models"
+	"github.com/theblitlabs/parity-server/internal/core/services"
+)
+
+type RunnerAuthHandler struct {
+	authService *services.RunnerAuthService
+	enforce     bool
+}
+
+func NewRunnerAuthHandler(authService *services.RunnerAuthService, enforce bool) *RunnerAuthHandler {
+	return &RunnerAuthHandler{
+		authService: authService,
+		enforce:     enforce,
+	}
+}
+
+// Middleware authenticates runner requests against this handler's sessions.
+func (h *RunnerAuthHandler) Middleware() gin.HandlerFunc {
+	return middleware.RunnerAuth(h.authService, h.enforce)
+}
+
+func (h *RunnerAuthHandler) CreateChallenge(c *gin.Context) {
+	log := gologger.WithComponent("runner_auth_handler")
+
+	deviceID := c.GetHeader("X-Device-ID")
+	if deviceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Device ID is required"})
+		return
+	}
+
+	var req models.RunnerChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	challenge, err := h.authService.CreateChallenge(c.Request.Context(), deviceID, req.WalletAddress)
+	if err != nil {
+		if errors.Is(err, services.ErrWalletMismatch) || errors.Is(err, services.ErrWalletNotBound) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error().Err(err).Str("device_id", deviceID).Msg("Failed to create auth challenge")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"challenge_id": challenge.ID,
+		"message":      challenge.Message,
+		"expires_at":   challenge.ExpiresAt,
+	})
+}
+
+func (h *RunnerAuthHandler) Login(c *gin.Context) {
+	var req models.RunnerLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	tokens, err := h.authService.Login(c.Request.Context(), req.ChallengeID, req.Signature)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidChallenge) || errors.Is(err, services.ErrInvalidSignature) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *RunnerAuthHandler) Refresh(c *gin.Context) {
+	var req models.RunnerRefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	tokens, err := h.authService.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRunnerAuth) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *RunnerAuthHandler) Logout(c *gin.Context) {
+	token := middleware.BearerToken(c)
+	if token == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "runner authentication required"})
+		return
+	}
+
+	var req models.RunnerLogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+	}
+
+	if req.AllSessions {
+		session, err := h.authService.Authenticate(c.Request.Context(), token)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		revoked, err := h.authService.RevokeDevice(c.Request.Context(), session.DeviceID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"revoked_sessions": revoked})
+		return
+	}
+
+	if err := h.authService.Revoke(c.Request.Context(), token); err != nil {
+		if errors.Is(err, services.ErrInvalidRunnerAuth) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revoked_sessions": 1})
+}
+
+// sessionWalletMismatch reports whether an authenticated runner is trying to use a
+// wallet other than the one it signed in with.
+// runnerAuthenticated reports whether the request carries a runner session
+// rather than only an X-Device-ID header.
+func runnerAuthenticated(c *gin.Context) bool {
+	return c.GetString(middleware.RunnerDeviceIDKey) != ""
+}
+
+func sessionWalletMismatch(c *gin.Context, walletAddress string) bool {
+	sessionWallet := c.GetString(middleware.RunnerWalletAddressKey)
+	if sessionWallet == "" {
+		return false
+	}
+	return !strings.EqualFold(sessionWallet, walletAddress)
+}
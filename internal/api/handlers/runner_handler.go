@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -18,8 +20,9 @@ import (
 )
 
 type RunnerHandler struct {
-	taskService   *services.TaskService
-	runnerService *services.RunnerService
+	taskService    *services.TaskService
+	runnerService  *services.RunnerService
+	webhookService *services.WebhookService
 }
 
 func NewRunnerHandler(taskService *services.TaskService, runnerService *services.RunnerService) *RunnerHandler {
@@ -29,6 +32,10 @@ func NewRunnerHandler(taskService *services.TaskService, runnerService *services
 	}
 }
 
+func (h *RunnerHandler) SetWebhookService(service *services.WebhookService) {
+	h.webhookService = service
+}
+
 func (h *RunnerHandler) RegisterRunner(c *gin.Context) {
 	var req models.RegisterRunnerRequest
 	log := gologger.WithComponent("runner_handler")
@@ -52,11 +59,29 @@ func (h *RunnerHandler) RegisterRunner(c *gin.Context) {
 	runner := coremodels.Runner{
 		WalletAddress: req.WalletAddress,
 		Webhook:       req.Webhook,
+		DeliveryMode:  coremodels.DeliveryMode(req.DeliveryMode),
+		Slots:         req.Slots,
+		Labels:        req.Labels,
+	}
+
+	if runner.DeliveryMode != "" && !runner.DeliveryMode.Valid() {
+		log.Error().Str("delivery_mode", req.DeliveryMode).Msg("Invalid delivery mode")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "delivery_mode must be push or pull"})
+		return
+	}
+
+	if runner.Slots < 0 {
+		log.Error().Int("slots", req.Slots).Msg("Invalid slot count")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "slots must not be negative"})
+		return
 	}
 
 	log.Debug().Fields(map[string]interface{}{
 		"wallet_address": runner.WalletAddress,
 		"webhook":        runner.Webhook,
+		"delivery_mode":  runner.DeliveryMode,
+		"slots":          runner.Slots,
+		"labels":         runner.Labels,
 		"status":         runner.Status,
 	}).Msg("Parsed request body")
 
@@ -73,13 +98,26 @@ func (h *RunnerHandler) RegisterRunner(c *gin.Context) {
 		return
 	}
 
+	if sessionWalletMismatch(c, runner.WalletAddress) {
+		log.Warn().Str("device_id", deviceID).Msg("Wallet address does not match runner session")
+		c.JSON(http.StatusForbidden, gin.H{"error": "Wallet address does not match runner session"})
+		return
+	}
+
+	negotiation, ok := h.negotiateProtocol(c, deviceID, req.ProtocolVersion)
+	if !ok {
+		return
+	}
+
 	runner.Status = coremodels.RunnerStatusOnline
 	runner.DeviceID = deviceID
+	runner.ProtocolVersion = negotiation.Negotiated
+	runner.SoftwareVersion = req.RunnerVersion
 
 	createdRunner, err := h.runnerService.CreateOrUpdateRunner(c.Request.Context(), &runner)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to create/update runner")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(runnerErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -104,8 +142,26 @@ func (h *RunnerHandler) RegisterRunner(c *gin.Context) {
 		"wallet_address": createdRunner.WalletAddress,
 		"status":         createdRunner.Status,
 		"webhook":        createdRunner.Webhook,
+		"delivery_mode":  createdRunner.DeliveryMode,
+		"slots":          createdRunner.Capacity(),
+		"protocol":       createdRunner.ProtocolVersion,
+		"runner_version": createdRunner.SoftwareVersion,
 	}).Msg("Runner created/updated successfully")
 
+	if createdRunner.Webhook != "" && h.webhookService != nil {
+		secret, issued, err := h.webhookService.SigningSecret(c.Request.Context(), deviceID)
+		if err != nil {
+			log.Error().Err(err).Str("device_id", deviceID).Msg("Failed to issue webhook signing secret")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue webhook signing secret"})
+			return
+		}
+		// The secret goes out once, and only to an authenticated runner. Anyone
+		// else has to rotate it after logging in.
+		if issued && runnerAuthenticated(c) {
+			createdRunner.WebhookSigningSecret = secret
+		}
+	}
+
 	c.JSON(http.StatusCreated, createdRunner)
 }
 
@@ -124,19 +180,133 @@ func (h *RunnerHandler) RunnerHeartbeat(c *gin.Context) {
 	}
 
 	runner := &coremodels.Runner{
-		DeviceID: deviceID,
-		Status:   coremodels.RunnerStatusOnline,
-		Webhook:  payload.PublicIP,
+		DeviceID:        deviceID,
+		Status:          coremodels.RunnerStatusOnline,
+		Webhook:         payload.PublicIP,
+		SoftwareVersion: payload.RunnerVersion,
 	}
 
-	if _, err := h.runnerService.UpdateRunnerStatus(c.Request.Context(), runner); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// A heartbeat without a version keeps the protocol the runner registered
+	// with; only unknown runners fall back to legacy.
+	requested := payload.ProtocolVersion
+	if requested == 0 {
+		requested = h.runnerService.ProtocolVersion(c.Request.Context(), deviceID)
+	}
+	negotiation, ok := h.negotiateProtocol(c, deviceID, requested)
+	if !ok {
 		return
 	}
+	runner.ProtocolVersion = negotiation.Negotiated
+
+	telemetry := coremodels.HeartbeatTelemetry{
+		CPU:           payload.CPU,
+		MemoryBytes:   payload.Memory,
+		UptimeSeconds: payload.Uptime,
+		PublicIP:      payload.PublicIP,
+		Reported:      true,
+	}
+
+	if _, err := h.runnerService.RecordHeartbeat(c.Request.Context(), runner, telemetry); err != nil {
+		c.JSON(runnerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.runnerService.RecordCacheReport(c.Request.Context(), deviceID, coremodels.RunnerCacheReport{
+		Images: payload.CachedImages,
+		Models: payload.CachedModels,
+	})
 
 	c.Status(http.StatusOK)
 }
 
+// DrainRunner stops the runner from receiving new work while it finishes what it
+// has. An optional maintenance_minutes declares a window in which missed
+// heartbeats are not held against it.
+func (h *RunnerHandler) DrainRunner(c *gin.Context) {
+	deviceID := c.GetHeader("X-Device-ID")
+	if deviceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Device ID is required"})
+		return
+	}
+
+	var req models.DrainRunnerRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+			return
+		}
+	}
+	if req.MaintenanceMinutes < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "maintenance_minutes must not be negative"})
+		return
+	}
+
+	var maintenanceUntil *time.Time
+	if req.MaintenanceMinutes > 0 {
+		until := time.Now().Add(time.Duration(req.MaintenanceMinutes) * time.Minute)
+		maintenanceUntil = &until
+	}
+
+	runner, err := h.runnerService.DrainRunner(c.Request.Context(), deviceID, maintenanceUntil)
+	if err != nil {
+		c.JSON(runnerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, runner)
+}
+
+func (h *RunnerHandler) UndrainRunner(c *gin.Context) {
+	deviceID := c.GetHeader("X-Device-ID")
+	if deviceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Device ID is required"})
+		return
+	}
+
+	runner, err := h.runnerService.UndrainRunner(c.Request.Context(), deviceID)
+	if err != nil {
+		c.JSON(runnerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, runner)
+}
+
+// negotiateProtocol matches the runner's reported protocol against the
+// compatibility matrix. Unsupported versions are answered with 426 Upgrade
+// Required; deprecated ones are accepted with a warning header.
+func (h *RunnerHandler) negotiateProtocol(c *gin.Context, deviceID string, requested int) (*coremodels.ProtocolNegotiation, bool) {
+	log := gologger.WithComponent("runner_handler")
+
+	negotiation, err := h.runnerService.NegotiateProtocol(requested)
+	if err != nil {
+		log.Warn().Err(err).Str("device_id", deviceID).Int("protocol", requested).Msg("Rejected runner protocol version")
+		c.JSON(http.StatusUpgradeRequired, gin.H{
+			"error":            err.Error(),
+			"current_protocol": coremodels.CurrentRunnerProtocol,
+		})
+		return nil, false
+	}
+
+	c.Header("X-Parity-Protocol-Version", strconv.Itoa(negotiation.Negotiated))
+	if negotiation.Warning != "" {
+		log.Warn().Str("device_id", deviceID).Int("protocol", negotiation.Negotiated).Msg(negotiation.Warning)
+		c.Header("X-Parity-Protocol-Warning", negotiation.Warning)
+	}
+
+	return negotiation, true
+}
+
+func runnerErrorStatus(err error) int {
+	if errors.Is(err, services.ErrRunnerNotFound) || strings.Contains(err.Error(), "runner not found") {
+		return http.StatusNotFound
+	}
+	if errors.Is(err, services.ErrRunnerForcedOffline) {
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
 func (h *RunnerHandler) ListAvailableTasks(c *gin.Context) {
 	deviceID := c.GetHeader("X-Device-ID")
 	if deviceID == "" {
@@ -152,14 +322,91 @@ func (h *RunnerHandler) ListAvailableTasks(c *gin.Context) {
 
 	visibleTasks := make([]*coremodels.Task, 0, len(tasks))
 	for _, task := range tasks {
-		if task.RunnerID == "" || task.RunnerID == deviceID {
-			visibleTasks = append(visibleTasks, task)
+		if task.RunnerID != "" && task.RunnerID != deviceID {
+			continue
 		}
+		if selection := task.Selections.Latest(); task.HighValue && selection != nil && selection.SelectedRunnerID != deviceID {
+			continue
+		}
+		visibleTasks = append(visibleTasks, task)
 	}
 
 	c.JSON(http.StatusOK, visibleTasks)
 }
 
+// NextTask long-polls for work on behalf of a pull-mode runner. It answers with the
+// leased task, or 204 No Content when nothing was leased before the wait ran out.
+func (h *RunnerHandler) NextTask(c *gin.Context) {
+	deviceID := c.GetHeader("X-Device-ID")
+	if deviceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Device ID is required"})
+		return
+	}
+
+	var wait time.Duration
+	if waitStr := c.Query("wait"); waitStr != "" {
+		seconds, err := strconv.Atoi(waitStr)
+		if err != nil || seconds < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "wait must be a non-negative number of seconds"})
+			return
+		}
+		wait = time.Duration(seconds) * time.Second
+	}
+
+	task, err := h.taskService.NextTask(c.Request.Context(), deviceID, wait)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrRunnerNotPullMode) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	if task == nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+func (h *RunnerHandler) RenewTaskLease(c *gin.Context) {
+	taskID := c.Param("id")
+	if taskID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "task ID is required"})
+		return
+	}
+
+	deviceID := c.GetHeader("X-Device-ID")
+	if deviceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Device ID is required"})
+		return
+	}
+
+	task, err := h.taskService.RenewTaskLease(c.Request.Context(), taskID, deviceID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrTaskNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, services.ErrTaskUnavailable):
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"task_id":          task.ID,
+		"status":           task.Status,
+		"lease_expires_at": task.LeaseExpiresAt,
+	})
+}
+
 func (h *RunnerHandler) StartTask(c *gin.Context) {
 	taskID := c.Param("id")
 	if taskID == "" {
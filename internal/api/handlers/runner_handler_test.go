@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -128,6 +129,15 @@ func (r *runnerHandlerTaskRepo) GetTasksByRunner(ctx context.Context, runnerID s
 	return nil, nil
 }
 
+func (r *runnerHandlerTaskRepo) ClaimPending(ctx context.Context, task *models.Task, previousRunnerID, previousNonce string) (bool, error) {
+	stored, ok := r.tasks[task.ID]
+	if !ok || stored.Status != models.TaskStatusPending || stored.RunnerID != previousRunnerID || stored.Nonce != previousNonce {
+		return false, nil
+	}
+	r.tasks[task.ID] = cloneHandlerTask(task)
+	return true, nil
+}
+
 func cloneHandlerTask(task *models.Task) *models.Task {
 	cloned := *task
 	if task.CompletedAt != nil {
@@ -144,3 +154,73 @@ func cloneHandlerTask(task *models.Task) *models.Task {
 	}
 	return &cloned
 }
+
+func TestRunnerHeartbeatRejectsProtocolBelowMinimum(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	runnerService := services.NewRunnerService(nil)
+	runnerService.SetMinimumProtocol(models.RunnerProtocolEnvelope)
+	handler := NewRunnerHandler(nil, runnerService)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/runners/heartbeat", strings.NewReader(`{"status":"online","protocol_version":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Device-ID", "runner-1")
+	rec := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(rec)
+	ctx.Request = req
+
+	handler.RunnerHeartbeat(ctx)
+
+	if rec.Code != http.StatusUpgradeRequired {
+		t.Fatalf("status code = %d, want %d", rec.Code, http.StatusUpgradeRequired)
+	}
+}
+
+// heartbeatRunnerRepo stores a single runner; heartbeats only read and update it.
+type heartbeatRunnerRepo struct {
+	services.RunnerRepository
+	runner *models.Runner
+}
+
+func (r *heartbeatRunnerRepo) Get(ctx context.Context, deviceID string) (*models.Runner, error) {
+	if r.runner == nil || r.runner.DeviceID != deviceID {
+		return nil, services.ErrRunnerNotFound
+	}
+	stored := *r.runner
+	return &stored, nil
+}
+
+func (r *heartbeatRunnerRepo) Update(ctx context.Context, runner *models.Runner) (*models.Runner, error) {
+	stored := *runner
+	r.runner = &stored
+	return r.Get(ctx, runner.DeviceID)
+}
+
+func TestRunnerHeartbeatWithoutVersionKeepsRegisteredProtocol(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := &heartbeatRunnerRepo{runner: &models.Runner{
+		DeviceID:        "runner-1",
+		Status:          models.RunnerStatusOnline,
+		ProtocolVersion: models.RunnerProtocolEnvelope,
+	}}
+	handler := NewRunnerHandler(nil, services.NewRunnerService(repo))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/runners/heartbeat", strings.NewReader(`{"status":"online"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Device-ID", "runner-1")
+	rec := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(rec)
+	ctx.Request = req
+
+	handler.RunnerHeartbeat(ctx)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status code = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if repo.runner.ProtocolVersion != models.RunnerProtocolEnvelope {
+		t.Fatalf("protocol version = %d, want %d", repo.runner.ProtocolVersion, models.RunnerProtocolEnvelope)
+	}
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/theblitlabs/gologger"
+	coremodels "github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/internal/core/services"
+)
+
+const (
+	runnerSocketPingInterval = 30 * time.Second
+	runnerSocketPongWait     = 75 * time.Second
+	runnerSocketWriteWait    = 10 * time.Second
+	runnerSocketMaxMessage   = 1 << 20
+)
+
+type RunnerSocketHandler struct {
+	hub      *services.RunnerHub
+	upgrader websocket.Upgrader
+}
+
+func NewRunnerSocketHandler(hub *services.RunnerHub) *RunnerSocketHandler {
+	return &RunnerSocketHandler{
+		hub: hub,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  4096,
+			WriteBufferSize: 4096,
+			// Runners are not browsers; the runner auth middleware already vouched
+			// for the caller.
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+// Connect upgrades the request to the runner's WebSocket and serves it until the
+// runner or the server hangs up.
+func (h *RunnerSocketHandler) Connect(c *gin.Context) {
+	log := gologger.WithComponent("runner_socket")
+
+	deviceID := c.GetHeader("X-Device-ID")
+	if deviceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Device ID is required"})
+		return
+	}
+
+	socket, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Warn().Err(err).Str("device_id", deviceID).Msg("Failed to upgrade runner connection")
+		return
+	}
+
+	ctx := context.Background()
+	conn, err := h.hub.Connect(ctx, deviceID, socket)
+	if err != nil {
+		log.Warn().Err(err).Str("device_id", deviceID).Msg("Rejected runner connection")
+		_ = socket.WriteControl(
+			websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.ClosePolicyViolation, err.Error()),
+			time.Now().Add(runnerSocketWriteWait),
+		)
+		_ = socket.Close()
+		return
+	}
+	defer h.hub.Disconnect(ctx, conn)
+
+	socket.SetReadLimit(runnerSocketMaxMessage)
+	_ = socket.SetReadDeadline(time.Now().Add(runnerSocketPongWait))
+	socket.SetPongHandler(func(string) error {
+		h.hub.Touch(ctx, conn)
+		return socket.SetReadDeadline(time.Now().Add(runnerSocketPongWait))
+	})
+
+	done := make(chan struct{})
+	defer close(done)
+	go h.ping(socket, done)
+
+	for {
+		var message coremodels.RunnerMessage
+		if err := socket.ReadJSON(&message); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Warn().Err(err).Str("device_id", deviceID).Msg("Runner connection closed unexpectedly")
+			}
+			return
+		}
+
+		_ = socket.SetReadDeadline(time.Now().Add(runnerSocketPongWait))
+		h.hub.HandleMessage(ctx, conn, &message)
+	}
+}
+
+func (h *RunnerSocketHandler) ping(socket *websocket.Conn, done <-chan struct{}) {
+	ticker := time.NewTicker(runnerSocketPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := socket.WriteControl(websocket.PingMessage, nil, time.Now().Add(runnerSocketWriteWait)); err != nil {
+				return
+			}
+		}
+	}
+}
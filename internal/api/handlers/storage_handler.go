@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/core/ports"
+	"github.com/theblitlabs/parity-server/internal/core/services"
+)
+
+type StorageHandler struct {
+	storage      ports.Storage
+	imageService *services.ImageService
+}
+
+func NewStorageHandler(storage ports.Storage, imageService *services.ImageService) *StorageHandler {
+	return &StorageHandler{storage: storage, imageService: imageService}
+}
+
+// Download streams a stored object by key to the runner that needs it: the
+// image of a task it holds or the model of an FL session it trains in. Keys
+// are content hashes, so an object can be cached forever.
+func (h *StorageHandler) Download(c *gin.Context) {
+	key := c.Param("key")
+	deviceID := c.GetHeader("X-Device-ID")
+	if deviceID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "runner authentication required"})
+		return
+	}
+
+	allowed, err := h.imageService.RunnerCanDownload(c.Request.Context(), deviceID, key)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !allowed {
+		// Not found rather than forbidden, so keys cannot be probed.
+		c.JSON(http.StatusNotFound, gin.H{"error": "Object not found"})
+		return
+	}
+
+	reader, err := h.storage.Get(c.Request.Context(), key)
+	if errors.Is(err, ports.ErrObjectNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Object not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Content-Type", "application/octet-stream")
+	c.Header("Cache-Control", "private, max-age=31536000, immutable")
+	c.Header("ETag", `"`+key+`"`)
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, reader); err != nil {
+		log := gologger.WithComponent("storage")
+		log.Warn().Err(err).Str("key", key).Msg("Object download interrupted")
+	}
+}
@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
@@ -13,6 +15,7 @@ import (
 	"github.com/google/uuid"
 	walletsdk "github.com/theblitlabs/go-wallet-sdk"
 	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/api/middleware"
 	requestmodels "github.com/theblitlabs/parity-server/internal/api/models"
 	"github.com/theblitlabs/parity-server/internal/core/config"
 	"github.com/theblitlabs/parity-server/internal/core/models"
@@ -28,6 +31,8 @@ type TaskHandler struct {
 	verificationService *services.VerificationService
 	webhooks            map[string]requestmodels.WebhookRegistration
 	config              *config.Config
+	imageInspector      *services.ImageInspector
+	imageService        *services.ImageService
 }
 
 const defaultMaxUploadSizeMB int64 = 512
@@ -39,6 +44,7 @@ func NewTaskHandler(service *services.TaskService, storageService services.Stora
 		verificationService: verificationService,
 		webhooks:            make(map[string]requestmodels.WebhookRegistration),
 		config:              cfg,
+		imageInspector:      services.NewImageInspector(),
 	}
 }
 
@@ -50,6 +56,14 @@ func (h *TaskHandler) SetWebhookService(service *services.WebhookService) {
 	h.webhookService = service
 }
 
+func (h *TaskHandler) SetImageInspector(inspector *services.ImageInspector) {
+	h.imageInspector = inspector
+}
+
+func (h *TaskHandler) SetImageService(service *services.ImageService) {
+	h.imageService = service
+}
+
 func (h *TaskHandler) NotifyTaskUpdate() {
 	if h.webhookService == nil {
 		return
@@ -66,6 +80,10 @@ func (h *TaskHandler) GetTaskResult(c *gin.Context) {
 		return
 	}
 
+	if _, ok := h.authorizeTask(c, taskID); !ok {
+		return
+	}
+
 	result, err := h.service.GetTaskResult(c.Request.Context(), taskID)
 	if err != nil {
 		log.Error().Err(err).Str("task_id", taskID).Msg("Failed to get task result")
@@ -86,7 +104,10 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 	log := gologger.WithComponent("task_handler")
 	contentType := c.GetHeader("Content-Type")
 	var req requestmodels.CreateTaskRequest
-	var dockerImage []byte
+	var imageMetadata *models.ImageMetadata
+	var imageArchive io.ReadSeeker
+	var imageName string
+	var imageSize int64
 	maxUploadSize := h.maxUploadSizeBytes()
 
 	if strings.HasPrefix(contentType, "multipart/form-data") {
@@ -139,41 +160,16 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 				}
 			}()
 
-			dockerImage, err = io.ReadAll(io.LimitReader(f, maxUploadSize+1))
-			if err != nil {
-				log.Error().Err(err).Msg("Failed to read Docker image file")
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read Docker image file"})
-				return
-			}
-
-			if int64(len(dockerImage)) > maxUploadSize {
-				c.JSON(http.StatusRequestEntityTooLarge, gin.H{
-					"error": fmt.Sprintf("Docker image exceeds maximum upload size of %d MB", maxUploadSize/(1024*1024)),
-				})
-				return
-			}
-
-			imageURL, err := h.storageService.UploadDockerImage(c.Request.Context(), dockerImage, strings.TrimSuffix(file.Filename, ".tar"))
+			imageMetadata, err = h.imageInspector.Inspect(f)
 			if err != nil {
-				log.Error().Err(err).Msg("Failed to upload Docker image")
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload Docker image"})
+				log.Warn().Err(err).Str("filename", file.Filename).Msg("Rejected Docker image upload")
+				c.JSON(imageErrorStatus(err), gin.H{"error": err.Error()})
 				return
 			}
 
-			req.Environment = ensureDockerEnvironment(req.Environment, req.Command)
-
-			taskConfig := models.TaskConfig{
-				DockerImageURL: imageURL,
-				ImageName:      strings.TrimSuffix(file.Filename, ".tar"),
-			}
-
-			var configErr error
-			req.Config, configErr = json.Marshal(taskConfig)
-			if configErr != nil {
-				log.Error().Err(configErr).Msg("Failed to marshal task config")
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process task configuration"})
-				return
-			}
+			imageArchive = f
+			imageName = strings.TrimSuffix(file.Filename, ".tar")
+			imageSize = file.Size
 		}
 	} else {
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -187,6 +183,7 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 
 			taskConfig := models.TaskConfig{
 				ImageName: req.Image,
+				Inputs:    declaredInputs(req.Config),
 			}
 
 			var configErr error
@@ -211,6 +208,67 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 	}
 
 	creatorAddress := c.GetHeader("X-Creator-Address") // We store the creator address for reference, but don't require it now
+	if principal := middleware.PrincipalFrom(c); principal != nil {
+		if creatorAddress != "" && !strings.EqualFold(creatorAddress, principal.WalletAddress) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Creator address does not match authenticated account"})
+			return
+		}
+		creatorAddress = principal.WalletAddress
+	}
+
+	owner := creatorAddress
+	if owner == "" {
+		owner = deviceID
+	}
+
+	var image *models.StoredImage
+	switch {
+	case imageArchive != nil:
+		stored, err := h.storeTaskImage(c.Request.Context(), imageArchive, imageSize, imageName, imageMetadata, owner)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to upload Docker image")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload Docker image"})
+			return
+		}
+		image = stored
+	case req.ImageDigest != "":
+		if h.imageService == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Image registry is not available"})
+			return
+		}
+		resolved, err := h.imageService.Resolve(c.Request.Context(), req.ImageDigest, owner)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, services.ErrImageNotFound) {
+				status = http.StatusNotFound
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		image = resolved
+		imageName = resolved.Name
+	}
+
+	if image != nil {
+		req.Type = models.TaskTypeDocker
+		req.Environment = ensureDockerEnvironment(req.Environment, req.Command)
+
+		metadata := image.Metadata
+		taskConfig := models.TaskConfig{
+			DockerImageURL: image.URL,
+			ImageName:      imageName,
+			Image:          &metadata,
+			Inputs:         declaredInputs(req.Config),
+		}
+
+		var configErr error
+		req.Config, configErr = json.Marshal(taskConfig)
+		if configErr != nil {
+			log.Error().Err(configErr).Msg("Failed to marshal task config")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process task configuration"})
+			return
+		}
+	}
 
 	if req.Type != models.TaskTypeDocker && req.Type != models.TaskTypeCommand {
 		log.Error().Str("type", string(req.Type)).Msg("Invalid task type")
@@ -237,7 +295,6 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 		}
 	}
 
-	nonce := utils.GenerateNonce()
 	task := models.NewTask()
 	task.Title = req.Title
 	task.Description = req.Description
@@ -247,9 +304,19 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 	task.Reward = req.Reward
 	task.CreatorDeviceID = deviceID
 	task.CreatorAddress = creatorAddress
-	task.Nonce = nonce
 	task.ImageHash = req.ImageHash
+	if image != nil {
+		// The digest computed from the archive wins over whatever the client sent.
+		if req.ImageHash != "" && req.ImageHash != image.Metadata.Hash() {
+			log.Warn().
+				Str("client_hash", req.ImageHash).
+				Str("image_hash", image.Metadata.Hash()).
+				Msg("Ignoring client image hash that does not match the uploaded image")
+		}
+		task.ImageHash = image.Metadata.Hash()
+	}
 	task.CommandHash = req.CommandHash
+	task.HighValue = req.HighValue
 
 	if err := h.checkStakeBalance(task); err != nil {
 		log.Error().Err(err).
@@ -259,9 +326,36 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 		return
 	}
 
+	referenced := image != nil && h.imageService != nil
+	if referenced {
+		if err := h.imageService.AddReference(c.Request.Context(), image.Digest, task.ID, owner); err != nil {
+			if errors.Is(err, services.ErrImageNotFound) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Image was removed from storage; upload it again"})
+				return
+			}
+			log.Error().Err(err).Str("digest", image.Digest).Msg("Failed to reference stored image")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reference stored image"})
+			return
+		}
+	}
+
 	if err := h.service.CreateTask(c.Request.Context(), task); err != nil {
 		log.Error().Err(err).Msg("Failed to create task")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if referenced {
+			if releaseErr := h.imageService.ReleaseReferences(c.Request.Context(), task.ID); releaseErr != nil {
+				log.Error().Err(releaseErr).Str("task_id", task.ID.String()).Msg("Failed to release image reference")
+			}
+		}
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrInvalidTask), errors.Is(err, services.ErrTooManyInputs):
+			status = http.StatusBadRequest
+		case errors.Is(err, services.ErrDatasetNotFound), errors.Is(err, services.ErrDatasetMismatch):
+			status = http.StatusUnprocessableEntity
+		case errors.Is(err, services.ErrDatasetTimeout):
+			status = http.StatusGatewayTimeout
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -270,6 +364,60 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 	c.JSON(http.StatusCreated, task)
 }
 
+// storeTaskImage uploads an inspected archive through the image registry, which
+// skips the upload when the digest is already stored.
+func (h *TaskHandler) storeTaskImage(ctx context.Context, archive io.ReadSeeker, size int64, name string, metadata *models.ImageMetadata, owner string) (*models.StoredImage, error) {
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind image archive: %w", err)
+	}
+
+	if h.imageService != nil {
+		return h.imageService.Store(ctx, metadata, archive, size, name, owner)
+	}
+
+	url, err := h.storageService.UploadDockerImage(ctx, archive, name)
+	if err != nil {
+		return nil, err
+	}
+	return &models.StoredImage{
+		Digest:   metadata.Digest,
+		URL:      url,
+		Name:     name,
+		Size:     size,
+		Metadata: *metadata,
+	}, nil
+}
+
+func (h *TaskHandler) ListImages(c *gin.Context) {
+	if h.imageService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Image registry is not available"})
+		return
+	}
+
+	// Admins see every image unless they filter by owner.
+	owner := c.GetHeader("X-Creator-Address")
+	if owner == "" {
+		owner = c.GetHeader("X-Device-ID")
+	}
+	if principal := middleware.PrincipalFrom(c); principal != nil {
+		owner = principal.WalletAddress
+		if principal.IsAdmin() {
+			owner = c.Query("owner")
+		}
+	}
+
+	images, err := h.imageService.ListImages(c.Request.Context(), owner)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"images": images,
+		"count":  len(images),
+	})
+}
+
 func (h *TaskHandler) SaveTaskResult(c *gin.Context) {
 	taskID := c.Param("id")
 	if taskID == "" {
@@ -396,6 +544,27 @@ func (h *TaskHandler) maxUploadSizeBytes() int64 {
 	return defaultMaxUploadSizeMB * 1024 * 1024
 }
 
+func imageErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrImageRejected):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, services.ErrInvalidImage):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// declaredInputs returns the input datasets in a client's task config, which
+// are kept when the server builds the config from an image.
+func declaredInputs(raw json.RawMessage) []models.InputDataset {
+	var taskConfig models.TaskConfig
+	if len(raw) == 0 || json.Unmarshal(raw, &taskConfig) != nil {
+		return nil
+	}
+	return taskConfig.Inputs
+}
+
 func ensureDockerEnvironment(env *models.EnvironmentConfig, command []string) *models.EnvironmentConfig {
 	if env == nil {
 		env = &models.EnvironmentConfig{}
@@ -423,7 +592,20 @@ func (h *TaskHandler) ListTasks(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, tasks)
+
+	principal := middleware.PrincipalFrom(c)
+	if principal == nil || principal.IsAdmin() {
+		c.JSON(http.StatusOK, tasks)
+		return
+	}
+
+	ownTasks := make([]models.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if principal.Owns(task.CreatorAddress) {
+			ownTasks = append(ownTasks, task)
+		}
+	}
+	c.JSON(http.StatusOK, ownTasks)
 }
 
 func (h *TaskHandler) GetTask(c *gin.Context) {
@@ -433,12 +615,92 @@ func (h *TaskHandler) GetTask(c *gin.Context) {
 		return
 	}
 
+	task, ok := h.authorizeTask(c, taskID)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, task)
+}
+
+// authorizeTask loads the task and checks the caller created it. It writes the
+// error response itself and reports whether the handler may continue.
+func (h *TaskHandler) authorizeTask(c *gin.Context, taskID string) (*models.Task, bool) {
 	task, err := h.service.GetTask(c.Request.Context(), taskID)
 	if err != nil {
+		if errors.Is(err, services.ErrTaskNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			return nil, false
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	if principal := middleware.PrincipalFrom(c); principal != nil && !principal.Owns(task.CreatorAddress) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "task belongs to another creator"})
+		return nil, false
+	}
+
+	return task, true
+}
+
+func (h *TaskHandler) GetRunnerSelection(c *gin.Context) {
+	taskID := c.Param("id")
+	if taskID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "task ID is required"})
 		return
 	}
-	c.JSON(http.StatusOK, task)
+
+	task, ok := h.authorizeTask(c, taskID)
+	if !ok {
+		return
+	}
+
+	response := gin.H{
+		"task_id":    task.ID,
+		"high_value": task.HighValue,
+		"runner_id":  task.RunnerID,
+		"selections": task.Selections,
+		"verified":   false,
+	}
+
+	if err := h.service.VerifyRunnerSelection(c.Request.Context(), task); err != nil {
+		response["verification_error"] = err.Error()
+	} else {
+		response["verified"] = true
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetTaskNonce shows where the task's nonce came from and whether it can be
+// recomputed from the recorded beacon round.
+func (h *TaskHandler) GetTaskNonce(c *gin.Context) {
+	taskID := c.Param("id")
+	if taskID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "task ID is required"})
+		return
+	}
+
+	task, ok := h.authorizeTask(c, taskID)
+	if !ok {
+		return
+	}
+
+	response := gin.H{
+		"task_id":  task.ID,
+		"nonce":    task.Nonce,
+		"source":   task.NonceSource,
+		"round":    task.NonceRound,
+		"verified": false,
+	}
+
+	if err := h.service.VerifyTaskNonce(c.Request.Context(), task); err != nil {
+		response["verification_error"] = err.Error()
+	} else {
+		response["verified"] = true
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 func (h *TaskHandler) AssignTask(c *gin.Context) {
@@ -482,6 +744,10 @@ func (h *TaskHandler) GetTaskReward(c *gin.Context) {
 		return
 	}
 
+	if _, ok := h.authorizeTask(c, taskID); !ok {
+		return
+	}
+
 	reward, err := h.service.GetTaskReward(c.Request.Context(), taskID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -510,6 +776,7 @@ type VerificationRequest struct {
 	RunnerID            string `json:"runner_id"`
 	ImageHashVerified   string `json:"image_hash_verified"`
 	CommandHashVerified string `json:"command_hash_verified"`
+	InputHashVerified   string `json:"input_hash_verified,omitempty"`
 	Timestamp           int64  `json:"timestamp"`
 }
 
@@ -546,7 +813,7 @@ func (h *TaskHandler) VerifyTaskHashes(c *gin.Context) {
 		return
 	}
 
-	err := h.verificationService.VerifyTaskExecution(c.Request.Context(), taskID, req.ImageHashVerified, req.CommandHashVerified)
+	err := h.verificationService.VerifyTaskExecution(c.Request.Context(), taskID, req.ImageHashVerified, req.CommandHashVerified, req.InputHashVerified)
 	if err != nil {
 		log.Error().
 			Err(err).
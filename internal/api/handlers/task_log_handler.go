@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/theblitlabs/parity-server/internal/api/middleware"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/internal/core/services"
+)
+
+// taskLogKeepAlive is how often an idle log stream sends an SSE comment, so
+// proxies do not close it.
+const taskLogKeepAlive = 15 * time.Second
+
+type TaskLogHandler struct {
+	taskService    *services.TaskService
+	taskLogService *services.TaskLogService
+}
+
+func NewTaskLogHandler(taskService *services.TaskService, taskLogService *services.TaskLogService) *TaskLogHandler {
+	return &TaskLogHandler{
+		taskService:    taskService,
+		taskLogService: taskLogService,
+	}
+}
+
+type appendTaskLogRequest struct {
+	Stream models.TaskLogStream `json:"stream" binding:"required"`
+	Data   string               `json:"data" binding:"required"`
+}
+
+// AppendLog takes a stdout or stderr chunk from the runner executing the task.
+func (h *TaskLogHandler) AppendLog(c *gin.Context) {
+	deviceID := c.GetHeader("X-Device-ID")
+	if deviceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Device ID is required"})
+		return
+	}
+
+	task, err := h.taskService.GetTask(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, services.ErrTaskNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// JSON escaping can grow a chunk up to six times its size.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(h.taskLogService.MaxChunkBytes())*6+1024)
+	var req appendTaskLogRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": services.ErrLogChunkTooLarge.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	chunk, err := h.taskLogService.Append(c.Request.Context(), task.ID, deviceID, req.Stream, req.Data)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrInvalidLogChunk):
+			status = http.StatusBadRequest
+		case errors.Is(err, services.ErrLogNotWritable):
+			status = http.StatusConflict
+		case errors.Is(err, services.ErrLogChunkTooLarge), errors.Is(err, services.ErrLogLimitReached):
+			status = http.StatusRequestEntityTooLarge
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"seq": chunk.Seq})
+}
+
+// GetLogs returns a task's log to its creator. With follow=true the log is
+// streamed as Server-Sent Events until the task finishes; a client resumes a
+// dropped stream by sending Last-Event-ID.
+func (h *TaskLogHandler) GetLogs(c *gin.Context) {
+	task, err := h.taskService.GetTask(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, services.ErrTaskNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if principal := middleware.PrincipalFrom(c); principal != nil && !principal.Owns(task.CreatorAddress) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "task belongs to another creator"})
+		return
+	}
+
+	after := c.Query("after")
+	if after == "" {
+		after = c.GetHeader("Last-Event-ID")
+	}
+	var afterSeq int64
+	if after != "" {
+		if afterSeq, err = strconv.ParseInt(after, 10, 64); err != nil || afterSeq < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "after must be a non-negative sequence number"})
+			return
+		}
+	}
+
+	if c.Query("follow") != "true" {
+		chunks, live, err := h.taskLogService.Chunks(c.Request.Context(), task.ID, afterSeq)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"task_id": task.ID, "live": live, "chunks": chunks})
+		return
+	}
+
+	subscription, err := h.taskLogService.Subscribe(c.Request.Context(), task, afterSeq)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer subscription.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	for i := range subscription.Backlog {
+		if !writeLogEvent(c, &subscription.Backlog[i]) {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	if subscription.Chunks != nil {
+		keepAlive := time.NewTicker(taskLogKeepAlive)
+		defer keepAlive.Stop()
+
+	stream:
+		for {
+			select {
+			case <-c.Request.Context().Done():
+				return
+			case chunk, ok := <-subscription.Chunks:
+				if !ok {
+					break stream
+				}
+				if !writeLogEvent(c, &chunk) {
+					return
+				}
+			case <-keepAlive.C:
+				if _, err := fmt.Fprint(c.Writer, ": keep-alive\n\n"); err != nil {
+					return
+				}
+			}
+			c.Writer.Flush()
+		}
+
+		// The stream also ends when this follower fell behind; only announce
+		// the end if the task is actually done, so the client reconnects
+		// otherwise.
+		if task, err = h.taskService.GetTask(c.Request.Context(), task.ID.String()); err != nil {
+			return
+		}
+	}
+
+	if task.Status == models.TaskStatusCompleted || task.Status == models.TaskStatusFailed || task.Status == models.TaskStatusNotVerified {
+		data, _ := json.Marshal(gin.H{"status": task.Status})
+		fmt.Fprintf(c.Writer, "event: end\ndata: %s\n\n", data)
+		c.Writer.Flush()
+	}
+}
+
+func writeLogEvent(c *gin.Context, chunk *models.TaskLogChunk) bool {
+	data, err := json.Marshal(chunk)
+	if err != nil {
+		return false
+	}
+	_, err = fmt.Fprintf(c.Writer, "id: %d\nevent: log\ndata: %s\n\n", chunk.Seq, data)
+	return err == nil
+}
@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/theblitlabs/gologger"
@@ -44,6 +46,11 @@ func (h *WebhookHandler) RegisterWebhook(c *gin.Context) {
 		return
 	}
 
+	if req.WalletAddress != "" && sessionWalletMismatch(c, req.WalletAddress) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Wallet address does not match runner session"})
+		return
+	}
+
 	_, err := h.runnerService.CreateOrUpdateRunner(c.Request.Context(), &coremodels.Runner{
 		DeviceID:      deviceID,
 		Status:        coremodels.RunnerStatusOnline,
@@ -61,16 +68,55 @@ func (h *WebhookHandler) RegisterWebhook(c *gin.Context) {
 		WalletAddress: req.WalletAddress,
 	}
 
-	webhookID, err := h.webhookService.RegisterWebhook(serviceReq, deviceID)
+	webhookID, err := h.webhookService.RegisterWebhook(c.Request.Context(), serviceReq, deviceID)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to register webhook")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"id": webhookID,
-	})
+	signingSecret, issued, err := h.webhookService.SigningSecret(c.Request.Context(), deviceID)
+	if err != nil {
+		log.Error().Err(err).Str("device_id", deviceID).Msg("Failed to issue webhook signing secret")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{"id": webhookID}
+	if issued && runnerAuthenticated(c) {
+		response["signing_secret"] = signingSecret
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// RotateSigningSecret issues the runner a new webhook signing secret. The old
+// secret keeps signing webhooks until the overlap window ends.
+func (h *WebhookHandler) RotateSigningSecret(c *gin.Context) {
+	if !runnerAuthenticated(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "runner authentication required"})
+		return
+	}
+	deviceID := c.GetHeader("X-Device-ID")
+
+	var req models.RotateWebhookSecretRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+	}
+	if req.OverlapSeconds < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "overlap_seconds must not be negative"})
+		return
+	}
+
+	rotation, err := h.webhookService.RotateSigningSecret(c.Request.Context(), deviceID, time.Duration(req.OverlapSeconds)*time.Second)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rotation)
 }
 
 func (h *WebhookHandler) UnregisterWebhook(c *gin.Context) {
@@ -90,9 +136,50 @@ func (h *WebhookHandler) UnregisterWebhook(c *gin.Context) {
 		return
 	}
 
+	if err := h.webhookService.UnregisterDeviceWebhooks(c.Request.Context(), deviceID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	c.Status(http.StatusOK)
 }
 
+// ListDeliveries returns the calling runner's recent webhook deliveries and the
+// outcome of each attempt.
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	deviceID := c.GetHeader("X-Device-ID")
+	if deviceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "X-Device-ID header is required"})
+		return
+	}
+
+	status := coremodels.WebhookDeliveryStatus(c.Query("status"))
+	switch status {
+	case "", coremodels.WebhookDeliveryPending, coremodels.WebhookDeliveryDelivered, coremodels.WebhookDeliveryDead:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status filter"})
+		return
+	}
+
+	limit := 50
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(c.Request.Context(), deviceID, status, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
 func (h *WebhookHandler) CleanupResources() {
 	h.webhookService.CleanupResources()
 }
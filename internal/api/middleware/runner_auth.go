@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+const (
+	RunnerDeviceIDKey      = "runner_device_id"
+	RunnerWalletAddressKey = "runner_wallet_address"
+	RunnerSessionKey       = "runner_session"
+)
+
+type RunnerAuthenticator interface {
+	Authenticate(ctx context.Context, accessToken string) (*models.RunnerSession, error)
+}
+
+// RunnerAuth resolves the bearer token to a runner session and pins the request's
+// device identity to it. Without enforce, requests carrying no token fall through
+// with their X-Device-ID/X-Runner-ID headers so older runners keep working.
+func RunnerAuth(authenticator RunnerAuthenticator, enforce bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := gologger.WithComponent("runner_auth")
+
+		token := BearerToken(c)
+		if token == "" {
+			if enforce {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "runner authentication required"})
+				return
+			}
+			c.Next()
+			return
+		}
+
+		session, err := authenticator.Authenticate(c.Request.Context(), token)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired runner session"})
+			return
+		}
+
+		for _, header := range []string{"X-Device-ID", "X-Runner-ID"} {
+			if claimed := c.GetHeader(header); claimed != "" && claimed != session.DeviceID {
+				log.Warn().
+					Str("header", header).
+					Str("claimed_device_id", claimed).
+					Str("session_device_id", session.DeviceID).
+					Msg("Runner identity header does not match session")
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "device ID does not match runner session"})
+				return
+			}
+		}
+
+		// Handlers read the device ID from the headers, so overwrite them with the
+		// authenticated identity.
+		c.Request.Header.Set("X-Device-ID", session.DeviceID)
+		c.Request.Header.Set("X-Runner-ID", session.DeviceID)
+
+		c.Set(RunnerDeviceIDKey, session.DeviceID)
+		c.Set(RunnerWalletAddressKey, session.WalletAddress)
+		c.Set(RunnerSessionKey, session)
+
+		c.Next()
+	}
+}
+
+// BearerToken returns the token from an "Authorization: Bearer" header.
+func BearerToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if len(header) < 7 || !strings.EqualFold(header[:7], "bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(header[7:])
+}
@@ -19,6 +19,14 @@ type RegisterWebhookRequest struct {
 	WalletAddress string `json:"wallet_address"`
 }
 
+type RotateWebhookSecretRequest struct {
+	OverlapSeconds int `json:"overlap_seconds"`
+}
+
+type DrainRunnerRequest struct {
+	MaintenanceMinutes int `json:"maintenance_minutes"`
+}
+
 type WSMessage struct {
 	Type    string      `json:"type"`
 	Payload interface{} `json:"payload"`
@@ -31,11 +39,13 @@ type CreateTaskRequest struct {
 	Image       string                        `json:"image"`
 	Command     []string                      `json:"command,omitempty"`
 	ImageHash   string                        `json:"image_hash,omitempty"`
+	ImageDigest string                        `json:"image_digest,omitempty"`
 	CommandHash string                        `json:"command_hash,omitempty"`
 	Config      json.RawMessage               `json:"config"`
 	Environment *coremodels.EnvironmentConfig `json:"environment,omitempty"`
 	Reward      float64                       `json:"reward"`
 	CreatorID   string                        `json:"creator_id"`
+	HighValue   bool                          `json:"high_value,omitempty"`
 }
 
 type HeartbeatPayload struct {
@@ -46,7 +56,11 @@ type HeartbeatPayload struct {
 	Memory            int64                   `json:"memory_usage"`
 	CPU               float64                 `json:"cpu_usage"`
 	PublicIP          string                  `json:"public_ip,omitempty"`
+	ProtocolVersion   int                     `json:"protocol_version,omitempty"`
+	RunnerVersion     string                  `json:"runner_version,omitempty"`
 	ModelCapabilities []ModelCapabilityInfo   `json:"model_capabilities,omitempty"`
+	CachedImages      []string                `json:"cached_images,omitempty"`
+	CachedModels      []string                `json:"cached_models,omitempty"`
 }
 
 type ModelCapabilityInfo struct {
@@ -59,6 +73,10 @@ type PromptRequest struct {
 	Prompt         string `json:"prompt" binding:"required"`
 	ModelName      string `json:"model_name" binding:"required"`
 	CreatorAddress string `json:"creator_address" binding:"required"`
+
+	// Optional sampling parameters: system_prompt, temperature, top_p,
+	// max_tokens, stop and seed.
+	coremodels.SamplingParams
 }
 
 type PromptResponse struct {
@@ -80,6 +98,11 @@ type BillingMetricsResponse struct {
 type RegisterRunnerRequest struct {
 	WalletAddress     string                `json:"wallet_address" binding:"required"`
 	Webhook           string                `json:"webhook,omitempty"`
+	DeliveryMode      string                `json:"delivery_mode,omitempty"`
+	Slots             int                   `json:"slots,omitempty"`
+	Labels            map[string]string     `json:"labels,omitempty"`
+	ProtocolVersion   int                   `json:"protocol_version,omitempty"`
+	RunnerVersion     string                `json:"runner_version,omitempty"`
 	ModelCapabilities []ModelCapabilityInfo `json:"model_capabilities,omitempty"`
 }
 
@@ -196,3 +219,43 @@ type GlobalMetricsResponse struct {
 	Variance        float64 `json:"variance"`
 	Convergence     float64 `json:"convergence"`
 }
+
+type BindRunnerWalletRequest struct {
+	WalletAddress string `json:"wallet_address" binding:"required"`
+}
+
+type RunnerChallengeRequest struct {
+	WalletAddress string `json:"wallet_address" binding:"required"`
+}
+
+type RunnerLoginRequest struct {
+	ChallengeID string `json:"challenge_id" binding:"required"`
+	Signature   string `json:"signature" binding:"required"`
+}
+
+type RunnerRefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type RunnerLogoutRequest struct {
+	AllSessions bool `json:"all_sessions"`
+}
+
+type AuthChallengeRequest struct {
+	WalletAddress string `json:"wallet_address" binding:"required"`
+}
+
+type AuthLoginRequest struct {
+	ChallengeID string `json:"challenge_id" binding:"required"`
+	Signature   string `json:"signature" binding:"required"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name          string            `json:"name" binding:"required"`
+	Roles         []coremodels.Role `json:"roles,omitempty"`
+	ExpiresInDays int               `json:"expires_in_days,omitempty"`
+}
+
+type SetAccountRolesRequest struct {
+	Roles []coremodels.Role `json:"roles" binding:"required"`
+}
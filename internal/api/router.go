@@ -4,7 +4,6 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/theblitlabs/parity-server/internal/api/handlers"
 	"github.com/theblitlabs/parity-server/internal/api/middleware"
 	v1 "github.com/theblitlabs/parity-server/internal/api/v1"
 )
@@ -19,7 +18,7 @@ type Router struct {
 	endpoint string
 }
 
-func NewRouter(taskHandler *handlers.TaskHandler, runnerHandler *handlers.RunnerHandler, webhookHandler *handlers.WebhookHandler, llmHandler *handlers.LLMHandler, federatedLearningHandler *handlers.FederatedLearningHandler, reputationHandler *handlers.ReputationHandler, endpoint string) *Router {
+func NewRouter(h v1.Handlers, endpoint string) *Router {
 	engine := gin.New()
 
 	engine.Use(gin.Recovery())
@@ -30,11 +29,11 @@ func NewRouter(taskHandler *handlers.TaskHandler, runnerHandler *handlers.Runner
 		endpoint: endpoint,
 	}
 
-	r.registerRoutes(taskHandler, runnerHandler, webhookHandler, llmHandler, federatedLearningHandler, reputationHandler)
+	r.registerRoutes(h)
 	return r
 }
 
-func (r *Router) registerRoutes(taskHandler *handlers.TaskHandler, runnerHandler *handlers.RunnerHandler, webhookHandler *handlers.WebhookHandler, llmHandler *handlers.LLMHandler, federatedLearningHandler *handlers.FederatedLearningHandler, reputationHandler *handlers.ReputationHandler) {
+func (r *Router) registerRoutes(h v1.Handlers) {
 	// Root endpoint for server info
 	r.engine.GET("/", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -61,7 +60,7 @@ func (r *Router) registerRoutes(taskHandler *handlers.TaskHandler, runnerHandler
 
 	api := r.engine.Group(r.endpoint)
 	v1Group := api.Group("/v1")
-	v1.RegisterRoutes(v1Group, taskHandler, runnerHandler, webhookHandler, llmHandler, federatedLearningHandler, reputationHandler)
+	v1.RegisterRoutes(v1Group, h)
 }
 
 func (r *Router) Engine() *gin.Engine {
@@ -3,29 +3,86 @@ package v1
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/theblitlabs/parity-server/internal/api/handlers"
+	"github.com/theblitlabs/parity-server/internal/api/middleware"
+	"github.com/theblitlabs/parity-server/internal/core/models"
 )
 
-func registerTaskRoutes(router *gin.RouterGroup, taskHandler *handlers.TaskHandler) {
-	tasks := router.Group("/tasks")
+func registerAuthRoutes(router *gin.RouterGroup, authHandler *handlers.AuthHandler) {
+	siwe := router.Group("/auth/siwe")
+	{
+		siwe.POST("/challenge", authHandler.CreateChallenge)
+		siwe.POST("/login", authHandler.Login)
+	}
+
+	auth := router.Group("/auth", authHandler.Middleware())
+	{
+		auth.POST("/logout", authHandler.Logout)
+		auth.GET("/me", authHandler.Me)
+		auth.POST("/api-keys", authHandler.CreateAPIKey)
+		auth.GET("/api-keys", authHandler.ListAPIKeys)
+		auth.DELETE("/api-keys/:id", authHandler.RevokeAPIKey)
+		auth.PUT("/accounts/:wallet/roles", middleware.RequireAdmin(), authHandler.SetAccountRoles)
+	}
+}
+
+func registerTaskRoutes(router *gin.RouterGroup, taskHandler *handlers.TaskHandler, runnerAuthHandler *handlers.RunnerAuthHandler, authHandler *handlers.AuthHandler) {
+	router.POST("/tasks/:id/verify-hashes", runnerAuthHandler.Middleware(), taskHandler.VerifyTaskHashes)
+
+	tasks := router.Group("/tasks", authHandler.Middleware(), middleware.RequireRole(models.RoleCreator))
 	{
 		tasks.POST("", taskHandler.CreateTask)
 		tasks.GET("", taskHandler.ListTasks)
 		tasks.GET("/:id", taskHandler.GetTask)
 		tasks.GET("/:id/reward", taskHandler.GetTaskReward)
 		tasks.GET("/:id/result", taskHandler.GetTaskResult)
-		tasks.POST("/:id/verify-hashes", taskHandler.VerifyTaskHashes)
+		tasks.GET("/:id/selection", taskHandler.GetRunnerSelection)
+		tasks.GET("/:id/nonce", taskHandler.GetTaskNonce)
+	}
+
+	images := router.Group("/images", authHandler.Middleware(), middleware.RequireRole(models.RoleCreator))
+	{
+		images.GET("", taskHandler.ListImages)
 	}
 }
 
-func registerRunnerRoutes(router *gin.RouterGroup, taskHandler *handlers.TaskHandler, runnerHandler *handlers.RunnerHandler, webhookHandler *handlers.WebhookHandler) {
-	runners := router.Group("/runners")
+func registerRunnerRoutes(router *gin.RouterGroup, taskHandler *handlers.TaskHandler, runnerHandler *handlers.RunnerHandler, webhookHandler *handlers.WebhookHandler, runnerAuthHandler *handlers.RunnerAuthHandler, authHandler *handlers.AuthHandler, runnerSocketHandler *handlers.RunnerSocketHandler, runnerAdminHandler *handlers.RunnerAdminHandler) {
+	runnerAuth := router.Group("/runners/auth")
+	{
+		runnerAuth.POST("/challenge", runnerAuthHandler.CreateChallenge)
+		runnerAuth.POST("/login", runnerAuthHandler.Login)
+		runnerAuth.POST("/refresh", runnerAuthHandler.Refresh)
+		runnerAuth.POST("/logout", runnerAuthHandler.Logout)
+	}
+
+	runnerAdmin := router.Group("/runners/admin", authHandler.Middleware(), middleware.RequireAdmin())
+	{
+		runnerAdmin.GET("", runnerAdminHandler.ListRunners)
+		runnerAdmin.GET("/versions", runnerAdminHandler.GetVersionDistribution)
+		runnerAdmin.GET("/cache", runnerAdminHandler.GetCacheMetrics)
+		runnerAdmin.GET("/:device_id", runnerAdminHandler.GetRunner)
+		runnerAdmin.GET("/:device_id/telemetry", runnerAdminHandler.GetRunnerTelemetry)
+		runnerAdmin.GET("/:device_id/health", runnerAdminHandler.GetRunnerHealth)
+		runnerAdmin.GET("/:device_id/cache", runnerAdminHandler.GetRunnerCache)
+		runnerAdmin.POST("/:device_id/offline", runnerAdminHandler.ForceOffline)
+		runnerAdmin.POST("/:device_id/restore", runnerAdminHandler.RestoreRunner)
+		runnerAdmin.POST("/:device_id/wallet", runnerAdminHandler.BindWallet)
+		runnerAdmin.POST("/:device_id/requeue", runnerAdminHandler.RequeueWork)
+		runnerAdmin.DELETE("/:device_id", runnerAdminHandler.DeregisterRunner)
+	}
+
+	runners := router.Group("/runners", runnerAuthHandler.Middleware())
 	{
 		runners.POST("", runnerHandler.RegisterRunner)
 		runners.POST("/heartbeat", runnerHandler.RunnerHeartbeat)
+		runners.POST("/drain", runnerHandler.DrainRunner)
+		runners.POST("/undrain", runnerHandler.UndrainRunner)
+		runners.GET("/ws", runnerSocketHandler.Connect)
 
 		runnerTasks := runners.Group("/tasks")
 		{
 			runnerTasks.GET("/available", runnerHandler.ListAvailableTasks)
+			runnerTasks.GET("/next", runnerHandler.NextTask)
+			runnerTasks.POST("/:id/lease", runnerHandler.RenewTaskLease)
 			runnerTasks.POST("/:id/start", runnerHandler.StartTask)
 			runnerTasks.POST("/:id/complete", runnerHandler.CompleteTask)
 			runnerTasks.POST("/:id/result", taskHandler.SaveTaskResult)
@@ -35,43 +92,52 @@ func registerRunnerRoutes(router *gin.RouterGroup, taskHandler *handlers.TaskHan
 		{
 			runnerWebhooks.POST("", webhookHandler.RegisterWebhook)
 			runnerWebhooks.DELETE("", webhookHandler.UnregisterWebhook)
+			runnerWebhooks.GET("/deliveries", webhookHandler.ListDeliveries)
+			runnerWebhooks.POST("/secret/rotate", webhookHandler.RotateSigningSecret)
 		}
 	}
 }
 
-func registerLLMRoutes(router *gin.RouterGroup, llmHandler *handlers.LLMHandler) {
-	llm := router.Group("/llm")
+func registerLLMRoutes(router *gin.RouterGroup, llmHandler *handlers.LLMHandler, runnerAuthHandler *handlers.RunnerAuthHandler, authHandler *handlers.AuthHandler) {
+	router.GET("/llm/models", llmHandler.GetAvailableModels)
+	router.POST("/llm/prompts/:id/complete", runnerAuthHandler.Middleware(), llmHandler.CompletePrompt)
+
+	llm := router.Group("/llm", authHandler.Middleware(), middleware.RequireRole(models.RoleLLMClient))
 	{
-		llm.GET("/models", llmHandler.GetAvailableModels)
 		llm.POST("/prompts", llmHandler.SubmitPrompt)
 		llm.GET("/prompts", llmHandler.ListPrompts)
 		llm.GET("/prompts/:id", llmHandler.GetPrompt)
-		llm.POST("/prompts/:id/complete", llmHandler.CompletePrompt)
 		llm.GET("/billing/metrics", llmHandler.GetBillingMetrics)
 	}
 }
 
-func registerFederatedLearningRoutes(router *gin.RouterGroup, flHandler *handlers.FederatedLearningHandler) {
-	fl := router.Group("/federated-learning")
+func registerFederatedLearningRoutes(router *gin.RouterGroup, flHandler *handlers.FederatedLearningHandler, runnerAuthHandler *handlers.RunnerAuthHandler, authHandler *handlers.AuthHandler) {
+	router.POST("/federated-learning/model-updates", runnerAuthHandler.Middleware(), flHandler.SubmitModelUpdate)
+
+	fl := router.Group("/federated-learning", authHandler.Middleware(), middleware.RequireRole(models.RoleCreator))
 	{
 		fl.POST("/sessions", flHandler.CreateSession)
 		fl.GET("/sessions", flHandler.ListSessions)
 		fl.GET("/sessions/:id", flHandler.GetSession)
 		fl.POST("/sessions/:id/start", flHandler.StartSession)
 		fl.GET("/sessions/:id/model", flHandler.GetModel)
-		fl.POST("/model-updates", flHandler.SubmitModelUpdate)
 		fl.GET("/sessions/:id/rounds/:roundNumber", flHandler.GetRound)
 	}
 }
 
-func registerReputationRoutes(router *gin.RouterGroup, reputationHandler *handlers.ReputationHandler) {
+func registerReputationRoutes(router *gin.RouterGroup, reputationHandler *handlers.ReputationHandler, authHandler *handlers.AuthHandler) {
 	reputation := router.Group("/reputation")
 	{
 		reputation.GET("/runners/:runner_id", reputationHandler.GetRunnerReputation)
 		reputation.GET("/leaderboard", reputationHandler.GetLeaderboard)
 		reputation.GET("/network/stats", reputationHandler.GetNetworkStats)
 		reputation.GET("/runners/:runner_id/events", reputationHandler.GetRunnerEvents)
-		reputation.POST("/report/malicious", reputationHandler.ReportMaliciousBehavior)
+
+		admin := reputation.Group("", authHandler.Middleware(), middleware.RequireAdmin())
+		{
+			admin.POST("/report/malicious", reputationHandler.ReportMaliciousBehavior)
+			admin.POST("/runners/:runner_id/slash", reputationHandler.SlashRunnerStake)
+		}
 
 		monitoring := reputation.Group("/monitoring")
 		{
@@ -82,10 +148,84 @@ func registerReputationRoutes(router *gin.RouterGroup, reputationHandler *handle
 	}
 }
 
-func RegisterRoutes(api *gin.RouterGroup, taskHandler *handlers.TaskHandler, runnerHandler *handlers.RunnerHandler, webhookHandler *handlers.WebhookHandler, llmHandler *handlers.LLMHandler, flHandler *handlers.FederatedLearningHandler, reputationHandler *handlers.ReputationHandler) {
-	registerTaskRoutes(api, taskHandler)
-	registerRunnerRoutes(api, taskHandler, runnerHandler, webhookHandler)
-	registerLLMRoutes(api, llmHandler)
-	registerFederatedLearningRoutes(api, flHandler)
-	registerReputationRoutes(api, reputationHandler)
+func registerRetentionRoutes(router *gin.RouterGroup, retentionHandler *handlers.RetentionHandler, authHandler *handlers.AuthHandler) {
+	retention := router.Group("/retention", authHandler.Middleware(), middleware.RequireAdmin())
+	{
+		retention.GET("/policies", retentionHandler.ListPolicies)
+		retention.PUT("/policies/:creator", retentionHandler.SetPolicy)
+		retention.DELETE("/policies/:creator", retentionHandler.DeletePolicy)
+		retention.POST("/run", retentionHandler.Run)
+	}
+}
+
+func registerStorageRoutes(router *gin.RouterGroup, storageHandler *handlers.StorageHandler, runnerAuthHandler *handlers.RunnerAuthHandler) {
+	storage := router.Group("/storage", runnerAuthHandler.Middleware())
+	{
+		storage.GET("/download/:key", storageHandler.Download)
+	}
+}
+
+func registerArtifactRoutes(router *gin.RouterGroup, artifactHandler *handlers.ArtifactHandler, runnerAuthHandler *handlers.RunnerAuthHandler, authHandler *handlers.AuthHandler) {
+	uploads := router.Group("/runners/tasks/:id/artifacts", runnerAuthHandler.Middleware())
+	{
+		uploads.POST("", artifactHandler.StartUpload)
+		uploads.GET("/uploads/:upload_id", artifactHandler.GetUpload)
+		uploads.PATCH("/uploads/:upload_id", artifactHandler.AppendUpload)
+	}
+
+	router.GET("/tasks/:id/artifacts/:name", authHandler.Middleware(), middleware.RequireRole(models.RoleCreator), artifactHandler.Download)
+}
+
+func registerTaskLogRoutes(router *gin.RouterGroup, taskLogHandler *handlers.TaskLogHandler, runnerAuthHandler *handlers.RunnerAuthHandler, authHandler *handlers.AuthHandler) {
+	router.POST("/runners/tasks/:id/logs", runnerAuthHandler.Middleware(), taskLogHandler.AppendLog)
+	router.GET("/tasks/:id/logs", authHandler.Middleware(), middleware.RequireRole(models.RoleCreator), taskLogHandler.GetLogs)
+}
+
+func registerClientEventRoutes(router *gin.RouterGroup, clientEventHandler *handlers.ClientEventHandler, authHandler *handlers.AuthHandler) {
+	router.GET("/events", authHandler.Middleware(), middleware.RequireRole(models.RoleCreator, models.RoleLLMClient), clientEventHandler.Stream)
+}
+
+func registerCreatorWebhookRoutes(router *gin.RouterGroup, creatorWebhookHandler *handlers.CreatorWebhookHandler, authHandler *handlers.AuthHandler) {
+	webhooks := router.Group("/webhooks", authHandler.Middleware(), middleware.RequireRole(models.RoleCreator))
+	{
+		webhooks.POST("", creatorWebhookHandler.Register)
+		webhooks.GET("", creatorWebhookHandler.List)
+		webhooks.DELETE("/:id", creatorWebhookHandler.Delete)
+		webhooks.GET("/deliveries", creatorWebhookHandler.ListDeliveries)
+	}
+}
+
+// Handlers holds the handler for every feature served under /v1.
+type Handlers struct {
+	Task           *handlers.TaskHandler
+	Runner         *handlers.RunnerHandler
+	Webhook        *handlers.WebhookHandler
+	LLM            *handlers.LLMHandler
+	FL             *handlers.FederatedLearningHandler
+	Reputation     *handlers.ReputationHandler
+	RunnerAuth     *handlers.RunnerAuthHandler
+	Auth           *handlers.AuthHandler
+	RunnerSocket   *handlers.RunnerSocketHandler
+	RunnerAdmin    *handlers.RunnerAdminHandler
+	Retention      *handlers.RetentionHandler
+	Storage        *handlers.StorageHandler
+	Artifact       *handlers.ArtifactHandler
+	TaskLog        *handlers.TaskLogHandler
+	ClientEvent    *handlers.ClientEventHandler
+	CreatorWebhook *handlers.CreatorWebhookHandler
+}
+
+func RegisterRoutes(api *gin.RouterGroup, h Handlers) {
+	registerAuthRoutes(api, h.Auth)
+	registerTaskRoutes(api, h.Task, h.RunnerAuth, h.Auth)
+	registerRunnerRoutes(api, h.Task, h.Runner, h.Webhook, h.RunnerAuth, h.Auth, h.RunnerSocket, h.RunnerAdmin)
+	registerLLMRoutes(api, h.LLM, h.RunnerAuth, h.Auth)
+	registerFederatedLearningRoutes(api, h.FL, h.RunnerAuth, h.Auth)
+	registerReputationRoutes(api, h.Reputation, h.Auth)
+	registerRetentionRoutes(api, h.Retention, h.Auth)
+	registerStorageRoutes(api, h.Storage, h.RunnerAuth)
+	registerArtifactRoutes(api, h.Artifact, h.RunnerAuth, h.Auth)
+	registerTaskLogRoutes(api, h.TaskLog, h.RunnerAuth, h.Auth)
+	registerClientEventRoutes(api, h.ClientEvent, h.Auth)
+	registerCreatorWebhookRoutes(api, h.CreatorWebhook, h.Auth)
 }
@@ -16,11 +16,13 @@ import (
 	"github.com/theblitlabs/keystore"
 	"github.com/theblitlabs/parity-server/internal/api"
 	"github.com/theblitlabs/parity-server/internal/api/handlers"
+	v1 "github.com/theblitlabs/parity-server/internal/api/v1"
 	"github.com/theblitlabs/parity-server/internal/core/config"
 	"github.com/theblitlabs/parity-server/internal/core/ports"
 	"github.com/theblitlabs/parity-server/internal/core/services"
 	"github.com/theblitlabs/parity-server/internal/database"
 	"github.com/theblitlabs/parity-server/internal/database/repositories"
+	"github.com/theblitlabs/parity-server/internal/storage"
 	"github.com/theblitlabs/parity-server/internal/utils"
 	"gorm.io/gorm"
 )
@@ -36,6 +38,7 @@ type Server struct {
 	DB                      *gorm.DB
 	TaskService             *services.TaskService
 	RunnerService           *services.RunnerService
+	RunnerHub               *services.RunnerHub
 	ReputationService       *services.ReputationService
 	RunnerMonitoringService *services.RunnerMonitoringService
 	HeartbeatService        *services.HeartbeatService
@@ -104,6 +107,11 @@ func (s *Server) Shutdown(ctx context.Context) {
 		}
 	}
 
+	if s.RunnerHub != nil {
+		s.RunnerHub.CloseAll()
+		log.Info().Msg("Closed runner connections")
+	}
+
 	log.Info().Int("shutdown_timeout_seconds", 15).Msg("Initiating server shutdown sequence")
 	shutdownStart := time.Now()
 
@@ -145,16 +153,43 @@ type ServerBuilder struct {
 	flSessionRepo               ports.FLSessionRepository
 	flRoundRepo                 ports.FLRoundRepository
 	flParticipantRepo           ports.FLParticipantRepository
+	runnerAuthRepo              ports.RunnerAuthRepository
+	webhookRepo                 ports.WebhookRepository
+	telemetryRepo               ports.TelemetryRepository
+	imageRepo                   ports.ImageRepository
+	retentionRepo               ports.RetentionRepository
+	artifactRepo                ports.ArtifactRepository
+	taskLogRepo                 ports.TaskLogRepository
+	creatorWebhookRepo          ports.CreatorWebhookRepository
+	datasetRepo                 ports.DatasetRepository
+	runnerCacheRepo             ports.RunnerCacheRepository
+	clientEventRepo             ports.ClientEventRepository
+	accountRepo                 ports.AccountRepository
 	taskService                 *services.TaskService
 	runnerService               *services.RunnerService
+	runnerHub                   *services.RunnerHub
+	runnerAuthService           *services.RunnerAuthService
+	authService                 *services.AuthService
 	reputationService           *services.ReputationService
 	reputationBlockchainService *services.ReputationBlockchainService
 	runnerMonitoringService     *services.RunnerMonitoringService
+	runnerAdminService          *services.RunnerAdminService
 	llmService                  *services.LLMService
 	taskQueue                   *services.TaskQueue
 	heartbeatService            *services.HeartbeatService
 	webhookService              *services.WebhookService
+	telemetryService            *services.TelemetryService
+	objectStorage               ports.Storage
 	storageService              services.StorageService
+	imageInspector              *services.ImageInspector
+	imageService                *services.ImageService
+	retentionService            *services.RetentionService
+	artifactService             *services.ArtifactService
+	taskLogService              *services.TaskLogService
+	eventBus                    *services.EventBus
+	creatorWebhookService       *services.CreatorWebhookService
+	datasetService              *services.DatasetService
+	runnerCacheService          *services.RunnerCacheService
 	verificationService         *services.VerificationService
 	federatedLearningService    *services.FederatedLearningService
 	flRewardService             *services.FLRewardService
@@ -162,6 +197,16 @@ type ServerBuilder struct {
 	taskHandler                 *handlers.TaskHandler
 	runnerHandler               *handlers.RunnerHandler
 	reputationHandler           *handlers.ReputationHandler
+	runnerAuthHandler           *handlers.RunnerAuthHandler
+	authHandler                 *handlers.AuthHandler
+	runnerSocketHandler         *handlers.RunnerSocketHandler
+	runnerAdminHandler          *handlers.RunnerAdminHandler
+	retentionHandler            *handlers.RetentionHandler
+	storageHandler              *handlers.StorageHandler
+	artifactHandler             *handlers.ArtifactHandler
+	taskLogHandler              *handlers.TaskLogHandler
+	clientEventHandler          *handlers.ClientEventHandler
+	creatorWebhookHandler       *handlers.CreatorWebhookHandler
 	webhookHandler              *handlers.WebhookHandler
 	llmHandler                  *handlers.LLMHandler
 	federatedLearningHandler    *handlers.FederatedLearningHandler
@@ -216,6 +261,18 @@ func (sb *ServerBuilder) InitRepositories() *ServerBuilder {
 	sb.flSessionRepo = repositories.NewFLSessionRepository(sb.DB)
 	sb.flRoundRepo = repositories.NewFLRoundRepository(sb.DB)
 	sb.flParticipantRepo = repositories.NewFLParticipantRepository(sb.DB)
+	sb.runnerAuthRepo = repositories.NewRunnerAuthRepository(sb.DB)
+	sb.accountRepo = repositories.NewAccountRepository(sb.DB)
+	sb.webhookRepo = repositories.NewWebhookRepository(sb.DB)
+	sb.telemetryRepo = repositories.NewTelemetryRepository(sb.DB)
+	sb.imageRepo = repositories.NewImageRepository(sb.DB)
+	sb.retentionRepo = repositories.NewRetentionRepository(sb.DB)
+	sb.artifactRepo = repositories.NewArtifactRepository(sb.DB)
+	sb.taskLogRepo = repositories.NewTaskLogRepository(sb.DB)
+	sb.creatorWebhookRepo = repositories.NewCreatorWebhookRepository(sb.DB)
+	sb.datasetRepo = repositories.NewDatasetRepository(sb.DB)
+	sb.runnerCacheRepo = repositories.NewRunnerCacheRepository(sb.DB)
+	sb.clientEventRepo = repositories.NewClientEventRepository(sb.DB)
 
 	return sb
 }
@@ -228,6 +285,7 @@ func (sb *ServerBuilder) InitServices() *ServerBuilder {
 	rewardCalculator := services.NewRewardCalculator()
 
 	sb.runnerService = services.NewRunnerService(sb.runnerRepo)
+	sb.runnerService.SetMinimumProtocol(sb.config.Protocol.MinVersion)
 	sb.taskService = services.NewTaskService(sb.taskRepo, rewardCalculator.(*services.RewardCalculator), sb.runnerService)
 	if shouldEnableRewardDistribution(sb.config) {
 		sb.taskService.SetRewardClient(services.NewBlockchainRewardClient(sb.config))
@@ -236,20 +294,76 @@ func (sb *ServerBuilder) InitServices() *ServerBuilder {
 		log.Info().Msg("Reward distribution disabled by configuration")
 	}
 	sb.runnerService.SetTaskService(sb.taskService)
+	sb.runnerHub = services.NewRunnerHub(sb.runnerService)
+	sb.runnerService.SetRunnerHub(sb.runnerHub)
+	sb.taskService.SetRunnerHub(sb.runnerHub)
+	sb.runnerAuthService = services.NewRunnerAuthService(sb.runnerAuthRepo, sb.runnerService, sb.config.Auth)
+	sb.authService = services.NewAuthService(sb.accountRepo, sb.config)
 
-	sb.webhookService = services.NewWebhookService(sb.taskService)
-
-	storageService, err := services.NewStorageService(sb.config)
+	randomnessSource, err := services.NewRandomnessSource(sb.config)
 	if err != nil {
-		sb.err = fmt.Errorf("failed to initialize storage service: %w", err)
+		sb.err = fmt.Errorf("failed to initialize randomness source: %w", err)
 		return sb
 	}
-	sb.storageService = storageService
+	sb.taskService.SetNonceService(services.NewNonceService(randomnessSource))
+	sb.taskService.SetDispatchConfig(sb.config.Dispatch)
+
+	webhookSigner := services.NewWebhookSigner(sb.webhookRepo)
+	webhookSigner.SetOverlap(time.Duration(sb.config.Webhook.SecretOverlap) * time.Second)
+	sb.taskService.SetWebhookSigner(webhookSigner)
+	sb.runnerService.SetWebhookSigner(webhookSigner)
+	sb.webhookService = services.NewWebhookService(sb.webhookRepo, sb.taskService)
+	sb.webhookService.SetWebhookSigner(webhookSigner)
+	sb.webhookService.SetDeliveryConfig(sb.config.Webhook)
+	sb.webhookService.SetRunnerService(sb.runnerService)
+	sb.taskService.SetWebhookService(sb.webhookService)
+	sb.runnerService.SetWebhookService(sb.webhookService)
+
+	sb.telemetryService = services.NewTelemetryService(sb.telemetryRepo)
+	sb.telemetryService.SetConfig(sb.config.Telemetry)
+	sb.runnerService.SetTelemetryService(sb.telemetryService)
+
+	objectStorage, err := storage.New(sb.config)
+	if err != nil {
+		sb.err = fmt.Errorf("failed to initialize storage backend: %w", err)
+		return sb
+	}
+	sb.objectStorage = objectStorage
+	sb.storageService = services.NewStorageService(sb.objectStorage)
+
+	sb.imageInspector = services.NewImageInspector()
+	sb.imageInspector.SetConfig(sb.config.Image)
+	sb.imageService = services.NewImageService(sb.imageRepo, sb.storageService)
+	sb.retentionService = services.NewRetentionService(sb.retentionRepo, sb.storageService)
+	sb.retentionService.SetConfig(sb.config.Retention)
+	sb.retentionService.SetObjectStore(sb.objectStorage)
+	sb.artifactService = services.NewArtifactService(sb.artifactRepo, sb.objectStorage)
+	sb.artifactService.SetConfig(sb.config.Artifact)
+	sb.taskService.SetArtifactService(sb.artifactService)
+	sb.taskLogService = services.NewTaskLogService(sb.taskLogRepo, sb.taskRepo)
+	sb.taskLogService.SetConfig(sb.config.TaskLog)
+	sb.taskService.SetTaskLogService(sb.taskLogService)
+	sb.runnerHub.SetTaskLogService(sb.taskLogService)
+	sb.datasetService = services.NewDatasetService(sb.datasetRepo, sb.objectStorage)
+	sb.datasetService.SetConfig(sb.config.Dataset)
+	sb.taskService.SetDatasetService(sb.datasetService)
+	sb.runnerCacheService = services.NewRunnerCacheService(sb.runnerCacheRepo)
+	sb.runnerCacheService.SetConfig(sb.config.RunnerCache)
+	sb.runnerService.SetRunnerCacheService(sb.runnerCacheService)
+	sb.taskService.SetRunnerCacheService(sb.runnerCacheService)
+	sb.eventBus = services.NewEventBus()
+	sb.eventBus.SetConfig(sb.config.Events)
+	sb.eventBus.SetRepository(sb.clientEventRepo)
+	sb.taskService.SetEventBus(sb.eventBus)
+	sb.creatorWebhookService = services.NewCreatorWebhookService(sb.creatorWebhookRepo, sb.taskRepo)
+	sb.creatorWebhookService.SetDeliveryConfig(sb.config.Webhook)
+	sb.taskService.SetCreatorWebhookService(sb.creatorWebhookService)
 
 	sb.verificationService = services.NewVerificationService(sb.taskRepo)
 
 	// Initialize task queue before LLM service
 	sb.taskQueue = services.NewTaskQueue(sb.promptRepo, sb.runnerRepo, sb.runnerService)
+	sb.taskQueue.SetConfig(sb.config.PromptQueue)
 
 	sb.llmService = services.NewLLMService(sb.promptRepo, sb.billingRepo, sb.runnerRepo, sb.runnerService, sb.taskQueue)
 	sb.federatedLearningService = services.NewFederatedLearningService(
@@ -268,15 +382,12 @@ func (sb *ServerBuilder) InitServices() *ServerBuilder {
 		sb.runnerService,
 	)
 	sb.federatedLearningService.SetFLRewardService(sb.flRewardService)
+	sb.federatedLearningService.SetStorage(sb.objectStorage)
+	sb.llmService.SetEventBus(sb.eventBus)
+	sb.federatedLearningService.SetEventBus(sb.eventBus)
 
 	// Initialize reputation blockchain service
-	blockchainService, ok := sb.storageService.(*services.BlockchainService)
-	if !ok {
-		// Create a minimal service for reputation blockchain
-		blockchainService = nil
-	}
-
-	reputationBlockchainService, err := services.NewReputationBlockchainService(sb.config, blockchainService)
+	reputationBlockchainService, err := services.NewReputationBlockchainService(sb.config, sb.objectStorage)
 	if err != nil {
 		sb.err = fmt.Errorf("failed to initialize reputation blockchain service: %w", err)
 		return sb
@@ -295,6 +406,7 @@ func (sb *ServerBuilder) InitServices() *ServerBuilder {
 		return sb
 	}
 	sb.reputationService = reputationService
+	sb.reputationService.SetTelemetryService(sb.telemetryService)
 
 	// Initialize runner monitoring service
 	sb.runnerMonitoringService = services.NewRunnerMonitoringService(
@@ -303,6 +415,11 @@ func (sb *ServerBuilder) InitServices() *ServerBuilder {
 		sb.taskService,
 	)
 
+	sb.runnerAdminService = services.NewRunnerAdminService(sb.runnerService, sb.taskService)
+	sb.runnerAdminService.SetLLMService(sb.llmService)
+	sb.runnerAdminService.SetReputationService(sb.reputationService)
+	sb.runnerAdminService.SetTelemetryService(sb.telemetryService)
+
 	return sb
 }
 
@@ -377,6 +494,30 @@ func (sb *ServerBuilder) InitTaskMonitoring() *ServerBuilder {
 	go sb.taskQueue.Start(sb.monitorCtx)
 	log.Info().Msg("Task queue processor started")
 
+	go sb.webhookService.Start(sb.monitorCtx)
+	log.Info().Msg("Webhook delivery worker started")
+
+	go sb.telemetryService.Start(sb.monitorCtx)
+	log.Info().Msg("Telemetry compaction worker started")
+
+	go sb.retentionService.Start(sb.monitorCtx)
+	log.Info().Msg("Retention collector started")
+
+	go sb.artifactService.Start(sb.monitorCtx)
+	log.Info().Msg("Artifact upload cleanup worker started")
+
+	go sb.taskLogService.Start(sb.monitorCtx)
+	log.Info().Msg("Task log idle collector started")
+
+	go sb.creatorWebhookService.Start(sb.monitorCtx)
+	log.Info().Msg("Creator webhook delivery worker started")
+
+	go sb.runnerCacheService.Start(sb.monitorCtx)
+	log.Info().Msg("Runner cache index worker started")
+
+	go sb.eventBus.Start(sb.monitorCtx)
+	log.Info().Msg("Client event relay started")
+
 	// Start reputation monitoring service if enabled
 	if sb.config.Reputation.MonitoringEnabled && sb.runnerMonitoringService != nil {
 		if err := sb.runnerMonitoringService.Start(); err != nil {
@@ -448,25 +589,47 @@ func (sb *ServerBuilder) InitRouter() *ServerBuilder {
 	sb.taskHandler = handlers.NewTaskHandler(sb.taskService, sb.storageService, sb.verificationService, sb.config)
 	sb.taskHandler.SetStakeWallet(sb.stakeWallet)
 	sb.taskHandler.SetWebhookService(sb.webhookService)
+	sb.taskHandler.SetImageInspector(sb.imageInspector)
+	sb.taskHandler.SetImageService(sb.imageService)
 
 	// FL reward service now uses real blockchain transactions directly
 
 	sb.runnerHandler = handlers.NewRunnerHandler(sb.taskService, sb.runnerService)
+	sb.runnerHandler.SetWebhookService(sb.webhookService)
+	sb.runnerAuthHandler = handlers.NewRunnerAuthHandler(sb.runnerAuthService, sb.config.Auth.EnforceRunnerAuth)
+	sb.authHandler = handlers.NewAuthHandler(sb.authService, sb.config.Auth.EnforceUserAuth)
+	sb.runnerSocketHandler = handlers.NewRunnerSocketHandler(sb.runnerHub)
+	sb.runnerAdminHandler = handlers.NewRunnerAdminHandler(sb.runnerAdminService)
+	sb.retentionHandler = handlers.NewRetentionHandler(sb.retentionService)
+	sb.storageHandler = handlers.NewStorageHandler(sb.objectStorage, sb.imageService)
+	sb.artifactHandler = handlers.NewArtifactHandler(sb.taskService, sb.artifactService)
+	sb.taskLogHandler = handlers.NewTaskLogHandler(sb.taskService, sb.taskLogService)
+	sb.clientEventHandler = handlers.NewClientEventHandler(sb.eventBus)
+	sb.creatorWebhookHandler = handlers.NewCreatorWebhookHandler(sb.creatorWebhookService)
 	sb.webhookHandler = handlers.NewWebhookHandler(sb.webhookService, sb.runnerService)
 	sb.webhookHandler.SetStopChannel(sb.stopChannel)
 	sb.llmHandler = handlers.NewLLMHandler(sb.llmService)
 	sb.federatedLearningHandler = handlers.NewFederatedLearningHandler(sb.federatedLearningService)
 	sb.reputationHandler = handlers.NewReputationHandler(sb.reputationService, sb.runnerMonitoringService)
 
-	router := api.NewRouter(
-		sb.taskHandler,
-		sb.runnerHandler,
-		sb.webhookHandler,
-		sb.llmHandler,
-		sb.federatedLearningHandler,
-		sb.reputationHandler,
-		sb.config.Server.Endpoint,
-	)
+	router := api.NewRouter(v1.Handlers{
+		Task:           sb.taskHandler,
+		Runner:         sb.runnerHandler,
+		Webhook:        sb.webhookHandler,
+		LLM:            sb.llmHandler,
+		FL:             sb.federatedLearningHandler,
+		Reputation:     sb.reputationHandler,
+		RunnerAuth:     sb.runnerAuthHandler,
+		Auth:           sb.authHandler,
+		RunnerSocket:   sb.runnerSocketHandler,
+		RunnerAdmin:    sb.runnerAdminHandler,
+		Retention:      sb.retentionHandler,
+		Storage:        sb.storageHandler,
+		Artifact:       sb.artifactHandler,
+		TaskLog:        sb.taskLogHandler,
+		ClientEvent:    sb.clientEventHandler,
+		CreatorWebhook: sb.creatorWebhookHandler,
+	}, sb.config.Server.Endpoint)
 
 	if err := utils.VerifyPortAvailable(sb.config.Server.Host, sb.config.Server.Port); err != nil {
 		sb.err = fmt.Errorf("server port is not available: %w", err)
@@ -492,6 +655,7 @@ func (sb *ServerBuilder) Build() (*Server, error) {
 		DB:                      sb.DB,
 		TaskService:             sb.taskService,
 		RunnerService:           sb.runnerService,
+		RunnerHub:               sb.runnerHub,
 		ReputationService:       sb.reputationService,
 		RunnerMonitoringService: sb.runnerMonitoringService,
 		HeartbeatService:        sb.heartbeatService,
@@ -16,6 +16,21 @@ type Config struct {
 	Scheduler         SchedulerConfig         `mapstructure:"SCHEDULER"`
 	Reputation        ReputationConfig        `mapstructure:"REPUTATION"`
 	SmartContract     SmartContractConfig     `mapstructure:"SMART_CONTRACT"`
+	Randomness        RandomnessConfig        `mapstructure:"RANDOMNESS"`
+	Auth              AuthConfig              `mapstructure:"AUTH"`
+	Dispatch          DispatchConfig          `mapstructure:"DISPATCH"`
+	Webhook           WebhookConfig           `mapstructure:"WEBHOOK"`
+	Telemetry         TelemetryConfig         `mapstructure:"TELEMETRY"`
+	Protocol          ProtocolConfig          `mapstructure:"PROTOCOL"`
+	Image             ImageConfig             `mapstructure:"IMAGE"`
+	Retention         RetentionConfig         `mapstructure:"RETENTION"`
+	Storage           StorageConfig           `mapstructure:"STORAGE"`
+	Artifact          ArtifactConfig          `mapstructure:"ARTIFACT"`
+	TaskLog           TaskLogConfig           `mapstructure:"TASK_LOG"`
+	Dataset           DatasetConfig           `mapstructure:"DATASET"`
+	RunnerCache       RunnerCacheConfig       `mapstructure:"RUNNER_CACHE"`
+	Events            EventsConfig            `mapstructure:"EVENTS"`
+	PromptQueue       PromptQueueConfig       `mapstructure:"PROMPT_QUEUE"`
 }
 
 type ServerConfig struct {
@@ -67,6 +82,117 @@ type SmartContractConfig struct {
 	ReputationContractABIPath string `mapstructure:"REPUTATION_CONTRACT_ABI_PATH"`
 }
 
+type RandomnessConfig struct {
+	Source         string `mapstructure:"SOURCE"`
+	Fallback       string `mapstructure:"FALLBACK"`
+	DrandURLs      string `mapstructure:"DRAND_URLS"`
+	DrandChainHash string `mapstructure:"DRAND_CHAIN_HASH"`
+	LocalSeed      string `mapstructure:"LOCAL_SEED"`
+	LocalPeriod    int    `mapstructure:"LOCAL_PERIOD"`
+}
+
+type AuthConfig struct {
+	EnforceRunnerAuth bool   `mapstructure:"ENFORCE_RUNNER_AUTH"`
+	EnforceUserAuth   bool   `mapstructure:"ENFORCE_USER_AUTH"`
+	AdminWallets      string `mapstructure:"ADMIN_WALLETS"`
+	ChallengeTTL      int    `mapstructure:"CHALLENGE_TTL"`
+	SessionTTL        int    `mapstructure:"SESSION_TTL"`
+	RefreshTTL        int    `mapstructure:"REFRESH_TTL"`
+}
+
+type DispatchConfig struct {
+	LeaseTTL        int `mapstructure:"LEASE_TTL"`
+	LongPollTimeout int `mapstructure:"LONG_POLL_TIMEOUT"`
+}
+
+type WebhookConfig struct {
+	MaxAttempts      int `mapstructure:"MAX_ATTEMPTS"`
+	InitialBackoff   int `mapstructure:"INITIAL_BACKOFF"`
+	MaxBackoff       int `mapstructure:"MAX_BACKOFF"`
+	BreakerThreshold int `mapstructure:"BREAKER_THRESHOLD"`
+	BreakerCooldown  int `mapstructure:"BREAKER_COOLDOWN"`
+	SecretOverlap    int `mapstructure:"SECRET_OVERLAP"`
+}
+
+type TelemetryConfig struct {
+	RawRetention   int `mapstructure:"RAW_RETENTION"`
+	RollupInterval int `mapstructure:"ROLLUP_INTERVAL"`
+	Retention      int `mapstructure:"RETENTION"`
+	UptimeWindow   int `mapstructure:"UPTIME_WINDOW"`
+}
+
+type ProtocolConfig struct {
+	MinVersion int `mapstructure:"MIN_VERSION"`
+}
+
+type ImageConfig struct {
+	MaxLayers             int    `mapstructure:"MAX_LAYERS"`
+	MaxUncompressedSizeMB int    `mapstructure:"MAX_UNCOMPRESSED_SIZE_MB"`
+	AllowedArchitectures  string `mapstructure:"ALLOWED_ARCHITECTURES"`
+}
+
+type RetentionConfig struct {
+	Interval            int    `mapstructure:"INTERVAL"`
+	ImageDays           int    `mapstructure:"IMAGE_DAYS"`
+	CompletedResultDays int    `mapstructure:"COMPLETED_RESULT_DAYS"`
+	FailedResultDays    int    `mapstructure:"FAILED_RESULT_DAYS"`
+	ResultAction        string `mapstructure:"RESULT_ACTION"`
+	DryRun              bool   `mapstructure:"DRY_RUN"`
+}
+
+type StorageConfig struct {
+	Backend      string `mapstructure:"BACKEND"`
+	LocalPath    string `mapstructure:"LOCAL_PATH"`
+	LocalBaseURL string `mapstructure:"LOCAL_BASE_URL"`
+	S3Endpoint   string `mapstructure:"S3_ENDPOINT"`
+	S3Region     string `mapstructure:"S3_REGION"`
+	S3Bucket     string `mapstructure:"S3_BUCKET"`
+	S3Prefix     string `mapstructure:"S3_PREFIX"`
+	S3AccessKey  string `mapstructure:"S3_ACCESS_KEY"`
+	S3SecretKey  string `mapstructure:"S3_SECRET_KEY"`
+	S3PathStyle  bool   `mapstructure:"S3_PATH_STYLE"`
+	S3PublicURL  string `mapstructure:"S3_PUBLIC_URL"`
+}
+
+type ArtifactConfig struct {
+	MaxSizeMB  int    `mapstructure:"MAX_SIZE_MB"`
+	MaxCount   int    `mapstructure:"MAX_COUNT"`
+	StagingDir string `mapstructure:"STAGING_DIR"`
+	UploadTTL  int    `mapstructure:"UPLOAD_TTL"`
+}
+
+type TaskLogConfig struct {
+	BufferKB   int `mapstructure:"BUFFER_KB"`
+	MaxChunkKB int `mapstructure:"MAX_CHUNK_KB"`
+	MaxTaskMB  int `mapstructure:"MAX_TASK_MB"`
+	IdleTTL    int `mapstructure:"IDLE_TTL"`
+}
+
+type DatasetConfig struct {
+	MaxInputs     int `mapstructure:"MAX_INPUTS"`
+	MaxSizeMB     int `mapstructure:"MAX_SIZE_MB"`
+	VerifyTimeout int `mapstructure:"VERIFY_TIMEOUT"`
+}
+
+type RunnerCacheConfig struct {
+	MaxWait  int `mapstructure:"MAX_WAIT"`
+	EntryTTL int `mapstructure:"ENTRY_TTL"`
+}
+
+type EventsConfig struct {
+	BufferSize   int `mapstructure:"BUFFER_SIZE"`
+	PollInterval int `mapstructure:"POLL_INTERVAL"`
+}
+
+// PromptQueueConfig controls the database-backed queue of LLM prompts waiting
+// for a runner. Durations are in seconds.
+type PromptQueueConfig struct {
+	PollInterval      int `mapstructure:"POLL_INTERVAL"`
+	VisibilityTimeout int `mapstructure:"VISIBILITY_TIMEOUT"`
+	RetryDelay        int `mapstructure:"RETRY_DELAY"`
+	MaxRetries        int `mapstructure:"MAX_RETRIES"`
+}
+
 type ConfigManager struct {
 	config     *Config
 	configPath string
@@ -195,6 +321,115 @@ func loadConfigFile(path string) (*Config, error) {
 		"REPUTATION_CONTRACT_ABI_PATH": v.GetString("REPUTATION_CONTRACT_ABI_PATH"),
 	})
 
+	v.SetDefault("RANDOMNESS", map[string]interface{}{
+		"SOURCE":           v.GetString("RANDOMNESS_SOURCE"),
+		"FALLBACK":         v.GetString("RANDOMNESS_FALLBACK"),
+		"DRAND_URLS":       v.GetString("RANDOMNESS_DRAND_URLS"),
+		"DRAND_CHAIN_HASH": v.GetString("RANDOMNESS_DRAND_CHAIN_HASH"),
+		"LOCAL_SEED":       v.GetString("RANDOMNESS_LOCAL_SEED"),
+		"LOCAL_PERIOD":     v.GetInt("RANDOMNESS_LOCAL_PERIOD"),
+	})
+
+	v.SetDefault("AUTH", map[string]interface{}{
+		"ENFORCE_RUNNER_AUTH": v.GetBool("AUTH_ENFORCE_RUNNER_AUTH"),
+		"ENFORCE_USER_AUTH":   v.GetBool("AUTH_ENFORCE_USER_AUTH"),
+		"ADMIN_WALLETS":       v.GetString("AUTH_ADMIN_WALLETS"),
+		"CHALLENGE_TTL":       v.GetInt("AUTH_CHALLENGE_TTL"),
+		"SESSION_TTL":         v.GetInt("AUTH_SESSION_TTL"),
+		"REFRESH_TTL":         v.GetInt("AUTH_REFRESH_TTL"),
+	})
+
+	v.SetDefault("DISPATCH", map[string]interface{}{
+		"LEASE_TTL":         v.GetInt("DISPATCH_LEASE_TTL"),
+		"LONG_POLL_TIMEOUT": v.GetInt("DISPATCH_LONG_POLL_TIMEOUT"),
+	})
+
+	v.SetDefault("WEBHOOK", map[string]interface{}{
+		"MAX_ATTEMPTS":      v.GetInt("WEBHOOK_MAX_ATTEMPTS"),
+		"INITIAL_BACKOFF":   v.GetInt("WEBHOOK_INITIAL_BACKOFF"),
+		"MAX_BACKOFF":       v.GetInt("WEBHOOK_MAX_BACKOFF"),
+		"BREAKER_THRESHOLD": v.GetInt("WEBHOOK_BREAKER_THRESHOLD"),
+		"BREAKER_COOLDOWN":  v.GetInt("WEBHOOK_BREAKER_COOLDOWN"),
+		"SECRET_OVERLAP":    v.GetInt("WEBHOOK_SECRET_OVERLAP"),
+	})
+
+	v.SetDefault("TELEMETRY", map[string]interface{}{
+		"RAW_RETENTION":   v.GetInt("TELEMETRY_RAW_RETENTION"),
+		"ROLLUP_INTERVAL": v.GetInt("TELEMETRY_ROLLUP_INTERVAL"),
+		"RETENTION":       v.GetInt("TELEMETRY_RETENTION"),
+		"UPTIME_WINDOW":   v.GetInt("TELEMETRY_UPTIME_WINDOW"),
+	})
+
+	v.SetDefault("PROTOCOL", map[string]interface{}{
+		"MIN_VERSION": v.GetInt("PROTOCOL_MIN_VERSION"),
+	})
+
+	v.SetDefault("IMAGE", map[string]interface{}{
+		"MAX_LAYERS":               v.GetInt("IMAGE_MAX_LAYERS"),
+		"MAX_UNCOMPRESSED_SIZE_MB": v.GetInt("IMAGE_MAX_UNCOMPRESSED_SIZE_MB"),
+		"ALLOWED_ARCHITECTURES":    v.GetString("IMAGE_ALLOWED_ARCHITECTURES"),
+	})
+
+	v.SetDefault("RETENTION", map[string]interface{}{
+		"INTERVAL":              v.GetInt("RETENTION_INTERVAL"),
+		"IMAGE_DAYS":            v.GetInt("RETENTION_IMAGE_DAYS"),
+		"COMPLETED_RESULT_DAYS": v.GetInt("RETENTION_COMPLETED_RESULT_DAYS"),
+		"FAILED_RESULT_DAYS":    v.GetInt("RETENTION_FAILED_RESULT_DAYS"),
+		"RESULT_ACTION":         v.GetString("RETENTION_RESULT_ACTION"),
+		"DRY_RUN":               v.GetBool("RETENTION_DRY_RUN"),
+	})
+
+	v.SetDefault("STORAGE", map[string]interface{}{
+		"BACKEND":        v.GetString("STORAGE_BACKEND"),
+		"LOCAL_PATH":     v.GetString("STORAGE_LOCAL_PATH"),
+		"LOCAL_BASE_URL": v.GetString("STORAGE_LOCAL_BASE_URL"),
+		"S3_ENDPOINT":    v.GetString("STORAGE_S3_ENDPOINT"),
+		"S3_REGION":      v.GetString("STORAGE_S3_REGION"),
+		"S3_BUCKET":      v.GetString("STORAGE_S3_BUCKET"),
+		"S3_PREFIX":      v.GetString("STORAGE_S3_PREFIX"),
+		"S3_ACCESS_KEY":  v.GetString("STORAGE_S3_ACCESS_KEY"),
+		"S3_SECRET_KEY":  v.GetString("STORAGE_S3_SECRET_KEY"),
+		"S3_PATH_STYLE":  v.GetBool("STORAGE_S3_PATH_STYLE"),
+		"S3_PUBLIC_URL":  v.GetString("STORAGE_S3_PUBLIC_URL"),
+	})
+
+	v.SetDefault("ARTIFACT", map[string]interface{}{
+		"MAX_SIZE_MB": v.GetInt("ARTIFACT_MAX_SIZE_MB"),
+		"MAX_COUNT":   v.GetInt("ARTIFACT_MAX_COUNT"),
+		"STAGING_DIR": v.GetString("ARTIFACT_STAGING_DIR"),
+		"UPLOAD_TTL":  v.GetInt("ARTIFACT_UPLOAD_TTL"),
+	})
+
+	v.SetDefault("TASK_LOG", map[string]interface{}{
+		"BUFFER_KB":    v.GetInt("TASK_LOG_BUFFER_KB"),
+		"MAX_CHUNK_KB": v.GetInt("TASK_LOG_MAX_CHUNK_KB"),
+		"MAX_TASK_MB":  v.GetInt("TASK_LOG_MAX_TASK_MB"),
+		"IDLE_TTL":     v.GetInt("TASK_LOG_IDLE_TTL"),
+	})
+
+	v.SetDefault("DATASET", map[string]interface{}{
+		"MAX_INPUTS":     v.GetInt("DATASET_MAX_INPUTS"),
+		"MAX_SIZE_MB":    v.GetInt("DATASET_MAX_SIZE_MB"),
+		"VERIFY_TIMEOUT": v.GetInt("DATASET_VERIFY_TIMEOUT"),
+	})
+
+	v.SetDefault("RUNNER_CACHE", map[string]interface{}{
+		"MAX_WAIT":  v.GetInt("RUNNER_CACHE_MAX_WAIT"),
+		"ENTRY_TTL": v.GetInt("RUNNER_CACHE_ENTRY_TTL"),
+	})
+
+	v.SetDefault("EVENTS", map[string]interface{}{
+		"BUFFER_SIZE":   v.GetInt("EVENTS_BUFFER_SIZE"),
+		"POLL_INTERVAL": v.GetInt("EVENTS_POLL_INTERVAL"),
+	})
+
+	v.SetDefault("PROMPT_QUEUE", map[string]interface{}{
+		"POLL_INTERVAL":      v.GetInt("PROMPT_QUEUE_POLL_INTERVAL"),
+		"VISIBILITY_TIMEOUT": v.GetInt("PROMPT_QUEUE_VISIBILITY_TIMEOUT"),
+		"RETRY_DELAY":        v.GetInt("PROMPT_QUEUE_RETRY_DELAY"),
+		"MAX_RETRIES":        v.GetInt("PROMPT_QUEUE_MAX_RETRIES"),
+	})
+
 	var config Config
 	if err := v.Unmarshal(&config); err != nil {
 		return nil, fmt.Errorf("unable to decode into config struct: %w", err)
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Role string
+
+const (
+	RoleCreator   Role = "creator"
+	RoleLLMClient Role = "llm-client"
+	RoleAdmin     Role = "admin"
+)
+
+func (r Role) Valid() bool {
+	switch r {
+	case RoleCreator, RoleLLMClient, RoleAdmin:
+		return true
+	default:
+		return false
+	}
+}
+
+type RoleList []Role
+
+func (l RoleList) Value() (driver.Value, error) {
+	if l == nil {
+		return json.Marshal([]Role{})
+	}
+	return json.Marshal([]Role(l))
+}
+
+func (l *RoleList) Scan(value interface{}) error {
+	if value == nil {
+		*l = nil
+		return nil
+	}
+	return json.Unmarshal(value.([]byte), l)
+}
+
+func (l RoleList) Has(role Role) bool {
+	for _, r := range l {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Account is a wallet-backed API user. Creators, LLM clients and admins are all
+// accounts that differ only in their roles.
+type Account struct {
+	ID            uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	WalletAddress string    `json:"wallet_address" gorm:"type:varchar(42);uniqueIndex"`
+	Roles         RoleList  `json:"roles" gorm:"type:jsonb"`
+	CreatedAt     time.Time `json:"created_at" gorm:"type:timestamp"`
+	UpdatedAt     time.Time `json:"updated_at" gorm:"type:timestamp"`
+}
+
+// AccountAuthChallenge holds a Sign-In-With-Ethereum message issued to a wallet.
+type AccountAuthChallenge struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	WalletAddress string     `json:"wallet_address" gorm:"type:varchar(42);index"`
+	Message       string     `json:"message" gorm:"type:text"`
+	ExpiresAt     time.Time  `json:"expires_at" gorm:"type:timestamp"`
+	UsedAt        *time.Time `json:"used_at,omitempty" gorm:"type:timestamp"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"type:timestamp"`
+}
+
+type AccountSession struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	AccountID uuid.UUID  `json:"account_id" gorm:"type:uuid;index"`
+	TokenHash string     `json:"-" gorm:"type:varchar(64);uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"type:timestamp"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" gorm:"type:timestamp"`
+	CreatedAt time.Time  `json:"created_at" gorm:"type:timestamp"`
+}
+
+// APIKey is a long-lived credential for automation. Only the hash of the key is
+// stored; Prefix is kept so owners can tell their keys apart.
+type APIKey struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	AccountID  uuid.UUID  `json:"account_id" gorm:"type:uuid;index"`
+	Name       string     `json:"name" gorm:"type:varchar(255)"`
+	Prefix     string     `json:"prefix" gorm:"type:varchar(16)"`
+	KeyHash    string     `json:"-" gorm:"type:varchar(64);uniqueIndex"`
+	Roles      RoleList   `json:"roles" gorm:"type:jsonb"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" gorm:"type:timestamp"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" gorm:"type:timestamp"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" gorm:"type:timestamp"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"type:timestamp"`
+}
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	AccountID     uuid.UUID  `json:"account_id"`
+	WalletAddress string     `json:"wallet_address"`
+	Roles         RoleList   `json:"roles"`
+	APIKeyID      *uuid.UUID `json:"api_key_id,omitempty"`
+}
+
+// HasRole reports whether the principal holds role. Admins hold every role.
+func (p *Principal) HasRole(role Role) bool {
+	if p == nil {
+		return false
+	}
+	return p.Roles.Has(role) || p.Roles.Has(RoleAdmin)
+}
+
+func (p *Principal) IsAdmin() bool {
+	return p != nil && p.Roles.Has(RoleAdmin)
+}
+
+// Owns reports whether the principal may act on a resource owned by owner, which
+// is a wallet address or client ID.
+func (p *Principal) Owns(owner string) bool {
+	if p == nil {
+		return false
+	}
+	return p.IsAdmin() || (owner != "" && strings.EqualFold(p.WalletAddress, owner))
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var artifactNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,254}$`)
+
+// ValidArtifactName reports whether name can be used as an artifact name:
+// letters, digits, dots, dashes and underscores, not starting with a dot.
+func ValidArtifactName(name string) bool {
+	return artifactNamePattern.MatchString(name)
+}
+
+// TaskArtifact is a named output file a runner uploaded for a task. The
+// digest is computed by the server while receiving the upload.
+type TaskArtifact struct {
+	TaskID      uuid.UUID `json:"-" gorm:"type:uuid;primaryKey"`
+	Name        string    `json:"name" gorm:"type:varchar(255);primaryKey"`
+	Digest      string    `json:"digest" gorm:"type:varchar(71);not null"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type" gorm:"type:varchar(255)"`
+	StorageKey  string    `json:"storage_key" gorm:"type:varchar(255);not null"`
+	RunnerID    string    `json:"-" gorm:"type:varchar(255);not null"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TaskArtifacts is the artifact list a TaskResult references.
+type TaskArtifacts []TaskArtifact
+
+func (a TaskArtifacts) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
+	return json.Marshal(a)
+}
+
+func (a *TaskArtifacts) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	default:
+		return fmt.Errorf("unsupported task artifacts type %T", value)
+	}
+}
+
+// Find returns the artifact called name, or nil.
+func (a TaskArtifacts) Find(name string) *TaskArtifact {
+	for i := range a {
+		if a[i].Name == name {
+			return &a[i]
+		}
+	}
+	return nil
+}
+
+// ArtifactUpload is an artifact upload in progress. The bytes received so far
+// are staged on the server's disk; the upload's offset is the staged size.
+type ArtifactUpload struct {
+	ID          uuid.UUID `json:"upload_id" gorm:"type:uuid;primaryKey"`
+	TaskID      uuid.UUID `json:"task_id" gorm:"type:uuid;index;not null"`
+	RunnerID    string    `json:"-" gorm:"type:varchar(255);not null"`
+	Name        string    `json:"name" gorm:"type:varchar(255);not null"`
+	ContentType string    `json:"content_type" gorm:"type:varchar(255)"`
+	Size        int64     `json:"size"`
+	Offset      int64     `json:"offset" gorm:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"index"`
+}
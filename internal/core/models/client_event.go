@@ -0,0 +1,94 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ClientEventKind is the kind of resource a client event is about.
+type ClientEventKind string
+
+const (
+	ClientEventTask      ClientEventKind = "task"
+	ClientEventPrompt    ClientEventKind = "prompt"
+	ClientEventFLSession ClientEventKind = "fl_session"
+)
+
+func (k ClientEventKind) Valid() bool {
+	switch k {
+	case ClientEventTask, ClientEventPrompt, ClientEventFLSession:
+		return true
+	default:
+		return false
+	}
+}
+
+// ClientEvent is a state change of a task, prompt or FL session, sent to the
+// client that owns it. Type is the kind and what happened, such as
+// task.started; Data is the resource as it was right after the change.
+// Events are written to the client_events table so every server instance
+// sees them, and the row ID orders them across instances.
+type ClientEvent struct {
+	ID         int64           `json:"id" gorm:"primaryKey;autoIncrement"`
+	Type       string          `json:"type" gorm:"type:varchar(64);not null"`
+	Kind       ClientEventKind `json:"kind" gorm:"type:varchar(32);not null"`
+	ResourceID string          `json:"resource_id" gorm:"type:varchar(64);not null"`
+	Status     string          `json:"status" gorm:"type:varchar(32)"`
+	Data       json.RawMessage `json:"data,omitempty" gorm:"type:jsonb"`
+	OccurredAt time.Time       `json:"occurred_at" gorm:"index"`
+
+	// Owner is the wallet address or client ID the resource belongs to.
+	Owner string `json:"-" gorm:"type:varchar(255)"`
+}
+
+// NewClientEvent builds an event with data snapshotted as JSON, so later
+// changes to the resource do not leak into events already published.
+func NewClientEvent(kind ClientEventKind, action, resourceID, owner, status string, data interface{}) ClientEvent {
+	event := ClientEvent{
+		Type:       string(kind) + "." + action,
+		Kind:       kind,
+		ResourceID: resourceID,
+		Status:     status,
+		OccurredAt: time.Now(),
+		Owner:      owner,
+	}
+	if data != nil {
+		if raw, err := json.Marshal(data); err == nil {
+			event.Data = raw
+		}
+	}
+	return event
+}
+
+func NewTaskEvent(action string, task *Task) ClientEvent {
+	return NewClientEvent(ClientEventTask, action, task.ID.String(), task.CreatorAddress, string(task.Status), task)
+}
+
+func NewPromptEvent(action string, prompt *PromptRequest) ClientEvent {
+	return NewClientEvent(ClientEventPrompt, action, prompt.ID.String(), prompt.ClientID, string(prompt.Status), prompt)
+}
+
+func NewFLSessionEvent(action string, session *FederatedLearningSession) ClientEvent {
+	return NewClientEvent(ClientEventFLSession, action, session.ID.String(), session.CreatorAddress, string(session.Status), session)
+}
+
+// ClientEventFilter selects the events one subscriber receives: those about
+// resources the principal owns, optionally narrowed to some kinds or IDs.
+type ClientEventFilter struct {
+	Principal   *Principal
+	Kinds       map[ClientEventKind]bool
+	ResourceIDs map[string]bool
+}
+
+func (f ClientEventFilter) Matches(event *ClientEvent) bool {
+	if !f.Principal.Owns(event.Owner) {
+		return false
+	}
+	if len(f.Kinds) > 0 && !f.Kinds[event.Kind] {
+		return false
+	}
+	if len(f.ResourceIDs) > 0 && !f.ResourceIDs[event.ResourceID] {
+		return false
+	}
+	return true
+}
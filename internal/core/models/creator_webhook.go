@@ -0,0 +1,145 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreatorWebhookEvent is something that happened to a creator's task that a
+// creator webhook can subscribe to.
+type CreatorWebhookEvent string
+
+const (
+	CreatorWebhookTaskCompleted   CreatorWebhookEvent = "task.completed"
+	CreatorWebhookTaskFailed      CreatorWebhookEvent = "task.failed"
+	CreatorWebhookTaskVerified    CreatorWebhookEvent = "task.verified"
+	CreatorWebhookTaskNotVerified CreatorWebhookEvent = "task.not_verified"
+	CreatorWebhookRewardPaid      CreatorWebhookEvent = "reward.paid"
+)
+
+func (e CreatorWebhookEvent) Valid() bool {
+	switch e {
+	case CreatorWebhookTaskCompleted, CreatorWebhookTaskFailed, CreatorWebhookTaskVerified,
+		CreatorWebhookTaskNotVerified, CreatorWebhookRewardPaid:
+		return true
+	default:
+		return false
+	}
+}
+
+type CreatorWebhookEventList []CreatorWebhookEvent
+
+func (l CreatorWebhookEventList) Value() (driver.Value, error) {
+	if l == nil {
+		return json.Marshal([]CreatorWebhookEvent{})
+	}
+	return json.Marshal([]CreatorWebhookEvent(l))
+}
+
+func (l *CreatorWebhookEventList) Scan(value interface{}) error {
+	if value == nil {
+		*l = nil
+		return nil
+	}
+	return json.Unmarshal(value.([]byte), l)
+}
+
+// Has reports whether the list selects event. An empty list selects every event.
+func (l CreatorWebhookEventList) Has(event CreatorWebhookEvent) bool {
+	if len(l) == 0 {
+		return true
+	}
+	for _, e := range l {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// CreatorWebhook is a callback URL a creator registered for notifications about
+// their tasks. With a TaskID it only fires for that task; without one it fires
+// for every task the creator owns. The secret signs each delivery and is only
+// shown when the webhook is created.
+type CreatorWebhook struct {
+	ID             uuid.UUID               `json:"id" gorm:"type:uuid;primaryKey"`
+	CreatorAddress string                  `json:"creator_address" gorm:"type:varchar(255);index"`
+	TaskID         *uuid.UUID              `json:"task_id,omitempty" gorm:"type:uuid;index"`
+	URL            string                  `json:"url" gorm:"type:text"`
+	Events         CreatorWebhookEventList `json:"events" gorm:"type:jsonb"`
+	Secret         string                  `json:"-" gorm:"type:varchar(128)"`
+	CreatedAt      time.Time               `json:"created_at" gorm:"type:timestamp"`
+	UpdatedAt      time.Time               `json:"updated_at" gorm:"type:timestamp"`
+}
+
+// Matches reports whether the webhook fires for event on taskID.
+func (w *CreatorWebhook) Matches(event CreatorWebhookEvent, taskID uuid.UUID) bool {
+	if w.TaskID != nil && *w.TaskID != taskID {
+		return false
+	}
+	return w.Events.Has(event)
+}
+
+// CreatorWebhookDelivery is one notification to a creator webhook. Like runner
+// webhook deliveries it is written before any attempt is made and retried until
+// it is delivered or dead-lettered.
+type CreatorWebhookDelivery struct {
+	ID             uuid.UUID               `json:"id" gorm:"type:uuid;primaryKey"`
+	WebhookID      uuid.UUID               `json:"webhook_id" gorm:"type:uuid;index"`
+	CreatorAddress string                  `json:"creator_address" gorm:"type:varchar(255);index"`
+	URL            string                  `json:"url" gorm:"type:text"`
+	Event          CreatorWebhookEvent     `json:"event" gorm:"type:varchar(64)"`
+	TaskID         uuid.UUID               `json:"task_id" gorm:"type:uuid;index"`
+	Body           string                  `json:"-" gorm:"type:text"`
+	Status         WebhookDeliveryStatus   `json:"status" gorm:"type:varchar(20);index"`
+	Attempts       int                     `json:"attempts" gorm:"default:0"`
+	MaxAttempts    int                     `json:"max_attempts"`
+	NextAttemptAt  time.Time               `json:"next_attempt_at" gorm:"type:timestamp;index"`
+	LockedUntil    *time.Time              `json:"-" gorm:"type:timestamp"`
+	LastStatusCode int                     `json:"last_status_code,omitempty"`
+	LastError      string                  `json:"last_error,omitempty" gorm:"type:text"`
+	DeliveredAt    *time.Time              `json:"delivered_at,omitempty" gorm:"type:timestamp"`
+	CreatedAt      time.Time               `json:"created_at" gorm:"type:timestamp"`
+	UpdatedAt      time.Time               `json:"updated_at" gorm:"type:timestamp"`
+	AttemptLog     []CreatorWebhookAttempt `json:"attempt_log,omitempty" gorm:"foreignKey:DeliveryID"`
+}
+
+// CreatorWebhookAttempt records the outcome of a single POST of a delivery.
+type CreatorWebhookAttempt struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	DeliveryID uuid.UUID `json:"delivery_id" gorm:"type:uuid;index"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty" gorm:"type:text"`
+	DurationMs int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at" gorm:"type:timestamp"`
+}
+
+// CreatorWebhookPayload is the JSON body POSTed to a creator webhook.
+type CreatorWebhookPayload struct {
+	DeliveryID uuid.UUID           `json:"delivery_id"`
+	Event      CreatorWebhookEvent `json:"event"`
+	TaskID     uuid.UUID           `json:"task_id"`
+	OccurredAt time.Time           `json:"occurred_at"`
+	Task       *Task               `json:"task"`
+	Result     *TaskResult         `json:"result,omitempty"`
+}
+
+func NewCreatorWebhookDelivery(webhook *CreatorWebhook, event CreatorWebhookEvent, taskID uuid.UUID) *CreatorWebhookDelivery {
+	now := time.Now()
+	return &CreatorWebhookDelivery{
+		ID:             uuid.New(),
+		WebhookID:      webhook.ID,
+		CreatorAddress: webhook.CreatorAddress,
+		URL:            webhook.URL,
+		Event:          event,
+		TaskID:         taskID,
+		Status:         WebhookDeliveryPending,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+}
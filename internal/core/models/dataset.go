@@ -0,0 +1,85 @@
+package models
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"time"
+)
+
+// InputMountRoot is where inputs are mounted when no mount path is given.
+const InputMountRoot = "/inputs"
+
+var datasetDigestPattern = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+
+// InputDataset is a dataset a task reads, addressed by its key in the storage
+// backend: a CID for IPFS, the hex sha256 for the local and S3 backends. The
+// creator declares the name, content ID, size and digest; the server checks
+// the stored data against them and fills in where the runner fetches it.
+type InputDataset struct {
+	Name       string     `json:"name"`
+	ContentID  string     `json:"content_id"`
+	Size       int64      `json:"size"`
+	Digest     string     `json:"digest"`
+	MountPath  string     `json:"mount_path,omitempty"`
+	ReadOnly   bool       `json:"read_only"`
+	URL        string     `json:"url,omitempty"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+}
+
+func validateInputs(inputs []InputDataset) error {
+	names := make(map[string]bool, len(inputs))
+	mounts := make(map[string]bool, len(inputs))
+	for i := range inputs {
+		input := &inputs[i]
+		if !artifactNamePattern.MatchString(input.Name) {
+			return fmt.Errorf("input %q: name must contain only letters, digits, dots, dashes and underscores", input.Name)
+		}
+		if names[input.Name] {
+			return fmt.Errorf("input %q is declared twice", input.Name)
+		}
+		names[input.Name] = true
+
+		if input.ContentID == "" {
+			return fmt.Errorf("input %q: content_id is required", input.Name)
+		}
+		if input.Size < 0 {
+			return fmt.Errorf("input %q: size must not be negative", input.Name)
+		}
+		if !datasetDigestPattern.MatchString(input.Digest) {
+			return fmt.Errorf("input %q: digest must be sha256:<hex>", input.Name)
+		}
+
+		mount := input.MountPath
+		if mount == "" {
+			mount = path.Join(InputMountRoot, input.Name)
+		}
+		if !path.IsAbs(mount) || path.Clean(mount) != mount || mount == "/" {
+			return fmt.Errorf("input %q: mount_path must be a clean absolute path", input.Name)
+		}
+		if mounts[mount] {
+			return fmt.Errorf("input %q: mount_path %s is used twice", input.Name, mount)
+		}
+		mounts[mount] = true
+	}
+	return nil
+}
+
+// Normalize fills in defaults: the mount path under InputMountRoot and
+// read-only mounts, which are the only kind the server hands out.
+func (d *InputDataset) Normalize() {
+	if d.MountPath == "" {
+		d.MountPath = path.Join(InputMountRoot, d.Name)
+	}
+	d.ReadOnly = true
+}
+
+// VerifiedDataset records that a content ID in a storage backend was read and
+// found to have this digest and size, so later tasks using it skip the read.
+type VerifiedDataset struct {
+	Backend    string    `json:"backend" gorm:"type:varchar(16);primaryKey"`
+	ContentID  string    `json:"content_id" gorm:"type:varchar(255);primaryKey"`
+	Digest     string    `json:"digest" gorm:"type:varchar(71);not null"`
+	Size       int64     `json:"size"`
+	VerifiedAt time.Time `json:"verified_at"`
+}
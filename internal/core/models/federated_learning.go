@@ -28,6 +28,8 @@ type FederatedLearningSession struct {
 	Description     string             `json:"description" gorm:"type:text"`
 	ModelType       string             `json:"model_type" gorm:"type:varchar(100);not null"`
 	GlobalModel     json.RawMessage    `json:"global_model" gorm:"type:jsonb"`
+	GlobalModelKey  string             `json:"global_model_key,omitempty" gorm:"type:varchar(255)"`
+	GlobalModelURL  string             `json:"global_model_url,omitempty" gorm:"type:text"`
 	Status          FLSessionStatus    `json:"status" gorm:"type:varchar(50)"`
 	Config          FLConfig           `json:"config" gorm:"type:jsonb"`
 	TrainingData    TrainingDataConfig `json:"training_data" gorm:"type:jsonb"`
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ImageMetadata describes a Docker image archive (`docker save` output) as
+// parsed by the server on upload.
+type ImageMetadata struct {
+	// Digest is the sha256 of the image config, the same value Docker reports
+	// as the image ID.
+	Digest           string       `json:"digest"`
+	RepoTags         []string     `json:"repo_tags,omitempty"`
+	Architecture     string       `json:"architecture"`
+	OS               string       `json:"os"`
+	Variant          string       `json:"variant,omitempty"`
+	Layers           []ImageLayer `json:"layers"`
+	UncompressedSize int64        `json:"uncompressed_size"`
+}
+
+type ImageLayer struct {
+	Digest string `json:"digest"`
+	// DiffID is the sha256 of the uncompressed layer, as listed in the image
+	// config's rootfs.diff_ids.
+	DiffID           string `json:"diff_id"`
+	Size             int64  `json:"size"`
+	UncompressedSize int64  `json:"uncompressed_size"`
+}
+
+// LayersVerified reports whether every layer was hashed and matched against
+// the config's diff_ids on upload. Only then does Digest, which covers the
+// config, also cover the layer contents.
+func (m *ImageMetadata) LayersVerified() bool {
+	if len(m.Layers) == 0 {
+		return false
+	}
+	for _, layer := range m.Layers {
+		if layer.DiffID == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Hash returns the digest without its algorithm prefix, the form stored in
+// Task.ImageHash.
+func (m *ImageMetadata) Hash() string {
+	return strings.TrimPrefix(m.Digest, "sha256:")
+}
+
+func (m ImageMetadata) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+func (m *ImageMetadata) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*m = ImageMetadata{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("unsupported image metadata type %T", value)
+	}
+}
+
+// NormalizeImageDigest accepts a digest with or without its "sha256:" prefix
+// and returns the prefixed form, or "" if it is not a sha256 digest.
+func NormalizeImageDigest(digest string) string {
+	hex := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(digest), "sha256:"))
+	if len(hex) != 64 {
+		return ""
+	}
+	for _, r := range hex {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return ""
+		}
+	}
+	return "sha256:" + hex
+}
+
+// StoredImage is an uploaded image archive, keyed by digest so each image is
+// uploaded and pinned once however many tasks use it.
+type StoredImage struct {
+	Digest           string        `json:"digest" gorm:"type:varchar(71);primaryKey"`
+	URL              string        `json:"url" gorm:"type:text;not null"`
+	Name             string        `json:"name" gorm:"type:varchar(255)"`
+	Size             int64         `json:"size"`
+	Metadata         ImageMetadata `json:"metadata" gorm:"type:jsonb"`
+	UploadedBy       string        `json:"uploaded_by" gorm:"type:varchar(255);index"`
+	RefCount         int           `json:"ref_count" gorm:"not null;default:0"`
+	CreatedAt        time.Time     `json:"created_at"`
+	LastReferencedAt *time.Time    `json:"last_referenced_at,omitempty"`
+}
+
+// ImageReference records that a task uses a stored image. StoredImage.RefCount
+// is the number of these rows for the image.
+type ImageReference struct {
+	ImageDigest    string    `json:"image_digest" gorm:"type:varchar(71);primaryKey"`
+	TaskID         uuid.UUID `json:"task_id" gorm:"type:uuid;primaryKey;index"`
+	CreatorAddress string    `json:"creator_address" gorm:"type:varchar(255);index"`
+	CreatedAt      time.Time `json:"created_at"`
+}
@@ -1,6 +1,10 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,6 +21,104 @@ type PromptRequest struct {
 	Response       string       `json:"response" gorm:"type:text"`
 	CreatedAt      time.Time    `json:"created_at" gorm:"autoCreateTime"`
 	CompletedAt    *time.Time   `json:"completed_at,omitempty" gorm:"type:timestamp"`
+
+	SamplingParams `gorm:"embedded"`
+
+	// Queue state. A queued prompt may be leased once AvailableAt has passed;
+	// a lease hides it from other servers until LockedUntil. RetryCount is the
+	// number of attempts that found no runner or failed to reach one.
+	AvailableAt time.Time  `json:"available_at" gorm:"type:timestamp;default:CURRENT_TIMESTAMP;index"`
+	LockedUntil *time.Time `json:"-" gorm:"type:timestamp"`
+	RetryCount  int        `json:"retry_count" gorm:"default:0"`
+	LastError   string     `json:"last_error,omitempty" gorm:"type:text"`
+}
+
+const (
+	MaxStopSequences = 4
+
+	minTemperature = 0.0
+	maxTemperature = 2.0
+)
+
+// SamplingParams are the generation settings a client may set on a prompt.
+// Unset fields leave the choice to the model's defaults.
+type SamplingParams struct {
+	SystemPrompt  string        `json:"system_prompt,omitempty" gorm:"type:text"`
+	Temperature   *float64      `json:"temperature,omitempty"`
+	TopP          *float64      `json:"top_p,omitempty"`
+	MaxTokens     int           `json:"max_tokens,omitempty" gorm:"default:0"`
+	StopSequences StopSequences `json:"stop,omitempty" gorm:"type:jsonb"`
+	Seed          *int64        `json:"seed,omitempty"`
+}
+
+func (p *SamplingParams) Validate() error {
+	if p.Temperature != nil && (*p.Temperature < minTemperature || *p.Temperature > maxTemperature) {
+		return fmt.Errorf("temperature must be between %g and %g", minTemperature, maxTemperature)
+	}
+	if p.TopP != nil && (*p.TopP <= 0 || *p.TopP > 1) {
+		return errors.New("top_p must be greater than 0 and at most 1")
+	}
+	if p.MaxTokens < 0 {
+		return errors.New("max_tokens must be positive")
+	}
+	if len(p.StopSequences) > MaxStopSequences {
+		return fmt.Errorf("at most %d stop sequences are allowed", MaxStopSequences)
+	}
+	for _, stop := range p.StopSequences {
+		if stop == "" {
+			return errors.New("stop sequences must not be empty")
+		}
+	}
+	return nil
+}
+
+// Options returns the parameters that are set, keyed by the names runners
+// read from the task config.
+func (p *SamplingParams) Options() map[string]interface{} {
+	options := make(map[string]interface{})
+	if p.SystemPrompt != "" {
+		options["system_prompt"] = p.SystemPrompt
+	}
+	if p.Temperature != nil {
+		options["temperature"] = *p.Temperature
+	}
+	if p.TopP != nil {
+		options["top_p"] = *p.TopP
+	}
+	if p.MaxTokens > 0 {
+		options["max_tokens"] = p.MaxTokens
+	}
+	if len(p.StopSequences) > 0 {
+		options["stop"] = []string(p.StopSequences)
+	}
+	if p.Seed != nil {
+		options["seed"] = *p.Seed
+	}
+	return options
+}
+
+// StopSequences is stored as a JSON array.
+type StopSequences []string
+
+func (s StopSequences) Value() (driver.Value, error) {
+	if s == nil {
+		return json.Marshal([]string{})
+	}
+	return json.Marshal([]string(s))
+}
+
+func (s *StopSequences) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*s = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	default:
+		return fmt.Errorf("unsupported stop sequences type %T", value)
+	}
 }
 
 type PromptStatus string
@@ -27,6 +129,9 @@ const (
 	PromptStatusProcessing PromptStatus = "processing"
 	PromptStatusCompleted  PromptStatus = "completed"
 	PromptStatusFailed     PromptStatus = "failed"
+	// PromptStatusDeadLetter is a prompt the queue gave up on after its retries
+	// ran out.
+	PromptStatusDeadLetter PromptStatus = "dead_letter"
 )
 
 type ModelCapability struct {
@@ -40,6 +145,12 @@ type ModelCapability struct {
 	UpdatedAt time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
 }
 
+// SupportsMaxTokens reports whether the model can generate maxTokens tokens.
+// Zero on either side means there is no limit.
+func (c *ModelCapability) SupportsMaxTokens(maxTokens int) bool {
+	return maxTokens <= 0 || c.MaxTokens <= 0 || maxTokens <= c.MaxTokens
+}
+
 type BillingMetric struct {
 	ID             uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
 	ClientID       string    `json:"client_id" gorm:"type:varchar(255);not null"`
@@ -53,6 +164,7 @@ type BillingMetric struct {
 }
 
 func NewPromptRequest(clientID, prompt, modelName, creatorAddress string) *PromptRequest {
+	now := time.Now()
 	return &PromptRequest{
 		ID:             uuid.New(),
 		ClientID:       clientID,
@@ -60,7 +172,8 @@ func NewPromptRequest(clientID, prompt, modelName, creatorAddress string) *Promp
 		ModelName:      modelName,
 		CreatorAddress: creatorAddress,
 		Status:         PromptStatusPending,
-		CreatedAt:      time.Now(),
+		CreatedAt:      now,
+		AvailableAt:    now,
 	}
 }
 
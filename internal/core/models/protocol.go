@@ -0,0 +1,118 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Runner protocol versions. A runner reports the highest version it speaks and
+// the server answers in the highest version both sides support.
+//
+// Version 1 is the original webhook format: every body is
+// {"type": "available_tasks", "payload": ...} and the payload is a task list, a
+// single task or a forwarded prompt depending on why it was sent. Runners that
+// do not report a version are treated as version 1.
+//
+// Version 2 sends webhooks in the same RunnerMessage envelope as the WebSocket,
+// with a distinct type per event.
+const (
+	RunnerProtocolLegacy   = 1
+	RunnerProtocolEnvelope = 2
+
+	CurrentRunnerProtocol = RunnerProtocolEnvelope
+)
+
+type ProtocolSupport string
+
+const (
+	ProtocolSupported   ProtocolSupport = "supported"
+	ProtocolDeprecated  ProtocolSupport = "deprecated"
+	ProtocolUnsupported ProtocolSupport = "unsupported"
+)
+
+// runnerProtocolMatrix is the server's compatibility matrix. Versions missing
+// from it are unsupported.
+var runnerProtocolMatrix = map[int]ProtocolSupport{
+	RunnerProtocolLegacy:   ProtocolDeprecated,
+	RunnerProtocolEnvelope: ProtocolSupported,
+}
+
+var ErrUnsupportedProtocol = errors.New("unsupported runner protocol version")
+
+// ProtocolVersionSupport is one row of the compatibility matrix.
+type ProtocolVersionSupport struct {
+	Version int             `json:"version"`
+	Support ProtocolSupport `json:"support"`
+}
+
+// RunnerProtocolMatrix returns the compatibility matrix ordered by version,
+// with versions older than minimum marked unsupported.
+func RunnerProtocolMatrix(minimum int) []ProtocolVersionSupport {
+	matrix := make([]ProtocolVersionSupport, 0, len(runnerProtocolMatrix))
+	for version, support := range runnerProtocolMatrix {
+		if version < minimum {
+			support = ProtocolUnsupported
+		}
+		matrix = append(matrix, ProtocolVersionSupport{Version: version, Support: support})
+	}
+	sort.Slice(matrix, func(i, j int) bool { return matrix[i].Version < matrix[j].Version })
+	return matrix
+}
+
+// ProtocolNegotiation is the outcome of matching a runner's reported version
+// against the matrix.
+type ProtocolNegotiation struct {
+	Requested  int             `json:"requested"`
+	Negotiated int             `json:"negotiated"`
+	Support    ProtocolSupport `json:"support"`
+	Warning    string          `json:"warning,omitempty"`
+}
+
+// NegotiateRunnerProtocol picks the version to speak with a runner that reports
+// requested as its highest. It fails when that version is not in the matrix or
+// is older than minimum.
+func NegotiateRunnerProtocol(requested, minimum int) (*ProtocolNegotiation, error) {
+	negotiated := requested
+	if negotiated <= 0 {
+		negotiated = RunnerProtocolLegacy
+	}
+	if negotiated > CurrentRunnerProtocol {
+		negotiated = CurrentRunnerProtocol
+	}
+
+	support, ok := runnerProtocolMatrix[negotiated]
+	if !ok || negotiated < minimum {
+		support = ProtocolUnsupported
+	}
+
+	negotiation := &ProtocolNegotiation{
+		Requested:  requested,
+		Negotiated: negotiated,
+		Support:    support,
+	}
+
+	switch support {
+	case ProtocolUnsupported:
+		return negotiation, fmt.Errorf("%w: %d", ErrUnsupportedProtocol, negotiated)
+	case ProtocolDeprecated:
+		negotiation.Warning = fmt.Sprintf("runner protocol %d is deprecated; upgrade to protocol %d", negotiated, CurrentRunnerProtocol)
+	}
+
+	return negotiation, nil
+}
+
+// RunnerVersionCount is how many runners report a protocol and software
+// version pair.
+type RunnerVersionCount struct {
+	ProtocolVersion int    `json:"protocol_version"`
+	SoftwareVersion string `json:"software_version"`
+	Count           int64  `json:"count"`
+}
+
+// RunnerVersionDistribution summarizes the fleet's versions for operators.
+type RunnerVersionDistribution struct {
+	Protocols []ProtocolVersionSupport `json:"protocols"`
+	Current   int                      `json:"current_protocol"`
+	Versions  []RunnerVersionCount     `json:"versions"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNegotiateRunnerProtocol(t *testing.T) {
+	cases := []struct {
+		name       string
+		requested  int
+		minimum    int
+		negotiated int
+		support    ProtocolSupport
+		wantErr    bool
+	}{
+		{name: "unreported is legacy", requested: 0, minimum: 1, negotiated: RunnerProtocolLegacy, support: ProtocolDeprecated},
+		{name: "current", requested: CurrentRunnerProtocol, minimum: 1, negotiated: CurrentRunnerProtocol, support: ProtocolSupported},
+		{name: "newer runner speaks current", requested: CurrentRunnerProtocol + 3, minimum: 1, negotiated: CurrentRunnerProtocol, support: ProtocolSupported},
+		{name: "legacy below minimum", requested: RunnerProtocolLegacy, minimum: RunnerProtocolEnvelope, negotiated: RunnerProtocolLegacy, support: ProtocolUnsupported, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			negotiation, err := NegotiateRunnerProtocol(tc.requested, tc.minimum)
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrUnsupportedProtocol) {
+				t.Fatalf("expected ErrUnsupportedProtocol, got %v", err)
+			}
+			if negotiation.Negotiated != tc.negotiated || negotiation.Support != tc.support {
+				t.Fatalf("negotiated %d (%s), want %d (%s)", negotiation.Negotiated, negotiation.Support, tc.negotiated, tc.support)
+			}
+			if (tc.support == ProtocolDeprecated) != (negotiation.Warning != "") {
+				t.Fatalf("unexpected warning %q for %s protocol", negotiation.Warning, negotiation.Support)
+			}
+		})
+	}
+}
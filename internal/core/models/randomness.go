@@ -0,0 +1,24 @@
+package models
+
+type RandomnessSourceType string
+
+const (
+	RandomnessSourceDrand  RandomnessSourceType = "drand"
+	RandomnessSourceLocal  RandomnessSourceType = "local"
+	RandomnessSourceCrypto RandomnessSourceType = "crypto"
+)
+
+// BeaconRound is a single round of randomness produced by a randomness source.
+// Rounds from verifiable sources carry the signature needed to re-check them later.
+type BeaconRound struct {
+	Source            RandomnessSourceType `json:"source"`
+	Round             uint64               `json:"round"`
+	Randomness        []byte               `json:"randomness"`
+	Signature         []byte               `json:"signature,omitempty"`
+	PreviousSignature []byte               `json:"previous_signature,omitempty"`
+}
+
+// Verifiable reports whether the round can be independently re-derived and checked.
+func (b *BeaconRound) Verifiable() bool {
+	return b != nil && b.Source != RandomnessSourceCrypto && b.Round > 0
+}
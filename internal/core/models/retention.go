@@ -0,0 +1,109 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ResultRetentionAction string
+
+const (
+	// ResultActionArchive clears a result's output and error but keeps the
+	// row, so hashes, billing and verification history survive.
+	ResultActionArchive ResultRetentionAction = "archive"
+	// ResultActionPurge deletes the result row.
+	ResultActionPurge ResultRetentionAction = "purge"
+)
+
+// RetentionPolicy says how long stored images and task results are kept. The
+// global policy comes from configuration; a row for a creator overrides it
+// field by field, with zero values falling back to the global setting.
+type RetentionPolicy struct {
+	CreatorAddress      string                `json:"creator_address" gorm:"type:varchar(255);primaryKey"`
+	ImageDays           int                   `json:"image_days"`
+	CompletedResultDays int                   `json:"completed_result_days"`
+	FailedResultDays    int                   `json:"failed_result_days"`
+	ResultAction        ResultRetentionAction `json:"result_action,omitempty" gorm:"type:varchar(20)"`
+	UpdatedAt           time.Time             `json:"updated_at"`
+}
+
+// Over returns p with its unset fields taken from global.
+func (p RetentionPolicy) Over(global RetentionPolicy) RetentionPolicy {
+	if p.ImageDays <= 0 {
+		p.ImageDays = global.ImageDays
+	}
+	if p.CompletedResultDays <= 0 {
+		p.CompletedResultDays = global.CompletedResultDays
+	}
+	if p.FailedResultDays <= 0 {
+		p.FailedResultDays = global.FailedResultDays
+	}
+	if p.ResultAction == "" {
+		p.ResultAction = global.ResultAction
+	}
+	return p
+}
+
+// ResultDays is how long results of a task that ended in status are kept, or
+// 0 if such results are not collected.
+func (p RetentionPolicy) ResultDays(status TaskStatus) int {
+	switch status {
+	case TaskStatusCompleted:
+		return p.CompletedResultDays
+	case TaskStatusFailed, TaskStatusNotVerified:
+		return p.FailedResultDays
+	default:
+		return 0
+	}
+}
+
+// ImageRetentionCandidate is a stored image no pending or running task uses,
+// with every creator that uploaded or referenced it.
+type ImageRetentionCandidate struct {
+	Image  *StoredImage
+	Owners []string
+}
+
+// ResultRetentionCandidate is a task result whose task has finished.
+type ResultRetentionCandidate struct {
+	ResultID       uuid.UUID  `json:"result_id"`
+	TaskID         uuid.UUID  `json:"task_id"`
+	CreatorAddress string     `json:"creator_address"`
+	TaskStatus     TaskStatus `json:"task_status"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// ResultArchive is the part of a task result that archiving moves to object
+// storage. The row keeps the object's key and the sha256 of its JSON.
+type ResultArchive struct {
+	Output string `json:"output"`
+	Error  string `json:"error,omitempty"`
+}
+
+type CollectedImage struct {
+	Digest     string    `json:"digest"`
+	URL        string    `json:"url"`
+	Size       int64     `json:"size"`
+	UploadedBy string    `json:"uploaded_by"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+type CollectedResult struct {
+	ResultRetentionCandidate
+	Action ResultRetentionAction `json:"action"`
+}
+
+// RetentionReport is what one collection run removed, or would have removed
+// for a dry run.
+type RetentionReport struct {
+	DryRun          bool              `json:"dry_run"`
+	StartedAt       time.Time         `json:"started_at"`
+	FinishedAt      time.Time         `json:"finished_at"`
+	Images          []CollectedImage  `json:"images"`
+	ImageBytes      int64             `json:"image_bytes"`
+	Results         []CollectedResult `json:"results"`
+	ResultsArchived int               `json:"results_archived"`
+	ResultsPurged   int               `json:"results_purged"`
+	Errors          []string          `json:"errors,omitempty"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RunnerAuthChallenge is a single-use login challenge a runner signs with the
+// wallet it registered with.
+type RunnerAuthChallenge struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	DeviceID      string     `json:"device_id" gorm:"type:varchar(255);index"`
+	WalletAddress string     `json:"wallet_address" gorm:"type:varchar(42)"`
+	Message       string     `json:"message" gorm:"type:text"`
+	ExpiresAt     time.Time  `json:"expires_at" gorm:"type:timestamp"`
+	UsedAt        *time.Time `json:"used_at,omitempty" gorm:"type:timestamp"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"type:timestamp"`
+}
+
+// RunnerSession binds a session token to a device ID. Only hashes of the access
+// and refresh tokens are stored.
+type RunnerSession struct {
+	ID               uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	DeviceID         string     `json:"device_id" gorm:"type:varchar(255);index"`
+	WalletAddress    string     `json:"wallet_address" gorm:"type:varchar(42)"`
+	TokenHash        string     `json:"-" gorm:"type:varchar(64);uniqueIndex"`
+	RefreshTokenHash string     `json:"-" gorm:"type:varchar(64);uniqueIndex"`
+	ExpiresAt        time.Time  `json:"expires_at" gorm:"type:timestamp"`
+	RefreshExpiresAt time.Time  `json:"refresh_expires_at" gorm:"type:timestamp"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty" gorm:"type:timestamp"`
+	LastUsedAt       *time.Time `json:"last_used_at,omitempty" gorm:"type:timestamp"`
+	CreatedAt        time.Time  `json:"created_at" gorm:"type:timestamp"`
+	UpdatedAt        time.Time  `json:"updated_at" gorm:"type:timestamp"`
+}
+
+// RunnerSessionTokens is handed to a runner after a successful login or refresh.
+// The raw tokens are never persisted.
+type RunnerSessionTokens struct {
+	AccessToken      string    `json:"access_token"`
+	RefreshToken     string    `json:"refresh_token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	DeviceID         string    `json:"device_id"`
+}
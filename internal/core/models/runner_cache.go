@@ -0,0 +1,174 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RunnerCacheMaxEntries caps how many images or models one runner's report
+// may list; the rest are dropped.
+const RunnerCacheMaxEntries = 1024
+
+var imageIDPattern = regexp.MustCompile(`^(sha256:)?[0-9a-f]{64}$`)
+
+// RunnerCacheReport is what a runner says it holds locally in a heartbeat:
+// Docker image IDs and the names of model files it has downloaded. A
+// heartbeat that leaves both fields out keeps the previous report; an empty
+// list clears it.
+type RunnerCacheReport struct {
+	Images []string `json:"cached_images,omitempty"`
+	Models []string `json:"cached_models,omitempty"`
+}
+
+// Reported reports whether the heartbeat carried a cache report at all.
+func (r RunnerCacheReport) Reported() bool {
+	return r.Images != nil || r.Models != nil
+}
+
+// RunnerCache is the last cache report stored for a runner.
+type RunnerCache struct {
+	DeviceID   string    `json:"device_id" gorm:"type:varchar(255);primaryKey"`
+	Images     CacheKeys `json:"images" gorm:"type:jsonb"`
+	Models     CacheKeys `json:"models" gorm:"type:jsonb"`
+	ReportedAt time.Time `json:"reported_at" gorm:"type:timestamp;index"`
+}
+
+// NewRunnerCache normalizes a report: image IDs get their sha256: prefix and
+// anything that is not an image ID is dropped, model names are trimmed, and
+// both lists are sorted and de-duplicated.
+func NewRunnerCache(deviceID string, report RunnerCacheReport, reportedAt time.Time) *RunnerCache {
+	images := make([]string, 0, len(report.Images))
+	for _, image := range report.Images {
+		image = strings.ToLower(strings.TrimSpace(image))
+		if !imageIDPattern.MatchString(image) {
+			continue
+		}
+		images = append(images, ImageCacheKey(image))
+	}
+
+	models := make([]string, 0, len(report.Models))
+	for _, model := range report.Models {
+		if model = strings.TrimSpace(model); model != "" {
+			models = append(models, model)
+		}
+	}
+
+	return &RunnerCache{
+		DeviceID:   deviceID,
+		Images:     newCacheKeys(images),
+		Models:     newCacheKeys(models),
+		ReportedAt: reportedAt,
+	}
+}
+
+// Holds reports whether every key the task needs is in the cache.
+func (c *RunnerCache) Holds(image, model string) bool {
+	return (image == "" || c.Images.Contains(image)) && (model == "" || c.Models.Contains(model))
+}
+
+// SameContents reports whether two reports list the same images and models.
+func (c *RunnerCache) SameContents(other *RunnerCache) bool {
+	return other != nil && c.Images.Equal(other.Images) && c.Models.Equal(other.Models)
+}
+
+// ImageCacheKey is the form image IDs are indexed under: sha256:<hex>.
+func ImageCacheKey(imageHash string) string {
+	if imageHash == "" {
+		return ""
+	}
+	return "sha256:" + strings.TrimPrefix(imageHash, "sha256:")
+}
+
+// CacheKeys is a sorted, de-duplicated list of cache entries.
+type CacheKeys []string
+
+func newCacheKeys(keys []string) CacheKeys {
+	sort.Strings(keys)
+	unique := make(CacheKeys, 0, len(keys))
+	for i, key := range keys {
+		if i > 0 && key == keys[i-1] {
+			continue
+		}
+		unique = append(unique, key)
+		if len(unique) == RunnerCacheMaxEntries {
+			break
+		}
+	}
+	return unique
+}
+
+func (k CacheKeys) Contains(key string) bool {
+	i := sort.SearchStrings(k, key)
+	return i < len(k) && k[i] == key
+}
+
+func (k CacheKeys) Equal(other CacheKeys) bool {
+	if len(k) != len(other) {
+		return false
+	}
+	for i := range k {
+		if k[i] != other[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (k CacheKeys) Value() (driver.Value, error) {
+	if k == nil {
+		return json.Marshal([]string{})
+	}
+	return json.Marshal([]string(k))
+}
+
+func (k *CacheKeys) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*k = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, k)
+	case string:
+		return json.Unmarshal([]byte(v), k)
+	default:
+		return fmt.Errorf("unsupported cache keys type %T", value)
+	}
+}
+
+// CacheKeysForTask returns the image ID and model a task needs, either of
+// which may be empty. LLM tasks name their model in the environment config.
+func CacheKeysForTask(task *Task) (image, model string) {
+	image = ImageCacheKey(task.ImageHash)
+	if task.Type == TaskTypeLLM && task.Environment != nil {
+		if name, ok := task.Environment.Config["MODEL"].(string); ok {
+			model = strings.TrimSpace(name)
+		}
+	}
+	return image, model
+}
+
+// CacheHitStats counts assignments that needed a cached item and whether the
+// chosen runner had it.
+type CacheHitStats struct {
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hit_rate"`
+}
+
+// RunnerCacheMetrics is the fleet-wide view of the cache index and how often
+// cache-aware scheduling found a warm runner.
+type RunnerCacheMetrics struct {
+	Images           CacheHitStats `json:"images"`
+	Models           CacheHitStats `json:"models"`
+	HeldForWarm      int64         `json:"held_for_warm_runner"`
+	RunnersReporting int           `json:"runners_reporting"`
+	ImagesIndexed    int           `json:"images_indexed"`
+	ModelsIndexed    int           `json:"models_indexed"`
+	MaxWaitSeconds   int           `json:"max_wait_seconds"`
+	Since            time.Time     `json:"since"`
+}
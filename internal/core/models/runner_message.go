@@ -0,0 +1,77 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RunnerMessageVersion is the envelope version spoken on the runner WebSocket.
+const RunnerMessageVersion = 1
+
+type RunnerMessageType string
+
+const (
+	RunnerMessageTaskOffer      RunnerMessageType = "task_offer"
+	RunnerMessageAvailableTasks RunnerMessageType = "available_tasks"
+	RunnerMessageTaskCancel     RunnerMessageType = "task_cancel"
+	RunnerMessagePromptForward  RunnerMessageType = "prompt_forward"
+	RunnerMessageHeartbeat      RunnerMessageType = "heartbeat"
+	RunnerMessageTaskLog        RunnerMessageType = "task_log"
+	RunnerMessageAck            RunnerMessageType = "ack"
+	RunnerMessageError          RunnerMessageType = "error"
+)
+
+// RunnerMessage is the envelope for every message on the runner WebSocket. Acks
+// and errors name the message they answer in ReplyTo.
+type RunnerMessage struct {
+	Version int               `json:"version"`
+	ID      string            `json:"id"`
+	Type    RunnerMessageType `json:"type"`
+	ReplyTo string            `json:"reply_to,omitempty"`
+	Payload json.RawMessage   `json:"payload,omitempty"`
+	Error   string            `json:"error,omitempty"`
+	SentAt  time.Time         `json:"sent_at"`
+}
+
+type TaskCancelPayload struct {
+	TaskID string `json:"task_id"`
+	Reason string `json:"reason"`
+}
+
+func NewRunnerMessage(messageType RunnerMessageType, payload interface{}) (*RunnerMessage, error) {
+	message := &RunnerMessage{
+		Version: RunnerMessageVersion,
+		ID:      uuid.New().String(),
+		Type:    messageType,
+		SentAt:  time.Now(),
+	}
+
+	if payload != nil {
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s payload: %w", messageType, err)
+		}
+		message.Payload = raw
+	}
+
+	return message, nil
+}
+
+// NewRunnerReply builds an ack, or an error reply when errMessage is set.
+func NewRunnerReply(replyTo string, errMessage string) *RunnerMessage {
+	message := &RunnerMessage{
+		Version: RunnerMessageVersion,
+		ID:      uuid.New().String(),
+		Type:    RunnerMessageAck,
+		ReplyTo: replyTo,
+		SentAt:  time.Now(),
+	}
+	if errMessage != "" {
+		message.Type = RunnerMessageError
+		message.Error = errMessage
+	}
+	return message
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// RunnerSelection records a single beacon-driven draw of a runner for a task.
+// Candidates are stored sorted so the draw can be recomputed from the round alone.
+type RunnerSelection struct {
+	Source           RandomnessSourceType `json:"source"`
+	Round            uint64               `json:"round"`
+	Randomness       []byte               `json:"randomness"`
+	Candidates       []string             `json:"candidates"`
+	SelectedRunnerID string               `json:"selected_runner_id"`
+	Reason           string               `json:"reason,omitempty"`
+	SelectedAt       time.Time            `json:"selected_at"`
+}
+
+// RunnerSelectionLog keeps every draw made for a task, oldest first, so a redraw
+// never hides an earlier result.
+type RunnerSelectionLog []RunnerSelection
+
+func (l RunnerSelectionLog) Value() (driver.Value, error) {
+	if l == nil {
+		return json.Marshal([]RunnerSelection{})
+	}
+	return json.Marshal([]RunnerSelection(l))
+}
+
+func (l *RunnerSelectionLog) Scan(value interface{}) error {
+	if value == nil {
+		*l = nil
+		return nil
+	}
+	return json.Unmarshal(value.([]byte), l)
+}
+
+// Latest returns the selection currently in force, or nil when no draw was made.
+func (l RunnerSelectionLog) Latest() *RunnerSelection {
+	if len(l) == 0 {
+		return nil
+	}
+	return &l[len(l)-1]
+}
@@ -1,23 +1,70 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type Runner struct {
-	ID                uint              `json:"id" gorm:"primaryKey;autoIncrement"`
-	DeviceID          string            `json:"device_id" gorm:"type:varchar(255);unique"`
-	WalletAddress     string            `json:"wallet_address" gorm:"type:varchar(42)"`
-	Status            RunnerStatus      `json:"status" gorm:"type:varchar(255)"`
-	Webhook           string            `json:"webhook" gorm:"type:varchar(255)"`
-	TaskID            *uuid.UUID        `json:"task_id,omitempty" gorm:"type:uuid;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
-	Task              *Task             `json:"task,omitempty" gorm:"foreignKey:TaskID"`
-	ModelCapabilities []ModelCapability `json:"model_capabilities,omitempty" gorm:"foreignKey:RunnerID;references:DeviceID"`
-	LastHeartbeat     time.Time         `json:"last_heartbeat" gorm:"type:timestamp;default:now()"`
-	CreatedAt         time.Time         `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt         time.Time         `json:"updated_at" gorm:"autoUpdateTime"`
+	ID                uint               `json:"id" gorm:"primaryKey;autoIncrement"`
+	DeviceID          string             `json:"device_id" gorm:"type:varchar(255);unique"`
+	WalletAddress     string             `json:"wallet_address" gorm:"type:varchar(42)"`
+	Status            RunnerStatus       `json:"status" gorm:"type:varchar(255)"`
+	Webhook           string             `json:"webhook" gorm:"type:varchar(255)"`
+	DeliveryMode      DeliveryMode       `json:"delivery_mode" gorm:"type:varchar(20);default:'push'"`
+	Slots             int                `json:"slots" gorm:"default:1"`
+	Labels            RunnerLabels       `json:"labels,omitempty" gorm:"type:jsonb"`
+	ProtocolVersion   int                `json:"protocol_version" gorm:"default:1"`
+	SoftwareVersion   string             `json:"software_version,omitempty" gorm:"type:varchar(64)"`
+	Assignments       []RunnerAssignment `json:"assignments,omitempty" gorm:"foreignKey:DeviceID;references:DeviceID"`
+	ModelCapabilities []ModelCapability  `json:"model_capabilities,omitempty" gorm:"foreignKey:RunnerID;references:DeviceID"`
+	LastHeartbeat     time.Time          `json:"last_heartbeat" gorm:"type:timestamp;default:now()"`
+	MaintenanceUntil  *time.Time         `json:"maintenance_until,omitempty" gorm:"type:timestamp"`
+	ForcedOffline     bool               `json:"forced_offline" gorm:"default:false"`
+	CreatedAt         time.Time          `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt         time.Time          `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// WebhookSigningSecret is only filled in on the registration response.
+	WebhookSigningSecret string `json:"webhook_signing_secret,omitempty" gorm:"-"`
+}
+
+// RunnerLabels are operator-facing key/value tags a runner registers with,
+// such as region or hardware class.
+type RunnerLabels map[string]string
+
+func (l RunnerLabels) Value() (driver.Value, error) {
+	if l == nil {
+		return json.Marshal(map[string]string{})
+	}
+	return json.Marshal(map[string]string(l))
+}
+
+func (l *RunnerLabels) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, l)
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	default:
+		return fmt.Errorf("unsupported runner labels type %T", value)
+	}
+}
+
+// Matches reports whether the labels contain every key/value in want.
+func (l RunnerLabels) Matches(want map[string]string) bool {
+	for key, value := range want {
+		if l[key] != value {
+			return false
+		}
+	}
+	return true
 }
 
 type RunnerStatus string
@@ -26,4 +73,145 @@ const (
 	RunnerStatusOnline  RunnerStatus = "online"
 	RunnerStatusOffline RunnerStatus = "offline"
 	RunnerStatusBusy    RunnerStatus = "busy"
+	// RunnerStatusDraining keeps a runner connected and finishing its current
+	// work while it is skipped for new tasks, prompts and FL rounds.
+	RunnerStatusDraining RunnerStatus = "draining"
+)
+
+// DeliveryMode is how a runner receives work: pushed to its webhook, or leased
+// to it when it long-polls for the next task.
+type DeliveryMode string
+
+const (
+	DeliveryModePush DeliveryMode = "push"
+	DeliveryModePull DeliveryMode = "pull"
+)
+
+func (m DeliveryMode) Valid() bool {
+	return m == DeliveryModePush || m == DeliveryModePull
+}
+
+// PullsTasks reports whether the runner fetches its own work instead of being
+// notified through its webhook.
+func (r *Runner) PullsTasks() bool {
+	return r.DeliveryMode == DeliveryModePull
+}
+
+func (r *Runner) IsDraining() bool {
+	return r.Status == RunnerStatusDraining
+}
+
+// InMaintenance reports whether now falls inside the runner's declared
+// maintenance window, during which missed heartbeats are not held against it.
+func (r *Runner) InMaintenance(now time.Time) bool {
+	return r.MaintenanceUntil != nil && now.Before(*r.MaintenanceUntil)
+}
+
+// Capacity is how many tasks, prompts and FL training rounds the runner works
+// on at once.
+func (r *Runner) Capacity() int {
+	if r.Slots < 1 {
+		return 1
+	}
+	return r.Slots
+}
+
+func (r *Runner) FreeSlots() int {
+	free := r.Capacity() - len(r.Assignments)
+	if free < 0 {
+		return 0
+	}
+	return free
+}
+
+// HasAssignment reports whether workID holds one of the runner's slots.
+func (r *Runner) HasAssignment(workID uuid.UUID) bool {
+	for _, assignment := range r.Assignments {
+		if assignment.WorkID == workID {
+			return true
+		}
+	}
+	return false
+}
+
+// HasLoadedModel reports whether the runner has modelName loaded and is online.
+func (r *Runner) HasLoadedModel(modelName string) bool {
+	return r.LoadedModel(modelName) != nil
+}
+
+// LoadedModel returns the runner's capability for modelName, or nil unless the
+// runner is online with the model loaded.
+func (r *Runner) LoadedModel(modelName string) *ModelCapability {
+	if r.Status != RunnerStatusOnline {
+		return nil
+	}
+	for i := range r.ModelCapabilities {
+		if r.ModelCapabilities[i].ModelName == modelName && r.ModelCapabilities[i].IsLoaded {
+			return &r.ModelCapabilities[i]
+		}
+	}
+	return nil
+}
+
+type AssignmentKind string
+
+const (
+	AssignmentKindTask       AssignmentKind = "task"
+	AssignmentKindPrompt     AssignmentKind = "prompt"
+	AssignmentKindFLTraining AssignmentKind = "fl_training"
 )
+
+// RunnerAssignment is one piece of work occupying a runner slot. WorkID is the
+// task ID for tasks and FL training, and the prompt ID for LLM prompts.
+type RunnerAssignment struct {
+	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey"`
+	DeviceID  string         `json:"device_id" gorm:"type:varchar(255);not null;uniqueIndex:idx_runner_assignment_work"`
+	WorkID    uuid.UUID      `json:"work_id" gorm:"type:uuid;not null;uniqueIndex:idx_runner_assignment_work"`
+	Kind      AssignmentKind `json:"kind" gorm:"type:varchar(20);not null"`
+	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func NewRunnerAssignment(deviceID string, workID uuid.UUID, kind AssignmentKind) *RunnerAssignment {
+	return &RunnerAssignment{
+		ID:        uuid.New(),
+		DeviceID:  deviceID,
+		WorkID:    workID,
+		Kind:      kind,
+		CreatedAt: time.Now(),
+	}
+}
+
+// AssignmentKindForTask is the slot kind a task occupies.
+func AssignmentKindForTask(task *Task) AssignmentKind {
+	if task.Type == TaskTypeFederatedLearning {
+		return AssignmentKindFLTraining
+	}
+	return AssignmentKindTask
+}
+
+// RunnerFilter narrows an admin runner listing. Zero-valued fields match every
+// runner.
+type RunnerFilter struct {
+	Status          RunnerStatus
+	Model           string
+	WalletAddress   string
+	HeartbeatAfter  *time.Time
+	HeartbeatBefore *time.Time
+	Labels          map[string]string
+	Limit           int
+	Offset          int
+}
+
+// RunnerDetails is the admin view of one runner and the work it holds.
+type RunnerDetails struct {
+	Runner     *Runner           `json:"runner"`
+	Tasks      []*Task           `json:"tasks"`
+	Prompts    []*PromptRequest  `json:"prompts"`
+	Reputation *RunnerReputation `json:"reputation,omitempty"`
+}
+
+// RequeueResult counts the work taken back from a runner.
+type RequeueResult struct {
+	Tasks   int `json:"tasks_requeued"`
+	Prompts int `json:"prompts_requeued"`
+}
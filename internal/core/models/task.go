@@ -41,6 +41,8 @@ type TaskConfig struct {
 	Resources      ResourceConfig    `json:"resources,omitempty"`
 	DockerImageURL string            `json:"docker_image_url,omitempty"`
 	ImageName      string            `json:"image_name,omitempty"`
+	Image          *ImageMetadata    `json:"image,omitempty"`
+	Inputs         []InputDataset    `json:"inputs,omitempty"`
 }
 
 type ResourceConfig struct {
@@ -50,6 +52,10 @@ type ResourceConfig struct {
 }
 
 func (c *TaskConfig) Validate(taskType TaskType) error {
+	if err := validateInputs(c.Inputs); err != nil {
+		return err
+	}
+
 	switch taskType {
 	case TaskTypeDocker:
 		if c.ImageName == "" {
@@ -70,23 +76,30 @@ func (c *TaskConfig) Validate(taskType TaskType) error {
 }
 
 type Task struct {
-	ID              uuid.UUID          `json:"id" gorm:"type:uuid;primaryKey"`
-	Title           string             `json:"title" gorm:"type:varchar(255)"`
-	Description     string             `json:"description" gorm:"type:text"`
-	Type            TaskType           `json:"type" gorm:"type:varchar(50)"`
-	Status          TaskStatus         `json:"status" gorm:"type:varchar(50)"`
-	Config          json.RawMessage    `json:"config" gorm:"type:jsonb"`
-	Environment     *EnvironmentConfig `json:"environment" gorm:"type:jsonb"`
-	Reward          float64            `json:"reward,omitempty" gorm:"type:decimal(20,8);default:0"`
-	CreatorAddress  string             `json:"creator_address" gorm:"type:varchar(42)"`
-	CreatorDeviceID string             `json:"creator_device_id" gorm:"type:varchar(255)"`
-	RunnerID        string             `json:"runner_id" gorm:"type:varchar(255)"`
-	Nonce           string             `json:"nonce" gorm:"type:varchar(64);not null"`
-	ImageHash       string             `json:"image_hash" gorm:"type:varchar(64)"`
-	CommandHash     string             `json:"command_hash" gorm:"type:varchar(64)"`
-	CreatedAt       time.Time          `json:"created_at" gorm:"type:timestamp"`
-	UpdatedAt       time.Time          `json:"updated_at" gorm:"type:timestamp"`
-	CompletedAt     *time.Time         `json:"completed_at" gorm:"type:timestamp"`
+	ID              uuid.UUID            `json:"id" gorm:"type:uuid;primaryKey"`
+	Title           string               `json:"title" gorm:"type:varchar(255)"`
+	Description     string               `json:"description" gorm:"type:text"`
+	Type            TaskType             `json:"type" gorm:"type:varchar(50)"`
+	Status          TaskStatus           `json:"status" gorm:"type:varchar(50)"`
+	Config          json.RawMessage      `json:"config" gorm:"type:jsonb"`
+	Environment     *EnvironmentConfig   `json:"environment" gorm:"type:jsonb"`
+	Reward          float64              `json:"reward,omitempty" gorm:"type:decimal(20,8);default:0"`
+	CreatorAddress  string               `json:"creator_address" gorm:"type:varchar(42)"`
+	CreatorDeviceID string               `json:"creator_device_id" gorm:"type:varchar(255)"`
+	RunnerID        string               `json:"runner_id" gorm:"type:varchar(255)"`
+	Nonce           string               `json:"nonce" gorm:"type:varchar(64);not null"`
+	NonceSource     RandomnessSourceType `json:"nonce_source,omitempty" gorm:"type:varchar(20)"`
+	NonceRound      uint64               `json:"nonce_round,omitempty" gorm:"type:bigint;default:0"`
+	ImageHash       string               `json:"image_hash" gorm:"type:varchar(64)"`
+	CommandHash     string               `json:"command_hash" gorm:"type:varchar(64)"`
+	InputHash       string               `json:"input_hash,omitempty" gorm:"type:varchar(64)"`
+	HighValue       bool                 `json:"high_value" gorm:"default:false"`
+	Selections      RunnerSelectionLog   `json:"selections,omitempty" gorm:"type:jsonb"`
+	SelectionRound  uint64               `json:"selection_round,omitempty" gorm:"type:bigint;default:0"`
+	LeaseExpiresAt  *time.Time           `json:"lease_expires_at,omitempty" gorm:"type:timestamp"`
+	CreatedAt       time.Time            `json:"created_at" gorm:"type:timestamp"`
+	UpdatedAt       time.Time            `json:"updated_at" gorm:"type:timestamp"`
+	CompletedAt     *time.Time           `json:"completed_at" gorm:"type:timestamp"`
 }
 
 func NewTask() *Task {
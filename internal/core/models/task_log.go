@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TaskLogStream string
+
+const (
+	TaskLogStdout TaskLogStream = "stdout"
+	TaskLogStderr TaskLogStream = "stderr"
+)
+
+func (s TaskLogStream) Valid() bool {
+	return s == TaskLogStdout || s == TaskLogStderr
+}
+
+// TaskLogChunk is a piece of a task's stdout or stderr. Seq is assigned by the
+// server in arrival order and doubles as the SSE event ID, so a client that
+// reconnects can resume after the last chunk it saw.
+type TaskLogChunk struct {
+	TaskID    uuid.UUID     `json:"-" gorm:"type:uuid;primaryKey"`
+	Seq       int64         `json:"seq" gorm:"primaryKey;autoIncrement:false"`
+	Stream    TaskLogStream `json:"stream" gorm:"type:varchar(16);not null"`
+	Data      string        `json:"data" gorm:"type:text"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// TaskLogUsage is how many log bytes a task has streamed. It is saved with
+// the chunks so the size cap and network billing survive the live log being
+// closed while the task is still running.
+type TaskLogUsage struct {
+	TaskID    uuid.UUID `json:"task_id" gorm:"type:uuid;primaryKey"`
+	Bytes     int64     `json:"bytes" gorm:"not null;default:0"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TaskLogPayload is the payload of a task_log message on the runner socket.
+type TaskLogPayload struct {
+	TaskID string        `json:"task_id"`
+	Stream TaskLogStream `json:"stream"`
+	Data   string        `json:"data"`
+}
@@ -10,29 +10,36 @@ import (
 )
 
 type TaskResult struct {
-	ID                  uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
-	TaskID              uuid.UUID `json:"task_id" gorm:"type:uuid;index;not null;constraint:fk_task,onDelete:CASCADE"`
-	DeviceID            string    `json:"device_id" gorm:"type:varchar(255);not null"`
-	DeviceIDHash        string    `json:"device_id_hash" gorm:"type:varchar(64);not null"`
-	RunnerAddress       string    `json:"runner_address" gorm:"type:varchar(255);not null"`
-	CreatorAddress      string    `json:"creator_address" gorm:"type:varchar(255);not null"`
-	Output              string    `json:"output" gorm:"type:text"`
-	Error               string    `json:"error,omitempty" gorm:"type:text"`
-	ExitCode            int       `json:"exit_code" gorm:"type:int"`
-	ExecutionTime       int64     `json:"execution_time" gorm:"type:bigint"`
-	ResultHash          string    `json:"result_hash" gorm:"type:varchar(64)"`
-	ImageHashVerified   string    `json:"image_hash_verified" gorm:"type:varchar(64)"`
-	CommandHashVerified string    `json:"command_hash_verified" gorm:"type:varchar(64)"`
-	VerificationStatus  string    `json:"verification_status" gorm:"type:varchar(50);default:'pending'"`
-	CreatedAt           time.Time `json:"created_at" gorm:"type:timestamp with time zone;default:now()"`
-	CreatorDeviceID     string    `json:"creator_device_id" gorm:"type:text"`
-	SolverDeviceID      string    `json:"solver_device_id" gorm:"type:text"`
-	Reward              float64   `json:"reward" gorm:"type:decimal(20,8)"`
-	CPUSeconds          float64   `json:"cpu_seconds" gorm:"type:decimal(20,8);default:0"`
-	EstimatedCycles     uint64    `json:"estimated_cycles" gorm:"type:bigint;not null;default:0"`
-	MemoryGBHours       float64   `json:"memory_gb_hours" gorm:"type:decimal(20,8);default:0"`
-	StorageGB           float64   `json:"storage_gb" gorm:"type:decimal(20,8);default:0"`
-	NetworkDataGB       float64   `json:"network_data_gb" gorm:"type:decimal(20,8);default:0"`
+	ID                  uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	TaskID              uuid.UUID  `json:"task_id" gorm:"type:uuid;index;not null;constraint:fk_task,onDelete:CASCADE"`
+	DeviceID            string     `json:"device_id" gorm:"type:varchar(255);not null"`
+	DeviceIDHash        string     `json:"device_id_hash" gorm:"type:varchar(64);not null"`
+	RunnerAddress       string     `json:"runner_address" gorm:"type:varchar(255);not null"`
+	CreatorAddress      string     `json:"creator_address" gorm:"type:varchar(255);not null"`
+	Output              string     `json:"output" gorm:"type:text"`
+	Error               string     `json:"error,omitempty" gorm:"type:text"`
+	ExitCode            int        `json:"exit_code" gorm:"type:int"`
+	ExecutionTime       int64      `json:"execution_time" gorm:"type:bigint"`
+	ResultHash          string     `json:"result_hash" gorm:"type:varchar(64)"`
+	ImageHashVerified   string     `json:"image_hash_verified" gorm:"type:varchar(64)"`
+	CommandHashVerified string     `json:"command_hash_verified" gorm:"type:varchar(64)"`
+	InputHashVerified   string     `json:"input_hash_verified,omitempty" gorm:"type:varchar(64)"`
+	VerificationStatus  string     `json:"verification_status" gorm:"type:varchar(50);default:'pending'"`
+	CreatedAt           time.Time  `json:"created_at" gorm:"type:timestamp with time zone;default:now()"`
+	CreatorDeviceID     string     `json:"creator_device_id" gorm:"type:text"`
+	SolverDeviceID      string     `json:"solver_device_id" gorm:"type:text"`
+	Reward              float64    `json:"reward" gorm:"type:decimal(20,8)"`
+	CPUSeconds          float64    `json:"cpu_seconds" gorm:"type:decimal(20,8);default:0"`
+	EstimatedCycles     uint64     `json:"estimated_cycles" gorm:"type:bigint;not null;default:0"`
+	MemoryGBHours       float64    `json:"memory_gb_hours" gorm:"type:decimal(20,8);default:0"`
+	StorageGB           float64    `json:"storage_gb" gorm:"type:decimal(20,8);default:0"`
+	NetworkDataGB       float64    `json:"network_data_gb" gorm:"type:decimal(20,8);default:0"`
+	ArchivedAt          *time.Time `json:"archived_at,omitempty" gorm:"type:timestamp with time zone"`
+	ArchiveKey          string     `json:"archive_key,omitempty" gorm:"type:varchar(255)"`
+	ArchiveHash         string     `json:"archive_hash,omitempty" gorm:"type:varchar(64)"`
+	// Artifacts are the output files the runner uploaded. Their digests are
+	// part of ResultHash.
+	Artifacts TaskArtifacts `json:"artifacts,omitempty" gorm:"type:jsonb"`
 }
 
 func (r *TaskResult) Clean() {
@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 )
 
@@ -29,3 +30,31 @@ func TestTaskValidateAllowsRegistryBackedDockerImage(t *testing.T) {
 		t.Fatalf("expected registry-backed docker task to validate, got error: %v", err)
 	}
 }
+
+func TestTaskConfigValidateRejectsBadInputs(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	valid := InputDataset{Name: "train.csv", ContentID: "bafy", Size: 10, Digest: digest}
+
+	cases := map[string][]InputDataset{
+		"bad name":       {{Name: "../train", ContentID: "bafy", Digest: digest}},
+		"missing cid":    {{Name: "train.csv", Digest: digest}},
+		"bad digest":     {{Name: "train.csv", ContentID: "bafy", Digest: "md5:abc"}},
+		"relative mount": {{Name: "train.csv", ContentID: "bafy", Digest: digest, MountPath: "data/train"}},
+		"duplicate name": {valid, valid},
+		"shared mount": {
+			valid,
+			{Name: "eval.csv", ContentID: "bafy", Digest: digest, MountPath: "/inputs/train.csv"},
+		},
+	}
+	for name, inputs := range cases {
+		config := TaskConfig{Inputs: inputs}
+		if err := config.Validate(TaskTypeCommand); err == nil {
+			t.Errorf("%s: expected inputs to be rejected", name)
+		}
+	}
+
+	config := TaskConfig{Inputs: []InputDataset{valid}}
+	if err := config.Validate(TaskTypeCommand); err != nil {
+		t.Fatalf("expected valid input to pass, got %v", err)
+	}
+}
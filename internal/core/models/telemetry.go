@@ -0,0 +1,152 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// HeartbeatTelemetry is the resource usage a runner reports with a heartbeat.
+// Reported is false for bare liveness pings that carry no metrics.
+type HeartbeatTelemetry struct {
+	CPU           float64 `json:"cpu_usage"`
+	MemoryBytes   int64   `json:"memory_usage"`
+	UptimeSeconds int64   `json:"uptime"`
+	PublicIP      string  `json:"public_ip,omitempty"`
+	Reported      bool    `json:"-"`
+}
+
+// RunnerTelemetrySample is one row of a runner's heartbeat time series. A raw
+// row (Resolution 0) holds a single heartbeat; older rows are rolled up into
+// buckets of Resolution seconds that keep sums so they can be merged again.
+//
+// OnlineSeconds is the time since the previous heartbeat when that gap was
+// within the heartbeat timeout, so summing it over a window gives the time the
+// runner was reachable. GapSum and GapSquares cover the same gaps and give the
+// heartbeat jitter.
+type RunnerTelemetrySample struct {
+	ID            uint64    `json:"-" gorm:"primaryKey;autoIncrement"`
+	DeviceID      string    `json:"device_id" gorm:"type:varchar(255);index:idx_runner_telemetry_device_bucket,priority:1"`
+	BucketStart   time.Time `json:"bucket_start" gorm:"type:timestamp;index:idx_runner_telemetry_device_bucket,priority:2"`
+	Resolution    int       `json:"resolution_seconds" gorm:"default:0;index"`
+	Heartbeats    int       `json:"heartbeats"`
+	Reports       int       `json:"-"`
+	CPUAvg        float64   `json:"cpu_avg"`
+	CPUMax        float64   `json:"cpu_max"`
+	MemoryAvg     int64     `json:"memory_avg"`
+	MemoryMax     int64     `json:"memory_max"`
+	UptimeSeconds int64     `json:"uptime_seconds"`
+	OnlineSeconds float64   `json:"online_seconds"`
+	Gaps          int       `json:"-"`
+	GapSum        float64   `json:"-"`
+	GapSquares    float64   `json:"-"`
+	PublicIP      string    `json:"public_ip,omitempty" gorm:"type:varchar(64)"`
+}
+
+// NewRunnerTelemetrySample builds the raw row for one heartbeat. gap is the time
+// since the previous heartbeat and is only counted when it is positive and
+// within tolerance.
+func NewRunnerTelemetrySample(deviceID string, at time.Time, telemetry HeartbeatTelemetry, gap, tolerance time.Duration) *RunnerTelemetrySample {
+	sample := &RunnerTelemetrySample{
+		DeviceID:      deviceID,
+		BucketStart:   at,
+		Heartbeats:    1,
+		UptimeSeconds: telemetry.UptimeSeconds,
+		PublicIP:      telemetry.PublicIP,
+	}
+	if telemetry.Reported {
+		sample.Reports = 1
+		sample.CPUAvg = telemetry.CPU
+		sample.CPUMax = telemetry.CPU
+		sample.MemoryAvg = telemetry.MemoryBytes
+		sample.MemoryMax = telemetry.MemoryBytes
+	}
+	if gap > 0 && gap <= tolerance {
+		seconds := gap.Seconds()
+		sample.OnlineSeconds = seconds
+		sample.Gaps = 1
+		sample.GapSum = seconds
+		sample.GapSquares = seconds * seconds
+	}
+	return sample
+}
+
+// RunnerHealth summarizes a runner's heartbeat series over a window.
+type RunnerHealth struct {
+	DeviceID              string    `json:"device_id"`
+	WindowStart           time.Time `json:"window_start"`
+	WindowEnd             time.Time `json:"window_end"`
+	Heartbeats            int       `json:"heartbeats"`
+	UptimePercentage      float64   `json:"uptime_percentage"`
+	MeanHeartbeatInterval float64   `json:"mean_heartbeat_interval_seconds"`
+	HeartbeatJitter       float64   `json:"heartbeat_jitter_seconds"`
+	HeartbeatConsistency  float64   `json:"heartbeat_consistency"`
+	CPUAvg                float64   `json:"cpu_avg"`
+	CPUMax                float64   `json:"cpu_max"`
+	MemoryAvg             int64     `json:"memory_avg"`
+	MemoryMax             int64     `json:"memory_max"`
+}
+
+// SummarizeTelemetry computes health from samples ordered by BucketStart.
+// Uptime is measured from the later of windowStart and the first sample, so a
+// runner is not penalised for time before it first reported. The stretch after
+// the last heartbeat counts as online up to tolerance.
+func SummarizeTelemetry(deviceID string, samples []*RunnerTelemetrySample, windowStart, windowEnd time.Time, tolerance time.Duration) *RunnerHealth {
+	health := &RunnerHealth{
+		DeviceID:    deviceID,
+		WindowStart: windowStart,
+		WindowEnd:   windowEnd,
+	}
+	if len(samples) == 0 {
+		return health
+	}
+
+	var online, gapSum, gapSquares, cpuSum, memorySum float64
+	var gaps, reports int
+	for _, sample := range samples {
+		health.Heartbeats += sample.Heartbeats
+		online += sample.OnlineSeconds
+		gaps += sample.Gaps
+		gapSum += sample.GapSum
+		gapSquares += sample.GapSquares
+		if sample.Reports > 0 {
+			reports += sample.Reports
+			cpuSum += sample.CPUAvg * float64(sample.Reports)
+			memorySum += float64(sample.MemoryAvg) * float64(sample.Reports)
+			health.CPUMax = math.Max(health.CPUMax, sample.CPUMax)
+			if sample.MemoryMax > health.MemoryMax {
+				health.MemoryMax = sample.MemoryMax
+			}
+		}
+	}
+
+	last := samples[len(samples)-1]
+	lastSeen := last.BucketStart.Add(time.Duration(last.Resolution) * time.Second)
+	if tail := windowEnd.Sub(lastSeen); tail > 0 {
+		online += math.Min(tail.Seconds(), tolerance.Seconds())
+	}
+
+	from := windowStart
+	if first := samples[0].BucketStart; first.After(from) {
+		from = first
+	}
+	if span := windowEnd.Sub(from).Seconds(); span > 0 {
+		health.UptimePercentage = math.Min(100, online/span*100)
+	}
+
+	if reports > 0 {
+		health.CPUAvg = cpuSum / float64(reports)
+		health.MemoryAvg = int64(memorySum / float64(reports))
+	}
+
+	if gaps > 0 {
+		mean := gapSum / float64(gaps)
+		variance := math.Max(0, gapSquares/float64(gaps)-mean*mean)
+		health.MeanHeartbeatInterval = mean
+		health.HeartbeatJitter = math.Sqrt(variance)
+		if mean > 0 {
+			health.HeartbeatConsistency = math.Max(0, 100*(1-health.HeartbeatJitter/mean))
+		}
+	}
+
+	return health
+}
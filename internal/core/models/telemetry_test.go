@@ -0,0 +1,65 @@
+package models
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSummarizeTelemetryComputesUptimeAndJitter(t *testing.T) {
+	tolerance := 2 * time.Minute
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	report := HeartbeatTelemetry{CPU: 40, MemoryBytes: 1000, Reported: true}
+
+	// Heartbeats every 60s for ten minutes, then a 10 minute outage, then two
+	// more heartbeats 30s and 90s apart.
+	var samples []*RunnerTelemetrySample
+	at := start
+	samples = append(samples, NewRunnerTelemetrySample("runner-1", at, report, 0, tolerance))
+	for i := 0; i < 10; i++ {
+		at = at.Add(time.Minute)
+		samples = append(samples, NewRunnerTelemetrySample("runner-1", at, report, time.Minute, tolerance))
+	}
+	at = at.Add(10 * time.Minute)
+	samples = append(samples, NewRunnerTelemetrySample("runner-1", at, report, 10*time.Minute, tolerance))
+	at = at.Add(30 * time.Second)
+	samples = append(samples, NewRunnerTelemetrySample("runner-1", at, HeartbeatTelemetry{CPU: 100, MemoryBytes: 4000, Reported: true}, 30*time.Second, tolerance))
+	at = at.Add(90 * time.Second)
+	samples = append(samples, NewRunnerTelemetrySample("runner-1", at, HeartbeatTelemetry{}, 90*time.Second, tolerance))
+
+	end := at
+	health := SummarizeTelemetry("runner-1", samples, start.Add(-time.Hour), end, tolerance)
+
+	if health.Heartbeats != 14 {
+		t.Fatalf("expected 14 heartbeats, got %d", health.Heartbeats)
+	}
+
+	// Online: 10 one-minute gaps plus 30s and 90s; the outage is not counted.
+	span := end.Sub(start).Seconds()
+	wantUptime := (600.0 + 30 + 90) / span * 100
+	if math.Abs(health.UptimePercentage-wantUptime) > 0.01 {
+		t.Fatalf("expected uptime %.2f, got %.2f", wantUptime, health.UptimePercentage)
+	}
+
+	if math.Abs(health.MeanHeartbeatInterval-60) > 0.001 {
+		t.Fatalf("expected mean interval 60s, got %f", health.MeanHeartbeatInterval)
+	}
+	wantJitter := math.Sqrt((2 * 30.0 * 30.0) / 12)
+	if math.Abs(health.HeartbeatJitter-wantJitter) > 0.001 {
+		t.Fatalf("expected jitter %f, got %f", wantJitter, health.HeartbeatJitter)
+	}
+
+	// The bare ping does not drag the averages down.
+	wantCPU := (12*40.0 + 100) / 13
+	if math.Abs(health.CPUAvg-wantCPU) > 0.001 || health.CPUMax != 100 || health.MemoryMax != 4000 {
+		t.Fatalf("unexpected resource summary: %+v", health)
+	}
+}
+
+func TestSummarizeTelemetryWithoutSamples(t *testing.T) {
+	now := time.Now()
+	health := SummarizeTelemetry("runner-1", nil, now.Add(-time.Hour), now, time.Minute)
+	if health.Heartbeats != 0 || health.UptimePercentage != 0 {
+		t.Fatalf("expected empty summary, got %+v", health)
+	}
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookEndpoint is a runner's registered webhook. A device holds at most one;
+// registering again replaces the URL.
+type WebhookEndpoint struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	DeviceID  string    `json:"device_id" gorm:"type:varchar(255);uniqueIndex"`
+	URL       string    `json:"url" gorm:"type:text"`
+	CreatedAt time.Time `json:"created_at" gorm:"type:timestamp"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"type:timestamp"`
+}
+
+// WebhookSigningKey is a secret the server signs a runner's webhooks with. The
+// newest key has no expiry; a rotated-out key keeps signing until ExpiresAt so
+// runners have time to switch over. The secret is kept in the clear because the
+// server needs it to sign.
+type WebhookSigningKey struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	DeviceID  string     `json:"device_id" gorm:"type:varchar(255);index"`
+	Secret    string     `json:"-" gorm:"type:varchar(128)"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" gorm:"type:timestamp"`
+	CreatedAt time.Time  `json:"created_at" gorm:"type:timestamp"`
+}
+
+// WebhookSecretRotation is returned when a runner's signing secret is issued or
+// rotated. PreviousExpiresAt is when the replaced secret stops being used.
+type WebhookSecretRotation struct {
+	Secret            string     `json:"secret"`
+	PreviousExpiresAt *time.Time `json:"previous_expires_at,omitempty"`
+}
+
+// Webhook event types recorded on outbox deliveries.
+const (
+	WebhookEventAvailableTasks = "available_tasks"
+	WebhookEventTaskOffer      = "task_offer"
+	WebhookEventPromptForward  = "prompt_forward"
+)
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryDead      WebhookDeliveryStatus = "dead"
+)
+
+// WebhookDelivery is one outbound webhook message in the outbox. It is written
+// before any attempt is made and retried until it is delivered or dead-lettered.
+type WebhookDelivery struct {
+	ID             uuid.UUID                `json:"id" gorm:"type:uuid;primaryKey"`
+	DeviceID       string                   `json:"device_id" gorm:"type:varchar(255);index"`
+	URL            string                   `json:"url" gorm:"type:text"`
+	EventType      string                   `json:"event_type" gorm:"type:varchar(64)"`
+	TaskID         string                   `json:"task_id,omitempty" gorm:"type:varchar(255);index"`
+	Body           string                   `json:"-" gorm:"type:text"`
+	Status         WebhookDeliveryStatus    `json:"status" gorm:"type:varchar(20);index"`
+	Attempts       int                      `json:"attempts" gorm:"default:0"`
+	MaxAttempts    int                      `json:"max_attempts"`
+	NextAttemptAt  time.Time                `json:"next_attempt_at" gorm:"type:timestamp;index"`
+	LockedUntil    *time.Time               `json:"-" gorm:"type:timestamp"`
+	LastStatusCode int                      `json:"last_status_code,omitempty"`
+	LastError      string                   `json:"last_error,omitempty" gorm:"type:text"`
+	DeliveredAt    *time.Time               `json:"delivered_at,omitempty" gorm:"type:timestamp"`
+	CreatedAt      time.Time                `json:"created_at" gorm:"type:timestamp"`
+	UpdatedAt      time.Time                `json:"updated_at" gorm:"type:timestamp"`
+	AttemptLog     []WebhookDeliveryAttempt `json:"attempt_log,omitempty" gorm:"foreignKey:DeliveryID"`
+}
+
+// WebhookDeliveryAttempt records the outcome of a single POST of a delivery.
+type WebhookDeliveryAttempt struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	DeliveryID uuid.UUID `json:"delivery_id" gorm:"type:uuid;index"`
+	DeviceID   string    `json:"device_id" gorm:"type:varchar(255);index"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty" gorm:"type:text"`
+	DurationMs int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at" gorm:"type:timestamp"`
+}
+
+func NewWebhookDelivery(deviceID, url, eventType string, body []byte) *WebhookDelivery {
+	now := time.Now()
+	return &WebhookDelivery{
+		ID:            uuid.New(),
+		DeviceID:      deviceID,
+		URL:           url,
+		EventType:     eventType,
+		Body:          string(body),
+		Status:        WebhookDeliveryPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
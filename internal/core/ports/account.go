@@ -0,0 +1,26 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+type AccountRepository interface {
+	CreateChallenge(ctx context.Context, challenge *models.AccountAuthChallenge) error
+	GetChallenge(ctx context.Context, id uuid.UUID) (*models.AccountAuthChallenge, error)
+	MarkChallengeUsed(ctx context.Context, id uuid.UUID) (bool, error)
+	GetAccount(ctx context.Context, id uuid.UUID) (*models.Account, error)
+	GetAccountByWallet(ctx context.Context, walletAddress string) (*models.Account, error)
+	CreateAccount(ctx context.Context, account *models.Account) error
+	UpdateAccount(ctx context.Context, account *models.Account) error
+	CreateSession(ctx context.Context, session *models.AccountSession) error
+	GetSessionByTokenHash(ctx context.Context, tokenHash string) (*models.AccountSession, error)
+	UpdateSession(ctx context.Context, session *models.AccountSession) error
+	CreateAPIKey(ctx context.Context, key *models.APIKey) error
+	GetAPIKey(ctx context.Context, id uuid.UUID) (*models.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	ListAPIKeys(ctx context.Context, accountID uuid.UUID) ([]*models.APIKey, error)
+	UpdateAPIKey(ctx context.Context, key *models.APIKey) error
+}
@@ -0,0 +1,21 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+type ArtifactRepository interface {
+	CreateUpload(ctx context.Context, upload *models.ArtifactUpload) error
+	GetUpload(ctx context.Context, id uuid.UUID) (*models.ArtifactUpload, error)
+	FindUpload(ctx context.Context, taskID uuid.UUID, name string) (*models.ArtifactUpload, error)
+	TouchUpload(ctx context.Context, id uuid.UUID, at time.Time) error
+	DeleteUpload(ctx context.Context, id uuid.UUID) error
+	ListStaleUploads(ctx context.Context, updatedBefore time.Time) ([]*models.ArtifactUpload, error)
+
+	SaveArtifact(ctx context.Context, artifact *models.TaskArtifact) error
+	ListArtifacts(ctx context.Context, taskID uuid.UUID) ([]models.TaskArtifact, error)
+}
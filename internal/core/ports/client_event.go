@@ -0,0 +1,15 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+type ClientEventRepository interface {
+	Append(ctx context.Context, event *models.ClientEvent) error
+	ListAfter(ctx context.Context, afterID int64, limit int) ([]models.ClientEvent, error)
+	LastID(ctx context.Context) (int64, error)
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
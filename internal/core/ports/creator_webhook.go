@@ -0,0 +1,22 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+type CreatorWebhookRepository interface {
+	CreateWebhook(ctx context.Context, webhook *models.CreatorWebhook) error
+	GetWebhook(ctx context.Context, id uuid.UUID) (*models.CreatorWebhook, error)
+	ListWebhooks(ctx context.Context, creatorAddress string) ([]*models.CreatorWebhook, error)
+	ListWebhooksForTask(ctx context.Context, creatorAddress string, taskID uuid.UUID) ([]*models.CreatorWebhook, error)
+	DeleteWebhook(ctx context.Context, id uuid.UUID) error
+	CreateDelivery(ctx context.Context, delivery *models.CreatorWebhookDelivery) error
+	ClaimDueDeliveries(ctx context.Context, now time.Time, lockFor time.Duration, limit int) ([]*models.CreatorWebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *models.CreatorWebhookDelivery) error
+	CreateAttempt(ctx context.Context, attempt *models.CreatorWebhookAttempt) error
+	ListDeliveries(ctx context.Context, creatorAddress string, webhookID *uuid.UUID, status models.WebhookDeliveryStatus, limit int) ([]*models.CreatorWebhookDelivery, error)
+}
@@ -0,0 +1,12 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+type DatasetRepository interface {
+	GetVerified(ctx context.Context, backend, contentID string) (*models.VerifiedDataset, error)
+	SaveVerified(ctx context.Context, dataset *models.VerifiedDataset) error
+}
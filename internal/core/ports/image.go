@@ -0,0 +1,19 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+type ImageRepository interface {
+	GetImage(ctx context.Context, digest string) (*models.StoredImage, error)
+	CreateImage(ctx context.Context, image *models.StoredImage) (*models.StoredImage, error)
+	ReplaceImageArchive(ctx context.Context, image *models.StoredImage) (*models.StoredImage, error)
+	ListImages(ctx context.Context, owner string) ([]*models.StoredImage, error)
+	HasAccess(ctx context.Context, digest, owner string) (bool, error)
+	AddReference(ctx context.Context, reference *models.ImageReference) error
+	ReleaseReferences(ctx context.Context, taskID uuid.UUID) error
+	RunnerCanDownload(ctx context.Context, deviceID, key string) (bool, error)
+}
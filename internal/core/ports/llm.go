@@ -2,6 +2,7 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/theblitlabs/parity-server/internal/core/models"
@@ -13,6 +14,8 @@ type PromptRepository interface {
 	Update(ctx context.Context, prompt *models.PromptRequest) error
 	ListByClientID(ctx context.Context, clientID string, limit, offset int) ([]*models.PromptRequest, error)
 	GetPendingPrompts(ctx context.Context) ([]*models.PromptRequest, error)
+	LeaseQueued(ctx context.Context, now time.Time, visibility time.Duration, limit int) ([]*models.PromptRequest, error)
+	CountQueued(ctx context.Context) (int64, error)
 }
 
 type BillingRepository interface {
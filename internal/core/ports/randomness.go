@@ -0,0 +1,15 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+// RandomnessSource supplies rounds of randomness used for nonces and runner selection.
+type RandomnessSource interface {
+	Name() models.RandomnessSourceType
+	Latest(ctx context.Context) (*models.BeaconRound, error)
+	Round(ctx context.Context, round uint64) (*models.BeaconRound, error)
+	Verify(ctx context.Context, round *models.BeaconRound) error
+}
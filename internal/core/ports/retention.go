@@ -0,0 +1,24 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+type RetentionRepository interface {
+	ListPolicies(ctx context.Context) ([]*models.RetentionPolicy, error)
+	SavePolicy(ctx context.Context, policy *models.RetentionPolicy) error
+	DeletePolicy(ctx context.Context, creatorAddress string) error
+
+	ListIdleImages(ctx context.Context, usedBefore time.Time) ([]*models.ImageRetentionCandidate, error)
+	DeleteIdleImage(ctx context.Context, digest string) (bool, error)
+	ObjectKeyInUse(ctx context.Context, key string) (bool, error)
+
+	ListFinishedResults(ctx context.Context, createdBefore time.Time, after *models.ResultRetentionCandidate, limit int) ([]*models.ResultRetentionCandidate, error)
+	GetResultArchive(ctx context.Context, resultID uuid.UUID) (*models.ResultArchive, error)
+	ArchiveResult(ctx context.Context, resultID uuid.UUID, at time.Time, key, hash string) error
+	DeleteResult(ctx context.Context, resultID uuid.UUID) error
+}
@@ -0,0 +1,19 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+type RunnerAuthRepository interface {
+	CreateChallenge(ctx context.Context, challenge *models.RunnerAuthChallenge) error
+	GetChallenge(ctx context.Context, id uuid.UUID) (*models.RunnerAuthChallenge, error)
+	MarkChallengeUsed(ctx context.Context, id uuid.UUID) (bool, error)
+	CreateSession(ctx context.Context, session *models.RunnerSession) error
+	GetSessionByTokenHash(ctx context.Context, tokenHash string) (*models.RunnerSession, error)
+	GetSessionByRefreshTokenHash(ctx context.Context, refreshTokenHash string) (*models.RunnerSession, error)
+	UpdateSession(ctx context.Context, session *models.RunnerSession) error
+	RevokeSessionsByDevice(ctx context.Context, deviceID string) (int64, error)
+}
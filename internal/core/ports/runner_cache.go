@@ -0,0 +1,15 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+type RunnerCacheRepository interface {
+	Save(ctx context.Context, cache *models.RunnerCache) error
+	List(ctx context.Context) ([]*models.RunnerCache, error)
+	Delete(ctx context.Context, deviceID string) error
+	DeleteReportedBefore(ctx context.Context, before time.Time) (int64, error)
+}
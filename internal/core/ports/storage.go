@@ -0,0 +1,29 @@
+package ports
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrObjectNotFound = errors.New("object not found")
+
+// StoredObject is a blob written to a Storage backend.
+type StoredObject struct {
+	Key  string
+	URL  string
+	Size int64
+}
+
+// Storage is a content-addressed blob store. Backends derive the key from the
+// content, so writing the same bytes twice returns the same key. Keys never
+// contain a slash, and every URL a backend hands out ends in the key.
+type Storage interface {
+	Backend() string
+	Put(ctx context.Context, content io.Reader, name string) (*StoredObject, error)
+	// Get returns ErrObjectNotFound if nothing is stored under key.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete is a no-op for keys that are not stored.
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
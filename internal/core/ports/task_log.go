@@ -0,0 +1,16 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+type TaskLogRepository interface {
+	SaveChunks(ctx context.Context, chunks []models.TaskLogChunk) error
+	ListChunks(ctx context.Context, taskID uuid.UUID, afterSeq int64) ([]models.TaskLogChunk, error)
+	LastSeq(ctx context.Context, taskID uuid.UUID) (int64, error)
+	SaveStreamedBytes(ctx context.Context, taskID uuid.UUID, bytes int64) error
+	StreamedBytes(ctx context.Context, taskID uuid.UUID) (int64, error)
+}
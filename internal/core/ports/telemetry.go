@@ -0,0 +1,16 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+type TelemetryRepository interface {
+	CreateSample(ctx context.Context, sample *models.RunnerTelemetrySample) error
+	LatestSample(ctx context.Context, deviceID string) (*models.RunnerTelemetrySample, error)
+	ListSamples(ctx context.Context, deviceID string, since, until time.Time) ([]*models.RunnerTelemetrySample, error)
+	Downsample(ctx context.Context, before time.Time, resolution time.Duration) (int64, error)
+	DeleteSamplesBefore(ctx context.Context, before time.Time) (int64, error)
+}
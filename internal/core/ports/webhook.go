@@ -0,0 +1,25 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+type WebhookRepository interface {
+	UpsertEndpoint(ctx context.Context, endpoint *models.WebhookEndpoint) error
+	GetEndpoint(ctx context.Context, id uuid.UUID) (*models.WebhookEndpoint, error)
+	ListEndpoints(ctx context.Context) ([]*models.WebhookEndpoint, error)
+	DeleteEndpoint(ctx context.Context, id uuid.UUID) error
+	DeleteEndpointsByDevice(ctx context.Context, deviceID string) error
+	CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	ClaimDueDeliveries(ctx context.Context, now time.Time, lockFor time.Duration, limit int) ([]*models.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	CreateAttempt(ctx context.Context, attempt *models.WebhookDeliveryAttempt) error
+	ListDeliveriesByDevice(ctx context.Context, deviceID string, status models.WebhookDeliveryStatus, limit int) ([]*models.WebhookDelivery, error)
+	CreateSigningKey(ctx context.Context, key *models.WebhookSigningKey) error
+	ListActiveSigningKeys(ctx context.Context, deviceID string, now time.Time) ([]*models.WebhookSigningKey, error)
+	ExpireSigningKeys(ctx context.Context, deviceID string, expiresAt time.Time) error
+}
@@ -0,0 +1,363 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/core/config"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/internal/core/ports"
+)
+
+var (
+	ErrInvalidArtifact      = errors.New("invalid artifact")
+	ErrArtifactTooLarge     = errors.New("artifact exceeds the maximum size")
+	ErrTooManyArtifacts     = errors.New("task has too many artifacts")
+	ErrUploadNotFound       = errors.New("artifact upload not found")
+	ErrUploadOffsetMismatch = errors.New("upload offset does not match the bytes received")
+)
+
+// ArtifactService receives task output artifacts from runners. Uploads are
+// resumable: the bytes received so far are staged on disk, a runner that lost
+// its connection asks for the upload's offset and continues from there, and
+// once the last byte arrives the artifact is digested and moved into object
+// storage.
+type ArtifactService struct {
+	repo    ports.ArtifactRepository
+	storage ports.Storage
+
+	mu         sync.RWMutex
+	stagingDir string
+	maxSize    int64
+	maxCount   int
+	uploadTTL  time.Duration
+
+	// uploadLocks serialises appends to the same upload.
+	uploadLocks sync.Map
+}
+
+func NewArtifactService(repo ports.ArtifactRepository, storage ports.Storage) *ArtifactService {
+	return &ArtifactService{
+		repo:       repo,
+		storage:    storage,
+		stagingDir: filepath.Join(os.TempDir(), "parity-artifacts"),
+		maxSize:    10 << 30,
+		maxCount:   32,
+		uploadTTL:  24 * time.Hour,
+	}
+}
+
+func (s *ArtifactService) SetConfig(cfg config.ArtifactConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cfg.StagingDir != "" {
+		s.stagingDir = cfg.StagingDir
+	}
+	if cfg.MaxSizeMB > 0 {
+		s.maxSize = int64(cfg.MaxSizeMB) << 20
+	}
+	if cfg.MaxCount > 0 {
+		s.maxCount = cfg.MaxCount
+	}
+	if cfg.UploadTTL > 0 {
+		s.uploadTTL = time.Duration(cfg.UploadTTL) * time.Hour
+	}
+}
+
+func (s *ArtifactService) stagingPath(id uuid.UUID) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return filepath.Join(s.stagingDir, id.String())
+}
+
+// staged returns how many bytes of the upload have been received.
+func (s *ArtifactService) staged(upload *models.ArtifactUpload) (int64, error) {
+	info, err := os.Stat(s.stagingPath(upload.ID))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat staged upload: %w", err)
+	}
+	return info.Size(), nil
+}
+
+func (s *ArtifactService) lock(id uuid.UUID) func() {
+	value, _ := s.uploadLocks.LoadOrStore(id, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// StartUpload begins an upload of the named artifact for a task. If the runner
+// already started uploading an artifact with the same name and size, that
+// upload is returned with its current offset so the runner can resume it.
+func (s *ArtifactService) StartUpload(ctx context.Context, taskID uuid.UUID, runnerID, name, contentType string, size int64) (*models.ArtifactUpload, error) {
+	if !models.ValidArtifactName(name) {
+		return nil, fmt.Errorf("%w: name must contain only letters, digits, dots, dashes and underscores", ErrInvalidArtifact)
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("%w: size must not be negative", ErrInvalidArtifact)
+	}
+
+	s.mu.RLock()
+	maxSize, maxCount, stagingDir := s.maxSize, s.maxCount, s.stagingDir
+	s.mu.RUnlock()
+
+	if size > maxSize {
+		return nil, ErrArtifactTooLarge
+	}
+
+	existing, err := s.repo.FindUpload(ctx, taskID, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up upload: %w", err)
+	}
+	if existing != nil {
+		if existing.RunnerID == runnerID && existing.Size == size {
+			if existing.Offset, err = s.staged(existing); err != nil {
+				return nil, err
+			}
+			return existing, nil
+		}
+		if err := s.discard(ctx, existing); err != nil {
+			return nil, err
+		}
+	}
+
+	artifacts, err := s.repo.ListArtifacts(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list artifacts: %w", err)
+	}
+	if len(artifacts) >= maxCount && models.TaskArtifacts(artifacts).Find(name) == nil {
+		return nil, ErrTooManyArtifacts
+	}
+
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	upload := &models.ArtifactUpload{
+		ID:          uuid.New(),
+		TaskID:      taskID,
+		RunnerID:    runnerID,
+		Name:        name,
+		ContentType: contentType,
+		Size:        size,
+	}
+
+	if err := os.MkdirAll(stagingDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	file, err := os.Create(s.stagingPath(upload.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staged upload: %w", err)
+	}
+	file.Close()
+
+	if err := s.repo.CreateUpload(ctx, upload); err != nil {
+		os.Remove(s.stagingPath(upload.ID))
+		return nil, fmt.Errorf("failed to create upload: %w", err)
+	}
+
+	// An empty artifact is complete as soon as it is announced.
+	if size == 0 {
+		if _, err := s.finalize(ctx, upload); err != nil {
+			return nil, err
+		}
+	}
+	return upload, nil
+}
+
+// GetUpload returns the upload with its current offset, provided it belongs to
+// the task and runner.
+func (s *ArtifactService) GetUpload(ctx context.Context, taskID, uploadID uuid.UUID, runnerID string) (*models.ArtifactUpload, error) {
+	upload, err := s.repo.GetUpload(ctx, uploadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upload: %w", err)
+	}
+	if upload == nil || upload.TaskID != taskID || upload.RunnerID != runnerID {
+		return nil, ErrUploadNotFound
+	}
+	if upload.Offset, err = s.staged(upload); err != nil {
+		return nil, err
+	}
+	return upload, nil
+}
+
+// AppendUpload writes a chunk at offset, which must equal the number of bytes
+// received so far. Bytes that arrived before the chunk was cut short are kept,
+// so the runner resumes from the offset GetUpload reports. When the upload is
+// complete the stored artifact is returned as well.
+func (s *ArtifactService) AppendUpload(ctx context.Context, taskID, uploadID uuid.UUID, runnerID string, offset int64, chunk io.Reader) (*models.ArtifactUpload, *models.TaskArtifact, error) {
+	unlock := s.lock(uploadID)
+	defer unlock()
+
+	upload, err := s.GetUpload(ctx, taskID, uploadID, runnerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if offset != upload.Offset {
+		return upload, nil, ErrUploadOffsetMismatch
+	}
+
+	file, err := os.OpenFile(s.stagingPath(upload.ID), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open staged upload: %w", err)
+	}
+
+	remaining := upload.Size - upload.Offset
+	written, copyErr := io.Copy(file, io.LimitReader(chunk, remaining+1))
+	if written > remaining {
+		file.Truncate(upload.Size)
+		written = remaining
+		copyErr = ErrArtifactTooLarge
+	}
+	if err := file.Close(); err != nil && copyErr == nil {
+		copyErr = fmt.Errorf("failed to write staged upload: %w", err)
+	}
+	upload.Offset += written
+
+	if err := s.repo.TouchUpload(ctx, upload.ID, time.Now()); err != nil {
+		log := gologger.WithComponent("artifacts")
+		log.Warn().Err(err).
+			Str("upload_id", upload.ID.String()).
+			Msg("Failed to record upload activity")
+	}
+	if copyErr != nil {
+		return upload, nil, copyErr
+	}
+
+	if upload.Offset < upload.Size {
+		return upload, nil, nil
+	}
+	artifact, err := s.finalize(ctx, upload)
+	if err != nil {
+		return upload, nil, err
+	}
+	return upload, artifact, nil
+}
+
+// finalize digests a fully received upload, moves it into object storage and
+// records the artifact.
+func (s *ArtifactService) finalize(ctx context.Context, upload *models.ArtifactUpload) (*models.TaskArtifact, error) {
+	file, err := os.Open(s.stagingPath(upload.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open staged upload: %w", err)
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	object, err := s.storage.Put(ctx, io.TeeReader(file, hasher), upload.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store artifact %s: %w", upload.Name, err)
+	}
+
+	artifact := &models.TaskArtifact{
+		TaskID:      upload.TaskID,
+		Name:        upload.Name,
+		Digest:      "sha256:" + hex.EncodeToString(hasher.Sum(nil)),
+		Size:        upload.Size,
+		ContentType: upload.ContentType,
+		StorageKey:  object.Key,
+		RunnerID:    upload.RunnerID,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.repo.SaveArtifact(ctx, artifact); err != nil {
+		return nil, fmt.Errorf("failed to save artifact: %w", err)
+	}
+	if err := s.discard(ctx, upload); err != nil {
+		log := gologger.WithComponent("artifacts")
+		log.Warn().Err(err).
+			Str("upload_id", upload.ID.String()).
+			Msg("Failed to clean up finished upload")
+	}
+
+	return artifact, nil
+}
+
+func (s *ArtifactService) discard(ctx context.Context, upload *models.ArtifactUpload) error {
+	if err := os.Remove(s.stagingPath(upload.ID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove staged upload: %w", err)
+	}
+	if err := s.repo.DeleteUpload(ctx, upload.ID); err != nil {
+		return fmt.Errorf("failed to delete upload: %w", err)
+	}
+	s.uploadLocks.Delete(upload.ID)
+	return nil
+}
+
+// ResultArtifacts returns the artifacts the runner finished uploading for a
+// task, which its result is taken to include.
+func (s *ArtifactService) ResultArtifacts(ctx context.Context, taskID uuid.UUID, runnerID string) (models.TaskArtifacts, error) {
+	artifacts, err := s.repo.ListArtifacts(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list artifacts: %w", err)
+	}
+
+	var result models.TaskArtifacts
+	for _, artifact := range artifacts {
+		if artifact.RunnerID == runnerID {
+			result = append(result, artifact)
+		}
+	}
+	return result, nil
+}
+
+// Open returns the artifact's content from object storage.
+func (s *ArtifactService) Open(ctx context.Context, artifact *models.TaskArtifact) (io.ReadCloser, error) {
+	return s.storage.Get(ctx, artifact.StorageKey)
+}
+
+// CleanupStaleUploads discards uploads that received nothing for longer than
+// the upload TTL.
+func (s *ArtifactService) CleanupStaleUploads(ctx context.Context) (int, error) {
+	s.mu.RLock()
+	ttl := s.uploadTTL
+	s.mu.RUnlock()
+
+	uploads, err := s.repo.ListStaleUploads(ctx, time.Now().Add(-ttl))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list stale uploads: %w", err)
+	}
+
+	discarded := 0
+	for _, upload := range uploads {
+		if err := s.discard(ctx, upload); err != nil {
+			return discarded, err
+		}
+		discarded++
+	}
+	return discarded, nil
+}
+
+func (s *ArtifactService) Start(ctx context.Context) {
+	log := gologger.WithComponent("artifacts")
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			discarded, err := s.CleanupStaleUploads(ctx)
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to clean up stale artifact uploads")
+			}
+			if discarded > 0 {
+				log.Info().Int("discarded", discarded).Msg("Discarded stale artifact uploads")
+			}
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/parity-server/internal/core/config"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/internal/core/ports"
+	"github.com/theblitlabs/parity-server/internal/utils"
+)
+
+type inMemoryArtifactRepo struct {
+	mu        sync.Mutex
+	uploads   map[uuid.UUID]models.ArtifactUpload
+	artifacts map[uuid.UUID]map[string]models.TaskArtifact
+}
+
+func newInMemoryArtifactRepo() *inMemoryArtifactRepo {
+	return &inMemoryArtifactRepo{
+		uploads:   make(map[uuid.UUID]models.ArtifactUpload),
+		artifacts: make(map[uuid.UUID]map[string]models.TaskArtifact),
+	}
+}
+
+func (r *inMemoryArtifactRepo) CreateUpload(ctx context.Context, upload *models.ArtifactUpload) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	upload.CreatedAt = time.Now()
+	upload.UpdatedAt = upload.CreatedAt
+	r.uploads[upload.ID] = *upload
+	return nil
+}
+
+func (r *inMemoryArtifactRepo) GetUpload(ctx context.Context, id uuid.UUID) (*models.ArtifactUpload, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	upload, ok := r.uploads[id]
+	if !ok {
+		return nil, nil
+	}
+	return &upload, nil
+}
+
+func (r *inMemoryArtifactRepo) FindUpload(ctx context.Context, taskID uuid.UUID, name string) (*models.ArtifactUpload, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, upload := range r.uploads {
+		if upload.TaskID == taskID && upload.Name == name {
+			return &upload, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *inMemoryArtifactRepo) TouchUpload(ctx context.Context, id uuid.UUID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if upload, ok := r.uploads[id]; ok {
+		upload.UpdatedAt = at
+		r.uploads[id] = upload
+	}
+	return nil
+}
+
+func (r *inMemoryArtifactRepo) DeleteUpload(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.uploads, id)
+	return nil
+}
+
+func (r *inMemoryArtifactRepo) ListStaleUploads(ctx context.Context, updatedBefore time.Time) ([]*models.ArtifactUpload, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var stale []*models.ArtifactUpload
+	for _, upload := range r.uploads {
+		if upload.UpdatedAt.Before(updatedBefore) {
+			upload := upload
+			stale = append(stale, &upload)
+		}
+	}
+	return stale, nil
+}
+
+func (r *inMemoryArtifactRepo) SaveArtifact(ctx context.Context, artifact *models.TaskArtifact) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.artifacts[artifact.TaskID] == nil {
+		r.artifacts[artifact.TaskID] = make(map[string]models.TaskArtifact)
+	}
+	r.artifacts[artifact.TaskID][artifact.Name] = *artifact
+	return nil
+}
+
+func (r *inMemoryArtifactRepo) ListArtifacts(ctx context.Context, taskID uuid.UUID) ([]models.TaskArtifact, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var artifacts []models.TaskArtifact
+	for _, artifact := range r.artifacts[taskID] {
+		artifacts = append(artifacts, artifact)
+	}
+	return artifacts, nil
+}
+
+type memoryObjectStorage struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (s *memoryObjectStorage) Backend() string { return "memory" }
+
+func (s *memoryObjectStorage) Put(ctx context.Context, content io.Reader, name string) (*ports.StoredObject, error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	key := hex.EncodeToString(sum[:])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.objects == nil {
+		s.objects = make(map[string][]byte)
+	}
+	s.objects[key] = data
+	return &ports.StoredObject{Key: key, URL: s.URL(key), Size: int64(len(data))}, nil
+}
+
+func (s *memoryObjectStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, ports.ErrObjectNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *memoryObjectStorage) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, key)
+	return nil
+}
+
+func (s *memoryObjectStorage) URL(key string) string { return "memory://" + key }
+
+// interruptedReader returns its data and then fails, like a request body cut
+// off by a dropped connection.
+type interruptedReader struct {
+	data []byte
+}
+
+func (r *interruptedReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func newTestArtifactService(t *testing.T) (*ArtifactService, *inMemoryArtifactRepo) {
+	t.Helper()
+	repo := newInMemoryArtifactRepo()
+	service := NewArtifactService(repo, &memoryObjectStorage{})
+	service.SetConfig(config.ArtifactConfig{StagingDir: t.TempDir(), MaxSizeMB: 1, MaxCount: 2})
+	return service, repo
+}
+
+func TestArtifactUploadResumesAfterInterruption(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newTestArtifactService(t)
+	taskID := uuid.New()
+	content := []byte("model weights that arrive in two attempts")
+
+	upload, err := service.StartUpload(ctx, taskID, "runner-1", "model.bin", "", int64(len(content)))
+	if err != nil {
+		t.Fatalf("StartUpload failed: %v", err)
+	}
+
+	_, _, err = service.AppendUpload(ctx, taskID, upload.ID, "runner-1", 0, &interruptedReader{data: content[:10]})
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected the interrupted chunk to fail, got %v", err)
+	}
+
+	resumed, err := service.StartUpload(ctx, taskID, "runner-1", "model.bin", "", int64(len(content)))
+	if err != nil {
+		t.Fatalf("StartUpload to resume failed: %v", err)
+	}
+	if resumed.ID != upload.ID || resumed.Offset != 10 {
+		t.Fatalf("expected to resume upload %s at 10, got %s at %d", upload.ID, resumed.ID, resumed.Offset)
+	}
+
+	current, _, err := service.AppendUpload(ctx, taskID, upload.ID, "runner-1", 0, bytes.NewReader(content))
+	if !errors.Is(err, ErrUploadOffsetMismatch) || current.Offset != 10 {
+		t.Fatalf("expected an offset mismatch at 10, got %v", err)
+	}
+
+	_, artifact, err := service.AppendUpload(ctx, taskID, upload.ID, "runner-1", 10, bytes.NewReader(content[10:]))
+	if err != nil {
+		t.Fatalf("AppendUpload failed: %v", err)
+	}
+	sum := sha256.Sum256(content)
+	if artifact == nil || artifact.Digest != "sha256:"+hex.EncodeToString(sum[:]) {
+		t.Fatalf("expected artifact with the content digest, got %+v", artifact)
+	}
+	if artifact.ContentType != "application/octet-stream" {
+		t.Fatalf("expected default content type, got %q", artifact.ContentType)
+	}
+
+	reader, err := service.Open(ctx, artifact)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer reader.Close()
+	stored, _ := io.ReadAll(reader)
+	if !bytes.Equal(stored, content) {
+		t.Fatalf("stored artifact does not match the upload")
+	}
+
+	if _, err := service.GetUpload(ctx, taskID, upload.ID, "runner-1"); !errors.Is(err, ErrUploadNotFound) {
+		t.Fatalf("expected finished upload to be gone, got %v", err)
+	}
+}
+
+func TestArtifactUploadEnforcesLimits(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newTestArtifactService(t)
+	taskID := uuid.New()
+
+	if _, err := service.StartUpload(ctx, taskID, "runner-1", "../escape", "", 1); !errors.Is(err, ErrInvalidArtifact) {
+		t.Fatalf("expected invalid name to be rejected, got %v", err)
+	}
+	if _, err := service.StartUpload(ctx, taskID, "runner-1", "big.bin", "", 2<<20); !errors.Is(err, ErrArtifactTooLarge) {
+		t.Fatalf("expected oversized artifact to be rejected, got %v", err)
+	}
+
+	upload, err := service.StartUpload(ctx, taskID, "runner-1", "short.txt", "text/plain", 4)
+	if err != nil {
+		t.Fatalf("StartUpload failed: %v", err)
+	}
+	current, _, err := service.AppendUpload(ctx, taskID, upload.ID, "runner-1", 0, strings.NewReader("too long"))
+	if !errors.Is(err, ErrArtifactTooLarge) || current.Offset != 4 {
+		t.Fatalf("expected the excess bytes to be rejected at offset 4, got %v", err)
+	}
+	if _, _, err := service.AppendUpload(ctx, taskID, upload.ID, "runner-2", 4, strings.NewReader("")); !errors.Is(err, ErrUploadNotFound) {
+		t.Fatalf("expected another runner's upload to be hidden, got %v", err)
+	}
+
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if _, err := service.StartUpload(ctx, taskID, "runner-1", name, "", 0); err != nil {
+			t.Fatalf("StartUpload %s failed: %v", name, err)
+		}
+	}
+	if _, err := service.StartUpload(ctx, taskID, "runner-1", "c.txt", "", 0); !errors.Is(err, ErrTooManyArtifacts) {
+		t.Fatalf("expected the artifact count limit, got %v", err)
+	}
+}
+
+func TestArtifactDigestsChangeResultHash(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newTestArtifactService(t)
+	taskID := uuid.New()
+
+	upload, err := service.StartUpload(ctx, taskID, "runner-1", "out.txt", "", 5)
+	if err != nil {
+		t.Fatalf("StartUpload failed: %v", err)
+	}
+	if _, _, err := service.AppendUpload(ctx, taskID, upload.ID, "runner-1", 0, strings.NewReader("hello")); err != nil {
+		t.Fatalf("AppendUpload failed: %v", err)
+	}
+
+	artifacts, err := service.ResultArtifacts(ctx, taskID, "runner-1")
+	if err != nil || len(artifacts) != 1 {
+		t.Fatalf("expected one artifact, got %v (%v)", artifacts, err)
+	}
+	if others, _ := service.ResultArtifacts(ctx, taskID, "runner-2"); len(others) != 0 {
+		t.Fatalf("expected no artifacts for another runner, got %v", others)
+	}
+
+	plain := utils.ComputeResultHash("ok", "", 0, nil)
+	withArtifact := utils.ComputeResultHash("ok", "", 0, artifacts)
+	if plain == withArtifact {
+		t.Fatalf("expected the artifact digest to change the result hash")
+	}
+
+	tampered := append(models.TaskArtifacts(nil), artifacts...)
+	tampered[0].Digest = "sha256:" + strings.Repeat("0", 64)
+	if utils.ComputeResultHash("ok", "", 0, tampered) == withArtifact {
+		t.Fatalf("expected a different artifact digest to change the result hash")
+	}
+}
@@ -0,0 +1,417 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/core/config"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/internal/core/ports"
+	"github.com/theblitlabs/parity-server/internal/database/repositories"
+	"github.com/theblitlabs/parity-server/internal/utils"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid or expired credentials")
+	ErrRoleNotGranted     = errors.New("role not granted to account")
+	ErrAPIKeyNotFound     = repositories.ErrAPIKeyNotFound
+	ErrAccountNotFound    = repositories.ErrAccountNotFound
+)
+
+const apiKeyPrefix = "pk_"
+
+var defaultAccountRoles = models.RoleList{models.RoleCreator, models.RoleLLMClient}
+
+type AccountSessionTokens struct {
+	AccessToken string          `json:"access_token"`
+	ExpiresAt   time.Time       `json:"expires_at"`
+	Account     *models.Account `json:"account"`
+}
+
+type CreatedAPIKey struct {
+	Key    string         `json:"key"`
+	APIKey *models.APIKey `json:"api_key"`
+}
+
+// AuthService signs in creators, LLM clients and admins with Sign-In-With-Ethereum
+// and resolves session tokens and API keys to a principal.
+type AuthService struct {
+	repo         ports.AccountRepository
+	domain       string
+	uri          string
+	chainID      int64
+	adminWallets map[string]bool
+	challengeTTL time.Duration
+	sessionTTL   time.Duration
+	now          func() time.Time
+}
+
+func NewAuthService(repo ports.AccountRepository, cfg *config.Config) *AuthService {
+	s := &AuthService{
+		repo:         repo,
+		domain:       "localhost",
+		adminWallets: make(map[string]bool),
+		challengeTTL: defaultChallengeTTL,
+		sessionTTL:   defaultSessionTTL,
+		now:          time.Now,
+	}
+
+	if cfg == nil {
+		return s
+	}
+
+	s.chainID = cfg.BlockchainNetwork.ChainID
+	s.uri = cfg.Server.Endpoint
+	if parsed, err := url.Parse(cfg.Server.Endpoint); err == nil && parsed.Host != "" {
+		s.domain = parsed.Host
+	}
+
+	for _, wallet := range strings.Split(cfg.Auth.AdminWallets, ",") {
+		if wallet = strings.TrimSpace(wallet); common.IsHexAddress(wallet) {
+			s.adminWallets[strings.ToLower(wallet)] = true
+		}
+	}
+
+	if cfg.Auth.ChallengeTTL > 0 {
+		s.challengeTTL = time.Duration(cfg.Auth.ChallengeTTL) * time.Second
+	}
+	if cfg.Auth.SessionTTL > 0 {
+		s.sessionTTL = time.Duration(cfg.Auth.SessionTTL) * time.Second
+	}
+
+	return s
+}
+
+// CreateChallenge issues an EIP-4361 message for the wallet to sign.
+func (s *AuthService) CreateChallenge(ctx context.Context, walletAddress string) (*models.AccountAuthChallenge, error) {
+	if !common.IsHexAddress(walletAddress) {
+		return nil, errors.New("invalid wallet address")
+	}
+
+	nonce, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	challenge := &models.AccountAuthChallenge{
+		ID:            uuid.New(),
+		WalletAddress: common.HexToAddress(walletAddress).Hex(),
+		ExpiresAt:     now.Add(s.challengeTTL),
+		CreatedAt:     now,
+	}
+
+	var message strings.Builder
+	fmt.Fprintf(&message, "%s wants you to sign in with your Ethereum account:\n%s\n\n", s.domain, challenge.WalletAddress)
+	message.WriteString("Sign in to Parity.\n\n")
+	if s.uri != "" {
+		fmt.Fprintf(&message, "URI: %s\n", s.uri)
+	}
+	message.WriteString("Version: 1\n")
+	fmt.Fprintf(&message, "Chain ID: %d\n", s.chainID)
+	fmt.Fprintf(&message, "Nonce: %s\n", nonce[:16])
+	fmt.Fprintf(&message, "Issued At: %s\n", now.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&message, "Expiration Time: %s\n", challenge.ExpiresAt.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&message, "Request ID: %s", challenge.ID)
+	challenge.Message = message.String()
+
+	if err := s.repo.CreateChallenge(ctx, challenge); err != nil {
+		return nil, fmt.Errorf("failed to store auth challenge: %w", err)
+	}
+
+	return challenge, nil
+}
+
+// Login checks the signed challenge, creating the account on first sign-in.
+func (s *AuthService) Login(ctx context.Context, challengeID string, signature string) (*AccountSessionTokens, error) {
+	log := gologger.WithComponent("auth_service")
+
+	id, err := uuid.Parse(challengeID)
+	if err != nil {
+		return nil, ErrInvalidChallenge
+	}
+
+	challenge, err := s.repo.GetChallenge(ctx, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrAuthChallengeNotFound) {
+			return nil, ErrInvalidChallenge
+		}
+		return nil, err
+	}
+	if challenge.UsedAt != nil || s.now().After(challenge.ExpiresAt) {
+		return nil, ErrInvalidChallenge
+	}
+
+	if err := utils.VerifyPersonalSignature(challenge.WalletAddress, challenge.Message, signature); err != nil {
+		log.Warn().Err(err).
+			Str("wallet_address", challenge.WalletAddress).
+			Msg("Sign-in signature rejected")
+		return nil, ErrInvalidSignature
+	}
+
+	consumed, err := s.repo.MarkChallengeUsed(ctx, challenge.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume auth challenge: %w", err)
+	}
+	if !consumed {
+		return nil, ErrInvalidChallenge
+	}
+
+	account, err := s.getOrCreateAccount(ctx, challenge.WalletAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	session := &models.AccountSession{
+		ID:        uuid.New(),
+		AccountID: account.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: now.Add(s.sessionTTL),
+		CreatedAt: now,
+	}
+	if err := s.repo.CreateSession(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to store account session: %w", err)
+	}
+
+	log.Info().
+		Str("wallet_address", account.WalletAddress).
+		Interface("roles", account.Roles).
+		Msg("Account signed in")
+
+	return &AccountSessionTokens{
+		AccessToken: token,
+		ExpiresAt:   session.ExpiresAt,
+		Account:     account,
+	}, nil
+}
+
+// Authenticate resolves a session token or API key to the calling principal.
+func (s *AuthService) Authenticate(ctx context.Context, token string) (*models.Principal, error) {
+	if token == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	if strings.HasPrefix(token, apiKeyPrefix) {
+		return s.authenticateAPIKey(ctx, token)
+	}
+
+	session, err := s.repo.GetSessionByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, repositories.ErrAccountSessionNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if session.RevokedAt != nil || s.now().After(session.ExpiresAt) {
+		return nil, ErrInvalidCredentials
+	}
+
+	account, err := s.repo.GetAccount(ctx, session.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Principal{
+		AccountID:     account.ID,
+		WalletAddress: account.WalletAddress,
+		Roles:         account.Roles,
+	}, nil
+}
+
+// Logout revokes a session token. API keys are revoked through RevokeAPIKey.
+func (s *AuthService) Logout(ctx context.Context, token string) error {
+	session, err := s.repo.GetSessionByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, repositories.ErrAccountSessionNotFound) {
+			return ErrInvalidCredentials
+		}
+		return err
+	}
+
+	now := s.now()
+	session.RevokedAt = &now
+	return s.repo.UpdateSession(ctx, session)
+}
+
+// CreateAPIKey issues a key carrying a subset of the account's roles. The raw key
+// is only returned here.
+func (s *AuthService) CreateAPIKey(ctx context.Context, principal *models.Principal, name string, roles models.RoleList, ttl time.Duration) (*CreatedAPIKey, error) {
+	account, err := s.repo.GetAccount(ctx, principal.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(roles) == 0 {
+		roles = account.Roles
+	}
+	for _, role := range roles {
+		if !role.Valid() || !account.Roles.Has(role) {
+			return nil, fmt.Errorf("%w: %s", ErrRoleNotGranted, role)
+		}
+	}
+
+	secret, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	rawKey := apiKeyPrefix + secret
+
+	now := s.now()
+	key := &models.APIKey{
+		ID:        uuid.New(),
+		AccountID: account.ID,
+		Name:      name,
+		Prefix:    rawKey[:len(apiKeyPrefix)+8],
+		KeyHash:   hashToken(rawKey),
+		Roles:     roles,
+		CreatedAt: now,
+	}
+	if ttl > 0 {
+		expiresAt := now.Add(ttl)
+		key.ExpiresAt = &expiresAt
+	}
+
+	if err := s.repo.CreateAPIKey(ctx, key); err != nil {
+		return nil, fmt.Errorf("failed to store api key: %w", err)
+	}
+
+	return &CreatedAPIKey{Key: rawKey, APIKey: key}, nil
+}
+
+func (s *AuthService) ListAPIKeys(ctx context.Context, principal *models.Principal) ([]*models.APIKey, error) {
+	return s.repo.ListAPIKeys(ctx, principal.AccountID)
+}
+
+func (s *AuthService) RevokeAPIKey(ctx context.Context, principal *models.Principal, keyID uuid.UUID) error {
+	key, err := s.repo.GetAPIKey(ctx, keyID)
+	if err != nil {
+		return err
+	}
+	if key.AccountID != principal.AccountID && !principal.IsAdmin() {
+		return ErrAPIKeyNotFound
+	}
+	if key.RevokedAt != nil {
+		return nil
+	}
+
+	now := s.now()
+	key.RevokedAt = &now
+	return s.repo.UpdateAPIKey(ctx, key)
+}
+
+// SetAccountRoles replaces the roles of the account behind walletAddress.
+func (s *AuthService) SetAccountRoles(ctx context.Context, walletAddress string, roles models.RoleList) (*models.Account, error) {
+	for _, role := range roles {
+		if !role.Valid() {
+			return nil, fmt.Errorf("invalid role: %s", role)
+		}
+	}
+
+	account, err := s.getOrCreateAccount(ctx, walletAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	account.Roles = roles
+	account.UpdatedAt = s.now()
+	if err := s.repo.UpdateAccount(ctx, account); err != nil {
+		return nil, fmt.Errorf("failed to update account roles: %w", err)
+	}
+
+	return account, nil
+}
+
+func (s *AuthService) authenticateAPIKey(ctx context.Context, rawKey string) (*models.Principal, error) {
+	key, err := s.repo.GetAPIKeyByHash(ctx, hashToken(rawKey))
+	if err != nil {
+		if errors.Is(err, repositories.ErrAPIKeyNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	now := s.now()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && now.After(*key.ExpiresAt)) {
+		return nil, ErrInvalidCredentials
+	}
+
+	account, err := s.repo.GetAccount(ctx, key.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A key never carries more than its account currently holds.
+	roles := make(models.RoleList, 0, len(key.Roles))
+	for _, role := range key.Roles {
+		if account.Roles.Has(role) {
+			roles = append(roles, role)
+		}
+	}
+
+	key.LastUsedAt = &now
+	if err := s.repo.UpdateAPIKey(ctx, key); err != nil {
+		log := gologger.WithComponent("auth_service")
+		log.Warn().Err(err).Str("api_key_id", key.ID.String()).Msg("Failed to record api key use")
+	}
+
+	keyID := key.ID
+	return &models.Principal{
+		AccountID:     account.ID,
+		WalletAddress: account.WalletAddress,
+		Roles:         roles,
+		APIKeyID:      &keyID,
+	}, nil
+}
+
+func (s *AuthService) getOrCreateAccount(ctx context.Context, walletAddress string) (*models.Account, error) {
+	if !common.IsHexAddress(walletAddress) {
+		return nil, errors.New("invalid wallet address")
+	}
+	walletAddress = common.HexToAddress(walletAddress).Hex()
+
+	account, err := s.repo.GetAccountByWallet(ctx, walletAddress)
+	if err != nil && !errors.Is(err, repositories.ErrAccountNotFound) {
+		return nil, err
+	}
+
+	if account == nil {
+		now := s.now()
+		account = &models.Account{
+			ID:            uuid.New(),
+			WalletAddress: walletAddress,
+			Roles:         append(models.RoleList{}, defaultAccountRoles...),
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		if s.adminWallets[strings.ToLower(walletAddress)] {
+			account.Roles = append(account.Roles, models.RoleAdmin)
+		}
+		if err := s.repo.CreateAccount(ctx, account); err != nil {
+			return nil, fmt.Errorf("failed to create account: %w", err)
+		}
+		return account, nil
+	}
+
+	if s.adminWallets[strings.ToLower(walletAddress)] && !account.Roles.Has(models.RoleAdmin) {
+		account.Roles = append(account.Roles, models.RoleAdmin)
+		account.UpdatedAt = s.now()
+		if err := s.repo.UpdateAccount(ctx, account); err != nil {
+			return nil, fmt.Errorf("failed to grant admin role: %w", err)
+		}
+	}
+
+	return account, nil
+}
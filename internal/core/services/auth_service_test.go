@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"github.com/theblitlabs/parity-server/internal/core/config"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/internal/database/repositories"
+)
+
+type inMemoryAccountRepo struct {
+	challenges map[uuid.UUID]*models.AccountAuthChallenge
+	accounts   map[uuid.UUID]*models.Account
+	sessions   map[uuid.UUID]*models.AccountSession
+	apiKeys    map[uuid.UUID]*models.APIKey
+}
+
+func newInMemoryAccountRepo() *inMemoryAccountRepo {
+	return &inMemoryAccountRepo{
+		challenges: make(map[uuid.UUID]*models.AccountAuthChallenge),
+		accounts:   make(map[uuid.UUID]*models.Account),
+		sessions:   make(map[uuid.UUID]*models.AccountSession),
+		apiKeys:    make(map[uuid.UUID]*models.APIKey),
+	}
+}
+
+func (r *inMemoryAccountRepo) CreateChallenge(ctx context.Context, challenge *models.AccountAuthChallenge) error {
+	cloned := *challenge
+	r.challenges[challenge.ID] = &cloned
+	return nil
+}
+
+func (r *inMemoryAccountRepo) GetChallenge(ctx context.Context, id uuid.UUID) (*models.AccountAuthChallenge, error) {
+	challenge, ok := r.challenges[id]
+	if !ok {
+		return nil, repositories.ErrAuthChallengeNotFound
+	}
+	cloned := *challenge
+	return &cloned, nil
+}
+
+func (r *inMemoryAccountRepo) MarkChallengeUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	challenge, ok := r.challenges[id]
+	if !ok || challenge.UsedAt != nil {
+		return false, nil
+	}
+	now := challenge.CreatedAt
+	challenge.UsedAt = &now
+	return true, nil
+}
+
+func (r *inMemoryAccountRepo) GetAccount(ctx context.Context, id uuid.UUID) (*models.Account, error) {
+	account, ok := r.accounts[id]
+	if !ok {
+		return nil, repositories.ErrAccountNotFound
+	}
+	cloned := *account
+	cloned.Roles = append(models.RoleList{}, account.Roles...)
+	return &cloned, nil
+}
+
+func (r *inMemoryAccountRepo) GetAccountByWallet(ctx context.Context, walletAddress string) (*models.Account, error) {
+	for _, account := range r.accounts {
+		if strings.EqualFold(account.WalletAddress, walletAddress) {
+			return r.GetAccount(ctx, account.ID)
+		}
+	}
+	return nil, repositories.ErrAccountNotFound
+}
+
+func (r *inMemoryAccountRepo) CreateAccount(ctx context.Context, account *models.Account) error {
+	return r.UpdateAccount(ctx, account)
+}
+
+func (r *inMemoryAccountRepo) UpdateAccount(ctx context.Context, account *models.Account) error {
+	cloned := *account
+	cloned.Roles = append(models.RoleList{}, account.Roles...)
+	r.accounts[account.ID] = &cloned
+	return nil
+}
+
+func (r *inMemoryAccountRepo) CreateSession(ctx context.Context, session *models.AccountSession) error {
+	return r.UpdateSession(ctx, session)
+}
+
+func (r *inMemoryAccountRepo) GetSessionByTokenHash(ctx context.Context, tokenHash string) (*models.AccountSession, error) {
+	for _, session := range r.sessions {
+		if session.TokenHash == tokenHash {
+			cloned := *session
+			return &cloned, nil
+		}
+	}
+	return nil, repositories.ErrAccountSessionNotFound
+}
+
+func (r *inMemoryAccountRepo) UpdateSession(ctx context.Context, session *models.AccountSession) error {
+	cloned := *session
+	r.sessions[session.ID] = &cloned
+	return nil
+}
+
+func (r *inMemoryAccountRepo) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	return r.UpdateAPIKey(ctx, key)
+}
+
+func (r *inMemoryAccountRepo) GetAPIKey(ctx context.Context, id uuid.UUID) (*models.APIKey, error) {
+	key, ok := r.apiKeys[id]
+	if !ok {
+		return nil, repositories.ErrAPIKeyNotFound
+	}
+	cloned := *key
+	return &cloned, nil
+}
+
+func (r *inMemoryAccountRepo) GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	for _, key := range r.apiKeys {
+		if key.KeyHash == keyHash {
+			return r.GetAPIKey(ctx, key.ID)
+		}
+	}
+	return nil, repositories.ErrAPIKeyNotFound
+}
+
+func (r *inMemoryAccountRepo) ListAPIKeys(ctx context.Context, accountID uuid.UUID) ([]*models.APIKey, error) {
+	var keys []*models.APIKey
+	for _, key := range r.apiKeys {
+		if key.AccountID == accountID {
+			cloned := *key
+			keys = append(keys, &cloned)
+		}
+	}
+	return keys, nil
+}
+
+func (r *inMemoryAccountRepo) UpdateAPIKey(ctx context.Context, key *models.APIKey) error {
+	cloned := *key
+	r.apiKeys[key.ID] = &cloned
+	return nil
+}
+
+func signInWithEthereum(t *testing.T, ctx context.Context, authService *AuthService, key *ecdsa.PrivateKey) *AccountSessionTokens {
+	t.Helper()
+
+	challenge, err := authService.CreateChallenge(ctx, crypto.PubkeyToAddress(key.PublicKey).Hex())
+	if err != nil {
+		t.Fatalf("CreateChallenge returned error: %v", err)
+	}
+	signature, err := crypto.Sign(accounts.TextHash([]byte(challenge.Message)), key)
+	if err != nil {
+		t.Fatalf("failed to sign challenge: %v", err)
+	}
+
+	tokens, err := authService.Login(ctx, challenge.ID.String(), hexutil.Encode(signature))
+	if err != nil {
+		t.Fatalf("Login returned error: %v", err)
+	}
+	return tokens
+}
+
+func TestAuthServiceSessionsAndAPIKeys(t *testing.T) {
+	ctx := context.Background()
+
+	creatorKey, _ := crypto.GenerateKey()
+	adminKey, _ := crypto.GenerateKey()
+
+	cfg := &config.Config{}
+	cfg.Server.Endpoint = "https://parity.example/api"
+	cfg.Auth.AdminWallets = crypto.PubkeyToAddress(adminKey.PublicKey).Hex()
+	authService := NewAuthService(newInMemoryAccountRepo(), cfg)
+
+	tokens := signInWithEthereum(t, ctx, authService, creatorKey)
+	creator, err := authService.Authenticate(ctx, tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("Authenticate returned error: %v", err)
+	}
+	if !creator.HasRole(models.RoleCreator) || !creator.HasRole(models.RoleLLMClient) || creator.IsAdmin() {
+		t.Fatalf("expected default creator and llm-client roles, got %v", creator.Roles)
+	}
+
+	if _, err := authService.CreateAPIKey(ctx, creator, "ci", models.RoleList{models.RoleAdmin}, 0); !errors.Is(err, ErrRoleNotGranted) {
+		t.Fatalf("expected ErrRoleNotGranted for an escalated key, got %v", err)
+	}
+
+	created, err := authService.CreateAPIKey(ctx, creator, "ci", models.RoleList{models.RoleLLMClient}, 0)
+	if err != nil {
+		t.Fatalf("CreateAPIKey returned error: %v", err)
+	}
+	keyPrincipal, err := authService.Authenticate(ctx, created.Key)
+	if err != nil {
+		t.Fatalf("Authenticate with api key returned error: %v", err)
+	}
+	if keyPrincipal.HasRole(models.RoleCreator) || !keyPrincipal.HasRole(models.RoleLLMClient) {
+		t.Fatalf("expected api key limited to llm-client, got %v", keyPrincipal.Roles)
+	}
+	if !keyPrincipal.Owns(strings.ToLower(creator.WalletAddress)) {
+		t.Fatal("expected api key principal to own the account's resources")
+	}
+
+	admin, err := authService.Authenticate(ctx, signInWithEthereum(t, ctx, authService, adminKey).AccessToken)
+	if err != nil {
+		t.Fatalf("Authenticate returned error: %v", err)
+	}
+	if !admin.IsAdmin() || !admin.Owns(creator.WalletAddress) {
+		t.Fatalf("expected configured admin wallet to be admin, got %v", admin.Roles)
+	}
+
+	if _, err := authService.SetAccountRoles(ctx, creator.WalletAddress, models.RoleList{models.RoleCreator}); err != nil {
+		t.Fatalf("SetAccountRoles returned error: %v", err)
+	}
+	keyPrincipal, err = authService.Authenticate(ctx, created.Key)
+	if err != nil {
+		t.Fatalf("Authenticate with api key returned error: %v", err)
+	}
+	if len(keyPrincipal.Roles) != 0 {
+		t.Fatalf("expected api key to lose roles the account no longer holds, got %v", keyPrincipal.Roles)
+	}
+
+	if err := authService.RevokeAPIKey(ctx, admin, created.APIKey.ID); err != nil {
+		t.Fatalf("RevokeAPIKey returned error: %v", err)
+	}
+	if _, err := authService.Authenticate(ctx, created.Key); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("expected revoked api key to be rejected, got %v", err)
+	}
+
+	if err := authService.Logout(ctx, tokens.AccessToken); err != nil {
+		t.Fatalf("Logout returned error: %v", err)
+	}
+	if _, err := authService.Authenticate(ctx, tokens.AccessToken); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("expected signed-out session to be rejected, got %v", err)
+	}
+}
@@ -64,8 +64,13 @@ func (s *ConsensusService) ValidateResultIntegrity(result *models.TaskResult) er
 	if result.ResultHash == "" {
 		return fmt.Errorf("result hash is missing")
 	}
+	// An archived result no longer holds the output its hash covers; restore it
+	// through RetentionService.RestoreResult before validating.
+	if result.ArchivedAt != nil {
+		return fmt.Errorf("%w: %s", ErrResultArchived, result.ID)
+	}
 
-	expectedHash := utils.ComputeResultHash(result.Output, result.Error, result.ExitCode)
+	expectedHash := utils.ComputeResultHash(result.Output, result.Error, result.ExitCode, result.Artifacts)
 	if result.ResultHash != expectedHash {
 		log.Error().
 			Str("result_id", result.ID.String()).
@@ -0,0 +1,399 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/core/config"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/internal/core/ports"
+	"github.com/theblitlabs/parity-server/internal/database/repositories"
+	"github.com/theblitlabs/parity-server/pkg/webhooksig"
+)
+
+const maxCreatorWebhooksPerCreator = 20
+
+var (
+	ErrCreatorWebhookNotFound  = repositories.ErrCreatorWebhookNotFound
+	ErrInvalidCreatorWebhook   = errors.New("invalid creator webhook")
+	ErrTooManyCreatorWebhooks  = errors.New("creator has too many webhooks")
+	ErrCreatorWebhookForbidden = errors.New("webhook or task belongs to another creator")
+)
+
+type RegisterCreatorWebhookRequest struct {
+	URL    string                       `json:"url"`
+	TaskID *uuid.UUID                   `json:"task_id,omitempty"`
+	Events []models.CreatorWebhookEvent `json:"events,omitempty"`
+}
+
+// CreatorWebhookService notifies creators about their tasks by POSTing to the
+// callback URLs they register. It keeps its own outbox, separate from runner
+// webhooks: a notification is written for every matching webhook and a worker
+// delivers it, signed with that webhook's secret, retrying with exponential
+// backoff until it is delivered or dead-lettered. Webhooks may only point at
+// public addresses; the check runs at registration and on every connection.
+type CreatorWebhookService struct {
+	repo     ports.CreatorWebhookRepository
+	taskRepo TaskRepository
+	client   *http.Client
+	wakeCh   chan struct{}
+	mu       sync.Mutex
+	running  bool
+
+	lookupIP            func(ctx context.Context, host string) ([]net.IP, error)
+	allowPrivateTargets bool
+
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+}
+
+func NewCreatorWebhookService(repo ports.CreatorWebhookRepository, taskRepo TaskRepository) *CreatorWebhookService {
+	s := &CreatorWebhookService{
+		repo:           repo,
+		taskRepo:       taskRepo,
+		wakeCh:         make(chan struct{}, 1),
+		lookupIP:       lookupWebhookIP,
+		maxAttempts:    defaultWebhookMaxAttempts,
+		initialBackoff: defaultWebhookInitialBackoff,
+		maxBackoff:     defaultWebhookMaxBackoff,
+	}
+	s.client = s.newWebhookClient()
+	return s
+}
+
+// SetDeliveryConfig applies the runner webhook retry settings, which creator
+// webhooks share.
+func (s *CreatorWebhookService) SetDeliveryConfig(cfg config.WebhookConfig) {
+	if cfg.MaxAttempts > 0 {
+		s.maxAttempts = cfg.MaxAttempts
+	}
+	if cfg.InitialBackoff > 0 {
+		s.initialBackoff = time.Duration(cfg.InitialBackoff) * time.Second
+	}
+	if cfg.MaxBackoff > 0 {
+		s.maxBackoff = time.Duration(cfg.MaxBackoff) * time.Second
+	}
+}
+
+// Register adds a webhook for creatorAddress and returns it with its signing
+// secret, which is not shown again.
+func (s *CreatorWebhookService) Register(ctx context.Context, creatorAddress string, req RegisterCreatorWebhookRequest) (*models.CreatorWebhook, string, error) {
+	if creatorAddress == "" {
+		return nil, "", fmt.Errorf("%w: creator address is required", ErrInvalidCreatorWebhook)
+	}
+	parsed, err := url.Parse(req.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, "", fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidCreatorWebhook)
+	}
+	if err := s.checkWebhookHost(ctx, parsed.Hostname()); err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidCreatorWebhook, err)
+	}
+	for _, event := range req.Events {
+		if !event.Valid() {
+			return nil, "", fmt.Errorf("%w: unknown event %q", ErrInvalidCreatorWebhook, event)
+		}
+	}
+
+	if req.TaskID != nil {
+		task, err := s.taskRepo.Get(ctx, *req.TaskID)
+		if err != nil {
+			return nil, "", err
+		}
+		if !strings.EqualFold(task.CreatorAddress, creatorAddress) {
+			return nil, "", ErrCreatorWebhookForbidden
+		}
+	}
+
+	existing, err := s.repo.ListWebhooks(ctx, creatorAddress)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	if len(existing) >= maxCreatorWebhooksPerCreator {
+		return nil, "", ErrTooManyCreatorWebhooks
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	webhook := &models.CreatorWebhook{
+		ID:             uuid.New(),
+		CreatorAddress: creatorAddress,
+		TaskID:         req.TaskID,
+		URL:            req.URL,
+		Events:         models.CreatorWebhookEventList(req.Events),
+		Secret:         webhookSecretPrefix + token,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.repo.CreateWebhook(ctx, webhook); err != nil {
+		return nil, "", fmt.Errorf("failed to save webhook: %w", err)
+	}
+	return webhook, webhook.Secret, nil
+}
+
+// ListWebhooks returns the creator's webhooks; an empty address lists all.
+func (s *CreatorWebhookService) ListWebhooks(ctx context.Context, creatorAddress string) ([]*models.CreatorWebhook, error) {
+	return s.repo.ListWebhooks(ctx, creatorAddress)
+}
+
+// Delete removes a webhook. principal must own it.
+func (s *CreatorWebhookService) Delete(ctx context.Context, principal *models.Principal, id uuid.UUID) error {
+	webhook, err := s.repo.GetWebhook(ctx, id)
+	if err != nil {
+		return err
+	}
+	if principal != nil && !principal.Owns(webhook.CreatorAddress) {
+		return ErrCreatorWebhookForbidden
+	}
+	return s.repo.DeleteWebhook(ctx, id)
+}
+
+// ListDeliveries returns the creator's most recent deliveries with their
+// attempts, optionally narrowed to one webhook or status.
+func (s *CreatorWebhookService) ListDeliveries(ctx context.Context, creatorAddress string, webhookID *uuid.UUID, status models.WebhookDeliveryStatus, limit int) ([]*models.CreatorWebhookDelivery, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+	return s.repo.ListDeliveries(ctx, creatorAddress, webhookID, status, limit)
+}
+
+// Notify writes a delivery of event to every webhook of the task's creator that
+// subscribes to it, then wakes the delivery worker. result may be nil.
+func (s *CreatorWebhookService) Notify(ctx context.Context, event models.CreatorWebhookEvent, task *models.Task, result *models.TaskResult) error {
+	if task == nil || task.CreatorAddress == "" {
+		return nil
+	}
+
+	webhooks, err := s.repo.ListWebhooksForTask(ctx, task.CreatorAddress, task.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load creator webhooks: %w", err)
+	}
+
+	queued := 0
+	for _, webhook := range webhooks {
+		if !webhook.Matches(event, task.ID) {
+			continue
+		}
+
+		delivery := models.NewCreatorWebhookDelivery(webhook, event, task.ID)
+		delivery.MaxAttempts = s.maxAttempts
+		body, err := json.Marshal(models.CreatorWebhookPayload{
+			DeliveryID: delivery.ID,
+			Event:      event,
+			TaskID:     task.ID,
+			OccurredAt: delivery.CreatedAt,
+			Task:       task,
+			Result:     result,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal creator webhook payload: %w", err)
+		}
+		delivery.Body = string(body)
+
+		if err := s.repo.CreateDelivery(ctx, delivery); err != nil {
+			return fmt.Errorf("failed to write creator webhook to outbox: %w", err)
+		}
+		queued++
+	}
+
+	if queued > 0 {
+		select {
+		case s.wakeCh <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Start runs the delivery worker until ctx is cancelled.
+func (s *CreatorWebhookService) Start(ctx context.Context) {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	log := gologger.WithComponent("creator_webhook")
+	log.Info().Msg("Starting creator webhook delivery worker")
+
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.mu.Lock()
+			s.running = false
+			s.mu.Unlock()
+			log.Info().Msg("Creator webhook delivery worker stopped")
+			return
+		case <-ticker.C:
+			s.processDueDeliveries(ctx)
+		case <-s.wakeCh:
+			s.processDueDeliveries(ctx)
+		}
+	}
+}
+
+func (s *CreatorWebhookService) processDueDeliveries(ctx context.Context) {
+	log := gologger.WithComponent("creator_webhook")
+
+	for {
+		deliveries, err := s.repo.ClaimDueDeliveries(ctx, time.Now(), 2*webhookRequestTimeout, webhookConcurrency)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to claim creator webhook deliveries")
+			return
+		}
+		if len(deliveries) == 0 {
+			return
+		}
+
+		// Every claimed delivery is sent at once, so each one goes out well
+		// inside its lock and no other instance can claim it meanwhile.
+		var wg sync.WaitGroup
+		for _, delivery := range deliveries {
+			wg.Add(1)
+			go func(delivery *models.CreatorWebhookDelivery) {
+				defer wg.Done()
+				s.deliver(ctx, delivery)
+			}(delivery)
+		}
+		wg.Wait()
+
+		if len(deliveries) < webhookConcurrency {
+			return
+		}
+	}
+}
+
+func (s *CreatorWebhookService) deliver(ctx context.Context, delivery *models.CreatorWebhookDelivery) {
+	log := gologger.WithComponent("creator_webhook")
+
+	// The webhook may have been deleted since the delivery was queued.
+	webhook, err := s.repo.GetWebhook(ctx, delivery.WebhookID)
+	if errors.Is(err, ErrCreatorWebhookNotFound) {
+		delivery.Status = models.WebhookDeliveryDead
+		delivery.LastError = "webhook was deleted"
+		delivery.LockedUntil = nil
+		delivery.UpdatedAt = time.Now()
+		if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+			log.Error().Err(err).Str("delivery_id", delivery.ID.String()).Msg("Failed to drop creator webhook delivery")
+		}
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Str("delivery_id", delivery.ID.String()).Msg("Failed to load creator webhook")
+		return
+	}
+
+	start := time.Now()
+	statusCode, sendErr := s.post(ctx, delivery, webhook.Secret)
+	now := time.Now()
+
+	delivery.Attempts++
+	attempt := &models.CreatorWebhookAttempt{
+		ID:         uuid.New(),
+		DeliveryID: delivery.ID,
+		Attempt:    delivery.Attempts,
+		StatusCode: statusCode,
+		DurationMs: now.Sub(start).Milliseconds(),
+		CreatedAt:  now,
+	}
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+	}
+	if err := s.repo.CreateAttempt(ctx, attempt); err != nil {
+		log.Error().Err(err).Str("delivery_id", delivery.ID.String()).Msg("Failed to record creator webhook attempt")
+	}
+
+	delivery.LastStatusCode = statusCode
+	delivery.LockedUntil = nil
+	delivery.UpdatedAt = now
+
+	if sendErr == nil {
+		delivery.Status = models.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+		if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+			log.Error().Err(err).Str("delivery_id", delivery.ID.String()).Msg("Failed to mark creator webhook delivered")
+		}
+		return
+	}
+
+	delivery.LastError = sendErr.Error()
+
+	maxAttempts := delivery.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = s.maxAttempts
+	}
+
+	if delivery.Attempts >= maxAttempts {
+		delivery.Status = models.WebhookDeliveryDead
+		if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+			log.Error().Err(err).Str("delivery_id", delivery.ID.String()).Msg("Failed to dead-letter creator webhook")
+		}
+
+		log.Warn().
+			Str("delivery_id", delivery.ID.String()).
+			Str("creator_address", delivery.CreatorAddress).
+			Str("event", string(delivery.Event)).
+			Int("attempts", delivery.Attempts).
+			Str("last_error", delivery.LastError).
+			Msg("Creator webhook dead-lettered")
+		return
+	}
+
+	delivery.NextAttemptAt = now.Add(webhookBackoff(s.initialBackoff, s.maxBackoff, delivery.Attempts))
+	if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+		log.Error().Err(err).Str("delivery_id", delivery.ID.String()).Msg("Failed to reschedule creator webhook")
+	}
+}
+
+func (s *CreatorWebhookService) post(ctx context.Context, delivery *models.CreatorWebhookDelivery, secret string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, strings.NewReader(delivery.Body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Delivery-ID", delivery.ID.String())
+	req.Header.Set("X-Webhook-Event", string(delivery.Event))
+	webhooksig.SignRequest(req, []string{secret}, []byte(delivery.Body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send webhook request: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log := gologger.WithComponent("creator_webhook")
+			log.Error().Err(closeErr).Msg("Failed to close response body")
+		}
+	}()
+
+	// The body is drained so the connection can be reused but never recorded,
+	// so the delivery log cannot be used to read responses back.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,383 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/parity-server/internal/core/config"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/pkg/webhooksig"
+)
+
+type inMemoryCreatorWebhookRepo struct {
+	mu         sync.Mutex
+	webhooks   map[uuid.UUID]*models.CreatorWebhook
+	deliveries map[uuid.UUID]*models.CreatorWebhookDelivery
+	attempts   []*models.CreatorWebhookAttempt
+}
+
+func newInMemoryCreatorWebhookRepo() *inMemoryCreatorWebhookRepo {
+	return &inMemoryCreatorWebhookRepo{
+		webhooks:   make(map[uuid.UUID]*models.CreatorWebhook),
+		deliveries: make(map[uuid.UUID]*models.CreatorWebhookDelivery),
+	}
+}
+
+func (r *inMemoryCreatorWebhookRepo) CreateWebhook(ctx context.Context, webhook *models.CreatorWebhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *webhook
+	r.webhooks[webhook.ID] = &copied
+	return nil
+}
+
+func (r *inMemoryCreatorWebhookRepo) GetWebhook(ctx context.Context, id uuid.UUID) (*models.CreatorWebhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	webhook, ok := r.webhooks[id]
+	if !ok {
+		return nil, ErrCreatorWebhookNotFound
+	}
+	copied := *webhook
+	return &copied, nil
+}
+
+func (r *inMemoryCreatorWebhookRepo) ListWebhooks(ctx context.Context, creatorAddress string) ([]*models.CreatorWebhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var webhooks []*models.CreatorWebhook
+	for _, webhook := range r.webhooks {
+		if creatorAddress == "" || strings.EqualFold(webhook.CreatorAddress, creatorAddress) {
+			copied := *webhook
+			webhooks = append(webhooks, &copied)
+		}
+	}
+	return webhooks, nil
+}
+
+func (r *inMemoryCreatorWebhookRepo) ListWebhooksForTask(ctx context.Context, creatorAddress string, taskID uuid.UUID) ([]*models.CreatorWebhook, error) {
+	webhooks, _ := r.ListWebhooks(ctx, creatorAddress)
+	var matching []*models.CreatorWebhook
+	for _, webhook := range webhooks {
+		if webhook.TaskID == nil || *webhook.TaskID == taskID {
+			matching = append(matching, webhook)
+		}
+	}
+	return matching, nil
+}
+
+func (r *inMemoryCreatorWebhookRepo) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.webhooks[id]; !ok {
+		return ErrCreatorWebhookNotFound
+	}
+	delete(r.webhooks, id)
+	return nil
+}
+
+func (r *inMemoryCreatorWebhookRepo) CreateDelivery(ctx context.Context, delivery *models.CreatorWebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *delivery
+	r.deliveries[delivery.ID] = &copied
+	return nil
+}
+
+func (r *inMemoryCreatorWebhookRepo) ClaimDueDeliveries(ctx context.Context, now time.Time, lockFor time.Duration, limit int) ([]*models.CreatorWebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var due []*models.CreatorWebhookDelivery
+	for _, delivery := range r.deliveries {
+		if len(due) == limit {
+			break
+		}
+		if delivery.Status != models.WebhookDeliveryPending || delivery.NextAttemptAt.After(now) {
+			continue
+		}
+		if delivery.LockedUntil != nil && delivery.LockedUntil.After(now) {
+			continue
+		}
+		lockedUntil := now.Add(lockFor)
+		delivery.LockedUntil = &lockedUntil
+		copied := *delivery
+		due = append(due, &copied)
+	}
+	return due, nil
+}
+
+func (r *inMemoryCreatorWebhookRepo) UpdateDelivery(ctx context.Context, delivery *models.CreatorWebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *delivery
+	r.deliveries[delivery.ID] = &copied
+	return nil
+}
+
+func (r *inMemoryCreatorWebhookRepo) CreateAttempt(ctx context.Context, attempt *models.CreatorWebhookAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts = append(r.attempts, attempt)
+	return nil
+}
+
+func (r *inMemoryCreatorWebhookRepo) ListDeliveries(ctx context.Context, creatorAddress string, webhookID *uuid.UUID, status models.WebhookDeliveryStatus, limit int) ([]*models.CreatorWebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deliveries []*models.CreatorWebhookDelivery
+	for _, delivery := range r.deliveries {
+		if creatorAddress != "" && !strings.EqualFold(delivery.CreatorAddress, creatorAddress) {
+			continue
+		}
+		if webhookID != nil && delivery.WebhookID != *webhookID {
+			continue
+		}
+		if status != "" && delivery.Status != status {
+			continue
+		}
+		copied := *delivery
+		deliveries = append(deliveries, &copied)
+	}
+	return deliveries, nil
+}
+
+func newCreatorWebhookTask(t *testing.T, tasks *inMemoryTaskRepo, creator string) *models.Task {
+	t.Helper()
+	task := &models.Task{ID: uuid.New(), Status: models.TaskStatusRunning, CreatorAddress: creator}
+	if err := tasks.Create(context.Background(), task); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	return task
+}
+
+// newTestCreatorWebhookService resolves every host to a public address and,
+// when local is set, lets deliveries reach httptest servers on loopback.
+func newTestCreatorWebhookService(repo *inMemoryCreatorWebhookRepo, tasks TaskRepository, local bool) *CreatorWebhookService {
+	service := NewCreatorWebhookService(repo, tasks)
+	service.lookupIP = func(ctx context.Context, host string) ([]net.IP, error) {
+		return []net.IP{net.ParseIP("93.184.216.34")}, nil
+	}
+	service.allowPrivateTargets = local
+	return service
+}
+
+func TestCreatorWebhookNotifyMatchesTaskAndEvents(t *testing.T) {
+	ctx := context.Background()
+	tasks := newInMemoryTaskRepo()
+	repo := newInMemoryCreatorWebhookRepo()
+	service := newTestCreatorWebhookService(repo, tasks, false)
+
+	task := newCreatorWebhookTask(t, tasks, "0xCreator")
+	other := newCreatorWebhookTask(t, tasks, "0xcreator")
+
+	accountWide, _, err := service.Register(ctx, "0xcreator", RegisterCreatorWebhookRequest{URL: "https://example.com/all"})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	perTask, _, err := service.Register(ctx, "0xcreator", RegisterCreatorWebhookRequest{
+		URL:    "https://example.com/task",
+		TaskID: &task.ID,
+		Events: []models.CreatorWebhookEvent{models.CreatorWebhookTaskFailed},
+	})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	if err := service.Notify(ctx, models.CreatorWebhookTaskCompleted, task, nil); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if err := service.Notify(ctx, models.CreatorWebhookTaskFailed, other, nil); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if err := service.Notify(ctx, models.CreatorWebhookTaskFailed, task, nil); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+
+	forAccount, _ := repo.ListDeliveries(ctx, "", &accountWide.ID, "", 100)
+	if len(forAccount) != 3 {
+		t.Fatalf("account-wide webhook got %d deliveries, want 3", len(forAccount))
+	}
+	forTask, _ := repo.ListDeliveries(ctx, "", &perTask.ID, "", 100)
+	if len(forTask) != 1 || forTask[0].Event != models.CreatorWebhookTaskFailed || forTask[0].TaskID != task.ID {
+		t.Fatalf("per-task webhook deliveries = %+v, want one task.failed for %s", forTask, task.ID)
+	}
+}
+
+func TestCreatorWebhookRegisterValidates(t *testing.T) {
+	ctx := context.Background()
+	tasks := newInMemoryTaskRepo()
+	service := newTestCreatorWebhookService(newInMemoryCreatorWebhookRepo(), tasks, false)
+	someoneElses := newCreatorWebhookTask(t, tasks, "0xother")
+	missing := uuid.New()
+
+	cases := []struct {
+		name string
+		req  RegisterCreatorWebhookRequest
+		want error
+	}{
+		{"relative url", RegisterCreatorWebhookRequest{URL: "/callback"}, ErrInvalidCreatorWebhook},
+		{"non-http scheme", RegisterCreatorWebhookRequest{URL: "ftp://example.com"}, ErrInvalidCreatorWebhook},
+		{"unknown event", RegisterCreatorWebhookRequest{URL: "https://example.com", Events: []models.CreatorWebhookEvent{"task.exploded"}}, ErrInvalidCreatorWebhook},
+		{"another creator's task", RegisterCreatorWebhookRequest{URL: "https://example.com", TaskID: &someoneElses.ID}, ErrCreatorWebhookForbidden},
+		{"missing task", RegisterCreatorWebhookRequest{URL: "https://example.com", TaskID: &missing}, ErrTaskNotFound},
+		{"loopback address", RegisterCreatorWebhookRequest{URL: "http://127.0.0.1:8080/hook"}, ErrInvalidCreatorWebhook},
+		{"metadata address", RegisterCreatorWebhookRequest{URL: "http://169.254.169.254/latest"}, ErrInvalidCreatorWebhook},
+		{"host resolving to a private address", RegisterCreatorWebhookRequest{URL: "https://internal.example"}, ErrInvalidCreatorWebhook},
+	}
+	service.lookupIP = func(ctx context.Context, host string) ([]net.IP, error) {
+		if ip := net.ParseIP(host); ip != nil {
+			return []net.IP{ip}, nil
+		}
+		if host == "internal.example" {
+			return []net.IP{net.ParseIP("93.184.216.34"), net.ParseIP("10.0.0.5")}, nil
+		}
+		return []net.IP{net.ParseIP("93.184.216.34")}, nil
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, _, err := service.Register(ctx, "0xcreator", tc.req); !errors.Is(err, tc.want) {
+				t.Fatalf("Register error = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestCreatorWebhookDeliversSignedPayload(t *testing.T) {
+	ctx := context.Background()
+	tasks := newInMemoryTaskRepo()
+	repo := newInMemoryCreatorWebhookRepo()
+	service := newTestCreatorWebhookService(repo, tasks, true)
+
+	var (
+		mu       sync.Mutex
+		received []*http.Request
+		bodies   [][]byte
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, r)
+		bodies = append(bodies, body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	task := newCreatorWebhookTask(t, tasks, "0xcreator")
+	_, secret, err := service.Register(ctx, "0xcreator", RegisterCreatorWebhookRequest{URL: server.URL})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if !strings.HasPrefix(secret, webhookSecretPrefix) {
+		t.Fatalf("secret %q lacks the %q prefix", secret, webhookSecretPrefix)
+	}
+
+	if err := service.Notify(ctx, models.CreatorWebhookTaskCompleted, task, nil); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	service.processDueDeliveries(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 1 {
+		t.Fatalf("endpoint received %d requests, want 1", len(received))
+	}
+	if got := received[0].Header.Get("X-Webhook-Event"); got != string(models.CreatorWebhookTaskCompleted) {
+		t.Fatalf("X-Webhook-Event = %q, want %q", got, models.CreatorWebhookTaskCompleted)
+	}
+	header := received[0].Header.Get(webhooksig.SignatureHeader)
+	if err := webhooksig.Verify(header, bodies[0], []string{secret}, webhooksig.DefaultTolerance, time.Now()); err != nil {
+		t.Fatalf("signature did not verify: %v", err)
+	}
+	if !strings.Contains(string(bodies[0]), task.ID.String()) {
+		t.Fatalf("payload %s does not mention the task", bodies[0])
+	}
+
+	delivered, _ := repo.ListDeliveries(ctx, "0xcreator", nil, models.WebhookDeliveryDelivered, 100)
+	if len(delivered) != 1 || delivered[0].Attempts != 1 {
+		t.Fatalf("delivered = %+v, want one delivery after one attempt", delivered)
+	}
+}
+
+func TestCreatorWebhookRetriesThenDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	tasks := newInMemoryTaskRepo()
+	repo := newInMemoryCreatorWebhookRepo()
+	service := newTestCreatorWebhookService(repo, tasks, true)
+	service.SetDeliveryConfig(config.WebhookConfig{MaxAttempts: 3})
+	service.initialBackoff = 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "internal detail", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	task := newCreatorWebhookTask(t, tasks, "0xcreator")
+	if _, _, err := service.Register(ctx, "0xcreator", RegisterCreatorWebhookRequest{URL: server.URL}); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := service.Notify(ctx, models.CreatorWebhookTaskFailed, task, nil); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		service.processDueDeliveries(ctx)
+	}
+
+	dead, _ := repo.ListDeliveries(ctx, "0xcreator", nil, models.WebhookDeliveryDead, 100)
+	if len(dead) != 1 {
+		t.Fatalf("got %d dead deliveries, want 1", len(dead))
+	}
+	if dead[0].Attempts != 3 || dead[0].LastStatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("dead delivery attempts = %d, status = %d; want 3 attempts ending in 503", dead[0].Attempts, dead[0].LastStatusCode)
+	}
+	if strings.Contains(dead[0].LastError, "internal detail") {
+		t.Fatalf("last error %q echoes the response body", dead[0].LastError)
+	}
+	if len(repo.attempts) != 3 {
+		t.Fatalf("recorded %d attempts, want 3", len(repo.attempts))
+	}
+}
+
+func TestCreatorWebhookRefusesToDialPrivateAddress(t *testing.T) {
+	ctx := context.Background()
+	tasks := newInMemoryTaskRepo()
+	repo := newInMemoryCreatorWebhookRepo()
+	// The host resolved to a public address at registration but points at
+	// loopback by the time the delivery is sent.
+	service := newTestCreatorWebhookService(repo, tasks, false)
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	task := newCreatorWebhookTask(t, tasks, "0xcreator")
+	if _, _, err := service.Register(ctx, "0xcreator", RegisterCreatorWebhookRequest{URL: server.URL}); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := service.Notify(ctx, models.CreatorWebhookTaskCompleted, task, nil); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	service.processDueDeliveries(ctx)
+
+	if got := atomic.LoadInt32(&requests); got != 0 {
+		t.Fatalf("endpoint on loopback received %d requests", got)
+	}
+	pending, _ := repo.ListDeliveries(ctx, "0xcreator", nil, models.WebhookDeliveryPending, 100)
+	if len(pending) != 1 || !strings.Contains(pending[0].LastError, errWebhookTargetBlocked.Error()) {
+		t.Fatalf("pending deliveries = %+v, want one blocked at dial time", pending)
+	}
+}
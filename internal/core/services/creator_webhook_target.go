@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+var errWebhookTargetBlocked = errors.New("webhook target is not a public address")
+
+// blockedWebhookNetworks are special-purpose ranges the net.IP helpers do not
+// cover: "this network", carrier-grade NAT, IETF protocol assignments,
+// benchmarking and NAT64, which can reach IPv4 hosts behind the server.
+var blockedWebhookNetworks = mustParseCIDRs(
+	"0.0.0.0/8",
+	"100.64.0.0/10",
+	"192.0.0.0/24",
+	"198.18.0.0/15",
+	"64:ff9b::/96",
+	"64:ff9b:1::/48",
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// blockedWebhookIP reports whether a creator webhook must not connect to ip:
+// loopback, private, link-local, unspecified, multicast or another
+// special-purpose address.
+func blockedWebhookIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return true
+	}
+	for _, network := range blockedWebhookNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkWebhookHost resolves host and rejects it if any of its addresses is
+// blocked, so a creator cannot point a webhook at the server's own network.
+func (s *CreatorWebhookService) checkWebhookHost(ctx context.Context, host string) error {
+	if s.allowPrivateTargets {
+		return nil
+	}
+	ips, err := s.lookupIP(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", host, err)
+	}
+	if len(ips) == 0 {
+		return fmt.Errorf("%s has no addresses", host)
+	}
+	for _, ip := range ips {
+		if blockedWebhookIP(ip) {
+			return fmt.Errorf("%w: %s resolves to %s", errWebhookTargetBlocked, host, ip)
+		}
+	}
+	return nil
+}
+
+// checkDialAddress runs on every connection the webhook client opens, after
+// DNS resolution. It catches hosts that resolved to a public address when
+// the webhook was registered and to a private one since, and redirects.
+func (s *CreatorWebhookService) checkDialAddress(network, address string, _ syscall.RawConn) error {
+	if s.allowPrivateTargets {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || blockedWebhookIP(ip) {
+		return fmt.Errorf("%w: %s", errWebhookTargetBlocked, host)
+	}
+	return nil
+}
+
+// newWebhookClient returns the client creator webhooks are sent with. It
+// ignores proxy settings, since a proxy would dial the target on its behalf.
+func (s *CreatorWebhookService) newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookRequestTimeout,
+		Control: s.checkDialAddress,
+	}
+	return &http.Client{
+		Timeout: webhookRequestTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: webhookRequestTimeout,
+			IdleConnTimeout:     90 * time.Second,
+			MaxIdleConnsPerHost: webhookConcurrency,
+		},
+	}
+}
+
+func lookupWebhookIP(ctx context.Context, host string) ([]net.IP, error) {
+	return net.DefaultResolver.LookupIP(ctx, "ip", host)
+}
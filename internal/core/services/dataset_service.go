@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/core/config"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/internal/core/ports"
+)
+
+var (
+	ErrDatasetNotFound = errors.New("input dataset not found in storage")
+	ErrDatasetMismatch = errors.New("input dataset does not match its declared size or digest")
+	ErrTooManyInputs   = errors.New("task declares too many input datasets")
+	ErrDatasetTimeout  = errors.New("input dataset verification timed out")
+)
+
+// DatasetService checks the input datasets a task declares against the
+// storage backend before the task can be dispatched. Each content ID is read
+// and hashed once; later tasks that declare the same digest and size reuse
+// the recorded verification as long as the object is still stored. Staging
+// runs while the task is being created, so it is bounded by verifyTimeout.
+type DatasetService struct {
+	repo    ports.DatasetRepository
+	storage ports.Storage
+
+	mu            sync.RWMutex
+	maxInputs     int
+	maxSize       int64
+	verifyTimeout time.Duration
+}
+
+func NewDatasetService(repo ports.DatasetRepository, storage ports.Storage) *DatasetService {
+	return &DatasetService{
+		repo:          repo,
+		storage:       storage,
+		maxInputs:     16,
+		maxSize:       50 << 30,
+		verifyTimeout: 2 * time.Minute,
+	}
+}
+
+func (s *DatasetService) SetConfig(cfg config.DatasetConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cfg.MaxInputs > 0 {
+		s.maxInputs = cfg.MaxInputs
+	}
+	if cfg.MaxSizeMB > 0 {
+		s.maxSize = int64(cfg.MaxSizeMB) << 20
+	}
+	if cfg.VerifyTimeout > 0 {
+		s.verifyTimeout = time.Duration(cfg.VerifyTimeout) * time.Second
+	}
+}
+
+// Stage verifies every input and returns them with their mount instructions
+// filled in: read-only, at their mount path, fetched from the storage URL.
+func (s *DatasetService) Stage(ctx context.Context, inputs []models.InputDataset) ([]models.InputDataset, error) {
+	s.mu.RLock()
+	maxInputs, maxSize, timeout := s.maxInputs, s.maxSize, s.verifyTimeout
+	s.mu.RUnlock()
+
+	if len(inputs) > maxInputs {
+		return nil, ErrTooManyInputs
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	staged := make([]models.InputDataset, len(inputs))
+	for i, input := range inputs {
+		if input.Size > maxSize {
+			return nil, fmt.Errorf("%w: %s is larger than the %d MB verification limit", ErrDatasetMismatch, input.Name, maxSize>>20)
+		}
+
+		verifiedAt, err := s.verify(ctx, &input)
+		if err != nil {
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("%w: %s was not verified within %s", ErrDatasetTimeout, input.Name, timeout)
+			}
+			return nil, err
+		}
+
+		input.Normalize()
+		input.URL = s.storage.URL(input.ContentID)
+		input.VerifiedAt = &verifiedAt
+		staged[i] = input
+	}
+	return staged, nil
+}
+
+func (s *DatasetService) verify(ctx context.Context, input *models.InputDataset) (time.Time, error) {
+	backend := s.storage.Backend()
+
+	known, err := s.repo.GetVerified(ctx, backend, input.ContentID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to look up dataset: %w", err)
+	}
+	if known != nil {
+		if known.Digest != input.Digest || known.Size != input.Size {
+			return time.Time{}, fmt.Errorf("%w: %s is %d bytes with digest %s", ErrDatasetMismatch, input.Name, known.Size, known.Digest)
+		}
+		// The object may have been collected since it was verified.
+		reader, err := s.open(ctx, input)
+		if err != nil {
+			return time.Time{}, err
+		}
+		reader.Close()
+		return known.VerifiedAt, nil
+	}
+
+	reader, err := s.open(ctx, input)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer reader.Close()
+
+	hasher := sha256.New()
+	size, err := io.Copy(hasher, io.LimitReader(contextReader{ctx: ctx, r: reader}, input.Size+1))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read input %s: %w", input.Name, err)
+	}
+	digest := "sha256:" + hex.EncodeToString(hasher.Sum(nil))
+	if size != input.Size || digest != input.Digest {
+		return time.Time{}, fmt.Errorf("%w: %s", ErrDatasetMismatch, input.Name)
+	}
+
+	verified := &models.VerifiedDataset{
+		Backend:    backend,
+		ContentID:  input.ContentID,
+		Digest:     digest,
+		Size:       size,
+		VerifiedAt: time.Now(),
+	}
+	if err := s.repo.SaveVerified(ctx, verified); err != nil {
+		log := gologger.WithComponent("datasets")
+		log.Warn().Err(err).
+			Str("content_id", input.ContentID).
+			Msg("Failed to record dataset verification")
+	}
+	return verified.VerifiedAt, nil
+}
+
+func (s *DatasetService) open(ctx context.Context, input *models.InputDataset) (io.ReadCloser, error) {
+	reader, err := s.storage.Get(ctx, input.ContentID)
+	if errors.Is(err, ports.ErrObjectNotFound) {
+		return nil, fmt.Errorf("%w: %s (%s)", ErrDatasetNotFound, input.Name, input.ContentID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input %s: %w", input.Name, err)
+	}
+	return reader, nil
+}
+
+// contextReader stops a read once ctx is done, for backends whose readers do
+// not watch the context themselves.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/internal/utils"
+)
+
+type inMemoryDatasetRepo struct {
+	mu       sync.Mutex
+	verified map[string]models.VerifiedDataset
+}
+
+func (r *inMemoryDatasetRepo) GetVerified(ctx context.Context, backend, contentID string) (*models.VerifiedDataset, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	dataset, ok := r.verified[backend+"/"+contentID]
+	if !ok {
+		return nil, nil
+	}
+	return &dataset, nil
+}
+
+func (r *inMemoryDatasetRepo) SaveVerified(ctx context.Context, dataset *models.VerifiedDataset) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.verified == nil {
+		r.verified = make(map[string]models.VerifiedDataset)
+	}
+	r.verified[dataset.Backend+"/"+dataset.ContentID] = *dataset
+	return nil
+}
+
+func storeDataset(t *testing.T, store *memoryObjectStorage, content []byte) models.InputDataset {
+	t.Helper()
+	object, err := store.Put(context.Background(), bytes.NewReader(content), "dataset")
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	sum := sha256.Sum256(content)
+	return models.InputDataset{
+		ContentID: object.Key,
+		Size:      int64(len(content)),
+		Digest:    "sha256:" + hex.EncodeToString(sum[:]),
+	}
+}
+
+func TestDatasetStageVerifiesStoredData(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryObjectStorage{}
+	repo := &inMemoryDatasetRepo{}
+	service := NewDatasetService(repo, store)
+
+	input := storeDataset(t, store, []byte("a,b\n1,2\n"))
+	input.Name = "train.csv"
+
+	staged, err := service.Stage(ctx, []models.InputDataset{input})
+	if err != nil {
+		t.Fatalf("Stage failed: %v", err)
+	}
+	if staged[0].MountPath != "/inputs/train.csv" || !staged[0].ReadOnly || staged[0].URL != store.URL(input.ContentID) || staged[0].VerifiedAt == nil {
+		t.Fatalf("expected mount instructions to be filled in, got %+v", staged[0])
+	}
+	if len(repo.verified) != 1 {
+		t.Fatalf("expected the verification to be recorded")
+	}
+
+	wrongSize := input
+	wrongSize.Size++
+	if _, err := service.Stage(ctx, []models.InputDataset{wrongSize}); !errors.Is(err, ErrDatasetMismatch) {
+		t.Fatalf("expected a size mismatch, got %v", err)
+	}
+
+	if err := store.Delete(ctx, input.ContentID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := service.Stage(ctx, []models.InputDataset{input}); !errors.Is(err, ErrDatasetNotFound) {
+		t.Fatalf("expected a collected dataset to be reported missing, got %v", err)
+	}
+}
+
+func TestDatasetStageRejectsWrongDigest(t *testing.T) {
+	store := &memoryObjectStorage{}
+	service := NewDatasetService(&inMemoryDatasetRepo{}, store)
+
+	input := storeDataset(t, store, []byte("weights"))
+	input.Name = "model.bin"
+	other := sha256.Sum256([]byte("other weights"))
+	input.Digest = "sha256:" + hex.EncodeToString(other[:])
+
+	if _, err := service.Stage(context.Background(), []models.InputDataset{input}); !errors.Is(err, ErrDatasetMismatch) {
+		t.Fatalf("expected a digest mismatch, got %v", err)
+	}
+}
+
+// stalledObjectStorage never answers a read until the caller gives up.
+type stalledObjectStorage struct {
+	memoryObjectStorage
+}
+
+func (s *stalledObjectStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestDatasetStageGivesUpOnStalledStorage(t *testing.T) {
+	store := &stalledObjectStorage{}
+	service := NewDatasetService(&inMemoryDatasetRepo{}, store)
+	service.verifyTimeout = 20 * time.Millisecond
+
+	input := storeDataset(t, &store.memoryObjectStorage, []byte("weights"))
+	input.Name = "model.bin"
+
+	if _, err := service.Stage(context.Background(), []models.InputDataset{input}); !errors.Is(err, ErrDatasetTimeout) {
+		t.Fatalf("expected verification to time out, got %v", err)
+	}
+}
+
+func TestCreateTaskRecordsInputHash(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryObjectStorage{}
+	tasks := newInMemoryTaskRepo()
+	taskService := NewTaskService(tasks, nil, nil)
+	taskService.SetDatasetService(NewDatasetService(&inMemoryDatasetRepo{}, store))
+
+	input := storeDataset(t, store, []byte("images.tar"))
+	input.Name = "images"
+	config, _ := json.Marshal(map[string]interface{}{
+		"inputs": []models.InputDataset{input},
+		"extra":  "kept",
+	})
+
+	task := models.NewTask()
+	task.Title = "train"
+	task.Type = models.TaskTypeCommand
+	task.Config = config
+	if err := taskService.CreateTask(ctx, task); err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+
+	stored, err := tasks.Get(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	var saved struct {
+		Inputs []models.InputDataset `json:"inputs"`
+		Extra  string                `json:"extra"`
+	}
+	if err := json.Unmarshal(stored.Config, &saved); err != nil {
+		t.Fatalf("failed to unmarshal stored config: %v", err)
+	}
+	if saved.Extra != "kept" || len(saved.Inputs) != 1 || saved.Inputs[0].URL == "" {
+		t.Fatalf("expected staged inputs and untouched fields, got %+v", saved)
+	}
+	if stored.InputHash == "" || stored.InputHash != utils.ComputeInputHash(saved.Inputs) {
+		t.Fatalf("expected the input hash to be recorded, got %q", stored.InputHash)
+	}
+}
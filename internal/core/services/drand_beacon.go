@@ -0,0 +1,169 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/drand/drand/chain"
+	"github.com/drand/drand/client"
+	"github.com/drand/drand/client/http"
+	"github.com/drand/drand/crypto"
+	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+var (
+	defaultDrandURLs      = []string{"https://api.drand.sh", "https://drand.cloudflare.com"}
+	defaultDrandChainHash = "8990e7a9aaed2ffed73dbd7092123d6f289930540d7651336225dc172e51b2ce"
+)
+
+// DrandBeacon reads rounds from a drand network and checks every round's BLS
+// signature against the chain's group public key before handing it out.
+type DrandBeacon struct {
+	urls      []string
+	chainHash []byte
+
+	mu     sync.Mutex
+	client client.Client
+	info   *chain.Info
+	scheme *crypto.Scheme
+}
+
+func NewDrandBeacon(urls []string, chainHashHex string) (*DrandBeacon, error) {
+	if len(urls) == 0 {
+		urls = defaultDrandURLs
+	}
+	if chainHashHex == "" {
+		chainHashHex = defaultDrandChainHash
+	}
+
+	chainHash, err := hex.DecodeString(chainHashHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid drand chain hash: %w", err)
+	}
+
+	return &DrandBeacon{
+		urls:      urls,
+		chainHash: chainHash,
+	}, nil
+}
+
+func (d *DrandBeacon) Name() models.RandomnessSourceType {
+	return models.RandomnessSourceDrand
+}
+
+func (d *DrandBeacon) Latest(ctx context.Context) (*models.BeaconRound, error) {
+	return d.Round(ctx, 0)
+}
+
+func (d *DrandBeacon) Round(ctx context.Context, round uint64) (*models.BeaconRound, error) {
+	c, err := d.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := c.Get(ctx, round)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch drand round %d: %w", round, err)
+	}
+
+	beaconRound := &models.BeaconRound{
+		Source:     models.RandomnessSourceDrand,
+		Round:      result.Round(),
+		Randomness: result.Randomness(),
+		Signature:  result.Signature(),
+	}
+	if data, ok := result.(*client.RandomData); ok {
+		beaconRound.PreviousSignature = data.PreviousSignature
+	}
+
+	if err := d.Verify(ctx, beaconRound); err != nil {
+		return nil, err
+	}
+
+	return beaconRound, nil
+}
+
+func (d *DrandBeacon) Verify(ctx context.Context, round *models.BeaconRound) error {
+	if round == nil || round.Source != models.RandomnessSourceDrand {
+		return fmt.Errorf("%w: not a drand round", ErrRandomnessUnverifiable)
+	}
+
+	if _, err := d.connect(ctx); err != nil {
+		return err
+	}
+
+	if d.scheme.Name == crypto.DefaultSchemeID && len(round.PreviousSignature) == 0 && round.Round > 1 {
+		previous, err := d.client.Get(ctx, round.Round-1)
+		if err != nil {
+			return fmt.Errorf("failed to fetch previous drand round %d: %w", round.Round-1, err)
+		}
+		round.PreviousSignature = previous.Signature()
+	}
+
+	beacon := &chain.Beacon{
+		PreviousSig: round.PreviousSignature,
+		Round:       round.Round,
+		Signature:   round.Signature,
+	}
+	if err := d.scheme.VerifyBeacon(beacon, d.info.PublicKey.Clone()); err != nil {
+		return fmt.Errorf("drand round %d failed signature verification: %w", round.Round, err)
+	}
+
+	if !bytes.Equal(crypto.RandomnessFromSignature(round.Signature), round.Randomness) {
+		return fmt.Errorf("drand round %d randomness does not match its signature", round.Round)
+	}
+
+	return nil
+}
+
+func (d *DrandBeacon) connect(ctx context.Context) (client.Client, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.client != nil {
+		return d.client, nil
+	}
+
+	log := gologger.WithComponent("drand_beacon")
+
+	httpClients := http.ForURLs(d.urls, d.chainHash)
+	if len(httpClients) == 0 {
+		return nil, errors.New("no reachable drand endpoints")
+	}
+
+	c, err := client.New(
+		client.From(httpClients...),
+		client.WithChainHash(d.chainHash),
+		client.WithCacheSize(0), // Disable caching for nonces
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create drand client: %w", err)
+	}
+
+	info, err := c.Info(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch drand chain info: %w", err)
+	}
+
+	scheme, err := crypto.SchemeFromName(info.Scheme)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported drand scheme: %w", err)
+	}
+
+	d.client = c
+	d.info = info
+	d.scheme = scheme
+
+	log.Info().
+		Str("chain_hash", info.HashString()).
+		Str("scheme", info.Scheme).
+		Dur("period", info.Period).
+		Msg("Connected to drand network")
+
+	return d.client, nil
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/core/config"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/internal/core/ports"
+)
+
+const (
+	// eventSubscriberBuffer is how many events a subscriber may fall behind
+	// by before its stream is closed and it has to resume from its last
+	// event ID.
+	eventSubscriberBuffer = 256
+	// eventPollLimit is how many stored events one read fetches.
+	eventPollLimit = 500
+	// eventGapGrace is how long a missing event ID is waited for. IDs are
+	// taken when an insert starts, so a later event can become visible
+	// before an earlier one commits.
+	eventGapGrace = 5 * time.Second
+	// eventRetention is how long stored events are kept for instances that
+	// fall behind.
+	eventRetention     = time.Hour
+	eventPruneInterval = 10 * time.Minute
+)
+
+// EventBus fans task, prompt and FL session state changes out to the clients
+// that own them. The most recent events are kept in memory so a client that
+// reconnects can resume from the last event ID it saw.
+//
+// With a repository, Publish only stores the event and Start relays stored
+// events from every server instance to this instance's subscribers, so a
+// client sees all its events whichever instance it is connected to. Without
+// one, events are delivered in process and IDs start at the server's start
+// time in microseconds, so they keep increasing across restarts and an ID
+// from before a restart is recognised as a gap.
+type EventBus struct {
+	mu           sync.Mutex
+	lastID       int64
+	events       []models.ClientEvent
+	bufferSize   int
+	subscribers  map[*eventSubscriber]struct{}
+	repo         ports.ClientEventRepository
+	pollInterval time.Duration
+	wake         chan struct{}
+}
+
+type eventSubscriber struct {
+	filter models.ClientEventFilter
+	ch     chan models.ClientEvent
+}
+
+// EventSubscription is a client's view of the bus. Backlog holds the buffered
+// events after the requested ID. Missed is set when some events after that ID
+// are no longer buffered, so the client should refetch the current state.
+// Events is closed if the subscriber falls too far behind.
+type EventSubscription struct {
+	Backlog []models.ClientEvent
+	Missed  bool
+	Events  <-chan models.ClientEvent
+
+	close func()
+}
+
+func (s *EventSubscription) Close() {
+	s.close()
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{
+		lastID:       time.Now().UnixMicro(),
+		bufferSize:   1000,
+		subscribers:  make(map[*eventSubscriber]struct{}),
+		pollInterval: 500 * time.Millisecond,
+		wake:         make(chan struct{}, 1),
+	}
+}
+
+// SetRepository stores published events so other server instances can relay
+// them. Event IDs then come from the repository, and Start must run for
+// stored events to reach subscribers.
+func (b *EventBus) SetRepository(repo ports.ClientEventRepository) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.repo = repo
+	b.lastID = 0
+}
+
+func (b *EventBus) SetConfig(cfg config.EventsConfig) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if cfg.BufferSize > 0 {
+		b.bufferSize = cfg.BufferSize
+	}
+	if cfg.PollInterval > 0 {
+		b.pollInterval = time.Duration(cfg.PollInterval) * time.Millisecond
+	}
+}
+
+// Publish assigns the event its ID and delivers it. A nil bus drops events,
+// so services publish without checking whether streaming is set up.
+func (b *EventBus) Publish(event models.ClientEvent) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	repo := b.repo
+	b.mu.Unlock()
+
+	if repo != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := repo.Append(ctx, &event); err != nil {
+			log := gologger.WithComponent("event_bus")
+			log.Error().Err(err).Str("type", event.Type).Str("resource_id", event.ResourceID).Msg("Failed to store client event")
+			return
+		}
+		select {
+		case b.wake <- struct{}{}:
+		default:
+		}
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	event.ID = b.lastID
+	b.deliver(event)
+}
+
+// deliver buffers the event and sends it to matching subscribers. Callers
+// hold b.mu.
+func (b *EventBus) deliver(event models.ClientEvent) {
+	b.events = append(b.events, event)
+	if excess := len(b.events) - b.bufferSize; excess > 0 {
+		b.events = append(b.events[:0:0], b.events[excess:]...)
+	}
+
+	for subscriber := range b.subscribers {
+		if !subscriber.filter.Matches(&event) {
+			continue
+		}
+		select {
+		case subscriber.ch <- event:
+		default:
+			delete(b.subscribers, subscriber)
+			close(subscriber.ch)
+		}
+	}
+}
+
+// Subscribe starts delivering the events filter selects, after replaying the
+// buffered ones newer than afterID. An afterID of zero replays nothing.
+func (b *EventBus) Subscribe(filter models.ClientEventFilter, afterID int64) *EventSubscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subscription := &EventSubscription{}
+	if afterID > 0 {
+		oldest := b.lastID + 1
+		if len(b.events) > 0 {
+			oldest = b.events[0].ID
+		}
+		subscription.Missed = afterID < oldest-1
+
+		for i := range b.events {
+			if b.events[i].ID > afterID && filter.Matches(&b.events[i]) {
+				subscription.Backlog = append(subscription.Backlog, b.events[i])
+			}
+		}
+	}
+
+	subscriber := &eventSubscriber{
+		filter: filter,
+		ch:     make(chan models.ClientEvent, eventSubscriberBuffer),
+	}
+	b.subscribers[subscriber] = struct{}{}
+	subscription.Events = subscriber.ch
+	subscription.close = func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[subscriber]; ok {
+			delete(b.subscribers, subscriber)
+			close(subscriber.ch)
+		}
+	}
+	return subscription
+}
+
+// LastID is the ID of the most recent event.
+func (b *EventBus) LastID() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.lastID
+}
+
+// Start relays stored events to this instance's subscribers, starting after
+// the newest event stored when it is called, and prunes old events. It
+// returns at once when the bus has no repository.
+func (b *EventBus) Start(ctx context.Context) {
+	b.mu.Lock()
+	repo, interval := b.repo, b.pollInterval
+	b.mu.Unlock()
+	if repo == nil {
+		return
+	}
+
+	log := gologger.WithComponent("event_bus")
+
+	lastID, err := repo.LastID(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to read the last client event")
+	}
+	b.mu.Lock()
+	b.lastID = lastID
+	b.mu.Unlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	pruneTicker := time.NewTicker(eventPruneInterval)
+	defer pruneTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-b.wake:
+		case <-pruneTicker.C:
+			if _, err := repo.DeleteBefore(ctx, time.Now().Add(-eventRetention)); err != nil {
+				log.Error().Err(err).Msg("Failed to prune client events")
+			}
+			continue
+		}
+		if err := b.relay(ctx, repo); err != nil {
+			log.Error().Err(err).Msg("Failed to read client events")
+		}
+	}
+}
+
+// relay delivers the stored events after the last one delivered. It stops at
+// a missing ID younger than eventGapGrace, so an event that has not been
+// committed yet is not skipped.
+func (b *EventBus) relay(ctx context.Context, repo ports.ClientEventRepository) error {
+	for {
+		b.mu.Lock()
+		afterID := b.lastID
+		b.mu.Unlock()
+
+		events, err := repo.ListAfter(ctx, afterID, eventPollLimit)
+		if err != nil {
+			return err
+		}
+
+		b.mu.Lock()
+		for _, event := range events {
+			if event.ID != b.lastID+1 && time.Since(event.OccurredAt) < eventGapGrace {
+				b.mu.Unlock()
+				return nil
+			}
+			b.lastID = event.ID
+			b.deliver(event)
+		}
+		b.mu.Unlock()
+
+		if len(events) < eventPollLimit {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/parity-server/internal/core/config"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+type inMemoryClientEventRepo struct {
+	mu     sync.Mutex
+	events []models.ClientEvent
+}
+
+func (r *inMemoryClientEventRepo) Append(ctx context.Context, event *models.ClientEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	event.ID = int64(len(r.events) + 1)
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func (r *inMemoryClientEventRepo) ListAfter(ctx context.Context, afterID int64, limit int) ([]models.ClientEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []models.ClientEvent
+	for _, event := range r.events {
+		if event.ID > afterID && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (r *inMemoryClientEventRepo) LastID(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return int64(len(r.events)), nil
+}
+
+func (r *inMemoryClientEventRepo) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func newEventTask(creator string) *models.Task {
+	return &models.Task{ID: uuid.New(), Status: models.TaskStatusPending, CreatorAddress: creator}
+}
+
+func TestEventBusDeliversOnlyOwnedEvents(t *testing.T) {
+	bus := NewEventBus()
+	alice := &models.Principal{WalletAddress: "0xAlice"}
+	subscription := bus.Subscribe(models.ClientEventFilter{Principal: alice}, 0)
+	defer subscription.Close()
+
+	bus.Publish(models.NewTaskEvent("created", newEventTask("0xbob")))
+	own := newEventTask("0xalice")
+	bus.Publish(models.NewTaskEvent("created", own))
+
+	select {
+	case event := <-subscription.Events:
+		if event.ResourceID != own.ID.String() || event.Type != "task.created" {
+			t.Fatalf("got event %s for %s, want task.created for %s", event.Type, event.ResourceID, own.ID)
+		}
+	default:
+		t.Fatal("expected an event for the subscriber's own task")
+	}
+	select {
+	case event := <-subscription.Events:
+		t.Fatalf("unexpected event %s for %s", event.Type, event.ResourceID)
+	default:
+	}
+}
+
+func TestEventBusFiltersByKindAndResource(t *testing.T) {
+	bus := NewEventBus()
+	principal := &models.Principal{WalletAddress: "0xalice"}
+	task := newEventTask("0xalice")
+	subscription := bus.Subscribe(models.ClientEventFilter{
+		Principal:   principal,
+		Kinds:       map[models.ClientEventKind]bool{models.ClientEventTask: true},
+		ResourceIDs: map[string]bool{task.ID.String(): true},
+	}, 0)
+	defer subscription.Close()
+
+	bus.Publish(models.NewTaskEvent("created", newEventTask("0xalice")))
+	bus.Publish(models.NewPromptEvent("created", &models.PromptRequest{ID: uuid.New(), ClientID: "0xalice"}))
+	bus.Publish(models.NewTaskEvent("started", task))
+
+	if got := len(subscription.Events); got != 1 {
+		t.Fatalf("got %d events, want 1", got)
+	}
+	if event := <-subscription.Events; event.Type != "task.started" {
+		t.Fatalf("got %s, want task.started", event.Type)
+	}
+}
+
+func TestEventBusResumesFromLastEventID(t *testing.T) {
+	bus := NewEventBus()
+	principal := &models.Principal{WalletAddress: "0xalice"}
+	task := newEventTask("0xalice")
+
+	bus.Publish(models.NewTaskEvent("created", task))
+	seen := bus.LastID()
+	task.Status = models.TaskStatusRunning
+	bus.Publish(models.NewTaskEvent("started", task))
+	task.Status = models.TaskStatusCompleted
+	bus.Publish(models.NewTaskEvent("completed", task))
+
+	subscription := bus.Subscribe(models.ClientEventFilter{Principal: principal}, seen)
+	defer subscription.Close()
+
+	if subscription.Missed {
+		t.Fatal("no events were dropped, but the subscription reports a gap")
+	}
+	if len(subscription.Backlog) != 2 {
+		t.Fatalf("got %d backlog events, want 2", len(subscription.Backlog))
+	}
+	if subscription.Backlog[0].Status != string(models.TaskStatusRunning) || subscription.Backlog[1].Status != string(models.TaskStatusCompleted) {
+		t.Fatalf("backlog statuses = %s, %s; want running, completed", subscription.Backlog[0].Status, subscription.Backlog[1].Status)
+	}
+	if subscription.Backlog[0].ID != seen+1 {
+		t.Fatalf("first backlog ID = %d, want %d", subscription.Backlog[0].ID, seen+1)
+	}
+}
+
+func TestEventBusReportsEventsNoLongerBuffered(t *testing.T) {
+	bus := NewEventBus()
+	bus.SetConfig(config.EventsConfig{BufferSize: 2})
+	principal := &models.Principal{WalletAddress: "0xalice"}
+	task := newEventTask("0xalice")
+
+	bus.Publish(models.NewTaskEvent("created", task))
+	seen := bus.LastID()
+	for i := 0; i < 3; i++ {
+		bus.Publish(models.NewTaskEvent("updated", task))
+	}
+
+	subscription := bus.Subscribe(models.ClientEventFilter{Principal: principal}, seen)
+	defer subscription.Close()
+
+	if !subscription.Missed {
+		t.Fatal("expected the subscription to report dropped events")
+	}
+	if len(subscription.Backlog) != 2 {
+		t.Fatalf("got %d backlog events, want the 2 still buffered", len(subscription.Backlog))
+	}
+}
+
+func TestEventBusClosesSlowSubscribers(t *testing.T) {
+	bus := NewEventBus()
+	principal := &models.Principal{WalletAddress: "0xalice"}
+	subscription := bus.Subscribe(models.ClientEventFilter{Principal: principal}, 0)
+	defer subscription.Close()
+
+	task := newEventTask("0xalice")
+	for i := 0; i <= eventSubscriberBuffer; i++ {
+		bus.Publish(models.NewTaskEvent("updated", task))
+	}
+
+	for range subscription.Events {
+	}
+}
+
+func TestNilEventBusDropsEvents(t *testing.T) {
+	var bus *EventBus
+	bus.Publish(models.NewTaskEvent("created", newEventTask("0xalice")))
+}
+
+func TestEventBusRelaysEventsPublishedByAnotherInstance(t *testing.T) {
+	ctx := context.Background()
+	repo := &inMemoryClientEventRepo{}
+	publisher, relay := NewEventBus(), NewEventBus()
+	publisher.SetRepository(repo)
+	relay.SetRepository(repo)
+	principal := &models.Principal{WalletAddress: "0xalice"}
+
+	subscription := relay.Subscribe(models.ClientEventFilter{Principal: principal}, 0)
+	defer subscription.Close()
+
+	task := newEventTask("0xalice")
+	publisher.Publish(models.NewTaskEvent("created", task))
+	task.Status = models.TaskStatusRunning
+	publisher.Publish(models.NewTaskEvent("started", task))
+
+	if err := relay.relay(ctx, repo); err != nil {
+		t.Fatalf("relay returned error: %v", err)
+	}
+	if got := len(subscription.Events); got != 2 {
+		t.Fatalf("got %d relayed events, want 2", got)
+	}
+	if event := <-subscription.Events; event.ID != 1 || event.Type != "task.created" {
+		t.Fatalf("got event %d %s, want 1 task.created", event.ID, event.Type)
+	}
+	if relay.LastID() != 2 {
+		t.Fatalf("relay last ID = %d, want 2", relay.LastID())
+	}
+}
+
+func TestEventBusWaitsForUncommittedEventIDs(t *testing.T) {
+	ctx := context.Background()
+	repo := &inMemoryClientEventRepo{}
+	bus := NewEventBus()
+	bus.SetRepository(repo)
+	principal := &models.Principal{WalletAddress: "0xalice"}
+	subscription := bus.Subscribe(models.ClientEventFilter{Principal: principal}, 0)
+	defer subscription.Close()
+
+	// Event 2 has taken its ID but is not visible yet.
+	first := models.NewTaskEvent("created", newEventTask("0xalice"))
+	third := models.NewTaskEvent("created", newEventTask("0xalice"))
+	first.ID, third.ID = 1, 3
+	repo.events = []models.ClientEvent{first, third}
+
+	if err := bus.relay(ctx, repo); err != nil {
+		t.Fatalf("relay returned error: %v", err)
+	}
+	if got := len(subscription.Events); got != 1 || bus.LastID() != 1 {
+		t.Fatalf("got %d events up to ID %d, want only event 1", got, bus.LastID())
+	}
+
+	// Once the gap is older than the grace period it is skipped.
+	repo.events[1].OccurredAt = time.Now().Add(-2 * eventGapGrace)
+	if err := bus.relay(ctx, repo); err != nil {
+		t.Fatalf("relay returned error: %v", err)
+	}
+	if got := len(subscription.Events); got != 2 || bus.LastID() != 3 {
+		t.Fatalf("got %d events up to ID %d, want events 1 and 3", got, bus.LastID())
+	}
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -21,6 +22,8 @@ type FederatedLearningService struct {
 	runnerService     ports.RunnerService
 	taskService       ports.TaskService
 	flRewardService   *FLRewardService
+	storage           ports.Storage
+	eventBus          *EventBus
 }
 
 func NewFederatedLearningService(
@@ -43,6 +46,17 @@ func (s *FederatedLearningService) SetFLRewardService(flRewardService *FLRewardS
 	s.flRewardService = flRewardService
 }
 
+// SetStorage makes each aggregated global model also get written to storage,
+// so runners and creators can download it instead of reading it inline.
+func (s *FederatedLearningService) SetStorage(storage ports.Storage) {
+	s.storage = storage
+}
+
+// SetEventBus publishes session state changes to the creators' event streams.
+func (s *FederatedLearningService) SetEventBus(eventBus *EventBus) {
+	s.eventBus = eventBus
+}
+
 func (s *FederatedLearningService) CreateSession(ctx context.Context, req *requestmodels.CreateFLSessionRequest) (*models.FederatedLearningSession, error) {
 	log := log.With().Str("component", "federated_learning_service").Logger()
 
@@ -96,6 +110,7 @@ func (s *FederatedLearningService) CreateSession(ctx context.Context, req *reque
 		log.Error().Err(err).Msg("Failed to create FL session")
 		return nil, fmt.Errorf("failed to create FL session: %w", err)
 	}
+	s.eventBus.Publish(models.NewFLSessionEvent("created", session))
 
 	log.Info().
 		Str("session_id", session.ID.String()).
@@ -201,6 +216,7 @@ func (s *FederatedLearningService) StartSession(ctx context.Context, sessionID u
 	if err := s.flSessionRepo.Update(ctx, session); err != nil {
 		return fmt.Errorf("failed to update session status: %w", err)
 	}
+	s.eventBus.Publish(models.NewFLSessionEvent("started", session))
 
 	if err := s.StartNextRound(ctx, sessionID); err != nil {
 		return fmt.Errorf("failed to start first round: %w", err)
@@ -239,6 +255,7 @@ func (s *FederatedLearningService) StartNextRound(ctx context.Context, sessionID
 	if err := s.flSessionRepo.Update(ctx, session); err != nil {
 		return fmt.Errorf("failed to update session: %w", err)
 	}
+	s.eventBus.Publish(models.NewFLSessionEvent("round_started", session))
 
 	if err := s.assignParticipants(ctx, sessionID, round.ID); err != nil {
 		return fmt.Errorf("failed to assign participants: %w", err)
@@ -310,6 +327,9 @@ func (s *FederatedLearningService) assignParticipants(ctx context.Context, sessi
 		runner, err := s.runnerService.GetRunner(ctx, participantID)
 		if err != nil {
 			log.Warn().Str("runner_id", participantID).Msg("Failed to get runner, but creating participant anyway")
+		} else if runner.IsDraining() {
+			log.Info().Str("runner_id", participantID).Msg("Runner is draining, skipping FL round assignment")
+			continue
 		} else if runner.Status != models.RunnerStatusOnline {
 			log.Warn().Str("runner_id", participantID).Msg("Runner not online, but creating participant anyway")
 		}
@@ -426,6 +446,9 @@ func (s *FederatedLearningService) sendTrainingTask(ctx context.Context, session
 		"output_format": "json",
 		"global_model":  session.GlobalModel,
 	}
+	if session.GlobalModelURL != "" {
+		config["global_model_url"] = session.GlobalModelURL
+	}
 
 	log.Debug().
 		Str("dataset_cid", session.TrainingData.DatasetCID).
@@ -673,11 +696,22 @@ func (s *FederatedLearningService) AggregateRound(ctx context.Context, sessionID
 	}
 
 	session.GlobalModel = json.RawMessage(aggregationData)
+	if s.storage != nil {
+		name := fmt.Sprintf("fl-%s-round-%d.json", sessionID, round.RoundNumber)
+		object, err := s.storage.Put(ctx, bytes.NewReader(aggregationData), name)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to store global model artifact, keeping it inline only")
+		} else {
+			session.GlobalModelKey = object.Key
+			session.GlobalModelURL = object.URL
+		}
+	}
 	session.UpdatedAt = time.Now()
 
 	if err := s.flSessionRepo.Update(ctx, session); err != nil {
 		return fmt.Errorf("failed to update session with new global model: %w", err)
 	}
+	s.eventBus.Publish(models.NewFLSessionEvent("round_completed", session))
 
 	log.Info().
 		Int("participants", len(participants)).
@@ -856,6 +890,7 @@ func (s *FederatedLearningService) CompleteSession(ctx context.Context, sessionI
 	if err := s.flSessionRepo.Update(ctx, session); err != nil {
 		return fmt.Errorf("failed to update session: %w", err)
 	}
+	s.eventBus.Publish(models.NewFLSessionEvent("completed", session))
 
 	// Distribute completion bonus rewards
 	if s.flRewardService != nil {
@@ -901,6 +936,9 @@ func (s *FederatedLearningService) GetTrainedModel(ctx context.Context, sessionI
 		"completed_at": session.CompletedAt,
 		"model_data":   modelData,
 	}
+	if session.GlobalModelURL != "" {
+		result["model_url"] = session.GlobalModelURL
+	}
 
 	log.Info().Msg("Trained model retrieved successfully")
 	return result, nil
@@ -18,6 +18,7 @@ type FLQualityService struct {
 	flRoundRepo       ports.FLRoundRepository
 	flParticipantRepo ports.FLParticipantRepository
 	runnerService     ports.RunnerService
+	telemetry         *TelemetryService
 }
 
 func NewFLQualityService(
@@ -36,6 +37,12 @@ func NewFLQualityService(
 	}
 }
 
+// SetTelemetryService makes reliability metrics come from the runner's
+// heartbeat history.
+func (s *FLQualityService) SetTelemetryService(telemetry *TelemetryService) {
+	s.telemetry = telemetry
+}
+
 // MonitorParticipantQuality continuously monitors participant quality metrics
 func (s *FLQualityService) MonitorParticipantQuality(ctx context.Context, sessionID uuid.UUID, runnerID string) error {
 	logger := log.With().
@@ -52,7 +59,7 @@ func (s *FLQualityService) MonitorParticipantQuality(ctx context.Context, sessio
 	}
 
 	// Calculate reliability metrics
-	reliabilityMetrics, err := s.calculateParticipantReliability()
+	reliabilityMetrics, err := s.calculateParticipantReliability(ctx, runnerID)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to calculate participant reliability")
 		return err
@@ -312,15 +319,15 @@ func (s *FLQualityService) calculateParticipantPerformance(ctx context.Context,
 	}, nil
 }
 
-func (s *FLQualityService) calculateParticipantReliability() (*struct {
+func (s *FLQualityService) calculateParticipantReliability(ctx context.Context, runnerID string) (*struct {
 	UptimePercentage     float64
 	HeartbeatConsistency float64
 	ErrorRate            float64
 }, error,
 ) {
-	// This would calculate based on heartbeat history and task failure rates
-	// For now, returning sample values
-	return &struct {
+	// Uptime and consistency come from the heartbeat history when it is
+	// available; error rate is still a sample value
+	reliability := &struct {
 		UptimePercentage     float64
 		HeartbeatConsistency float64
 		ErrorRate            float64
@@ -328,7 +335,24 @@ func (s *FLQualityService) calculateParticipantReliability() (*struct {
 		UptimePercentage:     98.5,
 		HeartbeatConsistency: 95.0,
 		ErrorRate:            2.1,
-	}, nil
+	}
+
+	if s.telemetry == nil {
+		return reliability, nil
+	}
+
+	health, err := s.telemetry.Health(ctx, runnerID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load runner telemetry: %w", err)
+	}
+	if health.Heartbeats > 0 {
+		reliability.UptimePercentage = health.UptimePercentage
+	}
+	if health.MeanHeartbeatInterval > 0 {
+		reliability.HeartbeatConsistency = health.HeartbeatConsistency
+	}
+
+	return reliability, nil
 }
 
 func (s *FLQualityService) calculateNetworkQuality() (*struct {
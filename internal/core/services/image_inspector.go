@@ -0,0 +1,338 @@
+package services
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/theblitlabs/parity-server/internal/core/config"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+var (
+	ErrInvalidImage  = errors.New("invalid docker image archive")
+	ErrImageRejected = errors.New("docker image rejected")
+)
+
+const (
+	// maxImageMetadataSize caps how much of a single archive entry is kept in
+	// memory while streaming. Only manifest.json and the image config are read
+	// back; layers are hashed and counted without being buffered.
+	maxImageMetadataSize = 4 * 1024 * 1024
+
+	maxImageLinkDepth = 8
+)
+
+var sha256Hex = regexp.MustCompile(`^[a-f0-9]{64}$`)
+
+// ImageInspector parses Docker image archives as they are uploaded. It reads
+// the tar once, hashing every entry on the way through, then checks the
+// manifest, config and layers against each other and against the configured
+// limits.
+type ImageInspector struct {
+	mu                  sync.RWMutex
+	maxLayers           int
+	maxUncompressedSize int64
+	architectures       map[string]bool
+}
+
+func NewImageInspector() *ImageInspector {
+	return &ImageInspector{
+		maxLayers:           127,
+		maxUncompressedSize: 20 * 1024 * 1024 * 1024,
+		architectures:       map[string]bool{"amd64": true, "arm64": true},
+	}
+}
+
+func (i *ImageInspector) SetConfig(cfg config.ImageConfig) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if cfg.MaxLayers > 0 {
+		i.maxLayers = cfg.MaxLayers
+	}
+	if cfg.MaxUncompressedSizeMB > 0 {
+		i.maxUncompressedSize = int64(cfg.MaxUncompressedSizeMB) * 1024 * 1024
+	}
+	if cfg.AllowedArchitectures != "" {
+		i.architectures = make(map[string]bool)
+		for _, arch := range strings.Split(cfg.AllowedArchitectures, ",") {
+			if arch = strings.TrimSpace(arch); arch != "" {
+				i.architectures[arch] = true
+			}
+		}
+	}
+}
+
+type imageArchiveEntry struct {
+	digest           string
+	diffID           string
+	size             int64
+	uncompressedSize int64
+	data             []byte
+}
+
+type imageArchive struct {
+	entries map[string]*imageArchiveEntry
+	links   map[string]string
+}
+
+// lookup resolves name through any symlinks or hard links in the archive.
+// `docker save` links duplicate layers to the first copy.
+func (a *imageArchive) lookup(name string) (*imageArchiveEntry, bool) {
+	name = path.Clean(name)
+	for depth := 0; depth <= maxImageLinkDepth; depth++ {
+		if entry, ok := a.entries[name]; ok {
+			return entry, true
+		}
+		target, ok := a.links[name]
+		if !ok {
+			return nil, false
+		}
+		name = target
+	}
+	return nil, false
+}
+
+type imageManifest struct {
+	Config   string   `json:"Config"`
+	RepoTags []string `json:"RepoTags"`
+	Layers   []string `json:"Layers"`
+}
+
+type imageConfig struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant"`
+	RootFS       struct {
+		Type    string   `json:"type"`
+		DiffIDs []string `json:"diff_ids"`
+	} `json:"rootfs"`
+}
+
+// Inspect reads a `docker save` archive from r and returns its metadata. It
+// returns ErrInvalidImage when the archive is malformed and ErrImageRejected
+// when it breaks a limit. Either way r may not have been read to the end.
+func (i *ImageInspector) Inspect(r io.Reader) (*models.ImageMetadata, error) {
+	i.mu.RLock()
+	maxLayers := i.maxLayers
+	maxUncompressedSize := i.maxUncompressedSize
+	architectures := i.architectures
+	i.mu.RUnlock()
+
+	archive, err := readImageArchive(r, maxUncompressedSize)
+	if err != nil {
+		return nil, err
+	}
+
+	manifestEntry, ok := archive.lookup("manifest.json")
+	if !ok || manifestEntry.data == nil {
+		return nil, fmt.Errorf("%w: manifest.json is missing", ErrInvalidImage)
+	}
+	var manifests []imageManifest
+	if err := json.Unmarshal(manifestEntry.data, &manifests); err != nil {
+		return nil, fmt.Errorf("%w: manifest.json: %v", ErrInvalidImage, err)
+	}
+	if len(manifests) != 1 {
+		return nil, fmt.Errorf("%w: archive must contain exactly one image, found %d", ErrInvalidImage, len(manifests))
+	}
+	manifest := manifests[0]
+
+	configEntry, ok := archive.lookup(manifest.Config)
+	if manifest.Config == "" || !ok || configEntry.data == nil {
+		return nil, fmt.Errorf("%w: image config %q is missing", ErrInvalidImage, manifest.Config)
+	}
+	// Legacy archives name the config after its digest.
+	if name := strings.TrimSuffix(path.Base(manifest.Config), ".json"); sha256Hex.MatchString(name) && name != configEntry.digest {
+		return nil, fmt.Errorf("%w: image config does not match its digest", ErrInvalidImage)
+	}
+
+	var cfg imageConfig
+	if err := json.Unmarshal(configEntry.data, &cfg); err != nil {
+		return nil, fmt.Errorf("%w: image config: %v", ErrInvalidImage, err)
+	}
+	if cfg.Architecture == "" || cfg.OS == "" {
+		return nil, fmt.Errorf("%w: image config has no architecture or os", ErrInvalidImage)
+	}
+	if len(manifest.Layers) == 0 {
+		return nil, fmt.Errorf("%w: image has no layers", ErrInvalidImage)
+	}
+	if len(cfg.RootFS.DiffIDs) != len(manifest.Layers) {
+		return nil, fmt.Errorf("%w: manifest lists %d layers but config has %d", ErrInvalidImage, len(manifest.Layers), len(cfg.RootFS.DiffIDs))
+	}
+
+	metadata := &models.ImageMetadata{
+		Digest:       "sha256:" + configEntry.digest,
+		RepoTags:     manifest.RepoTags,
+		Architecture: cfg.Architecture,
+		OS:           cfg.OS,
+		Variant:      cfg.Variant,
+		Layers:       make([]models.ImageLayer, 0, len(manifest.Layers)),
+	}
+	for idx, layerPath := range manifest.Layers {
+		layer, ok := archive.lookup(layerPath)
+		if !ok {
+			return nil, fmt.Errorf("%w: layer %q is missing", ErrInvalidImage, layerPath)
+		}
+		// The config digest only covers the diff_ids, so each layer has to be
+		// checked against its own or the archive could carry any contents.
+		diffID := "sha256:" + layer.diffID
+		if diffID != cfg.RootFS.DiffIDs[idx] {
+			return nil, fmt.Errorf("%w: layer %q does not match diff_id %s", ErrInvalidImage, layerPath, cfg.RootFS.DiffIDs[idx])
+		}
+		metadata.Layers = append(metadata.Layers, models.ImageLayer{
+			Digest:           "sha256:" + layer.digest,
+			DiffID:           diffID,
+			Size:             layer.size,
+			UncompressedSize: layer.uncompressedSize,
+		})
+		metadata.UncompressedSize += layer.uncompressedSize
+	}
+
+	if len(metadata.Layers) > maxLayers {
+		return nil, fmt.Errorf("%w: image has %d layers, limit is %d", ErrImageRejected, len(metadata.Layers), maxLayers)
+	}
+	if !architectures["*"] && !architectures[metadata.Architecture] {
+		return nil, fmt.Errorf("%w: architecture %s is not allowed", ErrImageRejected, metadata.Architecture)
+	}
+
+	return metadata, nil
+}
+
+// readImageArchive streams the tar, recording a digest and size for every
+// regular file and keeping small entries in memory. It stops as soon as the
+// uncompressed contents pass maxUncompressedSize.
+func readImageArchive(r io.Reader, maxUncompressedSize int64) (*imageArchive, error) {
+	archive := &imageArchive{
+		entries: make(map[string]*imageArchiveEntry),
+		links:   make(map[string]string),
+	}
+
+	var total int64
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+		}
+
+		name := path.Clean(header.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, fmt.Errorf("%w: entry %q escapes the archive", ErrInvalidImage, header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeSymlink:
+			archive.links[name] = path.Join(path.Dir(name), header.Linkname)
+			continue
+		case tar.TypeLink:
+			archive.links[name] = path.Clean(header.Linkname)
+			continue
+		case tar.TypeReg:
+		default:
+			continue
+		}
+
+		entry, err := readImageArchiveEntry(tr, maxUncompressedSize-total)
+		if err != nil {
+			return nil, err
+		}
+		total += entry.uncompressedSize
+
+		// OCI archives store blobs under their digest.
+		if dir, file := path.Split(name); dir == "blobs/sha256/" && file != entry.digest {
+			return nil, fmt.Errorf("%w: blob %s does not match its digest", ErrInvalidImage, file)
+		}
+
+		archive.entries[name] = entry
+	}
+
+	return archive, nil
+}
+
+// readImageArchiveEntry hashes one entry and measures its uncompressed size,
+// decompressing gzip layers on the fly. Gzip layers are hashed a second time
+// uncompressed to get their diff ID; for anything else the two are the same.
+func readImageArchiveEntry(r io.Reader, budget int64) (*imageArchiveEntry, error) {
+	hasher := sha256.New()
+	var diffHasher hash.Hash
+	counted := &countingReader{r: io.TeeReader(r, hasher)}
+	buffered := bufio.NewReader(counted)
+
+	var data []byte
+	var uncompressed int64
+	magic, _ := buffered.Peek(2)
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+		}
+		diffHasher = sha256.New()
+		uncompressed, err = io.Copy(diffHasher, io.LimitReader(gz, budget+1))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+		}
+	} else {
+		head, err := io.ReadAll(io.LimitReader(buffered, maxImageMetadataSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+		}
+		if len(head) <= maxImageMetadataSize {
+			data = head
+		}
+		uncompressed = int64(len(head))
+	}
+	if uncompressed > budget {
+		return nil, fmt.Errorf("%w: uncompressed image exceeds the size limit", ErrImageRejected)
+	}
+
+	// Drain whatever is left so the digest covers the whole entry.
+	if _, err := io.Copy(io.Discard, buffered); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if data == nil {
+		uncompressed = max(uncompressed, counted.n)
+	}
+	if uncompressed > budget {
+		return nil, fmt.Errorf("%w: uncompressed image exceeds the size limit", ErrImageRejected)
+	}
+
+	digest := hex.EncodeToString(hasher.Sum(nil))
+	diffID := digest
+	if diffHasher != nil {
+		diffID = hex.EncodeToString(diffHasher.Sum(nil))
+	}
+
+	return &imageArchiveEntry{
+		digest:           digest,
+		diffID:           diffID,
+		size:             counted.n,
+		uncompressedSize: uncompressed,
+		data:             data,
+	}, nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/theblitlabs/parity-server/internal/core/config"
+)
+
+type testImageFile struct {
+	name string
+	data []byte
+	link string
+}
+
+// buildTestImage writes a `docker save` style archive. The first layer is
+// gzipped and a final layer links back to it, as docker does for repeats.
+func buildTestImage(t *testing.T, arch string, layers ...[]byte) ([]byte, string) {
+	t.Helper()
+
+	var files []testImageFile
+	var layerPaths, diffIDs []string
+	for i, layer := range layers {
+		data := layer
+		if i == 0 {
+			var gz bytes.Buffer
+			w := gzip.NewWriter(&gz)
+			_, _ = w.Write(layer)
+			_ = w.Close()
+			data = gz.Bytes()
+		}
+		name := fmt.Sprintf("%02d/layer.tar", i)
+		files = append(files, testImageFile{name: name, data: data})
+		layerPaths = append(layerPaths, name)
+		sum := sha256.Sum256(layer)
+		diffIDs = append(diffIDs, "sha256:"+hex.EncodeToString(sum[:]))
+	}
+	files = append(files, testImageFile{name: "dup/layer.tar", link: "../00/layer.tar"})
+	layerPaths = append(layerPaths, "dup/layer.tar")
+	diffIDs = append(diffIDs, diffIDs[0])
+
+	cfg, _ := json.Marshal(map[string]interface{}{
+		"architecture": arch,
+		"os":           "linux",
+		"rootfs":       map[string]interface{}{"type": "layers", "diff_ids": diffIDs},
+	})
+	sum := sha256.Sum256(cfg)
+	digest := hex.EncodeToString(sum[:])
+	files = append(files, testImageFile{name: digest + ".json", data: cfg})
+
+	manifest, _ := json.Marshal([]map[string]interface{}{{
+		"Config":   digest + ".json",
+		"RepoTags": []string{"example/app:latest"},
+		"Layers":   layerPaths,
+	}})
+	files = append(files, testImageFile{name: "manifest.json", data: manifest})
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, file := range files {
+		header := &tar.Header{Name: file.name, Mode: 0o644, Size: int64(len(file.data)), Typeflag: tar.TypeReg}
+		if file.link != "" {
+			header = &tar.Header{Name: file.name, Linkname: file.link, Typeflag: tar.TypeSymlink}
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatalf("failed to write tar header: %v", err)
+		}
+		if _, err := tw.Write(file.data); err != nil {
+			t.Fatalf("failed to write tar entry: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("failed to close tar: %v", err)
+	}
+
+	return buf.Bytes(), digest
+}
+
+func TestImageInspectorParsesDockerSaveArchive(t *testing.T) {
+	archive, digest := buildTestImage(t, "amd64", bytes.Repeat([]byte("a"), 1024), bytes.Repeat([]byte("b"), 2048))
+
+	metadata, err := NewImageInspector().Inspect(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatalf("Inspect returned error: %v", err)
+	}
+
+	if metadata.Digest != "sha256:"+digest || metadata.Hash() != digest {
+		t.Fatalf("expected digest of the image config %s, got %s", digest, metadata.Digest)
+	}
+	if metadata.Architecture != "amd64" || metadata.OS != "linux" || len(metadata.RepoTags) != 1 {
+		t.Fatalf("unexpected metadata: %+v", metadata)
+	}
+	if len(metadata.Layers) != 3 {
+		t.Fatalf("expected 3 layers including the linked duplicate, got %d", len(metadata.Layers))
+	}
+	if metadata.Layers[0].UncompressedSize != 1024 || metadata.Layers[0].Size >= 1024 {
+		t.Fatalf("expected the gzip layer to be measured uncompressed, got %+v", metadata.Layers[0])
+	}
+	if metadata.Layers[2] != metadata.Layers[0] {
+		t.Fatalf("expected the linked layer to resolve to the first layer, got %+v", metadata.Layers[2])
+	}
+	if metadata.UncompressedSize != 1024+2048+1024 {
+		t.Fatalf("unexpected uncompressed size %d", metadata.UncompressedSize)
+	}
+}
+
+func TestImageInspectorEnforcesLimits(t *testing.T) {
+	archive, _ := buildTestImage(t, "amd64", []byte("a"), []byte("b"))
+	bomb, _ := buildTestImage(t, "amd64", make([]byte, 2*1024*1024))
+	s390x, _ := buildTestImage(t, "s390x", []byte("a"))
+
+	cases := []struct {
+		name    string
+		cfg     config.ImageConfig
+		archive []byte
+		wantErr error
+	}{
+		{name: "too many layers", cfg: config.ImageConfig{MaxLayers: 2}, archive: archive, wantErr: ErrImageRejected},
+		{name: "within limits", cfg: config.ImageConfig{MaxLayers: 3}, archive: archive},
+		{name: "too large uncompressed", cfg: config.ImageConfig{MaxUncompressedSizeMB: 1}, archive: bomb, wantErr: ErrImageRejected},
+		{name: "architecture", cfg: config.ImageConfig{}, archive: s390x, wantErr: ErrImageRejected},
+		{name: "any architecture", cfg: config.ImageConfig{AllowedArchitectures: "*"}, archive: s390x},
+		{name: "not an archive", cfg: config.ImageConfig{}, archive: []byte("not a tar"), wantErr: ErrInvalidImage},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			inspector := NewImageInspector()
+			inspector.SetConfig(tc.cfg)
+
+			_, err := inspector.Inspect(bytes.NewReader(tc.archive))
+			if tc.wantErr == nil && err != nil {
+				t.Fatalf("Inspect returned error: %v", err)
+			}
+			if tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestImageInspectorRejectsLayerThatDoesNotMatchDiffID(t *testing.T) {
+	original := bytes.Repeat([]byte("b"), 2048)
+	archive, _ := buildTestImage(t, "amd64", []byte("a"), original)
+
+	// Swap the uncompressed layer's contents; the config and its digest stay
+	// the same.
+	tampered := bytes.Replace(archive, original, bytes.Repeat([]byte("c"), 2048), 1)
+
+	if _, err := NewImageInspector().Inspect(bytes.NewReader(tampered)); !errors.Is(err, ErrInvalidImage) {
+		t.Fatalf("expected ErrInvalidImage, got %v", err)
+	}
+}
+
+func TestImageInspectorRejectsArchiveWithoutManifest(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	_ = tw.WriteHeader(&tar.Header{Name: "layer.tar", Mode: 0o644, Size: 4, Typeflag: tar.TypeReg})
+	_, _ = tw.Write([]byte("data"))
+	_ = tw.Close()
+
+	if _, err := NewImageInspector().Inspect(&buf); !errors.Is(err, ErrInvalidImage) {
+		t.Fatalf("expected ErrInvalidImage, got %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/internal/core/ports"
+	"github.com/theblitlabs/parity-server/internal/database/repositories"
+)
+
+var ErrImageNotFound = errors.New("image not found")
+
+// ImageService is the registry of uploaded images. Images are keyed by digest,
+// so an archive that is already stored is not uploaded again, and each task
+// that uses an image holds a reference to it.
+type ImageService struct {
+	repo    ports.ImageRepository
+	storage StorageService
+}
+
+func NewImageService(repo ports.ImageRepository, storage StorageService) *ImageService {
+	return &ImageService{
+		repo:    repo,
+		storage: storage,
+	}
+}
+
+// Store returns the stored image for metadata.Digest, uploading archive first
+// if no image with that digest is stored yet. metadata must come from
+// ImageInspector with every layer verified: the digest only covers the config,
+// so deduplicating on it is safe only once the layers are known to match it.
+func (s *ImageService) Store(ctx context.Context, metadata *models.ImageMetadata, archive io.Reader, size int64, name, owner string) (*models.StoredImage, error) {
+	log := gologger.WithComponent("image_service")
+
+	if !metadata.LayersVerified() {
+		return nil, fmt.Errorf("%w: image layers were not verified", ErrInvalidImage)
+	}
+
+	existing, err := s.repo.GetImage(ctx, metadata.Digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up image: %w", err)
+	}
+	if existing != nil && existing.Metadata.LayersVerified() {
+		log.Info().
+			Str("digest", metadata.Digest).
+			Str("url", existing.URL).
+			Msg("Image already stored, skipping upload")
+		return existing, nil
+	}
+
+	url, err := s.storage.UploadDockerImage(ctx, archive, name)
+	if err != nil {
+		return nil, err
+	}
+
+	// An image stored before layers were verified may not hold what its digest
+	// says, so it is replaced with this verified archive rather than reused.
+	if existing != nil {
+		log.Warn().
+			Str("digest", metadata.Digest).
+			Str("previous_url", existing.URL).
+			Msg("Replacing unverified stored image")
+		return s.repo.ReplaceImageArchive(ctx, &models.StoredImage{
+			Digest:   metadata.Digest,
+			URL:      url,
+			Size:     size,
+			Metadata: *metadata,
+		})
+	}
+
+	image, err := s.repo.CreateImage(ctx, &models.StoredImage{
+		Digest:     metadata.Digest,
+		URL:        url,
+		Name:       name,
+		Size:       size,
+		Metadata:   *metadata,
+		UploadedBy: owner,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to register image: %w", err)
+	}
+	if image == nil {
+		return nil, fmt.Errorf("image %s was not registered", metadata.Digest)
+	}
+
+	return image, nil
+}
+
+// Resolve returns a stored image that owner may use by digest alone: one they
+// uploaded or have used in an earlier task.
+func (s *ImageService) Resolve(ctx context.Context, digest, owner string) (*models.StoredImage, error) {
+	normalized := models.NormalizeImageDigest(digest)
+	if normalized == "" {
+		return nil, fmt.Errorf("%w: %q is not a sha256 digest", ErrImageNotFound, digest)
+	}
+
+	ok, err := s.repo.HasAccess(ctx, normalized, owner)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrImageNotFound, normalized)
+	}
+
+	image, err := s.repo.GetImage(ctx, normalized)
+	if err != nil {
+		return nil, err
+	}
+	if image == nil {
+		return nil, fmt.Errorf("%w: %s", ErrImageNotFound, normalized)
+	}
+	return image, nil
+}
+
+// AddReference records that the task uses the image. It returns
+// ErrImageNotFound if the image was collected since it was looked up.
+func (s *ImageService) AddReference(ctx context.Context, digest string, taskID uuid.UUID, owner string) error {
+	err := s.repo.AddReference(ctx, &models.ImageReference{
+		ImageDigest:    digest,
+		TaskID:         taskID,
+		CreatorAddress: owner,
+	})
+	if errors.Is(err, repositories.ErrStoredImageNotFound) {
+		return fmt.Errorf("%w: %s", ErrImageNotFound, digest)
+	}
+	return err
+}
+
+// RunnerCanDownload reports whether the runner may fetch the stored object
+// key: an image for a task it holds or the model of an FL session it trains in.
+func (s *ImageService) RunnerCanDownload(ctx context.Context, deviceID, key string) (bool, error) {
+	if deviceID == "" || key == "" || strings.Contains(key, "/") {
+		return false, nil
+	}
+	return s.repo.RunnerCanDownload(ctx, deviceID, key)
+}
+
+// ReleaseReferences drops the task's image references.
+func (s *ImageService) ReleaseReferences(ctx context.Context, taskID uuid.UUID) error {
+	return s.repo.ReleaseReferences(ctx, taskID)
+}
+
+// ListImages returns the images owner uploaded or has used. An empty owner
+// lists every stored image.
+func (s *ImageService) ListImages(ctx context.Context, owner string) ([]*models.StoredImage, error) {
+	return s.repo.ListImages(ctx, owner)
+}
@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+type inMemoryImageRepo struct {
+	mu         sync.Mutex
+	images     map[string]*models.StoredImage
+	references []models.ImageReference
+}
+
+func newInMemoryImageRepo() *inMemoryImageRepo {
+	return &inMemoryImageRepo{images: make(map[string]*models.StoredImage)}
+}
+
+func (r *inMemoryImageRepo) GetImage(ctx context.Context, digest string) (*models.StoredImage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	image, ok := r.images[digest]
+	if !ok {
+		return nil, nil
+	}
+	cloned := *image
+	return &cloned, nil
+}
+
+func (r *inMemoryImageRepo) CreateImage(ctx context.Context, image *models.StoredImage) (*models.StoredImage, error) {
+	r.mu.Lock()
+	if _, ok := r.images[image.Digest]; !ok {
+		cloned := *image
+		r.images[image.Digest] = &cloned
+	}
+	r.mu.Unlock()
+	return r.GetImage(ctx, image.Digest)
+}
+
+func (r *inMemoryImageRepo) ReplaceImageArchive(ctx context.Context, image *models.StoredImage) (*models.StoredImage, error) {
+	r.mu.Lock()
+	stored, ok := r.images[image.Digest]
+	if ok {
+		stored.URL = image.URL
+		stored.Size = image.Size
+		stored.Metadata = image.Metadata
+	}
+	r.mu.Unlock()
+	if !ok {
+		return nil, errors.New("stored image not found")
+	}
+	return r.GetImage(ctx, image.Digest)
+}
+
+func (r *inMemoryImageRepo) owns(image *models.StoredImage, owner string) bool {
+	if strings.EqualFold(image.UploadedBy, owner) {
+		return true
+	}
+	for _, reference := range r.references {
+		if reference.ImageDigest == image.Digest && strings.EqualFold(reference.CreatorAddress, owner) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *inMemoryImageRepo) ListImages(ctx context.Context, owner string) ([]*models.StoredImage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var images []*models.StoredImage
+	for _, image := range r.images {
+		if owner == "" || r.owns(image, owner) {
+			cloned := *image
+			images = append(images, &cloned)
+		}
+	}
+	return images, nil
+}
+
+func (r *inMemoryImageRepo) HasAccess(ctx context.Context, digest, owner string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	image, ok := r.images[digest]
+	return ok && r.owns(image, owner), nil
+}
+
+func (r *inMemoryImageRepo) AddReference(ctx context.Context, reference *models.ImageReference) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.references {
+		if existing.ImageDigest == reference.ImageDigest && existing.TaskID == reference.TaskID {
+			return nil
+		}
+	}
+	r.references = append(r.references, *reference)
+	if image, ok := r.images[reference.ImageDigest]; ok {
+		image.RefCount++
+	}
+	return nil
+}
+
+func (r *inMemoryImageRepo) ReleaseReferences(ctx context.Context, taskID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.references[:0]
+	for _, reference := range r.references {
+		if reference.TaskID != taskID {
+			kept = append(kept, reference)
+			continue
+		}
+		if image, ok := r.images[reference.ImageDigest]; ok && image.RefCount > 0 {
+			image.RefCount--
+		}
+	}
+	r.references = kept
+	return nil
+}
+
+func (r *inMemoryImageRepo) RunnerCanDownload(ctx context.Context, deviceID, key string) (bool, error) {
+	return false, nil
+}
+
+type countingStorage struct {
+	uploads  int
+	unpinned []string
+}
+
+func (s *countingStorage) UploadDockerImage(ctx context.Context, image io.Reader, imageName string) (string, error) {
+	s.uploads++
+	if _, err := io.Copy(io.Discard, image); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("https://gateway.example/ipfs/%s-%d", imageName, s.uploads), nil
+}
+
+func (s *countingStorage) DeleteDockerImage(ctx context.Context, imageURL string) error {
+	s.unpinned = append(s.unpinned, imageURL)
+	return nil
+}
+
+func TestImageServiceDeduplicatesUploadsByDigest(t *testing.T) {
+	ctx := context.Background()
+	repo := newInMemoryImageRepo()
+	storage := &countingStorage{}
+	service := NewImageService(repo, storage)
+
+	metadata := verifiedTestMetadata(strings.Repeat("ab", 32))
+
+	first, err := service.Store(ctx, metadata, strings.NewReader("archive"), 7, "app", "0xCreator")
+	if err != nil {
+		t.Fatalf("Store returned error: %v", err)
+	}
+	second, err := service.Store(ctx, metadata, strings.NewReader("archive"), 7, "app-copy", "0xOther")
+	if err != nil {
+		t.Fatalf("Store returned error: %v", err)
+	}
+
+	if storage.uploads != 1 {
+		t.Fatalf("expected one upload, got %d", storage.uploads)
+	}
+	if first.URL != second.URL || second.UploadedBy != "0xCreator" {
+		t.Fatalf("expected the second store to reuse the first image, got %+v", second)
+	}
+
+	taskID := uuid.New()
+	if err := service.AddReference(ctx, first.Digest, taskID, "0xOther"); err != nil {
+		t.Fatalf("AddReference returned error: %v", err)
+	}
+	if err := service.AddReference(ctx, first.Digest, taskID, "0xOther"); err != nil {
+		t.Fatalf("AddReference returned error: %v", err)
+	}
+	if image, _ := repo.GetImage(ctx, first.Digest); image.RefCount != 1 {
+		t.Fatalf("expected a repeated reference to count once, got %d", image.RefCount)
+	}
+
+	if err := service.ReleaseReferences(ctx, taskID); err != nil {
+		t.Fatalf("ReleaseReferences returned error: %v", err)
+	}
+	if image, _ := repo.GetImage(ctx, first.Digest); image.RefCount != 0 {
+		t.Fatalf("expected no references after release, got %d", image.RefCount)
+	}
+}
+
+func verifiedTestMetadata(digest string) *models.ImageMetadata {
+	return &models.ImageMetadata{
+		Digest:       "sha256:" + digest,
+		Architecture: "amd64",
+		OS:           "linux",
+		Layers:       []models.ImageLayer{{Digest: "sha256:" + strings.Repeat("cd", 32), DiffID: "sha256:" + strings.Repeat("ef", 32)}},
+	}
+}
+
+func TestImageServiceOnlyDeduplicatesVerifiedImages(t *testing.T) {
+	ctx := context.Background()
+	repo := newInMemoryImageRepo()
+	storage := &countingStorage{}
+	service := NewImageService(repo, storage)
+
+	unverified := &models.ImageMetadata{Digest: "sha256:" + strings.Repeat("ab", 32), Architecture: "amd64", OS: "linux"}
+	if _, err := service.Store(ctx, unverified, strings.NewReader("archive"), 7, "app", "0xCreator"); !errors.Is(err, ErrInvalidImage) {
+		t.Fatalf("expected ErrInvalidImage for unverified layers, got %v", err)
+	}
+	if storage.uploads != 0 {
+		t.Fatalf("expected nothing to be uploaded, got %d uploads", storage.uploads)
+	}
+
+	// A row stored before layers were verified is replaced, not reused.
+	legacy, _ := repo.CreateImage(ctx, &models.StoredImage{
+		Digest:     unverified.Digest,
+		URL:        "https://gateway.example/ipfs/legacy",
+		Metadata:   *unverified,
+		UploadedBy: "0xCreator",
+	})
+
+	stored, err := service.Store(ctx, verifiedTestMetadata(strings.Repeat("ab", 32)), strings.NewReader("archive"), 7, "app", "0xOther")
+	if err != nil {
+		t.Fatalf("Store returned error: %v", err)
+	}
+	if storage.uploads != 1 || stored.URL == legacy.URL || !stored.Metadata.LayersVerified() {
+		t.Fatalf("expected the unverified image to be replaced by a new upload, got %+v", stored)
+	}
+	if stored.UploadedBy != "0xCreator" {
+		t.Fatalf("expected the replaced image to keep its owner, got %q", stored.UploadedBy)
+	}
+}
+
+func TestImageServiceResolveRequiresOwnership(t *testing.T) {
+	ctx := context.Background()
+	repo := newInMemoryImageRepo()
+	service := NewImageService(repo, &countingStorage{})
+
+	hash := strings.Repeat("cd", 32)
+	if _, err := service.Store(ctx, verifiedTestMetadata(hash), strings.NewReader("archive"), 7, "app", "0xCreator"); err != nil {
+		t.Fatalf("Store returned error: %v", err)
+	}
+
+	image, err := service.Resolve(ctx, strings.ToUpper(hash), "0xcreator")
+	if err != nil {
+		t.Fatalf("expected the uploader to resolve a bare digest, got %v", err)
+	}
+	if image.Digest != "sha256:"+hash {
+		t.Fatalf("unexpected image %+v", image)
+	}
+
+	if _, err := service.Resolve(ctx, "sha256:"+hash, "0xStranger"); !errors.Is(err, ErrImageNotFound) {
+		t.Fatalf("expected ErrImageNotFound for another creator, got %v", err)
+	}
+	if _, err := service.Resolve(ctx, "not-a-digest", "0xCreator"); !errors.Is(err, ErrImageNotFound) {
+		t.Fatalf("expected ErrImageNotFound for a malformed digest, got %v", err)
+	}
+}
@@ -15,6 +15,7 @@ import (
 var (
 	ErrPromptRunnerMismatch = errors.New("prompt is assigned to a different runner")
 	ErrPromptTerminalState  = errors.New("prompt is already in a terminal state")
+	ErrInvalidPromptRequest = errors.New("invalid prompt request")
 )
 
 type LLMService struct {
@@ -23,6 +24,7 @@ type LLMService struct {
 	runnerRepo    ports.RunnerRepository
 	runnerService *RunnerService
 	taskQueue     *TaskQueue
+	eventBus      *EventBus
 }
 
 func NewLLMService(
@@ -41,6 +43,15 @@ func NewLLMService(
 	}
 }
 
+// SetEventBus publishes prompt state changes, including those made by the
+// task queue, to the clients' event streams.
+func (s *LLMService) SetEventBus(eventBus *EventBus) {
+	s.eventBus = eventBus
+	if s.taskQueue != nil {
+		s.taskQueue.SetEventBus(eventBus)
+	}
+}
+
 func (s *LLMService) SubmitPrompt(ctx context.Context, clientID, prompt, modelName, creatorAddress string) (*models.PromptRequest, error) {
 	log := gologger.WithComponent("llm_service")
 
@@ -54,10 +65,20 @@ func (s *LLMService) SubmitPrompt(ctx context.Context, clientID, prompt, modelNa
 	promptReq.RunnerID = runner.DeviceID
 	promptReq.Status = models.PromptStatusProcessing
 
+	acquired, err := s.runnerService.AcquireSlot(ctx, runner.DeviceID, promptReq.ID, models.AssignmentKindPrompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve runner slot: %w", err)
+	}
+	if !acquired {
+		return nil, fmt.Errorf("no available runner found for model %s: runner %s has no free slots", modelName, runner.DeviceID)
+	}
+
 	if err := s.promptRepo.Create(ctx, promptReq); err != nil {
 		log.Error().Err(err).Msg("Failed to create prompt request")
+		s.releasePromptSlot(ctx, promptReq)
 		return nil, fmt.Errorf("failed to create prompt request: %w", err)
 	}
+	s.eventBus.Publish(models.NewPromptEvent("created", promptReq))
 
 	// Forward prompt to runner asynchronously
 	go func() {
@@ -73,7 +94,11 @@ func (s *LLMService) SubmitPrompt(ctx context.Context, clientID, prompt, modelNa
 			promptReq.CompletedAt = &now
 			if updateErr := s.promptRepo.Update(bgCtx, promptReq); updateErr != nil {
 				log.Error().Err(updateErr).Str("prompt_id", promptReq.ID.String()).Msg("Failed to update prompt status to failed")
+			} else {
+				s.eventBus.Publish(models.NewPromptEvent("failed", promptReq))
 			}
+
+			s.releasePromptSlot(bgCtx, promptReq)
 		}
 	}()
 
@@ -124,20 +149,19 @@ func (s *LLMService) CompletePrompt(ctx context.Context, promptID uuid.UUID, run
 		log.Error().Err(err).Str("prompt_id", promptID.String()).Msg("Failed to update prompt request")
 		return fmt.Errorf("failed to update prompt request: %w", err)
 	}
+	s.eventBus.Publish(models.NewPromptEvent("completed", promptReq))
 
-	// Free up the runner by clearing its TaskID
-	if promptReq.RunnerID != "" {
-		runner, err := s.runnerRepo.GetRunnerByDeviceID(ctx, promptReq.RunnerID)
-		if err != nil {
-			log.Error().Err(err).Str("runner_id", promptReq.RunnerID).Msg("Failed to get runner for cleanup")
-		} else {
-			runner.TaskID = nil
-			if _, err := s.runnerService.UpdateRunner(ctx, runner); err != nil {
-				log.Error().Err(err).Str("runner_id", promptReq.RunnerID).Msg("Failed to clear runner TaskID")
-			} else {
-				log.Info().Str("runner_id", promptReq.RunnerID).Msg("Runner freed after prompt completion")
-			}
-		}
+	s.releasePromptSlot(ctx, promptReq)
+
+	// The client is never charged for more response tokens than it allowed.
+	if promptReq.MaxTokens > 0 && responseTokens > promptReq.MaxTokens {
+		log.Warn().
+			Str("prompt_id", promptID.String()).
+			Str("runner_id", runnerID).
+			Int("response_tokens", responseTokens).
+			Int("max_tokens", promptReq.MaxTokens).
+			Msg("Runner reported more response tokens than requested, billing the requested cap")
+		responseTokens = promptReq.MaxTokens
 	}
 
 	metric := models.NewBillingMetric(
@@ -209,8 +233,15 @@ func (s *LLMService) findAvailableRunner(ctx context.Context, modelName string)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get online runners: %w", err)
 	}
+	if s.runnerService != nil {
+		runners = s.runnerService.PreferWarmForModel(runners, modelName)
+	}
 
 	for _, runner := range runners {
+		if runner.FreeSlots() == 0 {
+			continue
+		}
+
 		for _, capability := range runner.ModelCapabilities {
 			// Check for exact match first
 			if capability.ModelName == modelName && capability.IsLoaded {
@@ -231,6 +262,92 @@ func (s *LLMService) findAvailableRunner(ctx context.Context, modelName string)
 	return nil, fmt.Errorf("no available runner found for model %s", modelName)
 }
 
+// checkMaxTokens rejects a max_tokens above the largest limit any online runner
+// advertises for the model. When no online runner has the model loaded the
+// limit is unknown and the prompt is queued as it is.
+func (s *LLMService) checkMaxTokens(ctx context.Context, modelName string, maxTokens int) error {
+	if maxTokens <= 0 {
+		return nil
+	}
+
+	runners, err := s.runnerRepo.GetOnlineRunners(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get online runners: %w", err)
+	}
+
+	limit := 0
+	for _, runner := range runners {
+		capability := runner.LoadedModel(modelName)
+		if capability == nil {
+			continue
+		}
+		if capability.MaxTokens <= 0 {
+			return nil
+		}
+		if capability.MaxTokens > limit {
+			limit = capability.MaxTokens
+		}
+	}
+
+	if limit > 0 && maxTokens > limit {
+		return fmt.Errorf("%w: max_tokens %d exceeds the %d supported by model %s", ErrInvalidPromptRequest, maxTokens, limit, modelName)
+	}
+	return nil
+}
+
+// RequeuePrompt takes an in-flight prompt back from its runner and puts it on
+// the queue again. Prompts that are no longer processing are left alone.
+func (s *LLMService) RequeuePrompt(ctx context.Context, promptID uuid.UUID) (bool, error) {
+	promptReq, err := s.promptRepo.GetByID(ctx, promptID)
+	if err != nil {
+		return false, err
+	}
+
+	if promptReq.Status != models.PromptStatusProcessing {
+		s.releasePromptSlot(ctx, promptReq)
+		return false, nil
+	}
+
+	runnerID := promptReq.RunnerID
+	promptReq.Status = models.PromptStatusQueued
+	promptReq.RunnerID = ""
+	promptReq.AvailableAt = time.Now()
+	promptReq.LockedUntil = nil
+	if err := s.promptRepo.Update(ctx, promptReq); err != nil {
+		return false, fmt.Errorf("failed to requeue prompt: %w", err)
+	}
+	s.eventBus.Publish(models.NewPromptEvent("requeued", promptReq))
+
+	if runnerID != "" {
+		if err := s.runnerService.ReleaseSlot(ctx, runnerID, promptReq.ID); err != nil {
+			return false, fmt.Errorf("failed to release runner slot: %w", err)
+		}
+	}
+
+	if s.taskQueue != nil {
+		s.taskQueue.QueueTask(promptReq.ID, promptReq.ModelName)
+	}
+
+	return true, nil
+}
+
+// releasePromptSlot frees the runner slot the prompt was holding.
+func (s *LLMService) releasePromptSlot(ctx context.Context, promptReq *models.PromptRequest) {
+	if promptReq.RunnerID == "" {
+		return
+	}
+
+	log := gologger.WithComponent("llm_service")
+	if err := s.runnerService.ReleaseSlot(ctx, promptReq.RunnerID, promptReq.ID); err != nil {
+		log.Error().Err(err).Str("runner_id", promptReq.RunnerID).Msg("Failed to release runner slot")
+		return
+	}
+	log.Info().
+		Str("runner_id", promptReq.RunnerID).
+		Str("prompt_id", promptReq.ID.String()).
+		Msg("Runner slot released")
+}
+
 // matchesBaseModel checks if a model capability matches the requested model name
 // Supports matching "qwen3" against "qwen3:latest", "qwen3:8b", etc.
 func matchesBaseModel(capabilityModel, requestedModel string) bool {
@@ -260,25 +377,34 @@ func matchesBaseModel(capabilityModel, requestedModel string) bool {
 	return false
 }
 
-func (s *LLMService) CreatePrompt(ctx context.Context, clientID, prompt, modelName, creatorAddress string) (*models.PromptRequest, error) {
+func (s *LLMService) CreatePrompt(ctx context.Context, clientID, prompt, modelName, creatorAddress string, params models.SamplingParams) (*models.PromptRequest, error) {
 	log := gologger.WithComponent("llm_service")
 
 	if prompt == "" {
-		return nil, fmt.Errorf("prompt cannot be empty")
+		return nil, fmt.Errorf("%w: prompt cannot be empty", ErrInvalidPromptRequest)
 	}
 
 	if modelName == "" {
-		return nil, fmt.Errorf("model name cannot be empty")
+		return nil, fmt.Errorf("%w: model name cannot be empty", ErrInvalidPromptRequest)
 	}
 
 	if creatorAddress == "" {
-		return nil, fmt.Errorf("creator address cannot be empty")
+		return nil, fmt.Errorf("%w: creator address cannot be empty", ErrInvalidPromptRequest)
+	}
+
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPromptRequest, err)
+	}
+
+	if err := s.checkMaxTokens(ctx, modelName, params.MaxTokens); err != nil {
+		return nil, err
 	}
 
 	promptReq := models.NewPromptRequest(clientID, prompt, modelName, creatorAddress)
+	promptReq.SamplingParams = params
 
-	// Try to get available runner for model
-	runnerID, err := s.runnerService.GetAvailableRunnerForModel(ctx, modelName)
+	// Try to reserve a slot on a runner with the model loaded
+	runnerID, err := s.runnerService.ReserveRunnerForModel(ctx, modelName, params.MaxTokens, promptReq.ID)
 	if err != nil {
 		// No runner available, queue the task instead of failing
 		log.Info().
@@ -292,6 +418,7 @@ func (s *LLMService) CreatePrompt(ctx context.Context, clientID, prompt, modelNa
 		if err := s.promptRepo.Create(ctx, promptReq); err != nil {
 			return nil, fmt.Errorf("failed to create prompt request: %w", err)
 		}
+		s.eventBus.Publish(models.NewPromptEvent("created", promptReq))
 
 		// Add to task queue
 		s.taskQueue.QueueTask(promptReq.ID, modelName)
@@ -310,8 +437,10 @@ func (s *LLMService) CreatePrompt(ctx context.Context, clientID, prompt, modelNa
 
 	// Create prompt in DB
 	if err := s.promptRepo.Create(ctx, promptReq); err != nil {
+		s.releasePromptSlot(ctx, promptReq)
 		return nil, fmt.Errorf("failed to create prompt request: %w", err)
 	}
+	s.eventBus.Publish(models.NewPromptEvent("created", promptReq))
 
 	// Forward prompt to runner asynchronously
 	go func() {
@@ -327,20 +456,11 @@ func (s *LLMService) CreatePrompt(ctx context.Context, clientID, prompt, modelNa
 			promptReq.CompletedAt = &now
 			if updateErr := s.promptRepo.Update(bgCtx, promptReq); updateErr != nil {
 				log.Error().Err(updateErr).Str("prompt_id", promptReq.ID.String()).Msg("Failed to update prompt status to failed")
-			}
-
-			// Free up the runner by clearing its TaskID
-			runner, err := s.runnerRepo.GetRunnerByDeviceID(bgCtx, runnerID)
-			if err != nil {
-				log.Error().Err(err).Str("runner_id", runnerID).Msg("Failed to get runner for cleanup after failure")
 			} else {
-				runner.TaskID = nil
-				if _, err := s.runnerService.UpdateRunner(bgCtx, runner); err != nil {
-					log.Error().Err(err).Str("runner_id", runnerID).Msg("Failed to clear runner TaskID after failure")
-				} else {
-					log.Info().Str("runner_id", runnerID).Msg("Runner freed after prompt failure")
-				}
+				s.eventBus.Publish(models.NewPromptEvent("failed", promptReq))
 			}
+
+			s.releasePromptSlot(bgCtx, promptReq)
 		}
 	}()
 
@@ -3,13 +3,16 @@ package services
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/theblitlabs/parity-server/internal/core/models"
 )
 
 type inMemoryPromptRepo struct {
+	mu      sync.Mutex
 	prompts map[uuid.UUID]*models.PromptRequest
 }
 
@@ -20,11 +23,15 @@ func newInMemoryPromptRepo() *inMemoryPromptRepo {
 }
 
 func (r *inMemoryPromptRepo) Create(ctx context.Context, prompt *models.PromptRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.prompts[prompt.ID] = clonePrompt(prompt)
 	return nil
 }
 
 func (r *inMemoryPromptRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.PromptRequest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	prompt, ok := r.prompts[id]
 	if !ok {
 		return nil, errors.New("prompt not found")
@@ -33,6 +40,8 @@ func (r *inMemoryPromptRepo) GetByID(ctx context.Context, id uuid.UUID) (*models
 }
 
 func (r *inMemoryPromptRepo) Update(ctx context.Context, prompt *models.PromptRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.prompts[prompt.ID] = clonePrompt(prompt)
 	return nil
 }
@@ -45,6 +54,39 @@ func (r *inMemoryPromptRepo) GetPendingPrompts(ctx context.Context) ([]*models.P
 	return nil, nil
 }
 
+func (r *inMemoryPromptRepo) LeaseQueued(ctx context.Context, now time.Time, visibility time.Duration, limit int) ([]*models.PromptRequest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var leased []*models.PromptRequest
+	for _, prompt := range r.prompts {
+		if len(leased) == limit {
+			break
+		}
+		if prompt.Status != models.PromptStatusQueued || prompt.AvailableAt.After(now) {
+			continue
+		}
+		if prompt.LockedUntil != nil && !prompt.LockedUntil.Before(now) {
+			continue
+		}
+		lockedUntil := now.Add(visibility)
+		prompt.LockedUntil = &lockedUntil
+		leased = append(leased, clonePrompt(prompt))
+	}
+	return leased, nil
+}
+
+func (r *inMemoryPromptRepo) CountQueued(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, prompt := range r.prompts {
+		if prompt.Status == models.PromptStatusQueued {
+			count++
+		}
+	}
+	return count, nil
+}
+
 type inMemoryBillingRepo struct {
 	createCalls int
 	metrics     []*models.BillingMetric
@@ -70,6 +112,10 @@ func clonePrompt(prompt *models.PromptRequest) *models.PromptRequest {
 		completedAt := *prompt.CompletedAt
 		cloned.CompletedAt = &completedAt
 	}
+	if prompt.LockedUntil != nil {
+		lockedUntil := *prompt.LockedUntil
+		cloned.LockedUntil = &lockedUntil
+	}
 	return &cloned
 }
 
@@ -110,14 +156,13 @@ func TestCompletePromptIsIdempotentAfterSuccess(t *testing.T) {
 	runnerService := NewRunnerService(runnerRepo)
 	service := NewLLMService(promptRepo, billingRepo, runnerRepo, runnerService, nil)
 
-	taskID := uuid.New()
+	prompt := models.NewPromptRequest("client-1", "hello", "model-a", "0xabc")
 	runnerRepo.runners["runner-1"] = &models.Runner{
-		DeviceID: "runner-1",
-		Status:   models.RunnerStatusBusy,
-		TaskID:   &taskID,
+		DeviceID:    "runner-1",
+		Status:      models.RunnerStatusOnline,
+		Assignments: []models.RunnerAssignment{*models.NewRunnerAssignment("runner-1", prompt.ID, models.AssignmentKindPrompt)},
 	}
 
-	prompt := models.NewPromptRequest("client-1", "hello", "model-a", "0xabc")
 	prompt.RunnerID = "runner-1"
 	prompt.Status = models.PromptStatusProcessing
 	promptRepo.prompts[prompt.ID] = clonePrompt(prompt)
@@ -143,3 +188,103 @@ func TestCompletePromptIsIdempotentAfterSuccess(t *testing.T) {
 		t.Fatalf("prompt status = %q, want %q", storedPrompt.Status, models.PromptStatusCompleted)
 	}
 }
+
+func TestCreatePromptValidatesSamplingParams(t *testing.T) {
+	ctx := context.Background()
+	promptRepo := newInMemoryPromptRepo()
+	runnerRepo := newInMemoryRunnerRepo()
+	runnerService := NewRunnerService(runnerRepo)
+	service := NewLLMService(promptRepo, &inMemoryBillingRepo{}, runnerRepo, runnerService, NewTaskQueue(promptRepo, runnerRepo, runnerService))
+
+	runnerRepo.runners["runner-1"] = &models.Runner{
+		DeviceID: "runner-1",
+		Status:   models.RunnerStatusOnline,
+		ModelCapabilities: []models.ModelCapability{
+			{RunnerID: "runner-1", ModelName: "model-a", IsLoaded: true, MaxTokens: 2048},
+		},
+	}
+
+	temperature := 3.0
+	cases := map[string]models.SamplingParams{
+		"temperature out of range": {Temperature: &temperature},
+		"max tokens above limit":   {MaxTokens: 4096},
+		"empty stop sequence":      {StopSequences: models.StopSequences{"\n", ""}},
+	}
+	for name, params := range cases {
+		if _, err := service.CreatePrompt(ctx, "client-1", "hello", "model-a", "0xabc", params); !errors.Is(err, ErrInvalidPromptRequest) {
+			t.Errorf("%s: CreatePrompt() error = %v, want ErrInvalidPromptRequest", name, err)
+		}
+	}
+	if len(promptRepo.prompts) != 0 {
+		t.Fatalf("rejected prompts were stored: %d", len(promptRepo.prompts))
+	}
+}
+
+func TestCreatePromptSkipsRunnersWithLowerTokenLimit(t *testing.T) {
+	ctx := context.Background()
+	promptRepo := newInMemoryPromptRepo()
+	runnerRepo := newInMemoryRunnerRepo()
+	runnerService := NewRunnerService(runnerRepo)
+	service := NewLLMService(promptRepo, &inMemoryBillingRepo{}, runnerRepo, runnerService, NewTaskQueue(promptRepo, runnerRepo, runnerService))
+
+	runnerRepo.runners["small"] = &models.Runner{
+		DeviceID: "small",
+		Status:   models.RunnerStatusOnline,
+		ModelCapabilities: []models.ModelCapability{
+			{RunnerID: "small", ModelName: "model-a", IsLoaded: true, MaxTokens: 1024},
+		},
+	}
+	// The only runner that allows 2048 tokens is busy.
+	runnerRepo.runners["large"] = &models.Runner{
+		DeviceID: "large",
+		Status:   models.RunnerStatusOnline,
+		ModelCapabilities: []models.ModelCapability{
+			{RunnerID: "large", ModelName: "model-a", IsLoaded: true, MaxTokens: 4096},
+		},
+		Assignments: []models.RunnerAssignment{*models.NewRunnerAssignment("large", uuid.New(), models.AssignmentKindPrompt)},
+	}
+
+	seed := int64(7)
+	params := models.SamplingParams{SystemPrompt: "Be brief.", MaxTokens: 2048, Seed: &seed}
+	prompt, err := service.CreatePrompt(ctx, "client-1", "hello", "model-a", "0xabc", params)
+	if err != nil {
+		t.Fatalf("CreatePrompt() error = %v", err)
+	}
+	if prompt.Status != models.PromptStatusQueued {
+		t.Fatalf("prompt status = %q, want %q", prompt.Status, models.PromptStatusQueued)
+	}
+	if runnerRepo.runners["small"].HasAssignment(prompt.ID) {
+		t.Fatal("prompt was reserved on a runner that cannot generate 2048 tokens")
+	}
+
+	stored, _ := promptRepo.GetByID(ctx, prompt.ID)
+	if stored.MaxTokens != 2048 || stored.SystemPrompt != "Be brief." || stored.Seed == nil || *stored.Seed != 7 {
+		t.Fatalf("sampling params were not stored: %+v", stored.SamplingParams)
+	}
+}
+
+func TestCompletePromptBillsAtMostMaxTokens(t *testing.T) {
+	promptRepo := newInMemoryPromptRepo()
+	billingRepo := &inMemoryBillingRepo{}
+	runnerRepo := newInMemoryRunnerRepo()
+	service := NewLLMService(promptRepo, billingRepo, runnerRepo, NewRunnerService(runnerRepo), nil)
+
+	prompt := models.NewPromptRequest("client-1", "hello", "model-a", "0xabc")
+	prompt.MaxTokens = 100
+	prompt.RunnerID = "runner-1"
+	prompt.Status = models.PromptStatusProcessing
+	promptRepo.prompts[prompt.ID] = clonePrompt(prompt)
+	runnerRepo.runners["runner-1"] = &models.Runner{DeviceID: "runner-1", Status: models.RunnerStatusOnline}
+
+	if err := service.CompletePrompt(context.Background(), prompt.ID, "runner-1", "response", 10, 250, 30); err != nil {
+		t.Fatalf("CompletePrompt() error = %v", err)
+	}
+
+	if len(billingRepo.metrics) != 1 {
+		t.Fatalf("billing metrics = %d, want 1", len(billingRepo.metrics))
+	}
+	metric := billingRepo.metrics[0]
+	if metric.ResponseTokens != 100 || metric.TotalTokens != 110 {
+		t.Fatalf("billed %d response / %d total tokens, want 100 / 110", metric.ResponseTokens, metric.TotalTokens)
+	}
+}
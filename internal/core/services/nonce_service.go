@@ -2,51 +2,80 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 
-	"github.com/drand/drand/client"
-	"github.com/drand/drand/client/http"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/internal/core/ports"
 	"github.com/theblitlabs/parity-server/internal/utils"
 )
 
 type NonceService struct {
-	client client.Client
+	source ports.RandomnessSource
 }
 
-func NewNonceService() *NonceService {
-	urls := []string{"https://api.drand.sh", "https://drand.cloudflare.com"}
-	chainHash, _ := hex.DecodeString("8990e7a9aaed2ffed73dbd7092123d6f289930540d7651336225dc172e51b2ce")
+func NewNonceService(source ports.RandomnessSource) *NonceService {
+	if source == nil {
+		source = NewCryptoRandomnessSource()
+	}
 
-	httpClients := http.ForURLs(urls, chainHash)
-	if len(httpClients) == 0 {
-		return &NonceService{}
+	return &NonceService{
+		source: source,
 	}
+}
+
+func (s *NonceService) Source() ports.RandomnessSource {
+	return s.source
+}
 
-	c, err := client.New(
-		client.From(httpClients...),
-		client.WithChainHash(chainHash),
-		client.WithCacheSize(0), // Disable caching for nonces
-	)
+// GenerateNonce derives a nonce for scope (usually a task ID) from the latest
+// round of the configured randomness source and returns the round it came from.
+func (s *NonceService) GenerateNonce(ctx context.Context, scope string) (string, *models.BeaconRound, error) {
+	round, err := s.source.Latest(ctx)
 	if err != nil {
-		return &NonceService{}
+		return "", nil, fmt.Errorf("failed to get randomness: %w", err)
 	}
 
-	return &NonceService{
-		client: c,
-	}
+	return DeriveNonce(round.Randomness, scope), round, nil
 }
 
-func (s *NonceService) GenerateNonce() string {
-	if s.client != nil {
-		result, err := s.client.Get(context.Background(), 0)
-		if err == nil {
-			return hex.EncodeToString(result.Randomness())
-		}
+// VerifyTaskNonce re-fetches the beacon round recorded on the task, checks its
+// signature and confirms the task nonce was derived from it.
+func (s *NonceService) VerifyTaskNonce(ctx context.Context, task *models.Task) error {
+	if task.NonceSource == "" || task.NonceSource == models.RandomnessSourceCrypto || task.NonceRound == 0 {
+		return fmt.Errorf("%w: task nonce was not drawn from a beacon", ErrRandomnessUnverifiable)
+	}
+
+	if task.NonceSource != s.source.Name() {
+		return fmt.Errorf("task nonce was drawn from %s but the server uses %s", task.NonceSource, s.source.Name())
 	}
 
-	return utils.GenerateNonce()
+	round, err := s.source.Round(ctx, task.NonceRound)
+	if err != nil {
+		return fmt.Errorf("failed to fetch beacon round %d: %w", task.NonceRound, err)
+	}
+
+	if err := s.source.Verify(ctx, round); err != nil {
+		return err
+	}
+
+	if DeriveNonce(round.Randomness, task.ID.String()) != task.Nonce {
+		return fmt.Errorf("task nonce does not match beacon round %d", task.NonceRound)
+	}
+
+	return nil
 }
 
 func (s *NonceService) VerifyNonce(taskNonce string, taskOutput string) bool {
 	return utils.VerifyNonce(taskNonce, taskOutput)
 }
+
+// DeriveNonce binds beacon randomness to a scope so that every task drawn from the
+// same round still gets a distinct nonce.
+func DeriveNonce(randomness []byte, scope string) string {
+	h := sha256.New()
+	h.Write(randomness)
+	h.Write([]byte(scope))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+func TestNonceServiceVerifiesLocalBeaconNonce(t *testing.T) {
+	ctx := context.Background()
+	beacon := NewLocalBeacon([]byte("test-seed"), time.Unix(0, 0), time.Second)
+	nonceService := NewNonceService(beacon)
+
+	task := models.NewTask()
+	nonce, round, err := nonceService.GenerateNonce(ctx, task.ID.String())
+	if err != nil {
+		t.Fatalf("GenerateNonce returned error: %v", err)
+	}
+	if !round.Verifiable() {
+		t.Fatalf("expected local beacon round to be verifiable, got %+v", round)
+	}
+
+	task.Nonce = nonce
+	task.NonceSource = round.Source
+	task.NonceRound = round.Round
+
+	if err := nonceService.VerifyTaskNonce(ctx, task); err != nil {
+		t.Fatalf("VerifyTaskNonce returned error: %v", err)
+	}
+
+	task.Nonce = DeriveNonce(round.Randomness, "another-task")
+	if err := nonceService.VerifyTaskNonce(ctx, task); err == nil {
+		t.Fatal("expected nonce bound to another scope to fail verification")
+	}
+}
+
+func TestLocalBeaconRejectsTamperedRound(t *testing.T) {
+	ctx := context.Background()
+	beacon := NewLocalBeacon([]byte("test-seed"), time.Unix(0, 0), time.Second)
+
+	round, err := beacon.Round(ctx, 42)
+	if err != nil {
+		t.Fatalf("Round returned error: %v", err)
+	}
+	if err := beacon.Verify(ctx, round); err != nil {
+		t.Fatalf("Verify returned error for untouched round: %v", err)
+	}
+
+	round.Randomness[0] ^= 0xff
+	if err := beacon.Verify(ctx, round); err == nil {
+		t.Fatal("expected tampered randomness to fail verification")
+	}
+}
+
+func TestCryptoRandomnessIsNotVerifiable(t *testing.T) {
+	ctx := context.Background()
+	nonceService := NewNonceService(nil)
+
+	task := models.NewTask()
+	nonce, round, err := nonceService.GenerateNonce(ctx, task.ID.String())
+	if err != nil {
+		t.Fatalf("GenerateNonce returned error: %v", err)
+	}
+
+	task.Nonce = nonce
+	task.NonceSource = round.Source
+	task.NonceRound = round.Round
+
+	if err := nonceService.VerifyTaskNonce(ctx, task); !errors.Is(err, ErrRandomnessUnverifiable) {
+		t.Fatalf("expected ErrRandomnessUnverifiable, got %v", err)
+	}
+}
+
+func TestCreateTaskDrawsVerifiableNonce(t *testing.T) {
+	ctx := context.Background()
+	taskService := NewTaskService(newInMemoryTaskRepo(), nil, nil)
+	taskService.SetNonceService(NewNonceService(NewLocalBeacon([]byte("test-seed"), time.Unix(0, 0), time.Second)))
+
+	task := models.NewTask()
+	task.Title = "nonce"
+	task.Type = models.TaskTypeCommand
+	task.Config, _ = json.Marshal(models.TaskConfig{})
+	if err := taskService.CreateTask(ctx, task); err != nil {
+		t.Fatalf("CreateTask returned error: %v", err)
+	}
+
+	if task.Nonce == "" || task.NonceSource != models.RandomnessSourceLocal || task.NonceRound == 0 {
+		t.Fatalf("expected a nonce drawn from the local beacon, got %q from %s round %d", task.Nonce, task.NonceSource, task.NonceRound)
+	}
+	if err := taskService.VerifyTaskNonce(ctx, task); err != nil {
+		t.Fatalf("VerifyTaskNonce returned error: %v", err)
+	}
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/core/config"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/internal/core/ports"
+)
+
+var (
+	ErrRandomnessUnverifiable = errors.New("randomness round cannot be verified")
+	ErrRandomnessNotReady     = errors.New("randomness round has not been published yet")
+)
+
+const (
+	defaultLocalBeaconSeed   = "parity-local-beacon"
+	defaultLocalBeaconPeriod = 30 * time.Second
+)
+
+// NewRandomnessSource builds the randomness source selected in the configuration,
+// wrapping it with the configured fallback source when one is set.
+func NewRandomnessSource(cfg *config.Config) (ports.RandomnessSource, error) {
+	var rc config.RandomnessConfig
+	if cfg != nil {
+		rc = cfg.Randomness
+	}
+
+	primary, err := newRandomnessSourceByName(rc.Source, rc)
+	if err != nil {
+		return nil, err
+	}
+
+	if rc.Fallback == "" || strings.EqualFold(rc.Fallback, string(primary.Name())) {
+		return primary, nil
+	}
+
+	fallback, err := newRandomnessSourceByName(rc.Fallback, rc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid randomness fallback: %w", err)
+	}
+
+	return &fallbackRandomnessSource{primary: primary, fallback: fallback}, nil
+}
+
+func newRandomnessSourceByName(name string, rc config.RandomnessConfig) (ports.RandomnessSource, error) {
+	switch models.RandomnessSourceType(strings.ToLower(strings.TrimSpace(name))) {
+	case "", models.RandomnessSourceDrand:
+		var urls []string
+		for _, url := range strings.Split(rc.DrandURLs, ",") {
+			if url = strings.TrimSpace(url); url != "" {
+				urls = append(urls, url)
+			}
+		}
+		return NewDrandBeacon(urls, rc.DrandChainHash)
+	case models.RandomnessSourceLocal:
+		period := defaultLocalBeaconPeriod
+		if rc.LocalPeriod > 0 {
+			period = time.Duration(rc.LocalPeriod) * time.Second
+		}
+		seed := rc.LocalSeed
+		if seed == "" {
+			seed = defaultLocalBeaconSeed
+		}
+		return NewLocalBeacon([]byte(seed), time.Unix(0, 0), period), nil
+	case models.RandomnessSourceCrypto:
+		return NewCryptoRandomnessSource(), nil
+	default:
+		return nil, fmt.Errorf("unsupported randomness source: %s", name)
+	}
+}
+
+// LocalBeacon is a deterministic beacon for tests and offline deployments. Each
+// round's signature is an HMAC of the round number under the configured seed, so
+// anyone holding the seed can recompute and check any round.
+type LocalBeacon struct {
+	seed    []byte
+	genesis time.Time
+	period  time.Duration
+	now     func() time.Time
+}
+
+func NewLocalBeacon(seed []byte, genesis time.Time, period time.Duration) *LocalBeacon {
+	if period <= 0 {
+		period = defaultLocalBeaconPeriod
+	}
+	return &LocalBeacon{
+		seed:    seed,
+		genesis: genesis,
+		period:  period,
+		now:     time.Now,
+	}
+}
+
+func (b *LocalBeacon) Name() models.RandomnessSourceType {
+	return models.RandomnessSourceLocal
+}
+
+func (b *LocalBeacon) Latest(ctx context.Context) (*models.BeaconRound, error) {
+	return b.Round(ctx, b.roundAt(b.now()))
+}
+
+func (b *LocalBeacon) Round(ctx context.Context, round uint64) (*models.BeaconRound, error) {
+	if round == 0 {
+		return b.Latest(ctx)
+	}
+	if round > b.roundAt(b.now()) {
+		return nil, fmt.Errorf("%w: local beacon round %d", ErrRandomnessNotReady, round)
+	}
+
+	signature := b.sign(round)
+	randomness := sha256.Sum256(signature)
+
+	return &models.BeaconRound{
+		Source:     models.RandomnessSourceLocal,
+		Round:      round,
+		Randomness: randomness[:],
+		Signature:  signature,
+	}, nil
+}
+
+func (b *LocalBeacon) Verify(ctx context.Context, round *models.BeaconRound) error {
+	if round == nil || round.Source != models.RandomnessSourceLocal || round.Round == 0 {
+		return fmt.Errorf("%w: not a local beacon round", ErrRandomnessUnverifiable)
+	}
+
+	if !hmac.Equal(b.sign(round.Round), round.Signature) {
+		return fmt.Errorf("local beacon round %d has an invalid signature", round.Round)
+	}
+
+	randomness := sha256.Sum256(round.Signature)
+	if !bytes.Equal(randomness[:], round.Randomness) {
+		return fmt.Errorf("local beacon round %d randomness does not match its signature", round.Round)
+	}
+
+	return nil
+}
+
+func (b *LocalBeacon) roundAt(t time.Time) uint64 {
+	if t.Before(b.genesis) {
+		return 1
+	}
+	return uint64(t.Sub(b.genesis)/b.period) + 1
+}
+
+func (b *LocalBeacon) sign(round uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], round)
+
+	mac := hmac.New(sha256.New, b.seed)
+	mac.Write(buf[:])
+	return mac.Sum(nil)
+}
+
+// CryptoRandomnessSource draws from crypto/rand. Its rounds are not reproducible
+// and are recorded with round 0 so they can be told apart from beacon rounds.
+type CryptoRandomnessSource struct{}
+
+func NewCryptoRandomnessSource() *CryptoRandomnessSource {
+	return &CryptoRandomnessSource{}
+}
+
+func (s *CryptoRandomnessSource) Name() models.RandomnessSourceType {
+	return models.RandomnessSourceCrypto
+}
+
+func (s *CryptoRandomnessSource) Latest(ctx context.Context) (*models.BeaconRound, error) {
+	randomness := make([]byte, 32)
+	if _, err := rand.Read(randomness); err != nil {
+		return nil, fmt.Errorf("failed to read crypto randomness: %w", err)
+	}
+
+	return &models.BeaconRound{
+		Source:     models.RandomnessSourceCrypto,
+		Randomness: randomness,
+	}, nil
+}
+
+func (s *CryptoRandomnessSource) Round(ctx context.Context, round uint64) (*models.BeaconRound, error) {
+	if round != 0 {
+		return nil, fmt.Errorf("%w: crypto randomness has no rounds", ErrRandomnessUnverifiable)
+	}
+	return s.Latest(ctx)
+}
+
+func (s *CryptoRandomnessSource) Verify(ctx context.Context, round *models.BeaconRound) error {
+	return fmt.Errorf("%w: crypto randomness is not reproducible", ErrRandomnessUnverifiable)
+}
+
+// fallbackRandomnessSource uses the primary source and only switches to the
+// fallback when the primary fails. Rounds keep their real source, so a nonce drawn
+// from the fallback is never mistaken for a beacon round.
+type fallbackRandomnessSource struct {
+	primary  ports.RandomnessSource
+	fallback ports.RandomnessSource
+}
+
+func (s *fallbackRandomnessSource) Name() models.RandomnessSourceType {
+	return s.primary.Name()
+}
+
+func (s *fallbackRandomnessSource) Latest(ctx context.Context) (*models.BeaconRound, error) {
+	round, err := s.primary.Latest(ctx)
+	if err == nil {
+		return round, nil
+	}
+
+	log := gologger.WithComponent("randomness")
+	log.Warn().Err(err).
+		Str("primary", string(s.primary.Name())).
+		Str("fallback", string(s.fallback.Name())).
+		Msg("Primary randomness source failed, using fallback")
+
+	return s.fallback.Latest(ctx)
+}
+
+func (s *fallbackRandomnessSource) Round(ctx context.Context, round uint64) (*models.BeaconRound, error) {
+	return s.primary.Round(ctx, round)
+}
+
+func (s *fallbackRandomnessSource) Verify(ctx context.Context, round *models.BeaconRound) error {
+	if round != nil && round.Source == s.fallback.Name() {
+		return s.fallback.Verify(ctx, round)
+	}
+	return s.primary.Verify(ctx, round)
+}
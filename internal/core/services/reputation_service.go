@@ -104,6 +104,7 @@ type ReputationService struct {
 	ethClient       *ethclient.Client
 	contractABI     abi.ABI
 	contractAddress common.Address
+	telemetry       *TelemetryService
 }
 
 func NewReputationService(
@@ -310,6 +311,8 @@ func (s *ReputationService) UpdateReputationForTask(ctx context.Context, runnerI
 		reputation.Status = models.ReputationStatusActive
 	}
 
+	s.refreshUptime(ctx, reputation)
+
 	// Save to database
 	if err := s.reputationRepo.UpdateRunnerReputation(ctx, reputation); err != nil {
 		return fmt.Errorf("failed to update reputation: %w", err)
@@ -628,9 +631,35 @@ func (s *ReputationService) GetRunnerReputation(ctx context.Context, runnerID st
 		log.Warn().Str("runner_id", runnerID).Msg("Attempted to get reputation for banned runner")
 	}
 
+	s.refreshUptime(ctx, reputation)
+
 	return reputation, nil
 }
 
+// SetTelemetryService lets uptime be computed from the runner's heartbeat
+// history instead of the stored value.
+func (s *ReputationService) SetTelemetryService(telemetry *TelemetryService) {
+	s.telemetry = telemetry
+}
+
+// refreshUptime replaces UptimePercentage with the uptime measured over the
+// telemetry window. Runners with no recorded heartbeats keep the stored value.
+func (s *ReputationService) refreshUptime(ctx context.Context, reputation *models.RunnerReputation) {
+	if s.telemetry == nil {
+		return
+	}
+
+	health, err := s.telemetry.Health(ctx, reputation.RunnerID, 0)
+	if err != nil {
+		log := gologger.WithComponent("reputation_service")
+		log.Debug().Err(err).Str("runner_id", reputation.RunnerID).Msg("Runner telemetry unavailable")
+		return
+	}
+	if health.Heartbeats > 0 {
+		reputation.UptimePercentage = health.UptimePercentage
+	}
+}
+
 // SlashRunnerStake slashes a runner's stake for malicious behavior
 func (s *ReputationService) SlashRunnerStake(ctx context.Context, runnerID string, reason string) error {
 	log := gologger.WithComponent("reputation_service")
@@ -0,0 +1,393 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/core/config"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/internal/core/ports"
+)
+
+var (
+	ErrRetentionRunning       = errors.New("a retention run is already in progress")
+	ErrInvalidRetentionPolicy = errors.New("invalid retention policy")
+	ErrResultArchived         = errors.New("task result is archived")
+)
+
+const (
+	retentionResultBatch = 500
+	// retentionReportLimit caps how many images and results a report lists.
+	// The counts still cover everything the run collected.
+	retentionReportLimit = 1000
+)
+
+// RetentionService collects stored images and task results that have outlived
+// their retention policy. Images are unpinned once no pending or running task
+// uses them and they have been idle for the policy's image days; results of
+// finished tasks are archived or purged after the days set for the task's
+// final state. Archiving moves a result's output and error to object storage.
+type RetentionService struct {
+	repo    ports.RetentionRepository
+	storage StorageService
+	objects ports.Storage
+
+	mu       sync.RWMutex
+	global   models.RetentionPolicy
+	interval time.Duration
+	dryRun   bool
+
+	runMu sync.Mutex
+}
+
+func NewRetentionService(repo ports.RetentionRepository, storage StorageService) *RetentionService {
+	return &RetentionService{
+		repo:    repo,
+		storage: storage,
+		global: models.RetentionPolicy{
+			ImageDays:           30,
+			CompletedResultDays: 90,
+			FailedResultDays:    30,
+			ResultAction:        models.ResultActionArchive,
+		},
+		interval: time.Hour,
+	}
+}
+
+// SetObjectStore sets where archived result payloads are written. Without
+// one, results due for archiving are left in place and reported as errors.
+func (s *RetentionService) SetObjectStore(objects ports.Storage) {
+	s.objects = objects
+}
+
+func (s *RetentionService) SetConfig(cfg config.RetentionConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cfg.Interval > 0 {
+		s.interval = time.Duration(cfg.Interval) * time.Minute
+	}
+	if cfg.ImageDays > 0 {
+		s.global.ImageDays = cfg.ImageDays
+	}
+	if cfg.CompletedResultDays > 0 {
+		s.global.CompletedResultDays = cfg.CompletedResultDays
+	}
+	if cfg.FailedResultDays > 0 {
+		s.global.FailedResultDays = cfg.FailedResultDays
+	}
+	switch action := models.ResultRetentionAction(cfg.ResultAction); action {
+	case models.ResultActionArchive, models.ResultActionPurge:
+		s.global.ResultAction = action
+	}
+	s.dryRun = cfg.DryRun
+}
+
+func (s *RetentionService) GlobalPolicy() models.RetentionPolicy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.global
+}
+
+func (s *RetentionService) ListPolicies(ctx context.Context) ([]*models.RetentionPolicy, error) {
+	return s.repo.ListPolicies(ctx)
+}
+
+// SetPolicy stores a creator's override of the global policy.
+func (s *RetentionService) SetPolicy(ctx context.Context, policy *models.RetentionPolicy) error {
+	if policy.CreatorAddress == "" {
+		return fmt.Errorf("%w: creator address is required", ErrInvalidRetentionPolicy)
+	}
+	if policy.ImageDays < 0 || policy.CompletedResultDays < 0 || policy.FailedResultDays < 0 {
+		return fmt.Errorf("%w: retention days cannot be negative", ErrInvalidRetentionPolicy)
+	}
+	switch policy.ResultAction {
+	case "", models.ResultActionArchive, models.ResultActionPurge:
+	default:
+		return fmt.Errorf("%w: result action must be archive or purge", ErrInvalidRetentionPolicy)
+	}
+	return s.repo.SavePolicy(ctx, policy)
+}
+
+func (s *RetentionService) DeletePolicy(ctx context.Context, creatorAddress string) error {
+	return s.repo.DeletePolicy(ctx, creatorAddress)
+}
+
+// Run collects everything past retention. With dryRun it only reports what
+// would be collected. Only one run happens at a time; a second caller gets
+// ErrRetentionRunning.
+func (s *RetentionService) Run(ctx context.Context, dryRun bool) (*models.RetentionReport, error) {
+	if !s.runMu.TryLock() {
+		return nil, ErrRetentionRunning
+	}
+	defer s.runMu.Unlock()
+
+	log := gologger.WithComponent("retention")
+
+	global := s.GlobalPolicy()
+	overrides, err := s.repo.ListPolicies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load retention policies: %w", err)
+	}
+	policies := make(map[string]models.RetentionPolicy, len(overrides))
+	minImageDays, minResultDays := global.ImageDays, min(global.CompletedResultDays, global.FailedResultDays)
+	for _, override := range overrides {
+		policy := override.Over(global)
+		policies[strings.ToLower(override.CreatorAddress)] = policy
+		minImageDays = min(minImageDays, policy.ImageDays)
+		minResultDays = min(minResultDays, policy.CompletedResultDays, policy.FailedResultDays)
+	}
+	policyFor := func(creator string) models.RetentionPolicy {
+		if policy, ok := policies[strings.ToLower(creator)]; ok {
+			return policy
+		}
+		return global
+	}
+
+	now := time.Now()
+	report := &models.RetentionReport{
+		DryRun:    dryRun,
+		StartedAt: now,
+		Images:    []models.CollectedImage{},
+		Results:   []models.CollectedResult{},
+	}
+
+	if err := s.collectImages(ctx, report, now.AddDate(0, 0, -minImageDays), policyFor); err != nil {
+		return nil, err
+	}
+	if err := s.collectResults(ctx, report, now.AddDate(0, 0, -minResultDays), policyFor); err != nil {
+		return nil, err
+	}
+
+	report.FinishedAt = time.Now()
+
+	log.Info().
+		Bool("dry_run", dryRun).
+		Int("images", len(report.Images)).
+		Int64("image_bytes", report.ImageBytes).
+		Int("results_archived", report.ResultsArchived).
+		Int("results_purged", report.ResultsPurged).
+		Int("errors", len(report.Errors)).
+		Msg("Retention run finished")
+
+	return report, nil
+}
+
+// collectImages unpins idle images. An image is kept for the longest image
+// retention of any creator that uploaded or used it.
+func (s *RetentionService) collectImages(ctx context.Context, report *models.RetentionReport, usedBefore time.Time, policyFor func(string) models.RetentionPolicy) error {
+	log := gologger.WithComponent("retention")
+
+	candidates, err := s.repo.ListIdleImages(ctx, usedBefore)
+	if err != nil {
+		return fmt.Errorf("failed to list idle images: %w", err)
+	}
+
+	for _, candidate := range candidates {
+		image := candidate.Image
+		lastUsed := image.CreatedAt
+		if image.LastReferencedAt != nil {
+			lastUsed = *image.LastReferencedAt
+		}
+
+		keepDays := 0
+		for _, owner := range candidate.Owners {
+			keepDays = max(keepDays, policyFor(owner).ImageDays)
+		}
+		if lastUsed.After(report.StartedAt.AddDate(0, 0, -keepDays)) {
+			continue
+		}
+
+		if !report.DryRun {
+			// The row goes first so no new task can pick the image up while it
+			// is being unpinned.
+			removed, err := s.repo.DeleteIdleImage(ctx, image.Digest)
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("image %s: %v", image.Digest, err))
+				continue
+			}
+			if !removed {
+				continue
+			}
+			// Objects are content addressed, so another image, artifact or
+			// archive may still share this key.
+			inUse, err := s.repo.ObjectKeyInUse(ctx, path.Base(image.URL))
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("image %s: %v", image.Digest, err))
+				continue
+			}
+			if inUse {
+				log.Info().Str("digest", image.Digest).Msg("Kept collected image object still referenced elsewhere")
+			} else if err := s.storage.DeleteDockerImage(ctx, image.URL); err != nil {
+				log.Error().Err(err).Str("digest", image.Digest).Msg("Failed to unpin collected image")
+				report.Errors = append(report.Errors, fmt.Sprintf("image %s: %v", image.Digest, err))
+			}
+		}
+
+		report.ImageBytes += image.Size
+		if len(report.Images) < retentionReportLimit {
+			report.Images = append(report.Images, models.CollectedImage{
+				Digest:     image.Digest,
+				URL:        image.URL,
+				Size:       image.Size,
+				UploadedBy: image.UploadedBy,
+				LastUsedAt: lastUsed,
+			})
+		}
+	}
+
+	return nil
+}
+
+// collectResults archives or purges results of finished tasks according to
+// the task creator's policy for the task's final state.
+func (s *RetentionService) collectResults(ctx context.Context, report *models.RetentionReport, createdBefore time.Time, policyFor func(string) models.RetentionPolicy) error {
+	var after *models.ResultRetentionCandidate
+	for {
+		batch, err := s.repo.ListFinishedResults(ctx, createdBefore, after, retentionResultBatch)
+		if err != nil {
+			return fmt.Errorf("failed to list finished results: %w", err)
+		}
+
+		for _, candidate := range batch {
+			policy := policyFor(candidate.CreatorAddress)
+			days := policy.ResultDays(candidate.TaskStatus)
+			if days <= 0 || candidate.CreatedAt.After(report.StartedAt.AddDate(0, 0, -days)) {
+				continue
+			}
+
+			if !report.DryRun {
+				var err error
+				if policy.ResultAction == models.ResultActionPurge {
+					err = s.repo.DeleteResult(ctx, candidate.ResultID)
+				} else {
+					err = s.archiveResult(ctx, candidate, report.StartedAt)
+				}
+				if err != nil {
+					report.Errors = append(report.Errors, fmt.Sprintf("result %s: %v", candidate.ResultID, err))
+					continue
+				}
+			}
+
+			if policy.ResultAction == models.ResultActionPurge {
+				report.ResultsPurged++
+			} else {
+				report.ResultsArchived++
+			}
+			if len(report.Results) < retentionReportLimit {
+				report.Results = append(report.Results, models.CollectedResult{
+					ResultRetentionCandidate: *candidate,
+					Action:                   policy.ResultAction,
+				})
+			}
+		}
+
+		if len(batch) < retentionResultBatch {
+			return nil
+		}
+		after = batch[len(batch)-1]
+	}
+}
+
+// archiveResult writes the result's output and error to object storage, then
+// clears them from the row, keeping the object's key and hash.
+func (s *RetentionService) archiveResult(ctx context.Context, candidate *models.ResultRetentionCandidate, at time.Time) error {
+	if s.objects == nil {
+		return errors.New("no object storage is configured for result archives")
+	}
+
+	archive, err := s.repo.GetResultArchive(ctx, candidate.ResultID)
+	if err != nil {
+		return fmt.Errorf("failed to load result: %w", err)
+	}
+	body, err := json.Marshal(archive)
+	if err != nil {
+		return fmt.Errorf("failed to encode result archive: %w", err)
+	}
+	sum := sha256.Sum256(body)
+
+	object, err := s.objects.Put(ctx, bytes.NewReader(body), fmt.Sprintf("result-%s.json", candidate.ResultID))
+	if err != nil {
+		return fmt.Errorf("failed to store result archive: %w", err)
+	}
+	return s.repo.ArchiveResult(ctx, candidate.ResultID, at, object.Key, hex.EncodeToString(sum[:]))
+}
+
+// RestoreResult returns a copy of an archived result with its output and
+// error read back from object storage, as it was before archiving. Results
+// that are not archived are returned unchanged.
+func (s *RetentionService) RestoreResult(ctx context.Context, result *models.TaskResult) (*models.TaskResult, error) {
+	if result.ArchivedAt == nil {
+		return result, nil
+	}
+	if result.ArchiveKey == "" || s.objects == nil {
+		return nil, fmt.Errorf("%w: %s has no retrievable archive", ErrResultArchived, result.ID)
+	}
+
+	reader, err := s.objects.Get(ctx, result.ArchiveKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read result archive: %w", err)
+	}
+	defer reader.Close()
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read result archive: %w", err)
+	}
+	sum := sha256.Sum256(body)
+	if hex.EncodeToString(sum[:]) != result.ArchiveHash {
+		return nil, fmt.Errorf("result archive for %s does not match its hash", result.ID)
+	}
+
+	var archive models.ResultArchive
+	if err := json.Unmarshal(body, &archive); err != nil {
+		return nil, fmt.Errorf("failed to decode result archive: %w", err)
+	}
+
+	restored := *result
+	restored.Output = archive.Output
+	restored.Error = archive.Error
+	restored.ArchivedAt = nil
+	return &restored, nil
+}
+
+// Start runs a collection every interval until ctx is cancelled, honouring the
+// configured dry-run setting.
+func (s *RetentionService) Start(ctx context.Context) {
+	log := gologger.WithComponent("retention")
+
+	s.mu.RLock()
+	interval, dryRun := s.interval, s.dryRun
+	s.mu.RUnlock()
+
+	log.Info().
+		Dur("interval", interval).
+		Bool("dry_run", dryRun).
+		Msg("Starting retention collector")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("Retention collector stopped")
+			return
+		case <-ticker.C:
+			if _, err := s.Run(ctx, dryRun); err != nil && !errors.Is(err, ErrRetentionRunning) {
+				log.Error().Err(err).Msg("Retention run failed")
+			}
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/parity-server/internal/core/config"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/internal/utils"
+)
+
+type inMemoryRetentionRepo struct {
+	mu       sync.Mutex
+	policies map[string]*models.RetentionPolicy
+	images   []*models.ImageRetentionCandidate
+	results  []*models.ResultRetentionCandidate
+	archived map[uuid.UUID]bool
+	deleted  map[uuid.UUID]bool
+	payloads map[uuid.UUID]*models.ResultArchive
+	archives map[uuid.UUID]retentionArchivePointer
+	keys     map[string]bool
+}
+
+type retentionArchivePointer struct {
+	key  string
+	hash string
+}
+
+func newInMemoryRetentionRepo() *inMemoryRetentionRepo {
+	return &inMemoryRetentionRepo{
+		policies: make(map[string]*models.RetentionPolicy),
+		archived: make(map[uuid.UUID]bool),
+		deleted:  make(map[uuid.UUID]bool),
+		payloads: make(map[uuid.UUID]*models.ResultArchive),
+		archives: make(map[uuid.UUID]retentionArchivePointer),
+		keys:     make(map[string]bool),
+	}
+}
+
+func (r *inMemoryRetentionRepo) ListPolicies(ctx context.Context) ([]*models.RetentionPolicy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var policies []*models.RetentionPolicy
+	for _, policy := range r.policies {
+		cloned := *policy
+		policies = append(policies, &cloned)
+	}
+	return policies, nil
+}
+
+func (r *inMemoryRetentionRepo) SavePolicy(ctx context.Context, policy *models.RetentionPolicy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cloned := *policy
+	cloned.CreatorAddress = strings.ToLower(policy.CreatorAddress)
+	r.policies[cloned.CreatorAddress] = &cloned
+	return nil
+}
+
+func (r *inMemoryRetentionRepo) DeletePolicy(ctx context.Context, creatorAddress string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.policies, strings.ToLower(creatorAddress))
+	return nil
+}
+
+func (r *inMemoryRetentionRepo) ListIdleImages(ctx context.Context, usedBefore time.Time) ([]*models.ImageRetentionCandidate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var candidates []*models.ImageRetentionCandidate
+	for _, candidate := range r.images {
+		lastUsed := candidate.Image.CreatedAt
+		if candidate.Image.LastReferencedAt != nil {
+			lastUsed = *candidate.Image.LastReferencedAt
+		}
+		if lastUsed.Before(usedBefore) {
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates, nil
+}
+
+func (r *inMemoryRetentionRepo) DeleteIdleImage(ctx context.Context, digest string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, candidate := range r.images {
+		if candidate.Image.Digest == digest {
+			r.images = append(r.images[:i], r.images[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *inMemoryRetentionRepo) ObjectKeyInUse(ctx context.Context, key string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, candidate := range r.images {
+		if path.Base(candidate.Image.URL) == key {
+			return true, nil
+		}
+	}
+	return r.keys[key], nil
+}
+
+func (r *inMemoryRetentionRepo) ListFinishedResults(ctx context.Context, createdBefore time.Time, after *models.ResultRetentionCandidate, limit int) ([]*models.ResultRetentionCandidate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sort.Slice(r.results, func(i, j int) bool { return r.results[i].CreatedAt.Before(r.results[j].CreatedAt) })
+
+	var candidates []*models.ResultRetentionCandidate
+	for _, result := range r.results {
+		if r.archived[result.ResultID] || r.deleted[result.ResultID] || !result.CreatedAt.Before(createdBefore) {
+			continue
+		}
+		if after != nil && !result.CreatedAt.After(after.CreatedAt) {
+			continue
+		}
+		candidates = append(candidates, result)
+		if len(candidates) == limit {
+			break
+		}
+	}
+	return candidates, nil
+}
+
+func (r *inMemoryRetentionRepo) GetResultArchive(ctx context.Context, resultID uuid.UUID) (*models.ResultArchive, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if payload, ok := r.payloads[resultID]; ok {
+		cloned := *payload
+		return &cloned, nil
+	}
+	return &models.ResultArchive{}, nil
+}
+
+func (r *inMemoryRetentionRepo) ArchiveResult(ctx context.Context, resultID uuid.UUID, at time.Time, key, hash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.archived[resultID] = true
+	r.archives[resultID] = retentionArchivePointer{key: key, hash: hash}
+	delete(r.payloads, resultID)
+	return nil
+}
+
+func (r *inMemoryRetentionRepo) DeleteResult(ctx context.Context, resultID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.deleted[resultID] = true
+	return nil
+}
+
+func TestRetentionRunHonoursCreatorPoliciesAndDryRun(t *testing.T) {
+	ctx := context.Background()
+	repo := newInMemoryRetentionRepo()
+	storage := &countingStorage{}
+	service := NewRetentionService(repo, storage)
+	service.SetObjectStore(&memoryObjectStorage{})
+	service.SetConfig(config.RetentionConfig{ImageDays: 30, CompletedResultDays: 90, FailedResultDays: 30})
+
+	if err := service.SetPolicy(ctx, &models.RetentionPolicy{CreatorAddress: "0xKeeper", ImageDays: 365}); err != nil {
+		t.Fatalf("SetPolicy returned error: %v", err)
+	}
+	if err := service.SetPolicy(ctx, &models.RetentionPolicy{CreatorAddress: "0xPurger", ResultAction: models.ResultActionPurge}); err != nil {
+		t.Fatalf("SetPolicy returned error: %v", err)
+	}
+
+	now := time.Now()
+	daysAgo := func(days int) time.Time { return now.AddDate(0, 0, -days) }
+	stale := daysAgo(60)
+	repo.images = []*models.ImageRetentionCandidate{
+		{Image: &models.StoredImage{Digest: "sha256:stale", URL: "ipfs/stale", Size: 10, CreatedAt: daysAgo(100), LastReferencedAt: &stale}, Owners: []string{"0xCreator"}},
+		// Shared with a creator who keeps images for a year.
+		{Image: &models.StoredImage{Digest: "sha256:shared", URL: "ipfs/shared", Size: 20, CreatedAt: daysAgo(100)}, Owners: []string{"0xCreator", "0xkeeper"}},
+		{Image: &models.StoredImage{Digest: "sha256:recent", URL: "ipfs/recent", Size: 30, CreatedAt: daysAgo(5)}, Owners: []string{"0xCreator"}},
+	}
+
+	oldCompleted := &models.ResultRetentionCandidate{ResultID: uuid.New(), CreatorAddress: "0xCreator", TaskStatus: models.TaskStatusCompleted, CreatedAt: daysAgo(100)}
+	youngCompleted := &models.ResultRetentionCandidate{ResultID: uuid.New(), CreatorAddress: "0xCreator", TaskStatus: models.TaskStatusCompleted, CreatedAt: daysAgo(40)}
+	failed := &models.ResultRetentionCandidate{ResultID: uuid.New(), CreatorAddress: "0xPurger", TaskStatus: models.TaskStatusFailed, CreatedAt: daysAgo(45)}
+	repo.results = []*models.ResultRetentionCandidate{oldCompleted, youngCompleted, failed}
+
+	report, err := service.Run(ctx, true)
+	if err != nil {
+		t.Fatalf("dry run returned error: %v", err)
+	}
+	if len(report.Images) != 1 || report.Images[0].Digest != "sha256:stale" || report.ImageBytes != 10 {
+		t.Fatalf("expected the dry run to report only the stale image, got %+v", report.Images)
+	}
+	if report.ResultsArchived != 1 || report.ResultsPurged != 1 {
+		t.Fatalf("expected one archived and one purged result, got %+v", report)
+	}
+	if len(storage.unpinned) != 0 || len(repo.images) != 3 || len(repo.archived)+len(repo.deleted) != 0 {
+		t.Fatal("dry run must not remove anything")
+	}
+
+	if _, err := service.Run(ctx, false); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if len(storage.unpinned) != 1 || storage.unpinned[0] != "ipfs/stale" || len(repo.images) != 2 {
+		t.Fatalf("expected only the stale image to be unpinned, got %v", storage.unpinned)
+	}
+	if !repo.archived[oldCompleted.ResultID] || !repo.deleted[failed.ResultID] || repo.archived[youngCompleted.ResultID] {
+		t.Fatalf("unexpected result collection: archived %v, deleted %v", repo.archived, repo.deleted)
+	}
+}
+
+func TestRetentionKeepsImageObjectSharedWithAnotherImage(t *testing.T) {
+	ctx := context.Background()
+	repo := newInMemoryRetentionRepo()
+	storage := &countingStorage{}
+	service := NewRetentionService(repo, storage)
+	service.SetConfig(config.RetentionConfig{ImageDays: 30})
+
+	old := time.Now().AddDate(0, 0, -60)
+	repo.images = []*models.ImageRetentionCandidate{
+		{Image: &models.StoredImage{Digest: "sha256:old", URL: "ipfs/shared", Size: 10, CreatedAt: old, LastReferencedAt: &old}, Owners: []string{"0xCreator"}},
+		{Image: &models.StoredImage{Digest: "sha256:new", URL: "ipfs/shared", Size: 10, CreatedAt: time.Now()}, Owners: []string{"0xOther"}},
+		{Image: &models.StoredImage{Digest: "sha256:artifact", URL: "ipfs/artifact", Size: 10, CreatedAt: old, LastReferencedAt: &old}, Owners: []string{"0xCreator"}},
+	}
+	repo.keys["artifact"] = true
+
+	report, err := service.Run(ctx, false)
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if len(report.Images) != 2 || len(repo.images) != 1 {
+		t.Fatalf("expected both idle image rows to be collected, got %+v", report.Images)
+	}
+	if len(storage.unpinned) != 0 {
+		t.Fatalf("objects still referenced elsewhere must not be unpinned, got %v", storage.unpinned)
+	}
+}
+
+func TestRetentionArchivesResultPayloadToObjectStorage(t *testing.T) {
+	ctx := context.Background()
+	repo := newInMemoryRetentionRepo()
+	objects := &memoryObjectStorage{}
+	service := NewRetentionService(repo, &countingStorage{})
+	service.SetObjectStore(objects)
+	service.SetConfig(config.RetentionConfig{CompletedResultDays: 90, FailedResultDays: 30})
+
+	result := &models.TaskResult{
+		ID:        uuid.New(),
+		TaskID:    uuid.New(),
+		Output:    "hello",
+		Error:     "warning",
+		ExitCode:  0,
+		CreatedAt: time.Now().AddDate(0, 0, -100),
+	}
+	result.ResultHash = utils.ComputeResultHash(result.Output, result.Error, result.ExitCode, nil)
+	repo.results = []*models.ResultRetentionCandidate{{
+		ResultID:       result.ID,
+		TaskID:         result.TaskID,
+		CreatorAddress: "0xCreator",
+		TaskStatus:     models.TaskStatusCompleted,
+		CreatedAt:      result.CreatedAt,
+	}}
+	repo.payloads[result.ID] = &models.ResultArchive{Output: result.Output, Error: result.Error}
+
+	report, err := service.Run(ctx, false)
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if report.ResultsArchived != 1 || len(report.Errors) != 0 {
+		t.Fatalf("expected one archived result, got %+v", report)
+	}
+
+	pointer := repo.archives[result.ID]
+	archivedAt := report.StartedAt
+	archived := *result
+	archived.Output, archived.Error = "", ""
+	archived.ArchivedAt = &archivedAt
+	archived.ArchiveKey, archived.ArchiveHash = pointer.key, pointer.hash
+
+	consensus := NewConsensusService(nil, nil)
+	if err := consensus.ValidateResultIntegrity(&archived); !errors.Is(err, ErrResultArchived) {
+		t.Fatalf("expected archived result to be excluded from integrity checks, got %v", err)
+	}
+
+	restored, err := service.RestoreResult(ctx, &archived)
+	if err != nil {
+		t.Fatalf("RestoreResult returned error: %v", err)
+	}
+	if restored.Output != "hello" || restored.Error != "warning" {
+		t.Fatalf("expected the archived payload back, got %+v", restored)
+	}
+	if err := consensus.ValidateResultIntegrity(restored); err != nil {
+		t.Fatalf("expected the restored result to validate, got %v", err)
+	}
+
+	objects.objects[pointer.key] = []byte(`{"output":"tampered"}`)
+	if _, err := service.RestoreResult(ctx, &archived); err == nil {
+		t.Fatal("expected a tampered archive to be rejected")
+	}
+}
+
+func TestRetentionSetPolicyRejectsUnknownAction(t *testing.T) {
+	service := NewRetentionService(newInMemoryRetentionRepo(), &countingStorage{})
+
+	err := service.SetPolicy(context.Background(), &models.RetentionPolicy{CreatorAddress: "0xCreator", ResultAction: "shred"})
+	if !errors.Is(err, ErrInvalidRetentionPolicy) {
+		t.Fatalf("expected ErrInvalidRetentionPolicy, got %v", err)
+	}
+}
@@ -0,0 +1,289 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+var (
+	ErrTelemetryUnavailable   = errors.New("runner telemetry is not enabled")
+	ErrRunnerCacheUnavailable = errors.New("cache-aware scheduling is not enabled")
+	ErrInvalidWalletAddress   = errors.New("invalid wallet address")
+)
+
+// RunnerAdminService backs the operator-facing runner inventory: listing,
+// inspection and forcibly taking runners or their work out of rotation.
+type RunnerAdminService struct {
+	runnerService     *RunnerService
+	taskService       *TaskService
+	llmService        *LLMService
+	reputationService *ReputationService
+	telemetryService  *TelemetryService
+}
+
+func NewRunnerAdminService(runnerService *RunnerService, taskService *TaskService) *RunnerAdminService {
+	return &RunnerAdminService{
+		runnerService: runnerService,
+		taskService:   taskService,
+	}
+}
+
+func (s *RunnerAdminService) SetLLMService(llmService *LLMService) {
+	s.llmService = llmService
+}
+
+func (s *RunnerAdminService) SetReputationService(reputationService *ReputationService) {
+	s.reputationService = reputationService
+}
+
+func (s *RunnerAdminService) SetTelemetryService(telemetryService *TelemetryService) {
+	s.telemetryService = telemetryService
+}
+
+func (s *RunnerAdminService) ListRunners(ctx context.Context, filter models.RunnerFilter) ([]*models.Runner, error) {
+	return s.runnerService.repo.List(ctx, filter)
+}
+
+// GetRunnerDetails returns the runner with the tasks and prompts it currently
+// holds. Reputation is best effort and omitted when it cannot be loaded.
+func (s *RunnerAdminService) GetRunnerDetails(ctx context.Context, deviceID string) (*models.RunnerDetails, error) {
+	log := gologger.WithComponent("runner_admin")
+
+	runner, err := s.runnerService.GetRunner(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	details := &models.RunnerDetails{
+		Runner:  runner,
+		Tasks:   []*models.Task{},
+		Prompts: []*models.PromptRequest{},
+	}
+
+	for _, assignment := range runner.Assignments {
+		if assignment.Kind == models.AssignmentKindPrompt {
+			if s.llmService == nil {
+				continue
+			}
+			prompt, err := s.llmService.GetPrompt(ctx, assignment.WorkID)
+			if err != nil {
+				log.Warn().Err(err).Str("prompt_id", assignment.WorkID.String()).Msg("Failed to load assigned prompt")
+				continue
+			}
+			details.Prompts = append(details.Prompts, prompt)
+			continue
+		}
+
+		task, err := s.taskService.GetTask(ctx, assignment.WorkID.String())
+		if err != nil {
+			log.Warn().Err(err).Str("task_id", assignment.WorkID.String()).Msg("Failed to load assigned task")
+			continue
+		}
+		details.Tasks = append(details.Tasks, task)
+	}
+
+	if s.reputationService != nil {
+		reputation, err := s.reputationService.GetRunnerReputation(ctx, deviceID)
+		if err != nil {
+			log.Debug().Err(err).Str("device_id", deviceID).Msg("Runner reputation unavailable")
+		} else {
+			details.Reputation = reputation
+		}
+	}
+
+	return details, nil
+}
+
+// ForceOffline marks the runner offline and drops its socket. Work it holds is
+// left in place for the stall monitor or an explicit requeue. The runner's
+// heartbeats are refused until RestoreRunner is called.
+func (s *RunnerAdminService) ForceOffline(ctx context.Context, deviceID string) (*models.Runner, error) {
+	runner, err := s.runnerService.repo.SetForcedOffline(ctx, deviceID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.runnerService.runnerHub != nil {
+		s.runnerService.runnerHub.DisconnectDevice(deviceID)
+	}
+
+	log := gologger.WithComponent("runner_admin")
+	log.Info().Str("device_id", deviceID).Msg("Runner forced offline")
+
+	return runner, nil
+}
+
+// RestoreRunner lifts a forced offline. The runner stays offline until its next
+// heartbeat or connection.
+func (s *RunnerAdminService) RestoreRunner(ctx context.Context, deviceID string) (*models.Runner, error) {
+	runner, err := s.runnerService.repo.SetForcedOffline(ctx, deviceID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	log := gologger.WithComponent("runner_admin")
+	log.Info().Str("device_id", deviceID).Msg("Runner restored")
+
+	return runner, nil
+}
+
+// BindWallet sets the wallet a registered runner must log in with. Runners
+// registered before login existed have none and cannot log in until bound.
+func (s *RunnerAdminService) BindWallet(ctx context.Context, deviceID string, walletAddress string) (*models.Runner, error) {
+	if !common.IsHexAddress(walletAddress) {
+		return nil, ErrInvalidWalletAddress
+	}
+
+	runner, err := s.runnerService.repo.BindWallet(ctx, deviceID, common.HexToAddress(walletAddress).Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	log := gologger.WithComponent("runner_admin")
+	log.Info().
+		Str("device_id", deviceID).
+		Str("wallet_address", runner.WalletAddress).
+		Msg("Runner wallet bound")
+
+	return runner, nil
+}
+
+// RequeueWork returns every task and prompt the runner holds to the queue.
+func (s *RunnerAdminService) RequeueWork(ctx context.Context, deviceID string) (*models.RequeueResult, error) {
+	log := gologger.WithComponent("runner_admin")
+
+	runner, err := s.runnerService.GetRunner(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.RequeueResult{}
+	for _, assignment := range runner.Assignments {
+		if assignment.Kind == models.AssignmentKindPrompt {
+			if s.llmService == nil {
+				if err := s.runnerService.ReleaseSlot(ctx, deviceID, assignment.WorkID); err != nil {
+					return result, fmt.Errorf("failed to release prompt slot: %w", err)
+				}
+				continue
+			}
+			requeued, err := s.llmService.RequeuePrompt(ctx, assignment.WorkID)
+			if err != nil {
+				return result, fmt.Errorf("failed to requeue prompt %s: %w", assignment.WorkID, err)
+			}
+			if requeued {
+				result.Prompts++
+			}
+			continue
+		}
+
+		requeued, err := s.taskService.RequeueTask(ctx, assignment.WorkID, deviceID, "task was requeued by an operator")
+		if err != nil {
+			return result, fmt.Errorf("failed to requeue task %s: %w", assignment.WorkID, err)
+		}
+		if requeued {
+			result.Tasks++
+		}
+	}
+
+	log.Info().
+		Str("device_id", deviceID).
+		Int("tasks", result.Tasks).
+		Int("prompts", result.Prompts).
+		Msg("Runner work requeued")
+
+	return result, nil
+}
+
+// DeregisterRunner requeues the runner's work, removes its webhooks and
+// deletes it. The device has to register again before it receives work.
+func (s *RunnerAdminService) DeregisterRunner(ctx context.Context, deviceID string) (*models.RequeueResult, error) {
+	log := gologger.WithComponent("runner_admin")
+
+	if _, err := s.runnerService.repo.UpdateDrainState(ctx, deviceID, models.RunnerStatusOffline, nil); err != nil {
+		return nil, err
+	}
+
+	result, err := s.RequeueWork(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.runnerService.webhookService != nil {
+		if err := s.runnerService.webhookService.UnregisterDeviceWebhooks(ctx, deviceID); err != nil {
+			return nil, fmt.Errorf("failed to remove runner webhooks: %w", err)
+		}
+	}
+
+	if s.runnerService.cacheService != nil {
+		if err := s.runnerService.cacheService.Forget(ctx, deviceID); err != nil {
+			return nil, fmt.Errorf("failed to remove runner cache report: %w", err)
+		}
+	}
+
+	if s.runnerService.runnerHub != nil {
+		s.runnerService.runnerHub.DisconnectDevice(deviceID)
+	}
+
+	if err := s.runnerService.repo.Delete(ctx, deviceID); err != nil {
+		return nil, err
+	}
+
+	log.Info().Str("device_id", deviceID).Msg("Runner deregistered")
+
+	return result, nil
+}
+
+// VersionDistribution shows which protocol and software versions the fleet
+// runs.
+func (s *RunnerAdminService) VersionDistribution(ctx context.Context) (*models.RunnerVersionDistribution, error) {
+	return s.runnerService.VersionDistribution(ctx)
+}
+
+// CacheMetrics returns cache-hit rates for task assignment and the size of the
+// runner cache index.
+func (s *RunnerAdminService) CacheMetrics() (*models.RunnerCacheMetrics, error) {
+	if s.runnerService.cacheService == nil {
+		return nil, ErrRunnerCacheUnavailable
+	}
+	return s.runnerService.cacheService.Metrics(), nil
+}
+
+// RunnerCache returns the images and models the runner last reported holding.
+// A runner with no current report gets an empty cache.
+func (s *RunnerAdminService) RunnerCache(ctx context.Context, deviceID string) (*models.RunnerCache, error) {
+	if s.runnerService.cacheService == nil {
+		return nil, ErrRunnerCacheUnavailable
+	}
+	if _, err := s.runnerService.GetRunner(ctx, deviceID); err != nil {
+		return nil, err
+	}
+	if cache := s.runnerService.cacheService.Get(deviceID); cache != nil {
+		return cache, nil
+	}
+	return &models.RunnerCache{DeviceID: deviceID, Images: models.CacheKeys{}, Models: models.CacheKeys{}}, nil
+}
+
+// RunnerTelemetry returns the runner's utilization history in [since, until).
+func (s *RunnerAdminService) RunnerTelemetry(ctx context.Context, deviceID string, since, until time.Time) ([]*models.RunnerTelemetrySample, error) {
+	if s.telemetryService == nil {
+		return nil, ErrTelemetryUnavailable
+	}
+	return s.telemetryService.History(ctx, deviceID, since, until)
+}
+
+// RunnerHealth returns uptime and heartbeat jitter over the window ending now.
+func (s *RunnerAdminService) RunnerHealth(ctx context.Context, deviceID string, window time.Duration) (*models.RunnerHealth, error) {
+	if s.telemetryService == nil {
+		return nil, ErrTelemetryUnavailable
+	}
+	if _, err := s.runnerService.GetRunner(ctx, deviceID); err != nil {
+		return nil, err
+	}
+	return s.telemetryService.Health(ctx, deviceID, window)
+}
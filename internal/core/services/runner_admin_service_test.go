@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+func TestRequeueWorkReturnsRunnerTasksToQueue(t *testing.T) {
+	ctx := context.Background()
+	taskRepo := newInMemoryTaskRepo()
+	runnerRepo := newInMemoryRunnerRepo()
+	runnerService := NewRunnerService(runnerRepo)
+	taskService := NewTaskService(taskRepo, nil, runnerService)
+	adminService := NewRunnerAdminService(runnerService, taskService)
+
+	running := models.NewTask()
+	running.Status = models.TaskStatusRunning
+	running.RunnerID = "runner-1"
+	taskRepo.tasks[running.ID] = cloneTask(running)
+
+	finished := models.NewTask()
+	finished.Status = models.TaskStatusCompleted
+	finished.RunnerID = "runner-1"
+	taskRepo.tasks[finished.ID] = cloneTask(finished)
+
+	runnerRepo.runners["runner-1"] = &models.Runner{
+		DeviceID:     "runner-1",
+		Status:       models.RunnerStatusOnline,
+		DeliveryMode: models.DeliveryModePull,
+		Slots:        2,
+		Assignments: []models.RunnerAssignment{
+			*models.NewRunnerAssignment("runner-1", running.ID, models.AssignmentKindTask),
+			*models.NewRunnerAssignment("runner-1", finished.ID, models.AssignmentKindTask),
+		},
+	}
+
+	result, err := adminService.RequeueWork(ctx, "runner-1")
+	if err != nil {
+		t.Fatalf("RequeueWork returned error: %v", err)
+	}
+	if result.Tasks != 1 || result.Prompts != 0 {
+		t.Fatalf("expected one requeued task, got %+v", result)
+	}
+
+	stored, _ := taskRepo.Get(ctx, running.ID)
+	if stored.Status != models.TaskStatusPending || stored.RunnerID != "" {
+		t.Fatalf("expected running task to be pending and unassigned, got status %q runner %q", stored.Status, stored.RunnerID)
+	}
+	if stored, _ := taskRepo.Get(ctx, finished.ID); stored.Status != models.TaskStatusCompleted {
+		t.Fatalf("expected completed task to be left alone, got %q", stored.Status)
+	}
+
+	runner, _ := runnerRepo.Get(ctx, "runner-1")
+	if len(runner.Assignments) != 0 {
+		t.Fatalf("expected every slot to be released, got %d assignments", len(runner.Assignments))
+	}
+}
+
+func TestForceOfflineAndDeregisterRunner(t *testing.T) {
+	ctx := context.Background()
+	taskRepo := newInMemoryTaskRepo()
+	runnerRepo := newInMemoryRunnerRepo()
+	runnerService := NewRunnerService(runnerRepo)
+	taskService := NewTaskService(taskRepo, nil, runnerService)
+	adminService := NewRunnerAdminService(runnerService, taskService)
+
+	task := models.NewTask()
+	task.Status = models.TaskStatusRunning
+	task.RunnerID = "runner-1"
+	taskRepo.tasks[task.ID] = cloneTask(task)
+
+	runnerRepo.runners["runner-1"] = &models.Runner{
+		DeviceID:    "runner-1",
+		Status:      models.RunnerStatusOnline,
+		Assignments: []models.RunnerAssignment{*models.NewRunnerAssignment("runner-1", task.ID, models.AssignmentKindTask)},
+	}
+
+	runner, err := adminService.ForceOffline(ctx, "runner-1")
+	if err != nil {
+		t.Fatalf("ForceOffline returned error: %v", err)
+	}
+	if runner.Status != models.RunnerStatusOffline || len(runner.Assignments) != 1 {
+		t.Fatalf("expected offline runner that still holds its task, got %+v", runner)
+	}
+
+	result, err := adminService.DeregisterRunner(ctx, "runner-1")
+	if err != nil {
+		t.Fatalf("DeregisterRunner returned error: %v", err)
+	}
+	if result.Tasks != 1 {
+		t.Fatalf("expected the held task to be requeued, got %+v", result)
+	}
+	if _, err := runnerRepo.Get(ctx, "runner-1"); !errors.Is(err, ErrRunnerNotFound) {
+		t.Fatalf("expected runner to be deleted, got %v", err)
+	}
+	if stored, _ := taskRepo.Get(ctx, task.ID); stored.Status != models.TaskStatusPending {
+		t.Fatalf("expected task to be pending after deregistration, got %q", stored.Status)
+	}
+
+	if _, err := adminService.DeregisterRunner(ctx, "runner-1"); !errors.Is(err, ErrRunnerNotFound) {
+		t.Fatalf("expected not found for unknown runner, got %v", err)
+	}
+}
+
+func TestForcedOfflineRunnerStaysOfflineUntilRestored(t *testing.T) {
+	ctx := context.Background()
+	runnerRepo := newInMemoryRunnerRepo()
+	runnerService := NewRunnerService(runnerRepo)
+	adminService := NewRunnerAdminService(runnerService, nil)
+
+	runnerRepo.runners["runner-1"] = &models.Runner{
+		DeviceID: "runner-1",
+		Status:   models.RunnerStatusOnline,
+	}
+
+	if _, err := adminService.ForceOffline(ctx, "runner-1"); err != nil {
+		t.Fatalf("ForceOffline returned error: %v", err)
+	}
+
+	heartbeat := &models.Runner{DeviceID: "runner-1", Status: models.RunnerStatusOnline}
+	if _, err := runnerService.RecordHeartbeat(ctx, heartbeat, models.HeartbeatTelemetry{}); !errors.Is(err, ErrRunnerForcedOffline) {
+		t.Fatalf("expected the heartbeat to be refused, got %v", err)
+	}
+	if _, err := runnerService.UndrainRunner(ctx, "runner-1"); !errors.Is(err, ErrRunnerForcedOffline) {
+		t.Fatalf("expected undrain to be refused, got %v", err)
+	}
+	if stored, _ := runnerRepo.Get(ctx, "runner-1"); stored.Status != models.RunnerStatusOffline {
+		t.Fatalf("expected runner to stay offline, got %q", stored.Status)
+	}
+
+	if _, err := adminService.RestoreRunner(ctx, "runner-1"); err != nil {
+		t.Fatalf("RestoreRunner returned error: %v", err)
+	}
+	runner, err := runnerService.RecordHeartbeat(ctx, heartbeat, models.HeartbeatTelemetry{})
+	if err != nil {
+		t.Fatalf("RecordHeartbeat returned error: %v", err)
+	}
+	if runner.Status != models.RunnerStatusOnline {
+		t.Fatalf("expected restored runner to come back online, got %q", runner.Status)
+	}
+}
+
+func TestListRunnersFiltersByLabels(t *testing.T) {
+	ctx := context.Background()
+	runnerRepo := newInMemoryRunnerRepo()
+	runnerService := NewRunnerService(runnerRepo)
+	adminService := NewRunnerAdminService(runnerService, NewTaskService(newInMemoryTaskRepo(), nil, runnerService))
+
+	runnerRepo.runners["runner-eu"] = &models.Runner{
+		DeviceID: "runner-eu",
+		Status:   models.RunnerStatusOnline,
+		Labels:   models.RunnerLabels{"region": "eu", "gpu": "a100"},
+	}
+	runnerRepo.runners["runner-us"] = &models.Runner{
+		DeviceID: "runner-us",
+		Status:   models.RunnerStatusOnline,
+		Labels:   models.RunnerLabels{"region": "us"},
+	}
+
+	runners, err := adminService.ListRunners(ctx, models.RunnerFilter{Labels: map[string]string{"region": "eu"}})
+	if err != nil {
+		t.Fatalf("ListRunners returned error: %v", err)
+	}
+	if len(runners) != 1 || runners[0].DeviceID != "runner-eu" {
+		t.Fatalf("expected only runner-eu, got %+v", runners)
+	}
+}
+
+func TestForcedOfflineRunnerStaysOfflineAfterPostingResult(t *testing.T) {
+	ctx := context.Background()
+	taskRepo := newInMemoryTaskRepo()
+	runnerRepo := newInMemoryRunnerRepo()
+	runnerService := NewRunnerService(runnerRepo)
+	taskService := NewTaskService(taskRepo, nil, runnerService)
+	adminService := NewRunnerAdminService(runnerService, taskService)
+
+	task := models.NewTask()
+	task.Status = models.TaskStatusRunning
+	task.RunnerID = "runner-1"
+	taskRepo.tasks[task.ID] = cloneTask(task)
+
+	runnerRepo.runners["runner-1"] = &models.Runner{
+		DeviceID:    "runner-1",
+		Status:      models.RunnerStatusOnline,
+		Slots:       1,
+		Assignments: []models.RunnerAssignment{*models.NewRunnerAssignment("runner-1", task.ID, models.AssignmentKindTask)},
+	}
+
+	if _, err := adminService.ForceOffline(ctx, "runner-1"); err != nil {
+		t.Fatalf("ForceOffline returned error: %v", err)
+	}
+
+	result := &models.TaskResult{ID: uuid.New(), TaskID: task.ID, DeviceID: "runner-1"}
+	if err := taskService.SaveTaskResult(ctx, result); err != nil {
+		t.Fatalf("SaveTaskResult returned error: %v", err)
+	}
+
+	if stored, _ := runnerRepo.Get(ctx, "runner-1"); stored.Status != models.RunnerStatusOffline || !stored.ForcedOffline {
+		t.Fatalf("expected runner to stay forced offline, got status %q forced %v", stored.Status, stored.ForcedOffline)
+	}
+	online, err := runnerRepo.GetOnlineRunners(ctx)
+	if err != nil {
+		t.Fatalf("GetOnlineRunners returned error: %v", err)
+	}
+	if len(online) != 0 {
+		t.Fatalf("expected no online runners, got %d", len(online))
+	}
+}
@@ -0,0 +1,290 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/core/config"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/internal/core/ports"
+	"github.com/theblitlabs/parity-server/internal/database/repositories"
+	"github.com/theblitlabs/parity-server/internal/utils"
+)
+
+var (
+	ErrInvalidChallenge  = errors.New("invalid or expired auth challenge")
+	ErrInvalidSignature  = errors.New("invalid wallet signature")
+	ErrWalletMismatch    = errors.New("wallet address does not match registered runner")
+	ErrWalletNotBound    = errors.New("runner has no bound wallet; an operator must bind one before it can log in")
+	ErrInvalidRunnerAuth = errors.New("invalid or expired runner session")
+)
+
+const (
+	defaultChallengeTTL = 5 * time.Minute
+	defaultSessionTTL   = time.Hour
+	defaultRefreshTTL   = 7 * 24 * time.Hour
+)
+
+type RunnerAuthService struct {
+	repo          ports.RunnerAuthRepository
+	runnerService *RunnerService
+	challengeTTL  time.Duration
+	sessionTTL    time.Duration
+	refreshTTL    time.Duration
+	now           func() time.Time
+}
+
+func NewRunnerAuthService(repo ports.RunnerAuthRepository, runnerService *RunnerService, cfg config.AuthConfig) *RunnerAuthService {
+	s := &RunnerAuthService{
+		repo:          repo,
+		runnerService: runnerService,
+		challengeTTL:  defaultChallengeTTL,
+		sessionTTL:    defaultSessionTTL,
+		refreshTTL:    defaultRefreshTTL,
+		now:           time.Now,
+	}
+
+	if cfg.ChallengeTTL > 0 {
+		s.challengeTTL = time.Duration(cfg.ChallengeTTL) * time.Second
+	}
+	if cfg.SessionTTL > 0 {
+		s.sessionTTL = time.Duration(cfg.SessionTTL) * time.Second
+	}
+	if cfg.RefreshTTL > 0 {
+		s.refreshTTL = time.Duration(cfg.RefreshTTL) * time.Second
+	}
+
+	return s
+}
+
+// CreateChallenge issues a login message for the device to sign. A device that is
+// already registered must sign with the wallet it registered with. An unknown
+// device is claimed by the first wallet that logs in for it; a registered
+// device without a wallet is refused until an operator binds one, so it cannot
+// be taken over that way.
+func (s *RunnerAuthService) CreateChallenge(ctx context.Context, deviceID string, walletAddress string) (*models.RunnerAuthChallenge, error) {
+	if deviceID == "" {
+		return nil, errors.New("device ID is required")
+	}
+	if !common.IsHexAddress(walletAddress) {
+		return nil, errors.New("invalid wallet address")
+	}
+
+	runner, err := s.runnerService.GetRunner(ctx, deviceID)
+	if err != nil && !errors.Is(err, repositories.ErrRunnerNotFound) {
+		return nil, fmt.Errorf("failed to look up runner: %w", err)
+	}
+	if err == nil {
+		if runner.WalletAddress == "" {
+			return nil, ErrWalletNotBound
+		}
+		if !strings.EqualFold(runner.WalletAddress, walletAddress) {
+			return nil, ErrWalletMismatch
+		}
+	}
+
+	nonce, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	challenge := &models.RunnerAuthChallenge{
+		ID:            uuid.New(),
+		DeviceID:      deviceID,
+		WalletAddress: common.HexToAddress(walletAddress).Hex(),
+		ExpiresAt:     now.Add(s.challengeTTL),
+		CreatedAt:     now,
+	}
+	challenge.Message = fmt.Sprintf(
+		"Parity runner login\nDevice: %s\nWallet: %s\nChallenge: %s\nNonce: %s\nExpires: %s",
+		challenge.DeviceID,
+		challenge.WalletAddress,
+		challenge.ID,
+		nonce,
+		challenge.ExpiresAt.UTC().Format(time.RFC3339),
+	)
+
+	if err := s.repo.CreateChallenge(ctx, challenge); err != nil {
+		return nil, fmt.Errorf("failed to store auth challenge: %w", err)
+	}
+
+	return challenge, nil
+}
+
+// Login checks the signature over a challenge and opens a session for its device.
+func (s *RunnerAuthService) Login(ctx context.Context, challengeID string, signature string) (*models.RunnerSessionTokens, error) {
+	log := gologger.WithComponent("runner_auth")
+
+	id, err := uuid.Parse(challengeID)
+	if err != nil {
+		return nil, ErrInvalidChallenge
+	}
+
+	challenge, err := s.repo.GetChallenge(ctx, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrAuthChallengeNotFound) {
+			return nil, ErrInvalidChallenge
+		}
+		return nil, err
+	}
+	if challenge.UsedAt != nil || s.now().After(challenge.ExpiresAt) {
+		return nil, ErrInvalidChallenge
+	}
+
+	if err := utils.VerifyPersonalSignature(challenge.WalletAddress, challenge.Message, signature); err != nil {
+		log.Warn().Err(err).
+			Str("device_id", challenge.DeviceID).
+			Msg("Runner login signature rejected")
+		return nil, ErrInvalidSignature
+	}
+
+	consumed, err := s.repo.MarkChallengeUsed(ctx, challenge.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume auth challenge: %w", err)
+	}
+	if !consumed {
+		return nil, ErrInvalidChallenge
+	}
+
+	now := s.now()
+	session := &models.RunnerSession{
+		ID:            uuid.New(),
+		DeviceID:      challenge.DeviceID,
+		WalletAddress: challenge.WalletAddress,
+		CreatedAt:     now,
+	}
+
+	tokens, err := s.issueTokens(session, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateSession(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to store runner session: %w", err)
+	}
+
+	log.Info().
+		Str("device_id", session.DeviceID).
+		Str("wallet_address", session.WalletAddress).
+		Msg("Runner authenticated")
+
+	return tokens, nil
+}
+
+// Refresh rotates both tokens of a live session. The old pair stops working.
+func (s *RunnerAuthService) Refresh(ctx context.Context, refreshToken string) (*models.RunnerSessionTokens, error) {
+	if refreshToken == "" {
+		return nil, ErrInvalidRunnerAuth
+	}
+
+	session, err := s.repo.GetSessionByRefreshTokenHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, repositories.ErrRunnerSessionNotFound) {
+			return nil, ErrInvalidRunnerAuth
+		}
+		return nil, err
+	}
+
+	now := s.now()
+	if session.RevokedAt != nil || now.After(session.RefreshExpiresAt) {
+		return nil, ErrInvalidRunnerAuth
+	}
+
+	tokens, err := s.issueTokens(session, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateSession(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to rotate runner session: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// Authenticate resolves an access token to its session.
+func (s *RunnerAuthService) Authenticate(ctx context.Context, accessToken string) (*models.RunnerSession, error) {
+	if accessToken == "" {
+		return nil, ErrInvalidRunnerAuth
+	}
+
+	session, err := s.repo.GetSessionByTokenHash(ctx, hashToken(accessToken))
+	if err != nil {
+		if errors.Is(err, repositories.ErrRunnerSessionNotFound) {
+			return nil, ErrInvalidRunnerAuth
+		}
+		return nil, err
+	}
+
+	if session.RevokedAt != nil || s.now().After(session.ExpiresAt) {
+		return nil, ErrInvalidRunnerAuth
+	}
+
+	return session, nil
+}
+
+// Revoke ends the session behind the access token.
+func (s *RunnerAuthService) Revoke(ctx context.Context, accessToken string) error {
+	session, err := s.Authenticate(ctx, accessToken)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	session.RevokedAt = &now
+	session.UpdatedAt = now
+	return s.repo.UpdateSession(ctx, session)
+}
+
+// RevokeDevice ends every open session of a device.
+func (s *RunnerAuthService) RevokeDevice(ctx context.Context, deviceID string) (int64, error) {
+	return s.repo.RevokeSessionsByDevice(ctx, deviceID)
+}
+
+func (s *RunnerAuthService) issueTokens(session *models.RunnerSession, now time.Time) (*models.RunnerSessionTokens, error) {
+	accessToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session.TokenHash = hashToken(accessToken)
+	session.RefreshTokenHash = hashToken(refreshToken)
+	session.ExpiresAt = now.Add(s.sessionTTL)
+	session.RefreshExpiresAt = now.Add(s.refreshTTL)
+	session.UpdatedAt = now
+
+	return &models.RunnerSessionTokens{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		ExpiresAt:        session.ExpiresAt,
+		RefreshExpiresAt: session.RefreshExpiresAt,
+		DeviceID:         session.DeviceID,
+	}, nil
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
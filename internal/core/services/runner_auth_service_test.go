@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"github.com/theblitlabs/parity-server/internal/core/config"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/internal/database/repositories"
+)
+
+type inMemoryRunnerAuthRepo struct {
+	challenges map[uuid.UUID]*models.RunnerAuthChallenge
+	sessions   map[uuid.UUID]*models.RunnerSession
+}
+
+func newInMemoryRunnerAuthRepo() *inMemoryRunnerAuthRepo {
+	return &inMemoryRunnerAuthRepo{
+		challenges: make(map[uuid.UUID]*models.RunnerAuthChallenge),
+		sessions:   make(map[uuid.UUID]*models.RunnerSession),
+	}
+}
+
+func (r *inMemoryRunnerAuthRepo) CreateChallenge(ctx context.Context, challenge *models.RunnerAuthChallenge) error {
+	cloned := *challenge
+	r.challenges[challenge.ID] = &cloned
+	return nil
+}
+
+func (r *inMemoryRunnerAuthRepo) GetChallenge(ctx context.Context, id uuid.UUID) (*models.RunnerAuthChallenge, error) {
+	challenge, ok := r.challenges[id]
+	if !ok {
+		return nil, repositories.ErrAuthChallengeNotFound
+	}
+	cloned := *challenge
+	return &cloned, nil
+}
+
+func (r *inMemoryRunnerAuthRepo) MarkChallengeUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	challenge, ok := r.challenges[id]
+	if !ok || challenge.UsedAt != nil {
+		return false, nil
+	}
+	now := challenge.CreatedAt
+	challenge.UsedAt = &now
+	return true, nil
+}
+
+func (r *inMemoryRunnerAuthRepo) CreateSession(ctx context.Context, session *models.RunnerSession) error {
+	cloned := *session
+	r.sessions[session.ID] = &cloned
+	return nil
+}
+
+func (r *inMemoryRunnerAuthRepo) GetSessionByTokenHash(ctx context.Context, tokenHash string) (*models.RunnerSession, error) {
+	for _, session := range r.sessions {
+		if session.TokenHash == tokenHash {
+			cloned := *session
+			return &cloned, nil
+		}
+	}
+	return nil, repositories.ErrRunnerSessionNotFound
+}
+
+func (r *inMemoryRunnerAuthRepo) GetSessionByRefreshTokenHash(ctx context.Context, refreshTokenHash string) (*models.RunnerSession, error) {
+	for _, session := range r.sessions {
+		if session.RefreshTokenHash == refreshTokenHash {
+			cloned := *session
+			return &cloned, nil
+		}
+	}
+	return nil, repositories.ErrRunnerSessionNotFound
+}
+
+func (r *inMemoryRunnerAuthRepo) UpdateSession(ctx context.Context, session *models.RunnerSession) error {
+	cloned := *session
+	r.sessions[session.ID] = &cloned
+	return nil
+}
+
+func (r *inMemoryRunnerAuthRepo) RevokeSessionsByDevice(ctx context.Context, deviceID string) (int64, error) {
+	var revoked int64
+	for _, session := range r.sessions {
+		if session.DeviceID == deviceID && session.RevokedAt == nil {
+			now := session.CreatedAt
+			session.RevokedAt = &now
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+func TestRunnerAuthLoginRefreshAndRevoke(t *testing.T) {
+	ctx := context.Background()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	wallet := crypto.PubkeyToAddress(key.PublicKey).Hex()
+
+	runnerRepo := newInMemoryRunnerRepo()
+	if err := runnerRepo.Create(ctx, &models.Runner{DeviceID: "device-1", WalletAddress: wallet, Status: models.RunnerStatusOnline}); err != nil {
+		t.Fatalf("failed to seed runner: %v", err)
+	}
+	authService := NewRunnerAuthService(newInMemoryRunnerAuthRepo(), NewRunnerService(runnerRepo), config.AuthConfig{})
+
+	otherKey, _ := crypto.GenerateKey()
+	if _, err := authService.CreateChallenge(ctx, "device-1", crypto.PubkeyToAddress(otherKey.PublicKey).Hex()); !errors.Is(err, ErrWalletMismatch) {
+		t.Fatalf("expected ErrWalletMismatch for a foreign wallet, got %v", err)
+	}
+
+	challenge, err := authService.CreateChallenge(ctx, "device-1", wallet)
+	if err != nil {
+		t.Fatalf("CreateChallenge returned error: %v", err)
+	}
+
+	forged, _ := crypto.Sign(accounts.TextHash([]byte(challenge.Message)), otherKey)
+	if _, err := authService.Login(ctx, challenge.ID.String(), hexutil.Encode(forged)); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature for a foreign signer, got %v", err)
+	}
+
+	signature, err := crypto.Sign(accounts.TextHash([]byte(challenge.Message)), key)
+	if err != nil {
+		t.Fatalf("failed to sign challenge: %v", err)
+	}
+	signature[crypto.RecoveryIDOffset] += 27
+
+	tokens, err := authService.Login(ctx, challenge.ID.String(), hexutil.Encode(signature))
+	if err != nil {
+		t.Fatalf("Login returned error: %v", err)
+	}
+	if _, err := authService.Login(ctx, challenge.ID.String(), hexutil.Encode(signature)); !errors.Is(err, ErrInvalidChallenge) {
+		t.Fatalf("expected replayed challenge to be rejected, got %v", err)
+	}
+
+	session, err := authService.Authenticate(ctx, tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("Authenticate returned error: %v", err)
+	}
+	if session.DeviceID != "device-1" {
+		t.Fatalf("expected session for device-1, got %s", session.DeviceID)
+	}
+
+	refreshed, err := authService.Refresh(ctx, tokens.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh returned error: %v", err)
+	}
+	if _, err := authService.Authenticate(ctx, tokens.AccessToken); !errors.Is(err, ErrInvalidRunnerAuth) {
+		t.Fatalf("expected rotated access token to be rejected, got %v", err)
+	}
+
+	if err := authService.Revoke(ctx, refreshed.AccessToken); err != nil {
+		t.Fatalf("Revoke returned error: %v", err)
+	}
+	if _, err := authService.Authenticate(ctx, refreshed.AccessToken); !errors.Is(err, ErrInvalidRunnerAuth) {
+		t.Fatalf("expected revoked access token to be rejected, got %v", err)
+	}
+	if _, err := authService.Refresh(ctx, refreshed.RefreshToken); !errors.Is(err, ErrInvalidRunnerAuth) {
+		t.Fatalf("expected revoked session refresh to be rejected, got %v", err)
+	}
+}
+
+func TestRunnerAuthRefusesRunnerWithoutBoundWallet(t *testing.T) {
+	ctx := context.Background()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	wallet := crypto.PubkeyToAddress(key.PublicKey).Hex()
+
+	runnerRepo := newInMemoryRunnerRepo()
+	if err := runnerRepo.Create(ctx, &models.Runner{DeviceID: "legacy-1", Status: models.RunnerStatusOnline}); err != nil {
+		t.Fatalf("failed to seed runner: %v", err)
+	}
+	runnerService := NewRunnerService(runnerRepo)
+	authService := NewRunnerAuthService(newInMemoryRunnerAuthRepo(), runnerService, config.AuthConfig{})
+	adminService := NewRunnerAdminService(runnerService, nil)
+
+	if _, err := authService.CreateChallenge(ctx, "legacy-1", wallet); !errors.Is(err, ErrWalletNotBound) {
+		t.Fatalf("expected ErrWalletNotBound for a runner without a wallet, got %v", err)
+	}
+	if _, err := authService.CreateChallenge(ctx, "new-device", wallet); err != nil {
+		t.Fatalf("expected an unregistered device to get a challenge, got %v", err)
+	}
+
+	if _, err := adminService.BindWallet(ctx, "legacy-1", "not-a-wallet"); !errors.Is(err, ErrInvalidWalletAddress) {
+		t.Fatalf("expected ErrInvalidWalletAddress, got %v", err)
+	}
+	if _, err := adminService.BindWallet(ctx, "missing", wallet); !errors.Is(err, ErrRunnerNotFound) {
+		t.Fatalf("expected ErrRunnerNotFound, got %v", err)
+	}
+	if _, err := adminService.BindWallet(ctx, "legacy-1", strings.ToLower(wallet)); err != nil {
+		t.Fatalf("BindWallet returned error: %v", err)
+	}
+
+	if _, err := authService.CreateChallenge(ctx, "legacy-1", wallet); err != nil {
+		t.Fatalf("expected a challenge once the wallet is bound, got %v", err)
+	}
+	otherKey, _ := crypto.GenerateKey()
+	if _, err := authService.CreateChallenge(ctx, "legacy-1", crypto.PubkeyToAddress(otherKey.PublicKey).Hex()); !errors.Is(err, ErrWalletMismatch) {
+		t.Fatalf("expected ErrWalletMismatch for another wallet, got %v", err)
+	}
+}
@@ -0,0 +1,293 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/core/config"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/internal/core/ports"
+)
+
+const runnerCachePruneInterval = 10 * time.Minute
+
+// RunnerCacheService keeps the index of Docker images and model files each
+// runner reports holding, so the scheduler can prefer runners that will not
+// have to download them first. The index lives in memory and is saved when a
+// runner's report changes, so it survives restarts.
+type RunnerCacheService struct {
+	repo ports.RunnerCacheRepository
+
+	mu        sync.RWMutex
+	caches    map[string]*models.RunnerCache
+	savedAt   map[string]time.Time
+	maxWait   time.Duration
+	entryTTL  time.Duration
+	since     time.Time
+	imageHits int64
+	imageMiss int64
+	modelHits int64
+	modelMiss int64
+	held      int64
+}
+
+func NewRunnerCacheService(repo ports.RunnerCacheRepository) *RunnerCacheService {
+	return &RunnerCacheService{
+		repo:     repo,
+		caches:   make(map[string]*models.RunnerCache),
+		savedAt:  make(map[string]time.Time),
+		maxWait:  30 * time.Second,
+		entryTTL: 10 * time.Minute,
+		since:    time.Now(),
+	}
+}
+
+func (s *RunnerCacheService) SetConfig(cfg config.RunnerCacheConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cfg.MaxWait > 0 {
+		s.maxWait = time.Duration(cfg.MaxWait) * time.Second
+	}
+	if cfg.EntryTTL > 0 {
+		s.entryTTL = time.Duration(cfg.EntryTTL) * time.Minute
+	}
+}
+
+// Record stores a runner's cache report. Heartbeats that repeat the last
+// report only refresh it in memory; it is saved again once half the entry TTL
+// has passed so a restart does not treat it as stale.
+func (s *RunnerCacheService) Record(ctx context.Context, deviceID string, report models.RunnerCacheReport) error {
+	if !report.Reported() {
+		return nil
+	}
+
+	now := time.Now()
+	cache := models.NewRunnerCache(deviceID, report, now)
+
+	s.mu.Lock()
+	previous := s.caches[deviceID]
+	s.caches[deviceID] = cache
+	save := !cache.SameContents(previous) || now.Sub(s.savedAt[deviceID]) >= s.entryTTL/2
+	if save {
+		s.savedAt[deviceID] = now
+	}
+	s.mu.Unlock()
+
+	if !save {
+		return nil
+	}
+	return s.repo.Save(ctx, cache)
+}
+
+// Get returns the runner's current cache report, or nil when it has none or
+// the report is older than the entry TTL.
+func (s *RunnerCacheService) Get(deviceID string) *models.RunnerCache {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.fresh(deviceID, time.Now())
+}
+
+func (s *RunnerCacheService) fresh(deviceID string, now time.Time) *models.RunnerCache {
+	cache := s.caches[deviceID]
+	if cache == nil || now.Sub(cache.ReportedAt) > s.entryTTL {
+		return nil
+	}
+	return cache
+}
+
+// Warm reports whether the runner holds everything the task needs. Tasks that
+// need no image or model are never warm or cold; cacheable is false for them.
+func (s *RunnerCacheService) Warm(deviceID string, task *models.Task) (warm, cacheable bool) {
+	image, model := models.CacheKeysForTask(task)
+	if image == "" && model == "" {
+		return false, false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cache := s.fresh(deviceID, time.Now())
+	return cache != nil && cache.Holds(image, model), true
+}
+
+// HoldsModel reports whether the runner's current report lists the model.
+func (s *RunnerCacheService) HoldsModel(deviceID, model string) bool {
+	model = strings.TrimSpace(model)
+	if model == "" {
+		return false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cache := s.fresh(deviceID, time.Now())
+	return cache != nil && cache.Models.Contains(model)
+}
+
+// HoldTime is how much longer a task may wait for a warm runner before any
+// runner can take it.
+func (s *RunnerCacheService) HoldTime(task *models.Task) time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.maxWait - time.Since(task.CreatedAt)
+}
+
+// RecordHeld counts a task passed over by a cold runner because a warm one
+// was free.
+func (s *RunnerCacheService) RecordHeld() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.held++
+}
+
+// RecordAssignment counts whether the runner a task was assigned to already
+// held its image and model.
+func (s *RunnerCacheService) RecordAssignment(deviceID string, task *models.Task) {
+	image, model := models.CacheKeysForTask(task)
+	s.recordAssignment(deviceID, image, model)
+}
+
+// RecordModelAssignment counts whether the runner a prompt was sent to
+// already held its model.
+func (s *RunnerCacheService) RecordModelAssignment(deviceID, model string) {
+	s.recordAssignment(deviceID, "", strings.TrimSpace(model))
+}
+
+func (s *RunnerCacheService) recordAssignment(deviceID, image, model string) {
+	if image == "" && model == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cache := s.fresh(deviceID, time.Now())
+	if image != "" {
+		if cache != nil && cache.Images.Contains(image) {
+			s.imageHits++
+		} else {
+			s.imageMiss++
+		}
+	}
+	if model != "" {
+		if cache != nil && cache.Models.Contains(model) {
+			s.modelHits++
+		} else {
+			s.modelMiss++
+		}
+	}
+}
+
+// Metrics returns the cache-hit rates since the server started and the size
+// of the index.
+func (s *RunnerCacheService) Metrics() *models.RunnerCacheMetrics {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	metrics := &models.RunnerCacheMetrics{
+		Images:         cacheHitStats(s.imageHits, s.imageMiss),
+		Models:         cacheHitStats(s.modelHits, s.modelMiss),
+		HeldForWarm:    s.held,
+		MaxWaitSeconds: int(s.maxWait / time.Second),
+		Since:          s.since,
+	}
+
+	now := time.Now()
+	images := make(map[string]bool)
+	modelNames := make(map[string]bool)
+	for deviceID := range s.caches {
+		cache := s.fresh(deviceID, now)
+		if cache == nil {
+			continue
+		}
+		metrics.RunnersReporting++
+		for _, image := range cache.Images {
+			images[image] = true
+		}
+		for _, model := range cache.Models {
+			modelNames[model] = true
+		}
+	}
+	metrics.ImagesIndexed = len(images)
+	metrics.ModelsIndexed = len(modelNames)
+	return metrics
+}
+
+func cacheHitStats(hits, misses int64) models.CacheHitStats {
+	stats := models.CacheHitStats{Hits: hits, Misses: misses}
+	if total := hits + misses; total > 0 {
+		stats.HitRate = float64(hits) / float64(total)
+	}
+	return stats
+}
+
+// Forget drops a runner's report, for runners that are deregistered.
+func (s *RunnerCacheService) Forget(ctx context.Context, deviceID string) error {
+	s.mu.Lock()
+	delete(s.caches, deviceID)
+	delete(s.savedAt, deviceID)
+	s.mu.Unlock()
+
+	return s.repo.Delete(ctx, deviceID)
+}
+
+// Load fills the index from the saved reports. Reports already received since
+// startup win over saved ones.
+func (s *RunnerCacheService) Load(ctx context.Context) error {
+	caches, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, cache := range caches {
+		if _, ok := s.caches[cache.DeviceID]; ok {
+			continue
+		}
+		s.caches[cache.DeviceID] = cache
+		s.savedAt[cache.DeviceID] = cache.ReportedAt
+	}
+	return nil
+}
+
+// Prune drops reports older than the entry TTL from memory and the database.
+func (s *RunnerCacheService) Prune(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	cutoff := time.Now().Add(-s.entryTTL)
+	for deviceID, cache := range s.caches {
+		if cache.ReportedAt.Before(cutoff) {
+			delete(s.caches, deviceID)
+			delete(s.savedAt, deviceID)
+		}
+	}
+	s.mu.Unlock()
+
+	return s.repo.DeleteReportedBefore(ctx, cutoff)
+}
+
+func (s *RunnerCacheService) Start(ctx context.Context) {
+	log := gologger.WithComponent("runner_cache")
+	if err := s.Load(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to load runner cache index")
+	}
+	log.Info().Msg("Starting runner cache index worker")
+
+	ticker := time.NewTicker(runnerCachePruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("Runner cache index worker stopped")
+			return
+		case <-ticker.C:
+			if removed, err := s.Prune(ctx); err != nil {
+				log.Error().Err(err).Msg("Failed to prune runner cache index")
+			} else if removed > 0 {
+				log.Info().Int64("removed", removed).Msg("Pruned stale runner cache reports")
+			}
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/theblitlabs/parity-server/internal/core/config"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+type inMemoryRunnerCacheRepo struct {
+	mu     sync.Mutex
+	caches map[string]models.RunnerCache
+	saves  int
+}
+
+func (r *inMemoryRunnerCacheRepo) Save(ctx context.Context, cache *models.RunnerCache) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.caches == nil {
+		r.caches = make(map[string]models.RunnerCache)
+	}
+	r.caches[cache.DeviceID] = *cache
+	r.saves++
+	return nil
+}
+
+func (r *inMemoryRunnerCacheRepo) List(ctx context.Context) ([]*models.RunnerCache, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	caches := make([]*models.RunnerCache, 0, len(r.caches))
+	for _, cache := range r.caches {
+		cache := cache
+		caches = append(caches, &cache)
+	}
+	return caches, nil
+}
+
+func (r *inMemoryRunnerCacheRepo) Delete(ctx context.Context, deviceID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.caches, deviceID)
+	return nil
+}
+
+func (r *inMemoryRunnerCacheRepo) DeleteReportedBefore(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var removed int64
+	for deviceID, cache := range r.caches {
+		if cache.ReportedAt.Before(before) {
+			delete(r.caches, deviceID)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+func TestRunnerCacheRecordsNormalizedReports(t *testing.T) {
+	ctx := context.Background()
+	repo := &inMemoryRunnerCacheRepo{}
+	service := NewRunnerCacheService(repo)
+
+	imageHash := strings.Repeat("ab", 32)
+	report := models.RunnerCacheReport{
+		Images: []string{strings.ToUpper(imageHash), "sha256:" + imageHash, "not-an-image"},
+		Models: []string{" llama3 ", "llama3"},
+	}
+	if err := service.Record(ctx, "runner-1", report); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if err := service.Record(ctx, "runner-1", report); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if err := service.Record(ctx, "runner-1", models.RunnerCacheReport{}); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+
+	cache := service.Get("runner-1")
+	if cache == nil || len(cache.Images) != 1 || cache.Images[0] != "sha256:"+imageHash || len(cache.Models) != 1 || cache.Models[0] != "llama3" {
+		t.Fatalf("expected one image and one model, got %+v", cache)
+	}
+	if repo.saves != 1 {
+		t.Fatalf("expected an unchanged report to be saved once, got %d saves", repo.saves)
+	}
+
+	task := &models.Task{Type: models.TaskTypeDocker, ImageHash: imageHash}
+	if warm, cacheable := service.Warm("runner-1", task); !warm || !cacheable {
+		t.Fatalf("expected runner-1 to be warm for the image, got warm=%v cacheable=%v", warm, cacheable)
+	}
+	if warm, cacheable := service.Warm("runner-2", task); warm || !cacheable {
+		t.Fatalf("expected runner-2 to be cold, got warm=%v cacheable=%v", warm, cacheable)
+	}
+	if _, cacheable := service.Warm("runner-1", &models.Task{Type: models.TaskTypeCommand}); cacheable {
+		t.Fatalf("expected a task without an image or model not to be cacheable")
+	}
+
+	llmTask := &models.Task{
+		Type:        models.TaskTypeLLM,
+		Environment: &models.EnvironmentConfig{Config: map[string]interface{}{"MODEL": "mistral"}},
+	}
+	service.RecordAssignment("runner-1", task)
+	service.RecordAssignment("runner-2", task)
+	service.RecordAssignment("runner-1", llmTask)
+
+	metrics := service.Metrics()
+	if metrics.Images.Hits != 1 || metrics.Images.Misses != 1 || metrics.Images.HitRate != 0.5 {
+		t.Fatalf("expected a 50%% image hit rate, got %+v", metrics.Images)
+	}
+	if metrics.Models.Misses != 1 || metrics.RunnersReporting != 1 || metrics.ImagesIndexed != 1 || metrics.ModelsIndexed != 1 {
+		t.Fatalf("unexpected metrics %+v", metrics)
+	}
+
+	reloaded := NewRunnerCacheService(repo)
+	if err := reloaded.Load(ctx); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if reloaded.Get("runner-1") == nil {
+		t.Fatalf("expected the saved report to survive a restart")
+	}
+}
+
+func TestAssignmentPrefersWarmRunnerWithinMaxWait(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	taskRepo := newInMemoryTaskRepo()
+	runnerRepo := newInMemoryRunnerRepo()
+	runnerService := NewRunnerService(runnerRepo)
+	taskService := NewTaskService(taskRepo, nil, runnerService)
+	cacheService := NewRunnerCacheService(&inMemoryRunnerCacheRepo{})
+	cacheService.SetConfig(config.RunnerCacheConfig{MaxWait: 60})
+	runnerService.SetRunnerCacheService(cacheService)
+	taskService.SetRunnerCacheService(cacheService)
+
+	for _, deviceID := range []string{"cold", "warm"} {
+		runnerRepo.runners[deviceID] = &models.Runner{
+			DeviceID: deviceID,
+			Status:   models.RunnerStatusOnline,
+			Webhook:  server.URL,
+			Slots:    1,
+		}
+	}
+	imageHash := strings.Repeat("cd", 32)
+	runnerService.RecordCacheReport(ctx, "warm", models.RunnerCacheReport{Images: []string{imageHash}})
+
+	fresh := newPendingCommandTask(time.Now())
+	fresh.ImageHash = imageHash
+	taskRepo.tasks[fresh.ID] = cloneTask(fresh)
+
+	if err := taskService.checkAndAssignPendingTasksToRunner(ctx, "cold"); err != nil {
+		t.Fatalf("checkAndAssignPendingTasksToRunner returned error: %v", err)
+	}
+	if stored, _ := taskRepo.Get(ctx, fresh.ID); stored.RunnerID != "" {
+		t.Fatalf("expected the cold runner to leave the task for the warm one, got %q", stored.RunnerID)
+	}
+	if err := taskService.checkAndAssignPendingTasksToRunner(ctx, "warm"); err != nil {
+		t.Fatalf("checkAndAssignPendingTasksToRunner returned error: %v", err)
+	}
+	if stored, _ := taskRepo.Get(ctx, fresh.ID); stored.RunnerID != "warm" {
+		t.Fatalf("expected the warm runner to take the task, got %q", stored.RunnerID)
+	}
+
+	// A task that has waited past the bound goes to whichever runner asks,
+	// even while a warm runner is free.
+	runnerRepo.runners["warm"].Slots = 2
+	overdue := newPendingCommandTask(time.Now().Add(-2 * time.Minute))
+	overdue.ImageHash = imageHash
+	taskRepo.tasks[overdue.ID] = cloneTask(overdue)
+
+	if err := taskService.checkAndAssignPendingTasksToRunner(ctx, "cold"); err != nil {
+		t.Fatalf("checkAndAssignPendingTasksToRunner returned error: %v", err)
+	}
+	if stored, _ := taskRepo.Get(ctx, overdue.ID); stored.RunnerID != "cold" {
+		t.Fatalf("expected the overdue task to go to the cold runner, got %q", stored.RunnerID)
+	}
+
+	metrics := cacheService.Metrics()
+	if metrics.Images.Hits != 1 || metrics.Images.Misses != 1 || metrics.HeldForWarm != 1 {
+		t.Fatalf("unexpected cache metrics %+v", metrics)
+	}
+}
+
+func TestPromptReservationPrefersRunnerWithModelCached(t *testing.T) {
+	ctx := context.Background()
+	runnerRepo := newInMemoryRunnerRepo()
+	runnerService := NewRunnerService(runnerRepo)
+	cacheService := NewRunnerCacheService(&inMemoryRunnerCacheRepo{})
+	runnerService.SetRunnerCacheService(cacheService)
+
+	for _, deviceID := range []string{"cold", "warm"} {
+		runnerRepo.runners[deviceID] = &models.Runner{
+			DeviceID:          deviceID,
+			Status:            models.RunnerStatusOnline,
+			Slots:             1,
+			ModelCapabilities: []models.ModelCapability{{RunnerID: deviceID, ModelName: "mistral", IsLoaded: true}},
+		}
+	}
+	runnerService.RecordCacheReport(ctx, "warm", models.RunnerCacheReport{Models: []string{"mistral"}})
+
+	first := models.NewPromptRequest("client-1", "hi", "mistral", "0xabc")
+	if runnerID, err := runnerService.ReserveRunnerForModel(ctx, "mistral", 0, first.ID); err != nil || runnerID != "warm" {
+		t.Fatalf("expected the prompt to go to the warm runner, got %q and error %v", runnerID, err)
+	}
+
+	// Prompts are not held, so the cold runner takes the next one.
+	second := models.NewPromptRequest("client-1", "hi again", "mistral", "0xabc")
+	if runnerID, err := runnerService.ReserveRunnerForModel(ctx, "mistral", 0, second.ID); err != nil || runnerID != "cold" {
+		t.Fatalf("expected the next prompt to go to the cold runner, got %q and error %v", runnerID, err)
+	}
+
+	metrics := cacheService.Metrics()
+	if metrics.Models.Hits != 1 || metrics.Models.Misses != 1 {
+		t.Fatalf("unexpected model cache metrics %+v", metrics.Models)
+	}
+}
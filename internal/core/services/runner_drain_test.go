@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+func TestDrainedRunnerIsSkippedUntilUndrained(t *testing.T) {
+	ctx := context.Background()
+	taskRepo := newInMemoryTaskRepo()
+	runnerRepo := newInMemoryRunnerRepo()
+	runnerService := NewRunnerService(runnerRepo)
+	taskService := NewTaskService(taskRepo, nil, runnerService)
+
+	runnerRepo.runners["runner-1"] = &models.Runner{
+		DeviceID:     "runner-1",
+		Status:       models.RunnerStatusOnline,
+		DeliveryMode: models.DeliveryModePull,
+	}
+
+	until := time.Now().Add(30 * time.Minute)
+	drained, err := runnerService.DrainRunner(ctx, "runner-1", &until)
+	if err != nil {
+		t.Fatalf("DrainRunner returned error: %v", err)
+	}
+	if !drained.IsDraining() || !drained.InMaintenance(time.Now()) {
+		t.Fatalf("expected runner to be draining and in maintenance, got %+v", drained)
+	}
+
+	// A heartbeat must not put the runner back into rotation.
+	if _, err := runnerService.UpdateRunnerStatus(ctx, &models.Runner{DeviceID: "runner-1", Status: models.RunnerStatusOnline}); err != nil {
+		t.Fatalf("UpdateRunnerStatus returned error: %v", err)
+	}
+
+	available, err := taskService.getAvailableRunners(ctx)
+	if err != nil {
+		t.Fatalf("getAvailableRunners returned error: %v", err)
+	}
+	if len(available) != 0 {
+		t.Fatalf("expected draining runner to be unavailable, got %d runners", len(available))
+	}
+
+	task := models.NewTask()
+	task.Status = models.TaskStatusPending
+	taskRepo.tasks[task.ID] = cloneTask(task)
+
+	if err := taskService.checkAndAssignPendingTasksToRunner(ctx, "runner-1"); err != nil {
+		t.Fatalf("checkAndAssignPendingTasksToRunner returned error: %v", err)
+	}
+	if stored, _ := taskRepo.Get(ctx, task.ID); stored.RunnerID != "" {
+		t.Fatalf("expected no task to be assigned to a draining runner, got %q", stored.RunnerID)
+	}
+
+	undrained, err := runnerService.UndrainRunner(ctx, "runner-1")
+	if err != nil {
+		t.Fatalf("UndrainRunner returned error: %v", err)
+	}
+	if undrained.Status != models.RunnerStatusOnline || undrained.MaintenanceUntil != nil {
+		t.Fatalf("expected runner to be online with no maintenance window, got %+v", undrained)
+	}
+
+	available, err = taskService.getAvailableRunners(ctx)
+	if err != nil {
+		t.Fatalf("getAvailableRunners returned error: %v", err)
+	}
+	if len(available) != 1 {
+		t.Fatalf("expected undrained runner to be available, got %d runners", len(available))
+	}
+}
+
+func TestUndrainKeepsRunnerCurrentTask(t *testing.T) {
+	ctx := context.Background()
+	runnerRepo := newInMemoryRunnerRepo()
+	runnerService := NewRunnerService(runnerRepo)
+
+	task := models.NewTask()
+	runnerRepo.runners["runner-1"] = &models.Runner{
+		DeviceID:    "runner-1",
+		Status:      models.RunnerStatusOnline,
+		Assignments: []models.RunnerAssignment{*models.NewRunnerAssignment("runner-1", task.ID, models.AssignmentKindTask)},
+	}
+
+	if _, err := runnerService.DrainRunner(ctx, "runner-1", nil); err != nil {
+		t.Fatalf("DrainRunner returned error: %v", err)
+	}
+
+	runner, err := runnerService.UndrainRunner(ctx, "runner-1")
+	if err != nil {
+		t.Fatalf("UndrainRunner returned error: %v", err)
+	}
+	if runner.Status != models.RunnerStatusOnline || !runner.HasAssignment(task.ID) {
+		t.Fatalf("expected runner to be online and still hold its task, got %+v", runner)
+	}
+}
+
+func TestDrainingRunnerTimesOutOutsideMaintenanceWindow(t *testing.T) {
+	ctx := context.Background()
+	runnerRepo := newInMemoryRunnerRepo()
+	runnerService := NewRunnerService(runnerRepo)
+
+	stale := time.Now().Add(-time.Hour)
+	until := time.Now().Add(30 * time.Minute)
+	runnerRepo.runners["gone"] = &models.Runner{
+		DeviceID:      "gone",
+		Status:        models.RunnerStatusDraining,
+		LastHeartbeat: stale,
+	}
+	runnerRepo.runners["upgrading"] = &models.Runner{
+		DeviceID:         "upgrading",
+		Status:           models.RunnerStatusDraining,
+		LastHeartbeat:    stale,
+		MaintenanceUntil: &until,
+	}
+
+	if err := runnerService.UpdateOfflineRunners(ctx); err != nil {
+		t.Fatalf("UpdateOfflineRunners returned error: %v", err)
+	}
+
+	if got := runnerRepo.runners["gone"].Status; got != models.RunnerStatusOffline {
+		t.Fatalf("expected a silent draining runner to time out, got %q", got)
+	}
+	if got := runnerRepo.runners["upgrading"].Status; got != models.RunnerStatusDraining {
+		t.Fatalf("expected a runner inside its maintenance window to stay draining, got %q", got)
+	}
+}
@@ -0,0 +1,350 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+var (
+	ErrRunnerNotConnected = errors.New("runner has no open connection")
+	ErrRunnerAckTimeout   = errors.New("runner did not acknowledge message in time")
+	ErrRunnerRejected     = errors.New("runner rejected message")
+)
+
+const (
+	defaultRunnerAckTimeout = 10 * time.Second
+	runnerTouchInterval     = 30 * time.Second
+)
+
+// RunnerSocket is the write side of a runner's WebSocket.
+type RunnerSocket interface {
+	WriteJSON(v interface{}) error
+	Close() error
+}
+
+// RunnerConnection is one open runner WebSocket. Writes are serialized and
+// messages sent with RunnerHub.Send wait here for the runner's ack.
+type RunnerConnection struct {
+	DeviceID string
+
+	socket    RunnerSocket
+	writeMu   sync.Mutex
+	pendingMu sync.Mutex
+	pending   map[string]chan *models.RunnerMessage
+	lastTouch time.Time
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (c *RunnerConnection) write(message *models.RunnerMessage) error {
+	select {
+	case <-c.closed:
+		return ErrRunnerNotConnected
+	default:
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.socket.WriteJSON(message)
+}
+
+func (c *RunnerConnection) close() {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		if err := c.socket.Close(); err != nil {
+			log := gologger.WithComponent("runner_hub")
+			log.Debug().Err(err).Str("device_id", c.DeviceID).Msg("Failed to close runner socket")
+		}
+	})
+}
+
+// RunnerHub keeps the open runner WebSockets and delivers messages over them.
+// A runner counts as online while it holds a connection.
+type RunnerHub struct {
+	runnerService  *RunnerService
+	taskLogService *TaskLogService
+	ackTimeout     time.Duration
+	mu             sync.RWMutex
+	connections    map[string]*RunnerConnection
+}
+
+func NewRunnerHub(runnerService *RunnerService) *RunnerHub {
+	return &RunnerHub{
+		runnerService: runnerService,
+		ackTimeout:    defaultRunnerAckTimeout,
+		connections:   make(map[string]*RunnerConnection),
+	}
+}
+
+func (h *RunnerHub) SetTaskLogService(taskLogService *TaskLogService) {
+	h.taskLogService = taskLogService
+}
+
+// Connect registers a runner's socket, replacing any older connection from the
+// same device, and marks the runner online.
+func (h *RunnerHub) Connect(ctx context.Context, deviceID string, socket RunnerSocket) (*RunnerConnection, error) {
+	log := gologger.WithComponent("runner_hub")
+
+	runner, err := h.runnerService.GetRunner(ctx, deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("runner must register before connecting: %w", err)
+	}
+	if runner.ForcedOffline {
+		return nil, ErrRunnerForcedOffline
+	}
+
+	conn := &RunnerConnection{
+		DeviceID:  deviceID,
+		socket:    socket,
+		pending:   make(map[string]chan *models.RunnerMessage),
+		lastTouch: time.Now(),
+		closed:    make(chan struct{}),
+	}
+
+	h.mu.Lock()
+	previous := h.connections[deviceID]
+	h.connections[deviceID] = conn
+	h.mu.Unlock()
+
+	if previous != nil {
+		log.Info().Str("device_id", deviceID).Msg("Replacing existing runner connection")
+		previous.close()
+	}
+
+	if _, err := h.runnerService.UpdateRunnerStatus(ctx, &models.Runner{
+		DeviceID: deviceID,
+		Status:   models.RunnerStatusOnline,
+	}); err != nil {
+		log.Error().Err(err).Str("device_id", deviceID).Msg("Failed to mark connected runner online")
+	}
+
+	log.Info().Str("device_id", deviceID).Msg("Runner connected")
+	return conn, nil
+}
+
+// Disconnect drops the connection and, unless the device has already reconnected,
+// marks the runner offline.
+func (h *RunnerHub) Disconnect(ctx context.Context, conn *RunnerConnection) {
+	log := gologger.WithComponent("runner_hub")
+
+	conn.close()
+
+	h.mu.Lock()
+	current := h.connections[conn.DeviceID] == conn
+	if current {
+		delete(h.connections, conn.DeviceID)
+	}
+	h.mu.Unlock()
+
+	if !current {
+		return
+	}
+
+	if _, err := h.runnerService.UpdateRunnerStatus(ctx, &models.Runner{
+		DeviceID: conn.DeviceID,
+		Status:   models.RunnerStatusOffline,
+	}); err != nil {
+		log.Error().Err(err).Str("device_id", conn.DeviceID).Msg("Failed to mark disconnected runner offline")
+	}
+
+	log.Info().Str("device_id", conn.DeviceID).Msg("Runner disconnected")
+}
+
+// DisconnectDevice closes the device's socket, if any, without touching the
+// runner's stored status. Callers are expected to have already set it.
+func (h *RunnerHub) DisconnectDevice(deviceID string) {
+	h.mu.Lock()
+	conn := h.connections[deviceID]
+	delete(h.connections, deviceID)
+	h.mu.Unlock()
+
+	if conn != nil {
+		conn.close()
+	}
+}
+
+func (h *RunnerHub) IsConnected(deviceID string) bool {
+	return h.connection(deviceID) != nil
+}
+
+// Send delivers a message and waits for the runner to acknowledge it.
+func (h *RunnerHub) Send(ctx context.Context, deviceID string, messageType models.RunnerMessageType, payload interface{}) error {
+	conn := h.connection(deviceID)
+	if conn == nil {
+		return ErrRunnerNotConnected
+	}
+
+	message, err := models.NewRunnerMessage(messageType, payload)
+	if err != nil {
+		return err
+	}
+
+	reply := make(chan *models.RunnerMessage, 1)
+	conn.pendingMu.Lock()
+	conn.pending[message.ID] = reply
+	conn.pendingMu.Unlock()
+	defer func() {
+		conn.pendingMu.Lock()
+		delete(conn.pending, message.ID)
+		conn.pendingMu.Unlock()
+	}()
+
+	if err := conn.write(message); err != nil {
+		return fmt.Errorf("%w: %v", ErrRunnerNotConnected, err)
+	}
+
+	timer := time.NewTimer(h.ackTimeout)
+	defer timer.Stop()
+
+	select {
+	case ack := <-reply:
+		if ack.Type == models.RunnerMessageError {
+			return fmt.Errorf("%w: %s", ErrRunnerRejected, ack.Error)
+		}
+		return nil
+	case <-conn.closed:
+		return ErrRunnerNotConnected
+	case <-timer.C:
+		return ErrRunnerAckTimeout
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Notify delivers a message without waiting for an ack.
+func (h *RunnerHub) Notify(deviceID string, messageType models.RunnerMessageType, payload interface{}) error {
+	conn := h.connection(deviceID)
+	if conn == nil {
+		return ErrRunnerNotConnected
+	}
+
+	message, err := models.NewRunnerMessage(messageType, payload)
+	if err != nil {
+		return err
+	}
+	return conn.write(message)
+}
+
+// HandleMessage processes a message read from the runner's socket.
+func (h *RunnerHub) HandleMessage(ctx context.Context, conn *RunnerConnection, message *models.RunnerMessage) {
+	log := gologger.WithComponent("runner_hub")
+
+	if message.Version > models.RunnerMessageVersion {
+		h.reply(conn, models.NewRunnerReply(message.ID, fmt.Sprintf("unsupported message version %d", message.Version)))
+		return
+	}
+
+	switch message.Type {
+	case models.RunnerMessageAck, models.RunnerMessageError:
+		conn.pendingMu.Lock()
+		reply, ok := conn.pending[message.ReplyTo]
+		conn.pendingMu.Unlock()
+		if !ok {
+			log.Debug().
+				Str("device_id", conn.DeviceID).
+				Str("reply_to", message.ReplyTo).
+				Msg("Ignoring reply to unknown message")
+			return
+		}
+		select {
+		case reply <- message:
+		default:
+		}
+	case models.RunnerMessageHeartbeat:
+		var telemetry models.HeartbeatTelemetry
+		var cache models.RunnerCacheReport
+		if len(message.Payload) > 0 && json.Unmarshal(message.Payload, &telemetry) == nil {
+			telemetry.Reported = true
+			_ = json.Unmarshal(message.Payload, &cache)
+		}
+		h.touch(ctx, conn, telemetry)
+		h.runnerService.RecordCacheReport(ctx, conn.DeviceID, cache)
+		h.reply(conn, models.NewRunnerReply(message.ID, ""))
+	case models.RunnerMessageTaskLog:
+		h.reply(conn, models.NewRunnerReply(message.ID, h.appendTaskLog(ctx, conn, message.Payload)))
+	default:
+		h.reply(conn, models.NewRunnerReply(message.ID, fmt.Sprintf("unsupported message type %q", message.Type)))
+	}
+}
+
+// appendTaskLog handles a task_log message and returns the error to reply
+// with, if any.
+func (h *RunnerHub) appendTaskLog(ctx context.Context, conn *RunnerConnection, raw []byte) string {
+	if h.taskLogService == nil {
+		return fmt.Sprintf("unsupported message type %q", models.RunnerMessageTaskLog)
+	}
+
+	var payload models.TaskLogPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return "invalid task_log payload"
+	}
+	taskID, err := uuid.Parse(payload.TaskID)
+	if err != nil {
+		return "invalid task ID"
+	}
+
+	if _, err := h.taskLogService.Append(ctx, taskID, conn.DeviceID, payload.Stream, payload.Data); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// Touch records activity on the connection, refreshing the runner's heartbeat at
+// most once per runnerTouchInterval.
+func (h *RunnerHub) Touch(ctx context.Context, conn *RunnerConnection) {
+	h.touch(ctx, conn, models.HeartbeatTelemetry{})
+}
+
+func (h *RunnerHub) touch(ctx context.Context, conn *RunnerConnection, telemetry models.HeartbeatTelemetry) {
+	conn.pendingMu.Lock()
+	due := time.Since(conn.lastTouch) >= runnerTouchInterval
+	if due {
+		conn.lastTouch = time.Now()
+	}
+	conn.pendingMu.Unlock()
+
+	if !due {
+		return
+	}
+
+	if _, err := h.runnerService.RecordHeartbeat(ctx, &models.Runner{
+		DeviceID: conn.DeviceID,
+		Status:   models.RunnerStatusOnline,
+	}, telemetry); err != nil {
+		log := gologger.WithComponent("runner_hub")
+		log.Error().Err(err).Str("device_id", conn.DeviceID).Msg("Failed to refresh runner heartbeat")
+	}
+}
+
+// CloseAll drops every open connection without touching runner status.
+func (h *RunnerHub) CloseAll() {
+	h.mu.Lock()
+	connections := h.connections
+	h.connections = make(map[string]*RunnerConnection)
+	h.mu.Unlock()
+
+	for _, conn := range connections {
+		conn.close()
+	}
+}
+
+func (h *RunnerHub) connection(deviceID string) *RunnerConnection {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.connections[deviceID]
+}
+
+func (h *RunnerHub) reply(conn *RunnerConnection, message *models.RunnerMessage) {
+	if err := conn.write(message); err != nil {
+		log := gologger.WithComponent("runner_hub")
+		log.Debug().Err(err).Str("device_id", conn.DeviceID).Msg("Failed to reply to runner")
+	}
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+// fakeRunnerSocket records written messages and optionally answers them the way
+// a runner would.
+type fakeRunnerSocket struct {
+	mu       sync.Mutex
+	messages []*models.RunnerMessage
+	reply    func(*models.RunnerMessage)
+	closed   bool
+}
+
+func (s *fakeRunnerSocket) WriteJSON(v interface{}) error {
+	message := v.(*models.RunnerMessage)
+
+	s.mu.Lock()
+	s.messages = append(s.messages, message)
+	reply := s.reply
+	s.mu.Unlock()
+
+	if reply != nil {
+		go reply(message)
+	}
+	return nil
+}
+
+func (s *fakeRunnerSocket) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func (s *fakeRunnerSocket) sent(messageType models.RunnerMessageType) []*models.RunnerMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matched []*models.RunnerMessage
+	for _, message := range s.messages {
+		if message.Type == messageType {
+			matched = append(matched, message)
+		}
+	}
+	return matched
+}
+
+func TestRunnerHubDeliversTaskOffersOverSocket(t *testing.T) {
+	ctx := context.Background()
+
+	taskRepo := newInMemoryTaskRepo()
+	runnerRepo := newInMemoryRunnerRepo()
+	runnerService := NewRunnerService(runnerRepo)
+	taskService := NewTaskService(taskRepo, nil, runnerService)
+	hub := NewRunnerHub(runnerService)
+	taskService.SetRunnerHub(hub)
+
+	if err := runnerRepo.Create(ctx, &models.Runner{DeviceID: "runner-ws", Status: models.RunnerStatusOffline}); err != nil {
+		t.Fatalf("failed to seed runner: %v", err)
+	}
+
+	if _, err := hub.Connect(ctx, "runner-unknown", &fakeRunnerSocket{}); err == nil {
+		t.Fatal("expected unregistered runner to be refused")
+	}
+
+	socket := &fakeRunnerSocket{}
+	conn, err := hub.Connect(ctx, "runner-ws", socket)
+	if err != nil {
+		t.Fatalf("Connect returned error: %v", err)
+	}
+	socket.reply = func(message *models.RunnerMessage) {
+		if message.Type == models.RunnerMessageTaskOffer {
+			hub.HandleMessage(ctx, conn, models.NewRunnerReply(message.ID, ""))
+		}
+	}
+
+	runner, _ := runnerRepo.Get(ctx, "runner-ws")
+	if runner.Status != models.RunnerStatusOnline {
+		t.Fatalf("expected connected runner to be online, got %s", runner.Status)
+	}
+
+	task := models.NewTask()
+	task.Title = "socket task"
+	task.Type = models.TaskTypeCommand
+	task.Config, _ = json.Marshal(models.TaskConfig{})
+
+	// The runner has no webhook, so success means the offer went over the socket.
+	if err := taskService.notifyRunnerAboutTask(runner, task); err != nil {
+		t.Fatalf("notifyRunnerAboutTask returned error: %v", err)
+	}
+
+	offers := socket.sent(models.RunnerMessageTaskOffer)
+	if len(offers) != 1 {
+		t.Fatalf("expected one task offer, got %d", len(offers))
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(offers[0].Payload, &payload); err != nil {
+		t.Fatalf("failed to decode offer payload: %v", err)
+	}
+	if payload["id"] != task.ID.String() {
+		t.Fatalf("expected offer for task %s, got %v", task.ID, payload["id"])
+	}
+
+	taskService.notifyTaskCancelled("runner-ws", task.ID, "test")
+	if cancels := socket.sent(models.RunnerMessageTaskCancel); len(cancels) != 1 {
+		t.Fatalf("expected one task cancellation, got %d", len(cancels))
+	}
+
+	hub.Disconnect(ctx, conn)
+	runner, _ = runnerRepo.Get(ctx, "runner-ws")
+	if runner.Status != models.RunnerStatusOffline {
+		t.Fatalf("expected disconnected runner to be offline, got %s", runner.Status)
+	}
+	if err := taskService.notifyRunnerAboutTask(runner, task); err == nil {
+		t.Fatal("expected notify to fail once the runner is offline without a webhook")
+	}
+}
+
+func TestRunnerHubSendReportsRejectionAndTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	runnerRepo := newInMemoryRunnerRepo()
+	runnerService := NewRunnerService(runnerRepo)
+	hub := NewRunnerHub(runnerService)
+	hub.ackTimeout = 20 * time.Millisecond
+
+	if err := runnerRepo.Create(ctx, &models.Runner{DeviceID: "runner-ws", Status: models.RunnerStatusOnline}); err != nil {
+		t.Fatalf("failed to seed runner: %v", err)
+	}
+
+	if err := hub.Send(ctx, "runner-ws", models.RunnerMessageTaskOffer, nil); !errors.Is(err, ErrRunnerNotConnected) {
+		t.Fatalf("expected ErrRunnerNotConnected before connecting, got %v", err)
+	}
+
+	socket := &fakeRunnerSocket{}
+	conn, err := hub.Connect(ctx, "runner-ws", socket)
+	if err != nil {
+		t.Fatalf("Connect returned error: %v", err)
+	}
+
+	if err := hub.Send(ctx, "runner-ws", models.RunnerMessageTaskOffer, nil); !errors.Is(err, ErrRunnerAckTimeout) {
+		t.Fatalf("expected ErrRunnerAckTimeout without a reply, got %v", err)
+	}
+
+	socket.mu.Lock()
+	socket.reply = func(message *models.RunnerMessage) {
+		if message.Type == models.RunnerMessageTaskOffer {
+			hub.HandleMessage(ctx, conn, models.NewRunnerReply(message.ID, "busy"))
+		}
+	}
+	socket.mu.Unlock()
+
+	if err := hub.Send(ctx, "runner-ws", models.RunnerMessageTaskOffer, nil); !errors.Is(err, ErrRunnerRejected) {
+		t.Fatalf("expected ErrRunnerRejected, got %v", err)
+	}
+
+	replacement := &fakeRunnerSocket{}
+	if _, err := hub.Connect(ctx, "runner-ws", replacement); err != nil {
+		t.Fatalf("reconnect returned error: %v", err)
+	}
+	socket.mu.Lock()
+	closed := socket.closed
+	socket.mu.Unlock()
+	if !closed {
+		t.Fatal("expected the older connection to be closed on reconnect")
+	}
+
+	// Dropping the stale connection must not mark the reconnected runner offline.
+	hub.Disconnect(ctx, conn)
+	if !hub.IsConnected("runner-ws") {
+		t.Fatal("expected the replacement connection to stay registered")
+	}
+	runner, _ := runnerRepo.Get(ctx, "runner-ws")
+	if runner.Status != models.RunnerStatusOnline {
+		t.Fatalf("expected runner to stay online, got %s", runner.Status)
+	}
+}
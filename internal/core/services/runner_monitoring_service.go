@@ -367,8 +367,11 @@ func (s *RunnerMonitoringService) updateTargetMetrics(targetID string) error {
 		metrics.QualityScore = qualityScore
 	}
 
-	// Check for offline status
-	if time.Since(metrics.LastActivity) > 30*time.Minute {
+	// Check for offline status. Time inside a declared maintenance window
+	// does not count as downtime; draining alone does not exempt a runner.
+	if s.inMaintenance(targetID) {
+		metrics.OfflineDuration = 0
+	} else if time.Since(metrics.LastActivity) > 30*time.Minute {
 		metrics.OfflineDuration = time.Since(metrics.LastActivity)
 	} else {
 		metrics.OfflineDuration = 0
@@ -380,6 +383,14 @@ func (s *RunnerMonitoringService) updateTargetMetrics(targetID string) error {
 	return nil
 }
 
+func (s *RunnerMonitoringService) inMaintenance(targetID string) bool {
+	runner, err := s.runnerService.GetRunner(s.ctx, targetID)
+	if err != nil {
+		return false
+	}
+	return runner.InMaintenance(time.Now())
+}
+
 func (s *RunnerMonitoringService) detectSuspiciousPatterns(metrics *MonitoringMetrics, tasks []*models.Task) {
 	patterns := []string{}
 
@@ -468,6 +479,9 @@ func (s *RunnerMonitoringService) generateMonitoringReport(targetID string) (Mon
 	s.metricsMutex.RUnlock()
 
 	if !exists {
+		if s.inMaintenance(targetID) {
+			return GoodBehavior, "Runner in maintenance"
+		}
 		return Offline, "No metrics available for target runner"
 	}
 
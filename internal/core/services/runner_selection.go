@@ -0,0 +1,260 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+var (
+	ErrRunnerNotSelected = fmt.Errorf("%w: runner was not selected for this task", ErrRunnerUnavailable)
+	ErrNoRunnerSelection = errors.New("task has no runner selection")
+)
+
+const (
+	selectionReasonInitial     = "initial"
+	selectionReasonUnavailable = "selected runner unavailable"
+	selectionReasonExpired     = "selection expired"
+	selectionReasonExhausted   = "every candidate dropped out"
+)
+
+// SelectRunner picks a runner from candidates using beacon randomness bound to the
+// task ID. Candidates are sorted and de-duplicated first, so anyone holding the
+// round, the task ID and the recorded candidate list gets the same answer.
+func SelectRunner(randomness []byte, taskID string, candidates []string) (string, []string) {
+	sorted := make([]string, 0, len(candidates))
+	seen := make(map[string]bool, len(candidates))
+	for _, candidate := range candidates {
+		if candidate == "" || seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+		sorted = append(sorted, candidate)
+	}
+	sort.Strings(sorted)
+
+	if len(sorted) == 0 {
+		return "", sorted
+	}
+
+	h := sha256.New()
+	h.Write(randomness)
+	h.Write([]byte(taskID))
+
+	index := new(big.Int).Mod(new(big.Int).SetBytes(h.Sum(nil)), big.NewInt(int64(len(sorted))))
+	return sorted[index.Int64()], sorted
+}
+
+// commitSelectionRound fixes the beacon round a high-value task's runner will
+// be drawn from. It is the round after the latest one, so nobody knows the
+// outcome when the task is created. Sources without rounds leave it unset and
+// draws fall back to the latest randomness.
+func (s *TaskService) commitSelectionRound(ctx context.Context, task *models.Task) error {
+	round, err := s.nonceService.Source().Latest(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get selection randomness: %w", err)
+	}
+	if round.Verifiable() {
+		task.SelectionRound = round.Round + 1
+	}
+	return nil
+}
+
+// ensureRunnerSelection returns the selection in force for a high-value task,
+// drawing a new one when none exists or the selected runner can no longer take it.
+func (s *TaskService) ensureRunnerSelection(ctx context.Context, task *models.Task) (*models.RunnerSelection, error) {
+	current := task.Selections.Latest()
+	if current == nil {
+		return s.selectRunnerForTask(ctx, task, selectionReasonInitial)
+	}
+
+	if task.RunnerID != "" && task.RunnerID == current.SelectedRunnerID {
+		return current, nil
+	}
+
+	if time.Since(current.SelectedAt) >= pendingAssignmentTimeout {
+		return s.selectRunnerForTask(ctx, task, selectionReasonExpired)
+	}
+
+	runner, err := s.runnerService.GetRunner(ctx, current.SelectedRunnerID)
+	if err != nil {
+		if errors.Is(err, ErrRunnerNotFound) || strings.Contains(err.Error(), "runner not found") {
+			return s.selectRunnerForTask(ctx, task, selectionReasonUnavailable)
+		}
+		return nil, fmt.Errorf("failed to refresh selected runner: %w", err)
+	}
+
+	if runner.Status != models.RunnerStatusOnline || (!runner.HasAssignment(task.ID) && runner.FreeSlots() == 0) {
+		return s.selectRunnerForTask(ctx, task, selectionReasonUnavailable)
+	}
+
+	return current, nil
+}
+
+// selectRunnerForTask draws a runner for the task and appends the draw to the
+// task's selection log. The first draw is over the runners free to take the
+// task. A redraw keeps that candidate list and only drops the runners already
+// selected, so a runner that walks away hands the task to a runner fixed by the
+// committed round instead of getting a fresh roll of the dice. Once every
+// candidate has dropped out the list is rebuilt from the available runners.
+func (s *TaskService) selectRunnerForTask(ctx context.Context, task *models.Task, reason string) (*models.RunnerSelection, error) {
+	log := gologger.WithComponent("task_service")
+
+	round, err := s.selectionRandomness(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := make(map[string]bool, len(task.Selections))
+	for _, selection := range task.Selections {
+		if selection.Reason == selectionReasonExhausted {
+			excluded = make(map[string]bool, len(task.Selections))
+		}
+		excluded[selection.SelectedRunnerID] = true
+	}
+
+	var candidates []string
+	if previous := task.Selections.Latest(); previous != nil {
+		for _, candidate := range previous.Candidates {
+			if !excluded[candidate] {
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		runners, err := s.getAvailableRunners(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, runner := range runners {
+			if !excluded[runner.DeviceID] {
+				candidates = append(candidates, runner.DeviceID)
+			}
+		}
+		// Every available runner has had its turn, so start over with all
+		// of them rather than leave the task stranded.
+		if len(candidates) == 0 && len(excluded) > 0 {
+			for _, runner := range runners {
+				candidates = append(candidates, runner.DeviceID)
+			}
+			reason = selectionReasonExhausted
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, ErrRunnerUnavailable
+	}
+
+	selectedRunnerID, sorted := SelectRunner(round.Randomness, task.ID.String(), candidates)
+
+	task.Selections = append(task.Selections, models.RunnerSelection{
+		Source:           round.Source,
+		Round:            round.Round,
+		Randomness:       round.Randomness,
+		Candidates:       sorted,
+		SelectedRunnerID: selectedRunnerID,
+		Reason:           reason,
+		SelectedAt:       time.Now(),
+	})
+	task.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(ctx, task); err != nil {
+		return nil, fmt.Errorf("failed to record runner selection: %w", err)
+	}
+
+	log.Info().
+		Str("task_id", task.ID.String()).
+		Str("runner_id", selectedRunnerID).
+		Str("source", string(round.Source)).
+		Uint64("round", round.Round).
+		Int("candidates", len(sorted)).
+		Str("reason", reason).
+		Msg("Selected runner for high-value task")
+
+	return task.Selections.Latest(), nil
+}
+
+// selectionRandomness returns the committed round for the task, or the latest
+// round for tasks without one. Until the committed round is published the task
+// has no runner.
+func (s *TaskService) selectionRandomness(ctx context.Context, task *models.Task) (*models.BeaconRound, error) {
+	source := s.nonceService.Source()
+	if task.SelectionRound == 0 {
+		round, err := source.Latest(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get selection randomness: %w", err)
+		}
+		return round, nil
+	}
+
+	latest, err := source.Latest(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get selection randomness: %w", err)
+	}
+	if latest.Round < task.SelectionRound {
+		return nil, fmt.Errorf("%w: waiting for beacon round %d", ErrRunnerUnavailable, task.SelectionRound)
+	}
+
+	round, err := source.Round(ctx, task.SelectionRound)
+	if err != nil {
+		if errors.Is(err, ErrRandomnessNotReady) {
+			return nil, fmt.Errorf("%w: %v", ErrRunnerUnavailable, err)
+		}
+		return nil, fmt.Errorf("failed to fetch beacon round %d: %w", task.SelectionRound, err)
+	}
+	return round, nil
+}
+
+// VerifyRunnerSelection re-fetches the beacon round behind every draw recorded on
+// the task, checks it and recomputes the selected runner.
+func (s *TaskService) VerifyRunnerSelection(ctx context.Context, task *models.Task) error {
+	if len(task.Selections) == 0 {
+		return ErrNoRunnerSelection
+	}
+
+	source := s.nonceService.Source()
+	excluded := make(map[string]bool, len(task.Selections))
+	for i, selection := range task.Selections {
+		if selection.Source != source.Name() || selection.Round == 0 {
+			return fmt.Errorf("%w: selection %d was drawn from %s", ErrRandomnessUnverifiable, i, selection.Source)
+		}
+		if task.SelectionRound != 0 && selection.Round != task.SelectionRound {
+			return fmt.Errorf("selection %d used beacon round %d but the task committed to round %d", i, selection.Round, task.SelectionRound)
+		}
+		if selection.Reason == selectionReasonExhausted {
+			excluded = make(map[string]bool, len(task.Selections))
+		}
+		for _, candidate := range selection.Candidates {
+			if excluded[candidate] {
+				return fmt.Errorf("selection %d offered the task again to %s, which dropped out earlier", i, candidate)
+			}
+		}
+		excluded[selection.SelectedRunnerID] = true
+
+		round, err := source.Round(ctx, selection.Round)
+		if err != nil {
+			return fmt.Errorf("failed to fetch beacon round %d: %w", selection.Round, err)
+		}
+		if err := source.Verify(ctx, round); err != nil {
+			return err
+		}
+		if !bytes.Equal(round.Randomness, selection.Randomness) {
+			return fmt.Errorf("selection %d randomness does not match beacon round %d", i, selection.Round)
+		}
+
+		expected, _ := SelectRunner(round.Randomness, task.ID.String(), selection.Candidates)
+		if expected != selection.SelectedRunnerID {
+			return fmt.Errorf("selection %d chose %s but beacon round %d selects %s", i, selection.SelectedRunnerID, selection.Round, expected)
+		}
+	}
+
+	return nil
+}
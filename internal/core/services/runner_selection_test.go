@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+func TestSelectRunnerIgnoresCandidateOrder(t *testing.T) {
+	randomness := []byte("beacon-randomness")
+
+	first, sorted := SelectRunner(randomness, "task-1", []string{"runner-c", "runner-a", "runner-b", "runner-a"})
+	second, _ := SelectRunner(randomness, "task-1", []string{"runner-b", "runner-c", "runner-a"})
+
+	if first != second {
+		t.Fatalf("expected same runner regardless of order, got %s and %s", first, second)
+	}
+	if len(sorted) != 3 || sorted[0] != "runner-a" || sorted[2] != "runner-c" {
+		t.Fatalf("expected sorted unique candidates, got %v", sorted)
+	}
+}
+
+func TestAssignHighValueTaskOnlyToSelectedRunner(t *testing.T) {
+	ctx := context.Background()
+
+	taskRepo := newInMemoryTaskRepo()
+	runnerRepo := newInMemoryRunnerRepo()
+	runnerService := NewRunnerService(runnerRepo)
+	taskService := NewTaskService(taskRepo, nil, runnerService)
+	taskService.SetNonceService(NewNonceService(NewLocalBeacon([]byte("test-seed"), time.Unix(0, 0), time.Second)))
+
+	for _, deviceID := range []string{"runner-a", "runner-b", "runner-c"} {
+		if err := runnerRepo.Create(ctx, &models.Runner{DeviceID: deviceID, Status: models.RunnerStatusOnline}); err != nil {
+			t.Fatalf("failed to seed runner: %v", err)
+		}
+	}
+
+	task := models.NewTask()
+	task.Title = "sensitive"
+	task.Type = models.TaskTypeCommand
+	task.HighValue = true
+	task.Config, _ = json.Marshal(models.TaskConfig{})
+	if err := taskRepo.Create(ctx, task); err != nil {
+		t.Fatalf("failed to seed task: %v", err)
+	}
+
+	selection, err := taskService.ensureRunnerSelection(ctx, task)
+	if err != nil {
+		t.Fatalf("ensureRunnerSelection returned error: %v", err)
+	}
+
+	for _, deviceID := range []string{"runner-a", "runner-b", "runner-c"} {
+		if deviceID == selection.SelectedRunnerID {
+			continue
+		}
+		err := taskService.AssignTaskToRunner(ctx, task.ID.String(), deviceID)
+		if !errors.Is(err, ErrRunnerNotSelected) || !errors.Is(err, ErrRunnerUnavailable) {
+			t.Fatalf("expected ErrRunnerNotSelected for %s, got %v", deviceID, err)
+		}
+	}
+
+	stored, err := taskRepo.Get(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("failed to reload task: %v", err)
+	}
+	if len(stored.Selections) != 1 {
+		t.Fatalf("expected a single recorded selection, got %d", len(stored.Selections))
+	}
+	if err := taskService.VerifyRunnerSelection(ctx, stored); err != nil {
+		t.Fatalf("VerifyRunnerSelection returned error: %v", err)
+	}
+
+	stored.Selections[0].SelectedRunnerID = "runner-z"
+	if err := taskService.VerifyRunnerSelection(ctx, stored); err == nil {
+		t.Fatal("expected tampered selection to fail verification")
+	}
+}
+
+func TestHighValueRedrawUsesCommittedRoundAndExcludesDropout(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Unix(1000, 0)
+	beacon := NewLocalBeacon([]byte("test-seed"), time.Unix(0, 0), time.Second)
+	beacon.now = func() time.Time { return now }
+
+	taskRepo := newInMemoryTaskRepo()
+	runnerRepo := newInMemoryRunnerRepo()
+	runnerService := NewRunnerService(runnerRepo)
+	taskService := NewTaskService(taskRepo, nil, runnerService)
+	taskService.SetNonceService(NewNonceService(beacon))
+
+	for _, deviceID := range []string{"runner-a", "runner-b", "runner-c"} {
+		if err := runnerRepo.Create(ctx, &models.Runner{DeviceID: deviceID, Status: models.RunnerStatusOnline}); err != nil {
+			t.Fatalf("failed to seed runner: %v", err)
+		}
+	}
+
+	task := models.NewTask()
+	task.Title = "sensitive"
+	task.Type = models.TaskTypeCommand
+	task.HighValue = true
+	task.Config, _ = json.Marshal(models.TaskConfig{})
+	if err := taskService.CreateTask(ctx, task); err != nil {
+		t.Fatalf("CreateTask returned error: %v", err)
+	}
+
+	latest, _ := beacon.Latest(ctx)
+	if task.SelectionRound != latest.Round+1 {
+		t.Fatalf("expected the task to commit to round %d, got %d", latest.Round+1, task.SelectionRound)
+	}
+
+	if _, err := taskService.ensureRunnerSelection(ctx, task); !errors.Is(err, ErrRunnerUnavailable) {
+		t.Fatalf("expected no draw before the committed round, got %v", err)
+	}
+
+	now = now.Add(5 * time.Second)
+	first, err := taskService.ensureRunnerSelection(ctx, task)
+	if err != nil {
+		t.Fatalf("ensureRunnerSelection returned error: %v", err)
+	}
+	if first.Round != task.SelectionRound {
+		t.Fatalf("expected the draw to use round %d, got %d", task.SelectionRound, first.Round)
+	}
+	dropout := first.SelectedRunnerID
+
+	// The selected runner goes offline once the round is public.
+	runner, _ := runnerRepo.Get(ctx, dropout)
+	runner.Status = models.RunnerStatusOffline
+	if _, err := runnerRepo.Update(ctx, runner); err != nil {
+		t.Fatalf("failed to update runner: %v", err)
+	}
+	now = now.Add(time.Minute)
+
+	second, err := taskService.ensureRunnerSelection(ctx, task)
+	if err != nil {
+		t.Fatalf("ensureRunnerSelection returned error: %v", err)
+	}
+	if second.Round != task.SelectionRound {
+		t.Fatalf("expected the redraw to reuse round %d, got %d", task.SelectionRound, second.Round)
+	}
+	if second.SelectedRunnerID == dropout || len(second.Candidates) != 2 {
+		t.Fatalf("expected the redraw to exclude %s, got %s from %v", dropout, second.SelectedRunnerID, second.Candidates)
+	}
+
+	stored, _ := taskRepo.Get(ctx, task.ID)
+	if err := taskService.VerifyRunnerSelection(ctx, stored); err != nil {
+		t.Fatalf("VerifyRunnerSelection returned error: %v", err)
+	}
+
+	stored.Selections[1].Candidates = append(stored.Selections[1].Candidates, dropout)
+	if err := taskService.VerifyRunnerSelection(ctx, stored); err == nil {
+		t.Fatal("expected a redraw that offers the task to the dropout again to fail verification")
+	}
+}
@@ -3,7 +3,6 @@ package services
 import (
 	"bytes"
 	"context"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,11 +10,16 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/theblitlabs/gologger"
 	"github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/internal/database/repositories"
 )
 
-var ErrRunnerNotFound = errors.New("runner not found")
+var (
+	ErrRunnerNotFound      = repositories.ErrRunnerNotFound
+	ErrRunnerForcedOffline = errors.New("runner was taken offline by an operator")
+)
 
 type RunnerRepository interface {
 	Create(ctx context.Context, runner *models.Runner) error
@@ -27,12 +31,26 @@ type RunnerRepository interface {
 	GetOnlineRunners(ctx context.Context) ([]*models.Runner, error)
 	GetRunnerByDeviceID(ctx context.Context, deviceID string) (*models.Runner, error)
 	UpdateModelCapabilities(ctx context.Context, runnerID string, capabilities []models.ModelCapability) error
+	UpdateDrainState(ctx context.Context, deviceID string, status models.RunnerStatus, maintenanceUntil *time.Time) (*models.Runner, error)
+	SetForcedOffline(ctx context.Context, deviceID string, forced bool) (*models.Runner, error)
+	BindWallet(ctx context.Context, deviceID string, walletAddress string) (*models.Runner, error)
+	AcquireSlot(ctx context.Context, assignment *models.RunnerAssignment) (bool, error)
+	ReleaseSlot(ctx context.Context, deviceID string, workID uuid.UUID) error
+	List(ctx context.Context, filter models.RunnerFilter) ([]*models.Runner, error)
+	Delete(ctx context.Context, deviceID string) error
+	VersionDistribution(ctx context.Context) ([]models.RunnerVersionCount, error)
 }
 
 type RunnerService struct {
 	repo             RunnerRepository
 	taskService      *TaskService
+	runnerHub        *RunnerHub
+	webhookService   *WebhookService
+	webhookSigner    *WebhookSigner
+	telemetryService *TelemetryService
+	cacheService     *RunnerCacheService
 	heartbeatTimeout time.Duration
+	minProtocol      int
 	taskMonitorCh    chan struct{}
 }
 
@@ -40,6 +58,7 @@ func NewRunnerService(repo RunnerRepository) *RunnerService {
 	return &RunnerService{
 		repo:             repo,
 		heartbeatTimeout: 2 * time.Minute,
+		minProtocol:      models.RunnerProtocolLegacy,
 		taskMonitorCh:    make(chan struct{}, 10),
 	}
 }
@@ -50,6 +69,29 @@ func (s *RunnerService) SetTaskService(taskService *TaskService) {
 	go s.taskMonitorWorker()
 }
 
+func (s *RunnerService) SetRunnerHub(runnerHub *RunnerHub) {
+	s.runnerHub = runnerHub
+}
+
+// SetWebhookService routes prompt forwards through the webhook outbox. Prompts
+// whose delivery is dead-lettered fail their task.
+func (s *RunnerService) SetWebhookService(webhookService *WebhookService) {
+	s.webhookService = webhookService
+	webhookService.OnDeadLetter(s.handleDeadLetteredWebhook)
+}
+
+func (s *RunnerService) SetWebhookSigner(signer *WebhookSigner) {
+	s.webhookSigner = signer
+}
+
+func (s *RunnerService) handleDeadLetteredWebhook(ctx context.Context, delivery *models.WebhookDelivery) {
+	if delivery.EventType != models.WebhookEventPromptForward || delivery.TaskID == "" {
+		return
+	}
+	s.cleanupFailedTask(ctx, delivery.TaskID, delivery.DeviceID,
+		fmt.Sprintf("Webhook delivery failed after %d attempts: %s", delivery.Attempts, delivery.LastError))
+}
+
 func (s *RunnerService) taskMonitorWorker() {
 	var timer *time.Timer
 	for range s.taskMonitorCh {
@@ -93,8 +135,24 @@ func (s *RunnerService) TriggerTaskMonitor() {
 	s.triggerTaskMonitor()
 }
 
+// SetTelemetryService records heartbeat telemetry. Gaps longer than the
+// heartbeat timeout do not count as online time.
+func (s *RunnerService) SetTelemetryService(telemetryService *TelemetryService) {
+	s.telemetryService = telemetryService
+	telemetryService.SetGapTolerance(s.heartbeatTimeout)
+}
+
+// SetRunnerCacheService indexes the images and models runners report in their
+// heartbeats.
+func (s *RunnerService) SetRunnerCacheService(cacheService *RunnerCacheService) {
+	s.cacheService = cacheService
+}
+
 func (s *RunnerService) SetHeartbeatTimeout(timeout time.Duration) {
 	s.heartbeatTimeout = timeout
+	if s.telemetryService != nil {
+		s.telemetryService.SetGapTolerance(timeout)
+	}
 }
 
 func (s *RunnerService) CreateRunner(ctx context.Context, runner *models.Runner) error {
@@ -112,6 +170,8 @@ func (s *RunnerService) CreateOrUpdateRunner(ctx context.Context, runner *models
 
 	if err != nil {
 		isNewOrBecomingAvailable = runner.Status == models.RunnerStatusOnline
+	} else if existingRunner.ForcedOffline {
+		return nil, ErrRunnerForcedOffline
 	} else {
 		isNewOrBecomingAvailable = (existingRunner.Status == models.RunnerStatusOffline ||
 			existingRunner.Status == models.RunnerStatusBusy) &&
@@ -154,6 +214,9 @@ func (s *RunnerService) UpdateRunnerStatus(ctx context.Context, runner *models.R
 	if err != nil {
 		return nil, err
 	}
+	if existingRunner.ForcedOffline && runner.Status != models.RunnerStatusOffline {
+		return nil, ErrRunnerForcedOffline
+	}
 
 	becomingAvailable := (existingRunner.Status == models.RunnerStatusOffline ||
 		existingRunner.Status == models.RunnerStatusBusy) &&
@@ -176,6 +239,13 @@ func (s *RunnerService) UpdateRunnerStatus(ctx context.Context, runner *models.R
 		existingRunner.Webhook = runner.Webhook
 	}
 
+	if runner.ProtocolVersion > 0 {
+		existingRunner.ProtocolVersion = runner.ProtocolVersion
+	}
+	if runner.SoftwareVersion != "" {
+		existingRunner.SoftwareVersion = runner.SoftwareVersion
+	}
+
 	updatedRunner, err := s.repo.Update(ctx, existingRunner)
 	if err != nil {
 		return nil, err
@@ -188,6 +258,141 @@ func (s *RunnerService) UpdateRunnerStatus(ctx context.Context, runner *models.R
 	return updatedRunner, nil
 }
 
+// SetMinimumProtocol rejects runners that cannot speak at least this protocol.
+func (s *RunnerService) SetMinimumProtocol(version int) {
+	if version > 0 {
+		s.minProtocol = version
+	}
+}
+
+// NegotiateProtocol matches a runner's reported protocol against the
+// compatibility matrix.
+func (s *RunnerService) NegotiateProtocol(requested int) (*models.ProtocolNegotiation, error) {
+	return models.NegotiateRunnerProtocol(requested, s.minProtocol)
+}
+
+// ProtocolVersion returns the protocol negotiated with the runner, falling back
+// to the legacy protocol when the runner is unknown.
+func (s *RunnerService) ProtocolVersion(ctx context.Context, deviceID string) int {
+	runner, err := s.repo.Get(ctx, deviceID)
+	if err != nil || runner.ProtocolVersion < 1 {
+		return models.RunnerProtocolLegacy
+	}
+	return runner.ProtocolVersion
+}
+
+// VersionDistribution reports how many runners speak each protocol and
+// software version, alongside the compatibility matrix.
+func (s *RunnerService) VersionDistribution(ctx context.Context) (*models.RunnerVersionDistribution, error) {
+	versions, err := s.repo.VersionDistribution(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &models.RunnerVersionDistribution{
+		Protocols: models.RunnerProtocolMatrix(s.minProtocol),
+		Current:   models.CurrentRunnerProtocol,
+		Versions:  versions,
+	}, nil
+}
+
+// RecordHeartbeat refreshes the runner's status and stores the heartbeat's
+// telemetry. A telemetry write failure is logged and does not fail the
+// heartbeat.
+func (s *RunnerService) RecordHeartbeat(ctx context.Context, runner *models.Runner, telemetry models.HeartbeatTelemetry) (*models.Runner, error) {
+	updatedRunner, err := s.UpdateRunnerStatus(ctx, runner)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.telemetryService != nil {
+		if err := s.telemetryService.RecordHeartbeat(ctx, runner.DeviceID, time.Now(), telemetry); err != nil {
+			log := gologger.WithComponent("runner_service")
+			log.Error().Err(err).Str("device_id", runner.DeviceID).Msg("Failed to record heartbeat telemetry")
+		}
+	}
+
+	return updatedRunner, nil
+}
+
+// RecordCacheReport stores the image and model cache a runner reported with a
+// heartbeat. Like telemetry, a failure is logged and does not fail the
+// heartbeat.
+func (s *RunnerService) RecordCacheReport(ctx context.Context, deviceID string, report models.RunnerCacheReport) {
+	if s.cacheService == nil {
+		return
+	}
+	if err := s.cacheService.Record(ctx, deviceID, report); err != nil {
+		log := gologger.WithComponent("runner_service")
+		log.Error().Err(err).Str("device_id", deviceID).Msg("Failed to record runner cache report")
+	}
+}
+
+// DrainRunner takes a runner out of rotation: it keeps its current task but is
+// not offered new tasks, prompts or FL rounds until it is undrained. Heartbeats
+// missed before maintenanceUntil are not held against the runner.
+func (s *RunnerService) DrainRunner(ctx context.Context, deviceID string, maintenanceUntil *time.Time) (*models.Runner, error) {
+	if err := s.rejectForcedOffline(ctx, deviceID); err != nil {
+		return nil, err
+	}
+	runner, err := s.repo.UpdateDrainState(ctx, deviceID, models.RunnerStatusDraining, maintenanceUntil)
+	if err != nil {
+		return nil, err
+	}
+
+	log := gologger.WithComponent("runner_service")
+	event := log.Info().Str("device_id", deviceID)
+	if maintenanceUntil != nil {
+		event = event.Time("maintenance_until", *maintenanceUntil)
+	}
+	event.Msg("Runner draining")
+
+	return runner, nil
+}
+
+// UndrainRunner returns a drained runner to rotation and offers it pending work.
+// Work it kept while draining goes on holding its slots.
+func (s *RunnerService) UndrainRunner(ctx context.Context, deviceID string) (*models.Runner, error) {
+	if err := s.rejectForcedOffline(ctx, deviceID); err != nil {
+		return nil, err
+	}
+	runner, err := s.repo.UpdateDrainState(ctx, deviceID, models.RunnerStatusOnline, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	log := gologger.WithComponent("runner_service")
+	log.Info().Str("device_id", deviceID).Msg("Runner undrained")
+
+	s.triggerTaskMonitor()
+
+	return runner, nil
+}
+
+// rejectForcedOffline returns ErrRunnerForcedOffline while an operator holds the
+// runner offline.
+func (s *RunnerService) rejectForcedOffline(ctx context.Context, deviceID string) error {
+	runner, err := s.repo.Get(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+	if runner.ForcedOffline {
+		return ErrRunnerForcedOffline
+	}
+	return nil
+}
+
+// AcquireSlot reserves one of the runner's slots for a task, prompt or FL
+// training round. It reports false when the runner has no free slot.
+func (s *RunnerService) AcquireSlot(ctx context.Context, deviceID string, workID uuid.UUID, kind models.AssignmentKind) (bool, error) {
+	return s.repo.AcquireSlot(ctx, models.NewRunnerAssignment(deviceID, workID, kind))
+}
+
+// ReleaseSlot frees the slot workID holds on the runner. Releasing a slot that
+// is not held is not an error.
+func (s *RunnerService) ReleaseSlot(ctx context.Context, deviceID string, workID uuid.UUID) error {
+	return s.repo.ReleaseSlot(ctx, deviceID, workID)
+}
+
 func (s *RunnerService) ForwardPromptToRunner(ctx context.Context, runnerID string, promptReq *models.PromptRequest) error {
 	log := gologger.WithComponent("runner_service")
 
@@ -197,16 +402,28 @@ func (s *RunnerService) ForwardPromptToRunner(ctx context.Context, runnerID stri
 		return fmt.Errorf("failed to get runner: %w", err)
 	}
 
-	if runner.Webhook == "" {
+	connected := s.runnerHub != nil && s.runnerHub.IsConnected(runnerID)
+	if runner.Webhook == "" && !runner.PullsTasks() && !connected {
 		log.Error().Str("runner_id", runnerID).Msg("Runner has no webhook URL")
 		return fmt.Errorf("runner %s has no webhook URL", runnerID)
 	}
 
-	// Create LLM task config in the format expected by the runner executor
-	configData, err := json.Marshal(map[string]interface{}{
+	// Create LLM task config in the format expected by the runner executor.
+	// Sampling parameters are only sent when the client set them.
+	config := map[string]interface{}{
 		"model":  promptReq.ModelName,
 		"prompt": promptReq.Prompt,
-	})
+	}
+	environment := map[string]interface{}{
+		"MODEL":  promptReq.ModelName,
+		"PROMPT": promptReq.Prompt,
+	}
+	for name, value := range promptReq.SamplingParams.Options() {
+		config[name] = value
+		environment[strings.ToUpper(name)] = value
+	}
+
+	configData, err := json.Marshal(config)
 	if err != nil {
 		log.Error().Err(err).Str("runner_id", runnerID).Msg("Failed to marshal task config")
 		return fmt.Errorf("failed to marshal task config: %w", err)
@@ -219,25 +436,22 @@ func (s *RunnerService) ForwardPromptToRunner(ctx context.Context, runnerID stri
 		Type:        models.TaskTypeLLM,
 		Config:      configData,
 		Environment: &models.EnvironmentConfig{
-			Type: "llm",
-			Config: map[string]interface{}{
-				"MODEL":  promptReq.ModelName,
-				"PROMPT": promptReq.Prompt,
-			},
+			Type:   "llm",
+			Config: environment,
 		},
 		CreatorAddress:  promptReq.CreatorAddress,
 		CreatorDeviceID: "server",
 		RunnerID:        runnerID,
 		Reward:          0.0,
-		Nonce:           hex.EncodeToString([]byte(fmt.Sprintf("%d", time.Now().UnixNano()))),
 		Status:          models.TaskStatusPending,
 		CreatedAt:       time.Now(),
 		UpdatedAt:       time.Now(),
 	}
 
-	// Store the task in the database so the runner can find it
+	// Store the task in the database so the runner can find it. A queued
+	// prompt that failed to forward before reuses the task row of that attempt.
 	if s.taskService != nil {
-		if err := s.taskService.CreateTask(ctx, task); err != nil {
+		if err := s.taskService.CreateOrResetTask(ctx, task); err != nil {
 			log.Error().Err(err).Str("runner_id", runnerID).Str("task_id", task.ID.String()).Msg("Failed to store task in database")
 			return fmt.Errorf("failed to store task in database: %w", err)
 		}
@@ -257,10 +471,29 @@ func (s *RunnerService) ForwardPromptToRunner(ctx context.Context, runnerID stri
 		return fmt.Errorf("taskService not available")
 	}
 
-	// Create webhook message
-	type WebhookMessage struct {
-		Type    string          `json:"type"`
-		Payload json.RawMessage `json:"payload"`
+	if runner.PullsTasks() {
+		log.Info().
+			Str("runner_id", runnerID).
+			Str("prompt_id", promptReq.ID.String()).
+			Msg("Prompt task leased to pull-mode runner")
+		return nil
+	}
+
+	if connected {
+		err := s.runnerHub.Send(ctx, runnerID, models.RunnerMessagePromptForward, task)
+		if err == nil {
+			log.Info().
+				Str("runner_id", runnerID).
+				Str("prompt_id", promptReq.ID.String()).
+				Msg("Prompt forwarded to runner over its connection")
+			return nil
+		}
+		if !errors.Is(err, ErrRunnerNotConnected) || runner.Webhook == "" {
+			log.Error().Err(err).Str("runner_id", runnerID).Msg("Failed to forward prompt over runner connection")
+			s.cleanupFailedTask(ctx, task.ID.String(), runnerID, fmt.Sprintf("Connection delivery failed: %v", err))
+			return fmt.Errorf("failed to forward prompt over runner connection: %w", err)
+		}
+		log.Info().Str("runner_id", runnerID).Msg("Runner connection dropped, falling back to webhook")
 	}
 
 	taskPayload, err := json.Marshal(task)
@@ -270,9 +503,11 @@ func (s *RunnerService) ForwardPromptToRunner(ctx context.Context, runnerID stri
 		return fmt.Errorf("failed to marshal task payload: %w", err)
 	}
 
-	message := WebhookMessage{
-		Type:    "available_tasks",
-		Payload: taskPayload,
+	message, err := newWebhookMessage(runner.ProtocolVersion, models.RunnerMessagePromptForward, json.RawMessage(taskPayload))
+	if err != nil {
+		log.Error().Err(err).Str("runner_id", runnerID).Msg("Failed to build webhook message")
+		s.cleanupFailedTask(ctx, task.ID.String(), runnerID, "Failed to build webhook message")
+		return err
 	}
 
 	messageBytes, err := json.Marshal(message)
@@ -288,6 +523,15 @@ func (s *RunnerService) ForwardPromptToRunner(ctx context.Context, runnerID stri
 		Str("webhook", runner.Webhook).
 		Msg("Forwarding prompt to runner")
 
+	if s.webhookService != nil {
+		if _, err := s.webhookService.Enqueue(ctx, runnerID, runner.Webhook, models.WebhookEventPromptForward, task.ID.String(), message); err != nil {
+			log.Error().Err(err).Str("runner_id", runnerID).Msg("Failed to queue prompt for webhook delivery")
+			s.cleanupFailedTask(ctx, task.ID.String(), runnerID, "Failed to queue prompt for webhook delivery")
+			return fmt.Errorf("failed to queue prompt for webhook delivery: %w", err)
+		}
+		return nil
+	}
+
 	// Send HTTP request to runner webhook (with longer timeout)
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", runner.Webhook, bytes.NewBuffer(messageBytes))
 	if err != nil {
@@ -298,6 +542,14 @@ func (s *RunnerService) ForwardPromptToRunner(ctx context.Context, runnerID stri
 
 	httpReq.Header.Set("Content-Type", "application/json")
 
+	if s.webhookSigner != nil {
+		if err := s.webhookSigner.SignRequest(ctx, httpReq, runnerID, messageBytes); err != nil {
+			log.Error().Err(err).Str("runner_id", runnerID).Msg("Failed to sign webhook request")
+			s.cleanupFailedTask(ctx, task.ID.String(), runnerID, "Failed to sign webhook request")
+			return fmt.Errorf("failed to sign webhook request: %w", err)
+		}
+	}
+
 	client := &http.Client{
 		Timeout: 10 * time.Second, // Webhook should respond immediately
 	}
@@ -343,6 +595,12 @@ func (s *RunnerService) cleanupFailedTask(ctx context.Context, taskID, runnerID,
 		Str("reason", reason).
 		Msg("Cleaning up failed task")
 
+	if workID, err := uuid.Parse(taskID); err == nil {
+		if err := s.ReleaseSlot(ctx, runnerID, workID); err != nil {
+			log.Error().Err(err).Str("task_id", taskID).Str("runner_id", runnerID).Msg("Failed to release runner slot during cleanup")
+		}
+	}
+
 	if s.taskService != nil {
 		// FailTask will mark task as failed and clear runner assignment
 		if err := s.taskService.FailTask(ctx, taskID, reason); err != nil {
@@ -406,25 +664,69 @@ func (s *RunnerService) StopTaskMonitor() error {
 	return nil
 }
 
-func (s *RunnerService) GetAvailableRunnerForModel(ctx context.Context, modelName string) (string, error) {
+// ReserveRunnerForModel picks a runner with the model loaded and a free slot,
+// and reserves the slot for the prompt. A maxTokens above zero also requires
+// the runner to allow completions that long. Runners that report the model in
+// their cache are tried first.
+func (s *RunnerService) ReserveRunnerForModel(ctx context.Context, modelName string, maxTokens int, promptID uuid.UUID) (string, error) {
 	runners, err := s.repo.GetOnlineRunners(ctx)
 	if err != nil {
 		return "", fmt.Errorf("failed to get online runners: %w", err)
 	}
 
-	for _, runner := range runners {
-		// Check if runner is actually available (no task assigned)
-		if runner.TaskID != nil {
-			continue // Runner is busy with another task
+	for _, runner := range s.PreferWarmForModel(runners, modelName) {
+		if runner.FreeSlots() == 0 {
+			continue
+		}
+		capability := runner.LoadedModel(modelName)
+		if capability == nil || !capability.SupportsMaxTokens(maxTokens) {
+			continue
 		}
 
-		// Check if runner has the required model capability
-		for _, capability := range runner.ModelCapabilities {
-			if capability.ModelName == modelName && capability.IsLoaded {
-				if runner.Status == models.RunnerStatusOnline {
-					return runner.DeviceID, nil
-				}
+		acquired, err := s.AcquireSlot(ctx, runner.DeviceID, promptID, models.AssignmentKindPrompt)
+		if err != nil {
+			return "", fmt.Errorf("failed to reserve runner slot: %w", err)
+		}
+		if acquired {
+			if s.cacheService != nil {
+				s.cacheService.RecordModelAssignment(runner.DeviceID, modelName)
 			}
+			return runner.DeviceID, nil
+		}
+	}
+
+	return "", fmt.Errorf("no available runner found for model %s", modelName)
+}
+
+// PreferWarmForModel orders runners that hold the model in their cache ahead
+// of those that do not, keeping the repository's order otherwise. Prompts are
+// never held for a warm runner, so a cold runner still gets the prompt when
+// no warm one has a free slot.
+func (s *RunnerService) PreferWarmForModel(runners []*models.Runner, modelName string) []*models.Runner {
+	if s.cacheService == nil {
+		return runners
+	}
+	ordered := make([]*models.Runner, 0, len(runners))
+	var cold []*models.Runner
+	for _, runner := range runners {
+		if s.cacheService.HoldsModel(runner.DeviceID, modelName) {
+			ordered = append(ordered, runner)
+		} else {
+			cold = append(cold, runner)
+		}
+	}
+	return append(ordered, cold...)
+}
+
+func (s *RunnerService) GetAvailableRunnerForModel(ctx context.Context, modelName string) (string, error) {
+	runners, err := s.repo.GetOnlineRunners(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get online runners: %w", err)
+	}
+
+	for _, runner := range runners {
+		if runner.FreeSlots() > 0 && runner.HasLoadedModel(modelName) {
+			return runner.DeviceID, nil
 		}
 	}
 
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+func newPendingCommandTask(createdAt time.Time) *models.Task {
+	task := models.NewTask()
+	task.Type = models.TaskTypeCommand
+	task.Status = models.TaskStatusPending
+	task.CreatedAt = createdAt
+	return task
+}
+
+func TestRunnerTakesPendingTasksUpToItsSlots(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	taskRepo := newInMemoryTaskRepo()
+	runnerRepo := newInMemoryRunnerRepo()
+	runnerService := NewRunnerService(runnerRepo)
+	taskService := NewTaskService(taskRepo, nil, runnerService)
+
+	runnerRepo.runners["runner-1"] = &models.Runner{
+		DeviceID: "runner-1",
+		Status:   models.RunnerStatusOnline,
+		Webhook:  server.URL,
+		Slots:    2,
+	}
+
+	now := time.Now()
+	tasks := []*models.Task{
+		newPendingCommandTask(now.Add(-3 * time.Minute)),
+		newPendingCommandTask(now.Add(-2 * time.Minute)),
+		newPendingCommandTask(now.Add(-time.Minute)),
+	}
+	for _, task := range tasks {
+		taskRepo.tasks[task.ID] = cloneTask(task)
+	}
+
+	if err := taskService.checkAndAssignPendingTasksToRunner(ctx, "runner-1"); err != nil {
+		t.Fatalf("checkAndAssignPendingTasksToRunner returned error: %v", err)
+	}
+
+	runner, _ := runnerRepo.Get(ctx, "runner-1")
+	if len(runner.Assignments) != 2 || !runner.HasAssignment(tasks[0].ID) || !runner.HasAssignment(tasks[1].ID) {
+		t.Fatalf("expected the two oldest tasks to hold the runner's slots, got %+v", runner.Assignments)
+	}
+	if stored, _ := taskRepo.Get(ctx, tasks[2].ID); stored.RunnerID != "" {
+		t.Fatalf("expected third task to wait for a free slot, got runner %q", stored.RunnerID)
+	}
+
+	available, err := taskService.getAvailableRunners(ctx)
+	if err != nil {
+		t.Fatalf("getAvailableRunners returned error: %v", err)
+	}
+	if len(available) != 0 {
+		t.Fatalf("expected a runner with every slot taken to be unavailable, got %d", len(available))
+	}
+
+	if err := taskService.FailTask(ctx, tasks[0].ID.String(), "test"); err != nil {
+		t.Fatalf("FailTask returned error: %v", err)
+	}
+	if err := taskService.checkAndAssignPendingTasksToRunner(ctx, "runner-1"); err != nil {
+		t.Fatalf("checkAndAssignPendingTasksToRunner returned error: %v", err)
+	}
+
+	runner, _ = runnerRepo.Get(ctx, "runner-1")
+	if len(runner.Assignments) != 2 || !runner.HasAssignment(tasks[2].ID) {
+		t.Fatalf("expected freed slot to go to the waiting task, got %+v", runner.Assignments)
+	}
+}
+
+func TestPromptsAndTasksShareRunnerSlots(t *testing.T) {
+	ctx := context.Background()
+	runnerRepo := newInMemoryRunnerRepo()
+	runnerService := NewRunnerService(runnerRepo)
+
+	task := models.NewTask()
+	runnerRepo.runners["runner-1"] = &models.Runner{
+		DeviceID:          "runner-1",
+		Status:            models.RunnerStatusOnline,
+		Slots:             2,
+		Assignments:       []models.RunnerAssignment{*models.NewRunnerAssignment("runner-1", task.ID, models.AssignmentKindTask)},
+		ModelCapabilities: []models.ModelCapability{{ModelName: "llama3", IsLoaded: true}},
+	}
+
+	first := models.NewPromptRequest("client-1", "hi", "llama3", "0xabc")
+	runnerID, err := runnerService.ReserveRunnerForModel(ctx, "llama3", 0, first.ID)
+	if err != nil || runnerID != "runner-1" {
+		t.Fatalf("expected prompt to take the free slot on runner-1, got %q and error %v", runnerID, err)
+	}
+
+	second := models.NewPromptRequest("client-1", "hi again", "llama3", "0xabc")
+	if _, err := runnerService.ReserveRunnerForModel(ctx, "llama3", 0, second.ID); err == nil {
+		t.Fatal("expected no runner to be available once the task and prompt fill both slots")
+	}
+
+	if err := runnerService.ReleaseSlot(ctx, "runner-1", first.ID); err != nil {
+		t.Fatalf("ReleaseSlot returned error: %v", err)
+	}
+	if runnerID, err := runnerService.ReserveRunnerForModel(ctx, "llama3", 0, second.ID); err != nil || runnerID != "runner-1" {
+		t.Fatalf("expected released slot to be reusable, got %q and error %v", runnerID, err)
+	}
+}
@@ -2,15 +2,71 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"path"
 
-	"github.com/theblitlabs/parity-server/internal/core/config"
+	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/core/ports"
 )
 
 type StorageService interface {
-	UploadDockerImage(ctx context.Context, imageData []byte, imageName string) (string, error)
+	UploadDockerImage(ctx context.Context, image io.Reader, imageName string) (string, error)
 	DeleteDockerImage(ctx context.Context, imageURL string) error
 }
 
-func NewStorageService(cfg *config.Config) (StorageService, error) {
-	return NewBlockchainService(cfg)
+// NewStorageService stores Docker images in store. Image URLs end in the
+// object key, which is how DeleteDockerImage finds the object again.
+func NewStorageService(store ports.Storage) StorageService {
+	return &imageStorage{store: store}
+}
+
+type imageStorage struct {
+	store ports.Storage
+}
+
+func (s *imageStorage) UploadDockerImage(ctx context.Context, image io.Reader, imageName string) (string, error) {
+	log := gologger.WithComponent("storage")
+
+	filename := imageName + ".tar"
+
+	log.Info().
+		Str("filename", filename).
+		Str("backend", s.store.Backend()).
+		Msg("Uploading Docker image")
+
+	object, err := s.store.Put(ctx, image, filename)
+	if err != nil {
+		log.Error().Err(err).
+			Str("filename", filename).
+			Msg("Failed to upload Docker image")
+		return "", fmt.Errorf("failed to upload Docker image: %w", err)
+	}
+
+	log.Info().
+		Str("filename", filename).
+		Str("key", object.Key).
+		Str("url", object.URL).
+		Int64("size", object.Size).
+		Msg("Successfully uploaded Docker image")
+
+	return object.URL, nil
+}
+
+func (s *imageStorage) DeleteDockerImage(ctx context.Context, imageURL string) error {
+	log := gologger.WithComponent("storage")
+
+	key := path.Base(imageURL)
+	if err := s.store.Delete(ctx, key); err != nil {
+		log.Error().Err(err).
+			Str("key", key).
+			Msg("Failed to delete Docker image")
+		return fmt.Errorf("failed to delete Docker image: %w", err)
+	}
+
+	log.Info().
+		Str("key", key).
+		Msg("Deleted Docker image")
+
+	return nil
 }
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+var ErrRunnerNotPullMode = errors.New("runner is not in pull delivery mode")
+
+const (
+	defaultLeaseTTL        = time.Minute
+	defaultLongPollTimeout = 30 * time.Second
+)
+
+// leaseWaiter tracks the long polls open for one runner so the dispatcher can
+// wake them when it leases a task to that runner.
+type leaseWaiter struct {
+	wake    chan struct{}
+	pollers int
+}
+
+// NextTask leases the next task to a pull-mode runner, waiting up to wait for one
+// to become available. It returns a nil task when nothing was leased in time.
+// Polling again while a lease is held returns the same task and renews the lease.
+func (s *TaskService) NextTask(ctx context.Context, deviceID string, wait time.Duration) (*models.Task, error) {
+	if wait <= 0 || wait > s.longPollTimeout {
+		wait = s.longPollTimeout
+	}
+
+	waiter := s.acquireLeaseWaiter(deviceID)
+	defer s.releaseLeaseWaiter(deviceID)
+
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+
+	for {
+		task, err := s.leasedTask(ctx, deviceID)
+		if err != nil || task != nil {
+			return task, err
+		}
+
+		if err := s.checkAndAssignPendingTasksToRunner(ctx, deviceID); err != nil {
+			return nil, err
+		}
+
+		task, err = s.leasedTask(ctx, deviceID)
+		if err != nil || task != nil {
+			return task, err
+		}
+
+		select {
+		case <-waiter.wake:
+		case <-deadline.C:
+			return nil, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-s.stopChan:
+			return nil, nil
+		}
+	}
+}
+
+// RenewTaskLease extends the runner's hold on a task it was leased. For a task
+// that is already running it also counts as activity for stalled-task detection.
+func (s *TaskService) RenewTaskLease(ctx context.Context, taskID string, deviceID string) (*models.Task, error) {
+	taskUUID, err := uuid.Parse(taskID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid task ID: %w", err)
+	}
+
+	task, err := s.repo.Get(ctx, taskUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.RunnerID != deviceID {
+		return nil, ErrTaskUnavailable
+	}
+	if task.Status != models.TaskStatusPending && task.Status != models.TaskStatusRunning {
+		return nil, ErrTaskUnavailable
+	}
+
+	if err := s.extendLease(ctx, task); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// leasedTask returns the oldest pending task currently leased to the runner,
+// renewing its lease, or nil if the runner holds none.
+func (s *TaskService) leasedTask(ctx context.Context, deviceID string) (*models.Task, error) {
+	runner, err := s.runnerService.GetRunner(ctx, deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid runner ID: %w", err)
+	}
+	if !runner.PullsTasks() {
+		return nil, ErrRunnerNotPullMode
+	}
+
+	assignments := append([]models.RunnerAssignment(nil), runner.Assignments...)
+	sort.Slice(assignments, func(i, j int) bool {
+		return assignments[i].CreatedAt.Before(assignments[j].CreatedAt)
+	})
+
+	for _, assignment := range assignments {
+		if assignment.Kind == models.AssignmentKindPrompt {
+			continue
+		}
+
+		task, err := s.repo.Get(ctx, assignment.WorkID)
+		if err != nil {
+			if errors.Is(err, ErrTaskNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		if task.Status != models.TaskStatusPending || task.RunnerID != deviceID {
+			continue
+		}
+
+		if err := s.extendLease(ctx, task); err != nil {
+			return nil, err
+		}
+		return task, nil
+	}
+
+	return nil, nil
+}
+
+func (s *TaskService) extendLease(ctx context.Context, task *models.Task) error {
+	now := time.Now()
+	leaseExpiresAt := now.Add(s.leaseTTL)
+	task.LeaseExpiresAt = &leaseExpiresAt
+	task.UpdatedAt = now
+
+	if err := s.repo.Update(ctx, task); err != nil {
+		return fmt.Errorf("failed to renew task lease: %w", err)
+	}
+
+	log := gologger.WithComponent("task_service")
+	log.Debug().
+		Str("task_id", task.ID.String()).
+		Str("runner_id", task.RunnerID).
+		Time("lease_expires_at", leaseExpiresAt).
+		Msg("Task lease renewed")
+
+	return nil
+}
+
+func (s *TaskService) acquireLeaseWaiter(deviceID string) *leaseWaiter {
+	s.leaseWaitersMu.Lock()
+	defer s.leaseWaitersMu.Unlock()
+
+	waiter, ok := s.leaseWaiters[deviceID]
+	if !ok {
+		waiter = &leaseWaiter{wake: make(chan struct{}, 1)}
+		s.leaseWaiters[deviceID] = waiter
+	}
+	waiter.pollers++
+	return waiter
+}
+
+func (s *TaskService) releaseLeaseWaiter(deviceID string) {
+	s.leaseWaitersMu.Lock()
+	defer s.leaseWaitersMu.Unlock()
+
+	waiter, ok := s.leaseWaiters[deviceID]
+	if !ok {
+		return
+	}
+	waiter.pollers--
+	if waiter.pollers <= 0 {
+		delete(s.leaseWaiters, deviceID)
+	}
+}
+
+func (s *TaskService) isPolling(deviceID string) bool {
+	s.leaseWaitersMu.Lock()
+	defer s.leaseWaitersMu.Unlock()
+
+	_, ok := s.leaseWaiters[deviceID]
+	return ok
+}
+
+func (s *TaskService) wakeLeaseWaiter(deviceID string) {
+	s.leaseWaitersMu.Lock()
+	defer s.leaseWaitersMu.Unlock()
+
+	if waiter, ok := s.leaseWaiters[deviceID]; ok {
+		select {
+		case waiter.wake <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+func TestNextTaskLeasesToPollingPullRunner(t *testing.T) {
+	ctx := context.Background()
+
+	taskRepo := newInMemoryTaskRepo()
+	runnerRepo := newInMemoryRunnerRepo()
+	runnerService := NewRunnerService(runnerRepo)
+	taskService := NewTaskService(taskRepo, nil, runnerService)
+
+	if err := runnerRepo.Create(ctx, &models.Runner{DeviceID: "runner-pull", Status: models.RunnerStatusOnline, DeliveryMode: models.DeliveryModePull}); err != nil {
+		t.Fatalf("failed to seed runner: %v", err)
+	}
+	if err := runnerRepo.Create(ctx, &models.Runner{DeviceID: "runner-push", Status: models.RunnerStatusOnline, DeliveryMode: models.DeliveryModePush}); err != nil {
+		t.Fatalf("failed to seed runner: %v", err)
+	}
+
+	if _, err := taskService.NextTask(ctx, "runner-push", time.Millisecond); !errors.Is(err, ErrRunnerNotPullMode) {
+		t.Fatalf("expected ErrRunnerNotPullMode for a push runner, got %v", err)
+	}
+
+	task, err := taskService.NextTask(ctx, "runner-pull", 10*time.Millisecond)
+	if err != nil || task != nil {
+		t.Fatalf("expected an empty poll, got task %v and error %v", task, err)
+	}
+
+	pending := models.NewTask()
+	pending.Title = "pull me"
+	pending.Type = models.TaskTypeCommand
+	pending.Config, _ = json.Marshal(models.TaskConfig{})
+	if err := taskRepo.Create(ctx, pending); err != nil {
+		t.Fatalf("failed to seed task: %v", err)
+	}
+
+	// A pull runner that is not polling is skipped by the dispatcher.
+	if err := taskService.checkAndAssignPendingTasksToRunner(ctx, "runner-pull"); err != nil {
+		t.Fatalf("checkAndAssignPendingTasksToRunner returned error: %v", err)
+	}
+	if stored, _ := taskRepo.Get(ctx, pending.ID); stored.RunnerID != "" {
+		t.Fatalf("expected task to stay unleased while the runner is not polling, got %q", stored.RunnerID)
+	}
+
+	leased, err := taskService.NextTask(ctx, "runner-pull", time.Second)
+	if err != nil {
+		t.Fatalf("NextTask returned error: %v", err)
+	}
+	if leased == nil || leased.ID != pending.ID {
+		t.Fatalf("expected task %s to be leased, got %v", pending.ID, leased)
+	}
+	if leased.RunnerID != "runner-pull" || leased.LeaseExpiresAt == nil {
+		t.Fatalf("expected lease for runner-pull, got runner %q lease %v", leased.RunnerID, leased.LeaseExpiresAt)
+	}
+
+	again, err := taskService.NextTask(ctx, "runner-pull", time.Millisecond)
+	if err != nil || again == nil || again.ID != pending.ID {
+		t.Fatalf("expected re-poll to return the held lease, got %v and error %v", again, err)
+	}
+
+	if _, err := taskService.RenewTaskLease(ctx, pending.ID.String(), "runner-push"); !errors.Is(err, ErrTaskUnavailable) {
+		t.Fatalf("expected renewal by another runner to be rejected, got %v", err)
+	}
+
+	expired := time.Now().Add(-time.Second)
+	stored, _ := taskRepo.Get(ctx, pending.ID)
+	stored.LeaseExpiresAt = &expired
+	if err := taskRepo.Update(ctx, stored); err != nil {
+		t.Fatalf("failed to expire lease: %v", err)
+	}
+
+	if err := taskService.checkPendingAssignments(); err != nil {
+		t.Fatalf("checkPendingAssignments returned error: %v", err)
+	}
+
+	stored, _ = taskRepo.Get(ctx, pending.ID)
+	if stored.RunnerID != "" || stored.LeaseExpiresAt != nil {
+		t.Fatalf("expected expired lease to be released, got runner %q lease %v", stored.RunnerID, stored.LeaseExpiresAt)
+	}
+	runner, _ := runnerRepo.Get(ctx, "runner-pull")
+	if len(runner.Assignments) != 0 {
+		t.Fatalf("expected runner slot to be freed after lease expiry, got %d assignments", len(runner.Assignments))
+	}
+}
+
+func TestNextTaskWakesWhenDispatcherLeasesTask(t *testing.T) {
+	ctx := context.Background()
+
+	taskRepo := newInMemoryTaskRepo()
+	runnerRepo := newInMemoryRunnerRepo()
+	runnerService := NewRunnerService(runnerRepo)
+	taskService := NewTaskService(taskRepo, nil, runnerService)
+
+	if err := runnerRepo.Create(ctx, &models.Runner{DeviceID: "runner-pull", Status: models.RunnerStatusOnline, DeliveryMode: models.DeliveryModePull}); err != nil {
+		t.Fatalf("failed to seed runner: %v", err)
+	}
+
+	type pollResult struct {
+		task *models.Task
+		err  error
+	}
+	results := make(chan pollResult, 1)
+	go func() {
+		task, err := taskService.NextTask(ctx, "runner-pull", 5*time.Second)
+		results <- pollResult{task: task, err: err}
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for !taskService.isPolling("runner-pull") {
+		if time.Now().After(deadline) {
+			t.Fatal("long poll never registered")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	pending := models.NewTask()
+	pending.Title = "late arrival"
+	pending.Type = models.TaskTypeCommand
+	pending.Config, _ = json.Marshal(models.TaskConfig{})
+	if err := taskRepo.Create(ctx, pending); err != nil {
+		t.Fatalf("failed to seed task: %v", err)
+	}
+	if err := taskService.checkAndAssignPendingTasksToRunner(ctx, "runner-pull"); err != nil {
+		t.Fatalf("checkAndAssignPendingTasksToRunner returned error: %v", err)
+	}
+
+	select {
+	case result := <-results:
+		if result.err != nil {
+			t.Fatalf("NextTask returned error: %v", result.err)
+		}
+		if result.task == nil || result.task.ID != pending.ID {
+			t.Fatalf("expected waiting poll to receive task %s, got %v", pending.ID, result.task)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("waiting poll was not woken by the dispatcher")
+	}
+}
+
+// racingTaskRepo lets a rival lease the task between the read and the claim.
+type racingTaskRepo struct {
+	*inMemoryTaskRepo
+	rival string
+}
+
+func (r *racingTaskRepo) ClaimPending(ctx context.Context, task *models.Task, previousRunnerID, previousNonce string) (bool, error) {
+	if r.rival != "" {
+		stolen := cloneTask(task)
+		stolen.RunnerID = r.rival
+		stolen.Nonce = "rival-nonce"
+		if _, err := r.inMemoryTaskRepo.ClaimPending(ctx, stolen, previousRunnerID, previousNonce); err != nil {
+			return false, err
+		}
+		r.rival = ""
+	}
+	return r.inMemoryTaskRepo.ClaimPending(ctx, task, previousRunnerID, previousNonce)
+}
+
+func TestAssignTaskToRunnerReleasesSlotWhenClaimIsLost(t *testing.T) {
+	ctx := context.Background()
+
+	taskRepo := &racingTaskRepo{inMemoryTaskRepo: newInMemoryTaskRepo(), rival: "runner-b"}
+	runnerRepo := newInMemoryRunnerRepo()
+	runnerService := NewRunnerService(runnerRepo)
+	taskService := NewTaskService(taskRepo, nil, runnerService)
+
+	runner := &models.Runner{DeviceID: "runner-a", Status: models.RunnerStatusOnline, DeliveryMode: models.DeliveryModePull}
+	if err := runnerRepo.Create(ctx, runner); err != nil {
+		t.Fatalf("failed to seed runner: %v", err)
+	}
+
+	pending := models.NewTask()
+	pending.Title = "contested"
+	pending.Type = models.TaskTypeCommand
+	pending.Nonce = "initial-nonce"
+	pending.Config, _ = json.Marshal(models.TaskConfig{})
+	if err := taskRepo.Create(ctx, pending); err != nil {
+		t.Fatalf("failed to seed task: %v", err)
+	}
+
+	if err := taskService.assignTaskToRunner(ctx, pending, runner); !errors.Is(err, ErrTaskUnavailable) {
+		t.Fatalf("expected ErrTaskUnavailable after losing the claim, got %v", err)
+	}
+
+	stored, _ := taskRepo.Get(ctx, pending.ID)
+	if stored.RunnerID != "runner-b" {
+		t.Fatalf("expected the rival's lease to stand, got runner %q", stored.RunnerID)
+	}
+	if current, _ := runnerRepo.Get(ctx, "runner-a"); current.HasAssignment(pending.ID) {
+		t.Fatal("expected the losing runner's slot to be released")
+	}
+}
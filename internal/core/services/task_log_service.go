@@ -0,0 +1,365 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/core/config"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/internal/core/ports"
+)
+
+var (
+	ErrInvalidLogChunk  = errors.New("invalid log chunk")
+	ErrLogChunkTooLarge = errors.New("log chunk exceeds the maximum size")
+	ErrLogLimitReached  = errors.New("task log limit reached")
+	ErrLogNotWritable   = errors.New("task is not running on this runner")
+)
+
+// taskLogSubscriberBuffer is how many chunks a follower may fall behind before
+// it is disconnected. It reconnects and resumes from the last seq it saw.
+const taskLogSubscriberBuffer = 256
+
+// liveTaskLog is the in-memory log of a task that has not finished.
+type liveTaskLog struct {
+	runnerID     string
+	chunks       []models.TaskLogChunk
+	buffered     int
+	lastSeq      int64
+	total        int64
+	lastActivity time.Time
+	subscribers  map[chan models.TaskLogChunk]struct{}
+}
+
+// TaskLogSubscription follows a task's log. Backlog holds the chunks after
+// the requested seq; Chunks delivers new ones and is closed when the log ends
+// or the follower falls too far behind. Chunks is nil when the task's log is
+// no longer live.
+type TaskLogSubscription struct {
+	Backlog []models.TaskLogChunk
+	Chunks  <-chan models.TaskLogChunk
+	close   func()
+}
+
+func (s *TaskLogSubscription) Close() {
+	if s.close != nil {
+		s.close()
+	}
+}
+
+// TaskLogService relays stdout and stderr chunks that runners stream while a
+// task runs. Each running task keeps the most recent chunks in a ring buffer
+// of bufferBytes, which is saved to the database when the task finishes; older
+// chunks are only seen by followers that were connected when they arrived.
+// The total a task may stream is capped, and the bytes streamed are billed as
+// network data.
+type TaskLogService struct {
+	repo  ports.TaskLogRepository
+	tasks TaskRepository
+
+	mu          sync.Mutex
+	logs        map[uuid.UUID]*liveTaskLog
+	bufferBytes int
+	maxChunk    int
+	maxTask     int64
+	idleTTL     time.Duration
+}
+
+func NewTaskLogService(repo ports.TaskLogRepository, tasks TaskRepository) *TaskLogService {
+	return &TaskLogService{
+		repo:        repo,
+		tasks:       tasks,
+		logs:        make(map[uuid.UUID]*liveTaskLog),
+		bufferBytes: 1 << 20,
+		maxChunk:    64 << 10,
+		maxTask:     100 << 20,
+		idleTTL:     time.Hour,
+	}
+}
+
+func (s *TaskLogService) SetConfig(cfg config.TaskLogConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cfg.BufferKB > 0 {
+		s.bufferBytes = cfg.BufferKB << 10
+	}
+	if cfg.MaxChunkKB > 0 {
+		s.maxChunk = cfg.MaxChunkKB << 10
+	}
+	if cfg.MaxTaskMB > 0 {
+		s.maxTask = int64(cfg.MaxTaskMB) << 20
+	}
+	if cfg.IdleTTL > 0 {
+		s.idleTTL = time.Duration(cfg.IdleTTL) * time.Minute
+	}
+}
+
+// MaxChunkBytes is the largest chunk Append accepts.
+func (s *TaskLogService) MaxChunkBytes() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.maxChunk
+}
+
+// live returns the task's live log, creating it after the last persisted
+// chunk and byte count if there is none. Callers hold s.mu.
+func (s *TaskLogService) live(taskID uuid.UUID, lastSeq, total int64) *liveTaskLog {
+	log, ok := s.logs[taskID]
+	if !ok {
+		log = &liveTaskLog{
+			lastSeq:      lastSeq,
+			total:        total,
+			lastActivity: time.Now(),
+			subscribers:  make(map[chan models.TaskLogChunk]struct{}),
+		}
+		s.logs[taskID] = log
+	}
+	return log
+}
+
+// Append adds a chunk to a running task's log and sends it to its followers.
+func (s *TaskLogService) Append(ctx context.Context, taskID uuid.UUID, runnerID string, stream models.TaskLogStream, data string) (*models.TaskLogChunk, error) {
+	if !stream.Valid() {
+		return nil, fmt.Errorf("%w: stream must be stdout or stderr", ErrInvalidLogChunk)
+	}
+	if data == "" {
+		return nil, fmt.Errorf("%w: data is empty", ErrInvalidLogChunk)
+	}
+	if len(data) > s.MaxChunkBytes() {
+		return nil, ErrLogChunkTooLarge
+	}
+
+	s.mu.Lock()
+	existing := s.logs[taskID]
+	verified := existing != nil && existing.runnerID == runnerID
+	s.mu.Unlock()
+
+	var lastSeq, total int64
+	if !verified {
+		task, err := s.tasks.Get(ctx, taskID)
+		if err != nil {
+			return nil, err
+		}
+		if task == nil || task.RunnerID != runnerID || task.Status != models.TaskStatusRunning {
+			return nil, ErrLogNotWritable
+		}
+		if existing == nil {
+			if lastSeq, total, err = s.persisted(ctx, taskID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	log := s.live(taskID, lastSeq, total)
+	log.runnerID = runnerID
+	if log.total+int64(len(data)) > s.maxTask {
+		return nil, ErrLogLimitReached
+	}
+
+	log.lastSeq++
+	chunk := models.TaskLogChunk{
+		TaskID:    taskID,
+		Seq:       log.lastSeq,
+		Stream:    stream,
+		Data:      data,
+		CreatedAt: time.Now(),
+	}
+	log.chunks = append(log.chunks, chunk)
+	log.buffered += len(data)
+	for log.buffered > s.bufferBytes && len(log.chunks) > 1 {
+		log.buffered -= len(log.chunks[0].Data)
+		log.chunks = log.chunks[1:]
+	}
+	log.total += int64(len(data))
+	log.lastActivity = chunk.CreatedAt
+
+	for ch := range log.subscribers {
+		select {
+		case ch <- chunk:
+		default:
+			delete(log.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return &chunk, nil
+}
+
+// Chunks returns the task's log after afterSeq and whether it is still live.
+func (s *TaskLogService) Chunks(ctx context.Context, taskID uuid.UUID, afterSeq int64) ([]models.TaskLogChunk, bool, error) {
+	s.mu.Lock()
+	if log, ok := s.logs[taskID]; ok {
+		chunks := chunksAfter(log.chunks, afterSeq)
+		s.mu.Unlock()
+		return chunks, true, nil
+	}
+	s.mu.Unlock()
+
+	chunks, err := s.repo.ListChunks(ctx, taskID, afterSeq)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to list task log: %w", err)
+	}
+	return chunks, false, nil
+}
+
+// Subscribe follows the task's log from afterSeq. A task that has not started
+// streaming yet is followed from its first chunk; a finished task only has a
+// backlog.
+func (s *TaskLogService) Subscribe(ctx context.Context, task *models.Task, afterSeq int64) (*TaskLogSubscription, error) {
+	s.mu.Lock()
+	_, live := s.logs[task.ID]
+	s.mu.Unlock()
+
+	finished := task.Status == models.TaskStatusCompleted ||
+		task.Status == models.TaskStatusFailed ||
+		task.Status == models.TaskStatusNotVerified
+	var (
+		persisted      []models.TaskLogChunk
+		lastSeq, total int64
+	)
+	if !live {
+		var err error
+		if persisted, err = s.repo.ListChunks(ctx, task.ID, afterSeq); err != nil {
+			return nil, fmt.Errorf("failed to list task log: %w", err)
+		}
+		if finished {
+			return &TaskLogSubscription{Backlog: persisted}, nil
+		}
+		if lastSeq, total, err = s.persisted(ctx, task.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	log := s.live(task.ID, lastSeq, total)
+	ch := make(chan models.TaskLogChunk, taskLogSubscriberBuffer)
+	log.subscribers[ch] = struct{}{}
+
+	var once sync.Once
+	return &TaskLogSubscription{
+		Backlog: append(persisted, chunksAfter(log.chunks, afterSeq)...),
+		Chunks:  ch,
+		close: func() {
+			once.Do(func() {
+				s.mu.Lock()
+				defer s.mu.Unlock()
+				if _, ok := log.subscribers[ch]; ok {
+					delete(log.subscribers, ch)
+					close(ch)
+				}
+			})
+		},
+	}, nil
+}
+
+// persisted returns the last saved seq and the bytes streamed so far for a
+// task whose live log was closed before it finished.
+func (s *TaskLogService) persisted(ctx context.Context, taskID uuid.UUID) (int64, int64, error) {
+	lastSeq, err := s.repo.LastSeq(ctx, taskID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read task log: %w", err)
+	}
+	total, err := s.repo.StreamedBytes(ctx, taskID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read task log usage: %w", err)
+	}
+	return lastSeq, total, nil
+}
+
+// Finish saves the task's buffered log and byte count, ends its followers'
+// streams and returns how many bytes the task streamed, including any saved
+// by an earlier idle close.
+func (s *TaskLogService) Finish(ctx context.Context, taskID uuid.UUID) int64 {
+	s.mu.Lock()
+	log, ok := s.logs[taskID]
+	if ok {
+		delete(s.logs, taskID)
+		for ch := range log.subscribers {
+			delete(log.subscribers, ch)
+			close(ch)
+		}
+	}
+	s.mu.Unlock()
+
+	logger := gologger.WithComponent("task_logs")
+	if !ok {
+		total, err := s.repo.StreamedBytes(ctx, taskID)
+		if err != nil {
+			logger.Error().Err(err).
+				Str("task_id", taskID.String()).
+				Msg("Failed to read task log usage")
+		}
+		return total
+	}
+	if err := s.repo.SaveChunks(ctx, log.chunks); err != nil {
+		logger.Error().Err(err).
+			Str("task_id", taskID.String()).
+			Msg("Failed to save task log")
+	}
+	if log.total > 0 {
+		if err := s.repo.SaveStreamedBytes(ctx, taskID, log.total); err != nil {
+			logger.Error().Err(err).
+				Str("task_id", taskID.String()).
+				Msg("Failed to save task log usage")
+		}
+	}
+	return log.total
+}
+
+// FinishIdle finishes the logs that received nothing for longer than the idle
+// TTL, such as those of tasks whose runner disappeared.
+func (s *TaskLogService) FinishIdle(ctx context.Context) int {
+	s.mu.Lock()
+	cutoff := time.Now().Add(-s.idleTTL)
+	var idle []uuid.UUID
+	for taskID, log := range s.logs {
+		if log.lastActivity.Before(cutoff) {
+			idle = append(idle, taskID)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, taskID := range idle {
+		s.Finish(ctx, taskID)
+	}
+	return len(idle)
+}
+
+func (s *TaskLogService) Start(ctx context.Context) {
+	log := gologger.WithComponent("task_logs")
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if finished := s.FinishIdle(ctx); finished > 0 {
+				log.Info().Int("finished", finished).Msg("Closed idle task logs")
+			}
+		}
+	}
+}
+
+func chunksAfter(chunks []models.TaskLogChunk, afterSeq int64) []models.TaskLogChunk {
+	result := make([]models.TaskLogChunk, 0, len(chunks))
+	for _, chunk := range chunks {
+		if chunk.Seq > afterSeq {
+			result = append(result, chunk)
+		}
+	}
+	return result
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/parity-server/internal/core/config"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+type inMemoryTaskLogRepo struct {
+	mu       sync.Mutex
+	chunks   map[uuid.UUID][]models.TaskLogChunk
+	streamed map[uuid.UUID]int64
+}
+
+func (r *inMemoryTaskLogRepo) SaveChunks(ctx context.Context, chunks []models.TaskLogChunk) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.chunks == nil {
+		r.chunks = make(map[uuid.UUID][]models.TaskLogChunk)
+	}
+	for _, chunk := range chunks {
+		r.chunks[chunk.TaskID] = append(r.chunks[chunk.TaskID], chunk)
+	}
+	return nil
+}
+
+func (r *inMemoryTaskLogRepo) ListChunks(ctx context.Context, taskID uuid.UUID, afterSeq int64) ([]models.TaskLogChunk, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return chunksAfter(r.chunks[taskID], afterSeq), nil
+}
+
+func (r *inMemoryTaskLogRepo) LastSeq(ctx context.Context, taskID uuid.UUID) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	chunks := r.chunks[taskID]
+	if len(chunks) == 0 {
+		return 0, nil
+	}
+	return chunks[len(chunks)-1].Seq, nil
+}
+
+func (r *inMemoryTaskLogRepo) SaveStreamedBytes(ctx context.Context, taskID uuid.UUID, bytes int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.streamed == nil {
+		r.streamed = make(map[uuid.UUID]int64)
+	}
+	r.streamed[taskID] = bytes
+	return nil
+}
+
+func (r *inMemoryTaskLogRepo) StreamedBytes(ctx context.Context, taskID uuid.UUID) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.streamed[taskID], nil
+}
+
+func newRunningLogTask(t *testing.T, tasks *inMemoryTaskRepo, runnerID string) *models.Task {
+	t.Helper()
+	task := &models.Task{ID: uuid.New(), Status: models.TaskStatusRunning, RunnerID: runnerID}
+	if err := tasks.Create(context.Background(), task); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	return task
+}
+
+func TestTaskLogStreamsToFollowersAndKeepsTail(t *testing.T) {
+	ctx := context.Background()
+	tasks := newInMemoryTaskRepo()
+	repo := &inMemoryTaskLogRepo{}
+	service := NewTaskLogService(repo, tasks)
+	service.SetConfig(config.TaskLogConfig{BufferKB: 1})
+	task := newRunningLogTask(t, tasks, "runner-1")
+
+	subscription, err := service.Subscribe(ctx, task, 0)
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	defer subscription.Close()
+
+	if _, err := service.Append(ctx, task.ID, "runner-2", models.TaskLogStdout, "spoofed"); !errors.Is(err, ErrLogNotWritable) {
+		t.Fatalf("expected another runner to be rejected, got %v", err)
+	}
+
+	line := strings.Repeat("x", 400)
+	for i := 0; i < 4; i++ {
+		if _, err := service.Append(ctx, task.ID, "runner-1", models.TaskLogStdout, line); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+	if _, err := service.Append(ctx, task.ID, "runner-1", models.TaskLogStderr, "done"); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	for seq := int64(1); seq <= 5; seq++ {
+		chunk := <-subscription.Chunks
+		if chunk.Seq != seq {
+			t.Fatalf("expected follower to see chunk %d, got %d", seq, chunk.Seq)
+		}
+	}
+
+	chunks, live, err := service.Chunks(ctx, task.ID, 0)
+	if err != nil || !live {
+		t.Fatalf("expected live log, got live=%v err=%v", live, err)
+	}
+	if len(chunks) != 3 || chunks[0].Seq != 3 {
+		t.Fatalf("expected the 1KB buffer to keep chunks 3-5, got %d chunks from %d", len(chunks), chunks[0].Seq)
+	}
+
+	streamed := service.Finish(ctx, task.ID)
+	if streamed != 4*400+4 {
+		t.Fatalf("expected every streamed byte to be counted, got %d", streamed)
+	}
+	if _, ok := <-subscription.Chunks; ok {
+		t.Fatalf("expected the follower's stream to end")
+	}
+
+	persisted, live, err := service.Chunks(ctx, task.ID, 4)
+	if err != nil || live {
+		t.Fatalf("expected finished log, got live=%v err=%v", live, err)
+	}
+	if len(persisted) != 1 || persisted[0].Data != "done" {
+		t.Fatalf("expected the saved tail after seq 4, got %+v", persisted)
+	}
+}
+
+func TestTaskLogEnforcesLimits(t *testing.T) {
+	ctx := context.Background()
+	tasks := newInMemoryTaskRepo()
+	service := NewTaskLogService(&inMemoryTaskLogRepo{}, tasks)
+	service.SetConfig(config.TaskLogConfig{MaxChunkKB: 1, MaxTaskMB: 1})
+	task := newRunningLogTask(t, tasks, "runner-1")
+
+	if _, err := service.Append(ctx, task.ID, "runner-1", "stdin", "x"); !errors.Is(err, ErrInvalidLogChunk) {
+		t.Fatalf("expected unknown stream to be rejected, got %v", err)
+	}
+	if _, err := service.Append(ctx, task.ID, "runner-1", models.TaskLogStdout, strings.Repeat("x", 1025)); !errors.Is(err, ErrLogChunkTooLarge) {
+		t.Fatalf("expected oversized chunk to be rejected, got %v", err)
+	}
+
+	chunk := strings.Repeat("x", 1024)
+	for i := 0; i < 1024; i++ {
+		if _, err := service.Append(ctx, task.ID, "runner-1", models.TaskLogStdout, chunk); err != nil {
+			t.Fatalf("Append %d failed: %v", i, err)
+		}
+	}
+	if _, err := service.Append(ctx, task.ID, "runner-1", models.TaskLogStdout, "x"); !errors.Is(err, ErrLogLimitReached) {
+		t.Fatalf("expected the per-task limit, got %v", err)
+	}
+}
+
+func TestSaveTaskResultBillsStreamedLogs(t *testing.T) {
+	ctx := context.Background()
+	tasks := newInMemoryTaskRepo()
+	runnerService := NewRunnerService(newInMemoryRunnerRepo())
+	taskService := NewTaskService(tasks, nil, runnerService)
+	logService := NewTaskLogService(&inMemoryTaskLogRepo{}, tasks)
+	taskService.SetTaskLogService(logService)
+	task := newRunningLogTask(t, tasks, "runner-1")
+
+	data := strings.Repeat("x", 64<<10)
+	for i := 0; i < 16; i++ {
+		if _, err := logService.Append(ctx, task.ID, "runner-1", models.TaskLogStdout, data); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	result := &models.TaskResult{ID: uuid.New(), TaskID: task.ID, DeviceID: "runner-1", NetworkDataGB: 1}
+	if err := taskService.SaveTaskResult(ctx, result); err != nil {
+		t.Fatalf("SaveTaskResult failed: %v", err)
+	}
+
+	want := 1 + float64(16*64<<10)/(1<<30)
+	if result.NetworkDataGB != want {
+		t.Fatalf("expected network data %v GB, got %v", want, result.NetworkDataGB)
+	}
+}
+
+func TestTaskLogLimitAndBillingSurviveIdleClose(t *testing.T) {
+	ctx := context.Background()
+	tasks := newInMemoryTaskRepo()
+	runnerService := NewRunnerService(newInMemoryRunnerRepo())
+	taskService := NewTaskService(tasks, nil, runnerService)
+	logService := NewTaskLogService(&inMemoryTaskLogRepo{}, tasks)
+	logService.SetConfig(config.TaskLogConfig{MaxChunkKB: 64, MaxTaskMB: 1})
+	taskService.SetTaskLogService(logService)
+	task := newRunningLogTask(t, tasks, "runner-1")
+
+	data := strings.Repeat("x", 64<<10)
+	for i := 0; i < 10; i++ {
+		if _, err := logService.Append(ctx, task.ID, "runner-1", models.TaskLogStdout, data); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	// Close the log as if the runner had gone quiet past the idle TTL.
+	logService.idleTTL = -time.Second
+	if closed := logService.FinishIdle(ctx); closed != 1 {
+		t.Fatalf("expected the idle log to be closed, got %d", closed)
+	}
+
+	for i := 0; i < 6; i++ {
+		if _, err := logService.Append(ctx, task.ID, "runner-1", models.TaskLogStdout, data); err != nil {
+			t.Fatalf("Append after idle close failed: %v", err)
+		}
+	}
+	if _, err := logService.Append(ctx, task.ID, "runner-1", models.TaskLogStdout, "x"); !errors.Is(err, ErrLogLimitReached) {
+		t.Fatalf("expected the limit to count bytes streamed before the idle close, got %v", err)
+	}
+
+	result := &models.TaskResult{ID: uuid.New(), TaskID: task.ID, DeviceID: "runner-1"}
+	if err := taskService.SaveTaskResult(ctx, result); err != nil {
+		t.Fatalf("SaveTaskResult failed: %v", err)
+	}
+	if want := float64(16*64<<10) / (1 << 30); result.NetworkDataGB != want {
+		t.Fatalf("expected network data %v GB, got %v", want, result.NetworkDataGB)
+	}
+}
@@ -7,38 +7,75 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/core/config"
 	"github.com/theblitlabs/parity-server/internal/core/models"
 	"github.com/theblitlabs/parity-server/internal/core/ports"
 )
 
-type QueuedTask struct {
-	PromptID   uuid.UUID
-	ModelName  string
-	QueuedAt   time.Time
-	RetryCount int
-	MaxRetries int
-}
+const (
+	defaultPromptQueuePollInterval      = 10 * time.Second
+	defaultPromptQueueVisibilityTimeout = time.Minute
+	defaultPromptQueueRetryDelay        = 10 * time.Second
+	defaultPromptQueueMaxRetries        = 5
+
+	promptQueueBatchSize = 50
+)
 
+// TaskQueue hands queued LLM prompts to runners. The queue is the prompts
+// table itself: a prompt waits in the queued status until a worker leases it
+// with SELECT ... FOR UPDATE SKIP LOCKED, so queued prompts survive restarts
+// and several servers can share one queue. Each attempt that finds no runner,
+// or cannot reach the one it found, counts as a retry; once retries run out
+// the prompt is moved to the dead-letter status.
 type TaskQueue struct {
 	promptRepo    ports.PromptRepository
 	runnerRepo    ports.RunnerRepository
 	runnerService *RunnerService
-	queue         []QueuedTask
-	mu            sync.RWMutex
+	eventBus      *EventBus
+	wakeCh        chan struct{}
+	mu            sync.Mutex
 	stopCh        chan struct{}
 	running       bool
+
+	pollInterval      time.Duration
+	visibilityTimeout time.Duration
+	retryDelay        time.Duration
+	maxRetries        int
 }
 
 func NewTaskQueue(promptRepo ports.PromptRepository, runnerRepo ports.RunnerRepository, runnerService *RunnerService) *TaskQueue {
 	return &TaskQueue{
-		promptRepo:    promptRepo,
-		runnerRepo:    runnerRepo,
-		runnerService: runnerService,
-		queue:         make([]QueuedTask, 0),
-		stopCh:        make(chan struct{}),
+		promptRepo:        promptRepo,
+		runnerRepo:        runnerRepo,
+		runnerService:     runnerService,
+		wakeCh:            make(chan struct{}, 1),
+		stopCh:            make(chan struct{}),
+		pollInterval:      defaultPromptQueuePollInterval,
+		visibilityTimeout: defaultPromptQueueVisibilityTimeout,
+		retryDelay:        defaultPromptQueueRetryDelay,
+		maxRetries:        defaultPromptQueueMaxRetries,
 	}
 }
 
+func (tq *TaskQueue) SetConfig(cfg config.PromptQueueConfig) {
+	if cfg.PollInterval > 0 {
+		tq.pollInterval = time.Duration(cfg.PollInterval) * time.Second
+	}
+	if cfg.VisibilityTimeout > 0 {
+		tq.visibilityTimeout = time.Duration(cfg.VisibilityTimeout) * time.Second
+	}
+	if cfg.RetryDelay > 0 {
+		tq.retryDelay = time.Duration(cfg.RetryDelay) * time.Second
+	}
+	if cfg.MaxRetries > 0 {
+		tq.maxRetries = cfg.MaxRetries
+	}
+}
+
+func (tq *TaskQueue) SetEventBus(eventBus *EventBus) {
+	tq.eventBus = eventBus
+}
+
 func (tq *TaskQueue) Start(ctx context.Context) {
 	tq.mu.Lock()
 	if tq.running {
@@ -51,7 +88,10 @@ func (tq *TaskQueue) Start(ctx context.Context) {
 	log := gologger.WithComponent("task_queue")
 	log.Info().Msg("Starting task queue processor")
 
-	ticker := time.NewTicker(10 * time.Second)
+	// Prompts queued before a restart are picked up straight away.
+	tq.processQueue(ctx)
+
+	ticker := time.NewTicker(tq.pollInterval)
 	defer ticker.Stop()
 
 	for {
@@ -64,6 +104,8 @@ func (tq *TaskQueue) Start(ctx context.Context) {
 			return
 		case <-ticker.C:
 			tq.processQueue(ctx)
+		case <-tq.wakeCh:
+			tq.processQueue(ctx)
 		}
 	}
 }
@@ -80,127 +122,85 @@ func (tq *TaskQueue) Stop() {
 	tq.running = false
 }
 
+// QueueTask wakes the processor for a prompt the caller has already saved in
+// the queued status. The prompt is in the queue whether or not the wake-up is
+// delivered.
 func (tq *TaskQueue) QueueTask(promptID uuid.UUID, modelName string) {
-	tq.mu.Lock()
-	defer tq.mu.Unlock()
-
-	task := QueuedTask{
-		PromptID:   promptID,
-		ModelName:  modelName,
-		QueuedAt:   time.Now(),
-		RetryCount: 0,
-		MaxRetries: 5,
+	select {
+	case tq.wakeCh <- struct{}{}:
+	default:
 	}
 
-	tq.queue = append(tq.queue, task)
-
 	log := gologger.WithComponent("task_queue")
 	log.Info().
 		Str("prompt_id", promptID.String()).
 		Str("model_name", modelName).
-		Int("queue_size", len(tq.queue)).
 		Msg("Task queued for processing")
 }
 
 func (tq *TaskQueue) processQueue(ctx context.Context) {
-	tq.mu.Lock()
-	if len(tq.queue) == 0 {
-		tq.mu.Unlock()
-		return
-	}
-
-	queueCopy := make([]QueuedTask, len(tq.queue))
-	copy(queueCopy, tq.queue)
-	tq.mu.Unlock()
-
 	log := gologger.WithComponent("task_queue")
 
-	var processedTasks []int
-
-	for i, task := range queueCopy {
-		processed := tq.processTask(ctx, task)
-		if processed {
-			processedTasks = append(processedTasks, i)
+	processed := 0
+	for {
+		prompts, err := tq.promptRepo.LeaseQueued(ctx, time.Now(), tq.visibilityTimeout, promptQueueBatchSize)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to lease queued prompts")
+			return
 		}
-	}
 
-	if len(processedTasks) > 0 {
-		tq.mu.Lock()
-		// Remove processed tasks from queue (in reverse order to maintain indices)
-		for i := len(processedTasks) - 1; i >= 0; i-- {
-			idx := processedTasks[i]
-			if idx < len(tq.queue) {
-				tq.queue = append(tq.queue[:idx], tq.queue[idx+1:]...)
+		for _, promptReq := range prompts {
+			if tq.processTask(ctx, promptReq) {
+				processed++
 			}
 		}
+
+		if len(prompts) < promptQueueBatchSize {
+			break
+		}
+	}
+
+	if processed > 0 {
 		log.Info().
-			Int("processed_count", len(processedTasks)).
-			Int("remaining_queue_size", len(tq.queue)).
+			Int("processed_count", processed).
 			Msg("Processed queued tasks")
-		tq.mu.Unlock()
 	}
 }
 
-func (tq *TaskQueue) processTask(ctx context.Context, task QueuedTask) bool {
+// processTask tries to hand a leased prompt to a runner and reports whether it
+// left the queue.
+func (tq *TaskQueue) processTask(ctx context.Context, promptReq *models.PromptRequest) bool {
 	log := gologger.WithComponent("task_queue")
 
-	promptReq, err := tq.promptRepo.GetByID(ctx, task.PromptID)
+	runnerID, err := tq.runnerService.ReserveRunnerForModel(ctx, promptReq.ModelName, promptReq.MaxTokens, promptReq.ID)
 	if err != nil {
-		log.Error().
-			Err(err).
-			Str("prompt_id", task.PromptID.String()).
-			Msg("Failed to get prompt request from database")
-		return true // Remove from queue as it's not recoverable
-	}
-
-	if promptReq.Status != models.PromptStatusQueued {
-		log.Info().
-			Str("prompt_id", task.PromptID.String()).
-			Str("status", string(promptReq.Status)).
-			Msg("Prompt is no longer queued, removing from queue")
-		return true // Remove from queue as it's no longer queued
-	}
-
-	runnerID, err := tq.runnerService.GetAvailableRunnerForModel(ctx, task.ModelName)
-	if err != nil {
-		task.RetryCount++
-		if task.RetryCount >= task.MaxRetries {
-			log.Warn().
-				Str("prompt_id", task.PromptID.String()).
-				Str("model_name", task.ModelName).
-				Int("retry_count", task.RetryCount).
-				Msg("Max retries reached, marking prompt as failed")
-
-			now := time.Now()
-			promptReq.Status = models.PromptStatusFailed
-			promptReq.CompletedAt = &now
-			if updateErr := tq.promptRepo.Update(ctx, promptReq); updateErr != nil {
-				log.Error().
-					Err(updateErr).
-					Str("prompt_id", task.PromptID.String()).
-					Msg("Failed to update prompt status to failed")
-			}
-			return true // Remove from queue
-		}
-
 		log.Debug().
-			Str("prompt_id", task.PromptID.String()).
-			Str("model_name", task.ModelName).
-			Int("retry_count", task.RetryCount).
+			Str("prompt_id", promptReq.ID.String()).
+			Str("model_name", promptReq.ModelName).
+			Int("retry_count", promptReq.RetryCount+1).
 			Msg("No runner available yet, will retry later")
-		return false // Keep in queue for retry
+		return tq.retry(ctx, promptReq, err)
 	}
 
 	promptReq.RunnerID = runnerID
 	promptReq.Status = models.PromptStatusProcessing
+	promptReq.LockedUntil = nil
 
 	if err := tq.promptRepo.Update(ctx, promptReq); err != nil {
 		log.Error().
 			Err(err).
-			Str("prompt_id", task.PromptID.String()).
+			Str("prompt_id", promptReq.ID.String()).
 			Msg("Failed to update prompt status to processing")
-		return false // Keep in queue for retry
+		if releaseErr := tq.runnerService.ReleaseSlot(ctx, runnerID, promptReq.ID); releaseErr != nil {
+			log.Error().
+				Err(releaseErr).
+				Str("runner_id", runnerID).
+				Msg("Failed to release runner slot")
+		}
+		// The lease runs out and the prompt is picked up again.
+		return false
 	}
+	tq.eventBus.Publish(models.NewPromptEvent("processing", promptReq))
 
 	go func() {
 		bgCtx := context.Background()
@@ -208,58 +208,88 @@ func (tq *TaskQueue) processTask(ctx context.Context, task QueuedTask) bool {
 			log.Error().
 				Err(err).
 				Str("runner_id", runnerID).
-				Str("prompt_id", task.PromptID.String()).
-				Msg("Failed to forward prompt to runner - marking prompt as failed")
+				Str("prompt_id", promptReq.ID.String()).
+				Msg("Failed to forward prompt to runner - returning it to the queue")
 
-			now := time.Now()
-			promptReq.Status = models.PromptStatusFailed
-			promptReq.CompletedAt = &now
-			if updateErr := tq.promptRepo.Update(bgCtx, promptReq); updateErr != nil {
+			if err := tq.runnerService.ReleaseSlot(bgCtx, runnerID, promptReq.ID); err != nil {
 				log.Error().
-					Err(updateErr).
-					Str("prompt_id", promptReq.ID.String()).
-					Msg("Failed to update prompt status to failed")
+					Err(err).
+					Str("runner_id", runnerID).
+					Msg("Failed to release runner slot after failure")
+			} else {
+				log.Info().
+					Str("runner_id", runnerID).
+					Msg("Runner freed after prompt failure in queue processing")
 			}
 
-			// Free up the runner by clearing its TaskID if it was assigned
-			if promptReq.RunnerID != "" {
-				if runner, err := tq.runnerRepo.GetRunnerByDeviceID(bgCtx, promptReq.RunnerID); err == nil {
-					runner.TaskID = nil
-					if _, updateErr := tq.runnerService.UpdateRunner(bgCtx, runner); updateErr != nil {
-						log.Error().
-							Err(updateErr).
-							Str("runner_id", promptReq.RunnerID).
-							Msg("Failed to clear runner TaskID after failure")
-					} else {
-						log.Info().
-							Str("runner_id", promptReq.RunnerID).
-							Msg("Runner freed after prompt failure in queue processing")
-					}
-				}
-			}
+			promptReq.RunnerID = ""
+			tq.retry(bgCtx, promptReq, err)
 		}
 	}()
 
 	log.Info().
-		Str("prompt_id", task.PromptID.String()).
-		Str("model_name", task.ModelName).
+		Str("prompt_id", promptReq.ID.String()).
+		Str("model_name", promptReq.ModelName).
 		Str("runner_id", runnerID).
 		Msg("Queued task processed successfully")
 
-	return true // Remove from queue
+	return true
 }
 
-func (tq *TaskQueue) GetQueueSize() int {
-	tq.mu.RLock()
-	defer tq.mu.RUnlock()
-	return len(tq.queue)
-}
+// retry records a failed attempt. The prompt goes back on the queue after the
+// retry delay, or to the dead-letter status once it has used up its retries.
+// It reports whether the prompt left the queue.
+func (tq *TaskQueue) retry(ctx context.Context, promptReq *models.PromptRequest, cause error) bool {
+	log := gologger.WithComponent("task_queue")
+
+	now := time.Now()
+	promptReq.RetryCount++
+	promptReq.LastError = cause.Error()
+	promptReq.LockedUntil = nil
+
+	if promptReq.RetryCount >= tq.maxRetries {
+		log.Warn().
+			Str("prompt_id", promptReq.ID.String()).
+			Str("model_name", promptReq.ModelName).
+			Int("retry_count", promptReq.RetryCount).
+			Msg("Max retries reached, moving prompt to dead letter")
+
+		promptReq.Status = models.PromptStatusDeadLetter
+		promptReq.CompletedAt = &now
+		if err := tq.promptRepo.Update(ctx, promptReq); err != nil {
+			log.Error().
+				Err(err).
+				Str("prompt_id", promptReq.ID.String()).
+				Msg("Failed to move prompt to dead letter")
+			return false
+		}
+		tq.eventBus.Publish(models.NewPromptEvent("dead_lettered", promptReq))
+		return true
+	}
 
-func (tq *TaskQueue) GetQueuedTasks() []QueuedTask {
-	tq.mu.RLock()
-	defer tq.mu.RUnlock()
+	wasQueued := promptReq.Status == models.PromptStatusQueued
+	promptReq.Status = models.PromptStatusQueued
+	promptReq.AvailableAt = now.Add(tq.retryDelay)
+	if err := tq.promptRepo.Update(ctx, promptReq); err != nil {
+		log.Error().
+			Err(err).
+			Str("prompt_id", promptReq.ID.String()).
+			Msg("Failed to reschedule queued prompt")
+		return false
+	}
+	if !wasQueued {
+		tq.eventBus.Publish(models.NewPromptEvent("requeued", promptReq))
+	}
+	return false
+}
 
-	tasks := make([]QueuedTask, len(tq.queue))
-	copy(tasks, tq.queue)
-	return tasks
+// GetQueueSize returns how many prompts are waiting in the queue.
+func (tq *TaskQueue) GetQueueSize() int {
+	count, err := tq.promptRepo.CountQueued(context.Background())
+	if err != nil {
+		log := gologger.WithComponent("task_queue")
+		log.Error().Err(err).Msg("Failed to count queued prompts")
+		return 0
+	}
+	return int(count)
 }
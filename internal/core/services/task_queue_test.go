@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/theblitlabs/parity-server/internal/core/config"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+func queuePrompt(t *testing.T, repo *inMemoryPromptRepo, modelName string) *models.PromptRequest {
+	t.Helper()
+	prompt := models.NewPromptRequest("client-1", "hello", modelName, "0xabc")
+	prompt.Status = models.PromptStatusQueued
+	if err := repo.Create(context.Background(), prompt); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	return prompt
+}
+
+// makeDue moves a prompt's retry time into the past, as if the delay elapsed.
+func makeDue(repo *inMemoryPromptRepo, prompt *models.PromptRequest) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	repo.prompts[prompt.ID].AvailableAt = time.Now().Add(-time.Second)
+}
+
+func TestTaskQueueCountsRetriesAndDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	promptRepo := newInMemoryPromptRepo()
+	runnerRepo := newInMemoryRunnerRepo()
+	queue := NewTaskQueue(promptRepo, runnerRepo, NewRunnerService(runnerRepo))
+	queue.SetConfig(config.PromptQueueConfig{MaxRetries: 3, RetryDelay: 60})
+
+	prompt := queuePrompt(t, promptRepo, "model-a")
+
+	queue.processQueue(ctx)
+	stored, _ := promptRepo.GetByID(ctx, prompt.ID)
+	if stored.RetryCount != 1 || stored.Status != models.PromptStatusQueued {
+		t.Fatalf("after one attempt: retry_count = %d, status = %s; want 1, queued", stored.RetryCount, stored.Status)
+	}
+	if !stored.AvailableAt.After(time.Now()) {
+		t.Fatal("a prompt that found no runner should wait for the retry delay")
+	}
+	if stored.LastError == "" {
+		t.Fatal("expected the failed attempt to be recorded")
+	}
+
+	// Not due yet, so another pass leaves it alone.
+	queue.processQueue(ctx)
+	if stored, _ = promptRepo.GetByID(ctx, prompt.ID); stored.RetryCount != 1 {
+		t.Fatalf("retry_count = %d before the retry delay, want 1", stored.RetryCount)
+	}
+
+	for i := 0; i < 2; i++ {
+		makeDue(promptRepo, prompt)
+		queue.processQueue(ctx)
+	}
+
+	stored, _ = promptRepo.GetByID(ctx, prompt.ID)
+	if stored.Status != models.PromptStatusDeadLetter || stored.RetryCount != 3 {
+		t.Fatalf("status = %s, retry_count = %d; want dead_letter after 3 attempts", stored.Status, stored.RetryCount)
+	}
+	if stored.CompletedAt == nil {
+		t.Fatal("expected a dead-lettered prompt to have a completion time")
+	}
+	if size := queue.GetQueueSize(); size != 0 {
+		t.Fatalf("queue size = %d, want 0", size)
+	}
+}
+
+func TestTaskQueueSkipsLeasedPrompts(t *testing.T) {
+	ctx := context.Background()
+	promptRepo := newInMemoryPromptRepo()
+	prompt := queuePrompt(t, promptRepo, "model-a")
+
+	leased, err := promptRepo.LeaseQueued(ctx, time.Now(), time.Minute, 10)
+	if err != nil || len(leased) != 1 {
+		t.Fatalf("LeaseQueued = %d prompts, %v; want 1", len(leased), err)
+	}
+
+	runnerRepo := newInMemoryRunnerRepo()
+	queue := NewTaskQueue(promptRepo, runnerRepo, NewRunnerService(runnerRepo))
+	queue.processQueue(ctx)
+
+	stored, _ := promptRepo.GetByID(ctx, prompt.ID)
+	if stored.RetryCount != 0 {
+		t.Fatalf("retry_count = %d, want 0 while another worker holds the lease", stored.RetryCount)
+	}
+	if size := queue.GetQueueSize(); size != 1 {
+		t.Fatalf("queue size = %d, want 1", size)
+	}
+}
+
+func TestTaskQueueRequeuesPromptThatCannotBeForwarded(t *testing.T) {
+	ctx := context.Background()
+	promptRepo := newInMemoryPromptRepo()
+	runnerRepo := newInMemoryRunnerRepo()
+	runnerService := NewRunnerService(runnerRepo)
+	queue := NewTaskQueue(promptRepo, runnerRepo, runnerService)
+
+	// The runner has the model but no way to receive the prompt.
+	runnerRepo.runners["runner-1"] = &models.Runner{
+		DeviceID: "runner-1",
+		Status:   models.RunnerStatusOnline,
+		ModelCapabilities: []models.ModelCapability{
+			{RunnerID: "runner-1", ModelName: "model-a", IsLoaded: true},
+		},
+	}
+	prompt := queuePrompt(t, promptRepo, "model-a")
+
+	queue.processQueue(ctx)
+
+	deadline := time.Now().Add(2 * time.Second)
+	var stored *models.PromptRequest
+	for time.Now().Before(deadline) {
+		stored, _ = promptRepo.GetByID(ctx, prompt.ID)
+		if stored.RetryCount > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if stored.RetryCount != 1 || stored.Status != models.PromptStatusQueued || stored.RunnerID != "" {
+		t.Fatalf("retry_count = %d, status = %s, runner = %q; want the prompt back on the queue after one attempt",
+			stored.RetryCount, stored.Status, stored.RunnerID)
+	}
+	runner, err := runnerService.GetRunner(ctx, "runner-1")
+	if err != nil {
+		t.Fatalf("GetRunner failed: %v", err)
+	}
+	if runner.HasAssignment(prompt.ID) {
+		t.Fatal("expected the runner slot to be released")
+	}
+}
+
+func TestPromptForwardRetryReusesTaskRow(t *testing.T) {
+	ctx := context.Background()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	taskRepo := newInMemoryTaskRepo()
+	runnerRepo := newInMemoryRunnerRepo()
+	runnerService := NewRunnerService(runnerRepo)
+	runnerService.taskService = NewTaskService(taskRepo, nil, runnerService)
+	runnerRepo.runners["runner-1"] = &models.Runner{
+		DeviceID: "runner-1",
+		Status:   models.RunnerStatusOnline,
+		Webhook:  server.URL,
+		ModelCapabilities: []models.ModelCapability{
+			{RunnerID: "runner-1", ModelName: "model-a", IsLoaded: true},
+		},
+	}
+	prompt := models.NewPromptRequest("client-1", "hello", "model-a", "0xabc")
+
+	forward := func() error {
+		t.Helper()
+		if _, err := runnerService.ReserveRunnerForModel(ctx, "model-a", 0, prompt.ID); err != nil {
+			t.Fatalf("ReserveRunnerForModel failed: %v", err)
+		}
+		return runnerService.ForwardPromptToRunner(ctx, "runner-1", prompt)
+	}
+
+	if err := forward(); err == nil {
+		t.Fatal("expected the first forward to fail")
+	}
+	if failed, _ := taskRepo.Get(ctx, prompt.ID); failed.Status != models.TaskStatusFailed {
+		t.Fatalf("task status after the failed forward = %s, want failed", failed.Status)
+	}
+
+	if err := forward(); err != nil {
+		t.Fatalf("retried forward failed: %v", err)
+	}
+	task, err := taskRepo.Get(ctx, prompt.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if task.Status != models.TaskStatusPending || task.RunnerID != "runner-1" || task.CompletedAt != nil {
+		t.Fatalf("task after retry: status = %s, runner = %q, completed_at = %v; want a pending task on runner-1",
+			task.Status, task.RunnerID, task.CompletedAt)
+	}
+}
@@ -15,6 +15,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/core/config"
 	"github.com/theblitlabs/parity-server/internal/core/models"
 	"github.com/theblitlabs/parity-server/internal/core/ports"
 	"github.com/theblitlabs/parity-server/internal/database/repositories"
@@ -38,6 +39,9 @@ type TaskRepository interface {
 	SaveTaskResult(ctx context.Context, result *models.TaskResult) error
 	GetTaskResult(ctx context.Context, taskID uuid.UUID) (*models.TaskResult, error)
 	GetTasksByRunner(ctx context.Context, runnerID string, limit int) ([]*models.Task, error)
+	// ClaimPending records the task's runner, nonce and lease only if the task
+	// is still pending with the given runner and nonce, and reports whether it did.
+	ClaimPending(ctx context.Context, task *models.Task, previousRunnerID, previousNonce string) (bool, error)
 }
 
 type TaskService struct {
@@ -46,7 +50,20 @@ type TaskService struct {
 	rewardClient           ports.RewardClient
 	nonceService           *NonceService
 	runnerService          *RunnerService
+	runnerHub              *RunnerHub
+	webhookService         *WebhookService
+	webhookSigner          *WebhookSigner
+	artifactService        *ArtifactService
+	taskLogService         *TaskLogService
+	datasetService         *DatasetService
+	cacheService           *RunnerCacheService
+	eventBus               *EventBus
+	creatorWebhooks        *CreatorWebhookService
 	notificationInProgress sync.Map // Used to track in-progress notifications
+	leaseTTL               time.Duration
+	longPollTimeout        time.Duration
+	leaseWaiters           map[string]*leaseWaiter
+	leaseWaitersMu         sync.Mutex
 	stopChan               chan struct{}
 	wg                     sync.WaitGroup
 }
@@ -61,8 +78,11 @@ func NewTaskService(repo TaskRepository, rewardCalculator ports.RewardCalculator
 	return &TaskService{
 		repo:             repo,
 		rewardCalculator: rewardCalculator,
-		nonceService:     NewNonceService(),
+		nonceService:     NewNonceService(nil),
 		runnerService:    runnerService,
+		leaseTTL:         defaultLeaseTTL,
+		longPollTimeout:  defaultLongPollTimeout,
+		leaseWaiters:     make(map[string]*leaseWaiter),
 		stopChan:         make(chan struct{}),
 	}
 }
@@ -71,9 +91,67 @@ func (s *TaskService) SetRewardClient(client ports.RewardClient) {
 	s.rewardClient = client
 }
 
+func (s *TaskService) SetNonceService(nonceService *NonceService) {
+	s.nonceService = nonceService
+}
+
+func (s *TaskService) SetRunnerHub(runnerHub *RunnerHub) {
+	s.runnerHub = runnerHub
+}
+
+func (s *TaskService) SetWebhookService(webhookService *WebhookService) {
+	s.webhookService = webhookService
+}
+
+func (s *TaskService) SetWebhookSigner(signer *WebhookSigner) {
+	s.webhookSigner = signer
+}
+
+func (s *TaskService) SetArtifactService(artifactService *ArtifactService) {
+	s.artifactService = artifactService
+}
+
+func (s *TaskService) SetTaskLogService(taskLogService *TaskLogService) {
+	s.taskLogService = taskLogService
+}
+
+func (s *TaskService) SetDatasetService(datasetService *DatasetService) {
+	s.datasetService = datasetService
+}
+
+// SetRunnerCacheService makes assignment prefer runners that already hold a
+// task's image or model.
+func (s *TaskService) SetRunnerCacheService(cacheService *RunnerCacheService) {
+	s.cacheService = cacheService
+}
+
+// SetEventBus publishes task state changes to the creators' event streams.
+func (s *TaskService) SetEventBus(eventBus *EventBus) {
+	s.eventBus = eventBus
+}
+
+// SetCreatorWebhookService notifies creators' registered webhooks when their
+// tasks finish, are verified or pay out rewards.
+func (s *TaskService) SetCreatorWebhookService(creatorWebhooks *CreatorWebhookService) {
+	s.creatorWebhooks = creatorWebhooks
+}
+
+func (s *TaskService) SetDispatchConfig(cfg config.DispatchConfig) {
+	if cfg.LeaseTTL > 0 {
+		s.leaseTTL = time.Duration(cfg.LeaseTTL) * time.Second
+	}
+	if cfg.LongPollTimeout > 0 {
+		s.longPollTimeout = time.Duration(cfg.LongPollTimeout) * time.Second
+	}
+}
+
 func (s *TaskService) CreateTask(ctx context.Context, task *models.Task) error {
 	if err := task.Validate(); err != nil {
-		return ErrInvalidTask
+		return fmt.Errorf("%w: %v", ErrInvalidTask, err)
+	}
+
+	if err := s.stageInputs(ctx, task); err != nil {
+		return err
 	}
 
 	if task.ID == uuid.Nil {
@@ -87,9 +165,28 @@ func (s *TaskService) CreateTask(ctx context.Context, task *models.Task) error {
 	}
 	task.UpdatedAt = time.Now()
 
+	// Tasks get their first nonce from the randomness source so the source and
+	// round are on record from the start.
+	if task.Nonce == "" {
+		nonce, round, err := s.nonceService.GenerateNonce(ctx, task.ID.String())
+		if err != nil {
+			return fmt.Errorf("failed to generate task nonce: %w", err)
+		}
+		task.Nonce = nonce
+		task.NonceSource = round.Source
+		task.NonceRound = round.Round
+	}
+
+	if task.HighValue {
+		if err := s.commitSelectionRound(ctx, task); err != nil {
+			return err
+		}
+	}
+
 	if err := s.repo.Create(ctx, task); err != nil {
 		return err
 	}
+	s.eventBus.Publish(models.NewTaskEvent("created", task))
 
 	if s.runnerService != nil {
 		s.runnerService.TriggerTaskMonitor()
@@ -98,6 +195,87 @@ func (s *TaskService) CreateTask(ctx context.Context, task *models.Task) error {
 	return nil
 }
 
+// CreateOrResetTask stores a task whose ID the caller chose, such as the task
+// that carries a forwarded prompt. If an earlier attempt left a row with that
+// ID, the row is reset to the new task instead, so a retry does not collide
+// with it. A completed task is never reset.
+func (s *TaskService) CreateOrResetTask(ctx context.Context, task *models.Task) error {
+	existing, err := s.repo.Get(ctx, task.ID)
+	if errors.Is(err, ErrTaskNotFound) {
+		return s.CreateTask(ctx, task)
+	}
+	if err != nil {
+		return err
+	}
+	if existing.Status == models.TaskStatusCompleted {
+		return ErrTaskUnavailable
+	}
+	if err := task.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTask, err)
+	}
+
+	task.Status = models.TaskStatusPending
+	task.Nonce = existing.Nonce
+	task.NonceSource = existing.NonceSource
+	task.NonceRound = existing.NonceRound
+	task.CreatedAt = existing.CreatedAt
+	task.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(ctx, task); err != nil {
+		return fmt.Errorf("failed to reset task: %w", err)
+	}
+	s.eventBus.Publish(models.NewTaskEvent("requeued", task))
+
+	if s.runnerService != nil {
+		s.runnerService.TriggerTaskMonitor()
+	}
+	return nil
+}
+
+// stageInputs verifies the task's input datasets, writes their mount
+// instructions back into its config and records their digests as the task's
+// input hash.
+func (s *TaskService) stageInputs(ctx context.Context, task *models.Task) error {
+	if task.Type == models.TaskTypeFederatedLearning || len(task.Config) == 0 {
+		return nil
+	}
+
+	var taskConfig models.TaskConfig
+	if err := json.Unmarshal(task.Config, &taskConfig); err != nil || len(taskConfig.Inputs) == 0 {
+		return nil
+	}
+	if s.datasetService == nil {
+		return fmt.Errorf("%w: input datasets are not supported by this server", ErrInvalidTask)
+	}
+
+	inputs, err := s.datasetService.Stage(ctx, taskConfig.Inputs)
+	if err != nil {
+		return err
+	}
+
+	// Rewrite only the inputs so config fields TaskConfig does not know
+	// about survive.
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(task.Config, &raw); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTask, err)
+	}
+	if raw["inputs"], err = json.Marshal(inputs); err != nil {
+		return fmt.Errorf("failed to marshal task inputs: %w", err)
+	}
+	if task.Config, err = json.Marshal(raw); err != nil {
+		return fmt.Errorf("failed to marshal task config: %w", err)
+	}
+	task.InputHash = utils.ComputeInputHash(inputs)
+
+	return nil
+}
+
+// VerifyTaskNonce checks that the task's nonce was derived from the beacon
+// round recorded on it.
+func (s *TaskService) VerifyTaskNonce(ctx context.Context, task *models.Task) error {
+	return s.nonceService.VerifyTaskNonce(ctx, task)
+}
+
 func (s *TaskService) GetTask(ctx context.Context, id string) (*models.Task, error) {
 	taskID, err := uuid.Parse(id)
 	if err != nil {
@@ -159,21 +337,21 @@ func (s *TaskService) AssignTaskToRunner(ctx context.Context, taskID string, dev
 		return fmt.Errorf("invalid runner ID: %w", err)
 	}
 
-	if runner.TaskID != nil && *runner.TaskID != task.ID {
-		log.Warn().
+	if runner.HasAssignment(task.ID) {
+		log.Info().
 			Str("task_id", taskID).
 			Str("runner_id", deviceID).
-			Str("assigned_task_id", runner.TaskID.String()).
-			Msg("Runner is already processing another task")
-		return ErrRunnerUnavailable
+			Msg("Task already assigned to this runner, skipping reassignment")
+		return nil
 	}
 
-	if runner.TaskID != nil && *runner.TaskID == task.ID {
-		log.Info().
+	if runner.FreeSlots() == 0 {
+		log.Warn().
 			Str("task_id", taskID).
 			Str("runner_id", deviceID).
-			Msg("Task already assigned to this runner, skipping reassignment")
-		return nil
+			Int("slots", runner.Capacity()).
+			Msg("Runner has no free slots")
+		return ErrRunnerUnavailable
 	}
 
 	if task.Status == models.TaskStatusRunning {
@@ -200,6 +378,21 @@ func (s *TaskService) AssignTaskToRunner(ctx context.Context, taskID string, dev
 		return errors.New("invalid docker config")
 	}
 
+	if task.HighValue {
+		selection, err := s.ensureRunnerSelection(ctx, task)
+		if err != nil {
+			return err
+		}
+		if selection.SelectedRunnerID != deviceID {
+			log.Warn().
+				Str("task_id", taskID).
+				Str("runner_id", deviceID).
+				Str("selected_runner_id", selection.SelectedRunnerID).
+				Msg("Runner was not selected for high-value task")
+			return ErrRunnerNotSelected
+		}
+	}
+
 	return s.assignTaskToRunner(ctx, task, runner)
 }
 
@@ -261,6 +454,7 @@ func (s *TaskService) StartTask(ctx context.Context, id string) error {
 		log.Error().Err(err).Str("task_id", id).Msg("Failed to update task status")
 		return err
 	}
+	s.eventBus.Publish(models.NewTaskEvent("started", task))
 
 	log.Info().
 		Str("task_id", id).
@@ -303,6 +497,8 @@ func (s *TaskService) CompleteTask(ctx context.Context, id string) error {
 		log.Error().Err(err).Str("task_id", id).Msg("Failed to update task status")
 		return err
 	}
+	s.eventBus.Publish(models.NewTaskEvent("completed", task))
+	s.notifyCreator(ctx, models.CreatorWebhookTaskCompleted, task, nil)
 
 	log.Info().
 		Str("task_id", id).
@@ -346,17 +542,19 @@ func (s *TaskService) FailTask(ctx context.Context, id string, reason string) er
 		log.Error().Err(err).Str("task_id", id).Msg("Failed to update task status to failed")
 		return err
 	}
+	s.eventBus.Publish(models.NewTaskEvent("failed", task))
+	s.notifyCreator(ctx, models.CreatorWebhookTaskFailed, task, nil)
 
-	// Clear runner assignment if task has a runner
+	if s.taskLogService != nil {
+		s.taskLogService.Finish(ctx, task.ID)
+	}
+
+	// Free the runner slot if task has a runner
 	if task.RunnerID != "" {
-		runner, err := s.runnerService.GetRunner(ctx, task.RunnerID)
-		if err == nil {
-			runner.TaskID = nil
-			if _, updateErr := s.runnerService.UpdateRunner(ctx, runner); updateErr != nil {
-				log.Error().Err(updateErr).Str("runner_id", task.RunnerID).Msg("Failed to clear runner TaskID after task failure")
-			} else {
-				log.Info().Str("runner_id", task.RunnerID).Msg("Runner TaskID cleared after task failure")
-			}
+		if err := s.runnerService.ReleaseSlot(ctx, task.RunnerID, task.ID); err != nil {
+			log.Error().Err(err).Str("runner_id", task.RunnerID).Msg("Failed to release runner slot after task failure")
+		} else {
+			log.Info().Str("runner_id", task.RunnerID).Msg("Runner slot released after task failure")
 		}
 	}
 
@@ -419,59 +617,58 @@ func (s *TaskService) SaveTaskResult(ctx context.Context, result *models.TaskRes
 	if result.SolverDeviceID == "" {
 		result.SolverDeviceID = runnerID
 	}
-	if result.ResultHash == "" {
-		result.ResultHash = utils.ComputeResultHash(result.Output, result.Error, result.ExitCode)
+
+	// The artifacts a result covers are the ones the server received from
+	// the runner, never a list the runner reports.
+	result.Artifacts = nil
+	if s.artifactService != nil && runnerID != "" {
+		artifacts, err := s.artifactService.ResultArtifacts(ctx, result.TaskID, runnerID)
+		if err != nil {
+			return err
+		}
+		result.Artifacts = artifacts
+	}
+	if len(result.Artifacts) > 0 {
+		// Runners that predate artifacts hash only their output, so the
+		// server's hash, which includes the artifact digests, wins.
+		hash := utils.ComputeResultHash(result.Output, result.Error, result.ExitCode, result.Artifacts)
+		if result.ResultHash != "" && result.ResultHash != hash {
+			log.Warn().
+				Str("task_id", result.TaskID.String()).
+				Str("runner_id", runnerID).
+				Msg("Runner result hash does not cover its artifacts, using the server's hash")
+		}
+		result.ResultHash = hash
+	} else if result.ResultHash == "" {
+		result.ResultHash = utils.ComputeResultHash(result.Output, result.Error, result.ExitCode, nil)
 	}
 
 	result.VerificationStatus = determineVerificationStatus(task, result)
 
 	if runnerID != "" {
-		log.Info().
-			Str("task_id", result.TaskID.String()).
-			Str("runner_id", runnerID).
-			Msg("Attempting to clear runner TaskID")
-
-		runner, err := s.runnerService.GetRunner(ctx, runnerID)
-		if err != nil {
+		if err := s.runnerService.ReleaseSlot(ctx, runnerID, result.TaskID); err != nil {
 			log.Error().Err(err).
 				Str("task_id", result.TaskID.String()).
 				Str("runner_id", runnerID).
-				Msg("Failed to get runner info for clearing TaskID")
+				Msg("Failed to release runner slot")
 		} else {
 			log.Info().
 				Str("task_id", result.TaskID.String()).
 				Str("runner_id", runnerID).
-				Interface("current_task_id", runner.TaskID).
-				Msg("Found runner, clearing TaskID")
-
-			runner.TaskID = nil
-			runner.Status = models.RunnerStatusOnline // Ensure runner stays online
-			updatedRunner, err := s.runnerService.UpdateRunner(ctx, runner)
-			if err != nil {
-				log.Error().Err(err).
-					Str("task_id", result.TaskID.String()).
-					Str("runner_id", runnerID).
-					Msg("Failed to clear runner TaskID")
-			} else {
-				if updatedRunner.TaskID != nil {
-					log.Error().
-						Str("task_id", result.TaskID.String()).
-						Str("runner_id", runnerID).
-						Interface("task_id_after_update", updatedRunner.TaskID).
-						Msg("Runner TaskID not cleared properly")
-				} else {
-					log.Info().
-						Str("task_id", result.TaskID.String()).
-						Str("runner_id", runnerID).
-						Msg("Successfully cleared runner TaskID after task completion")
-				}
-			}
+				Msg("Released runner slot after task completion")
 		}
 	} else {
 		log.Warn().
 			Str("task_id", result.TaskID.String()).
 			Msg("No runner ID found in task or result")
-		return fmt.Errorf("no runner ID found to clear TaskID")
+		return fmt.Errorf("no runner ID found to release runner slot")
+	}
+
+	if s.taskLogService != nil {
+		// Streamed logs crossed the network like any other task data.
+		if streamed := s.taskLogService.Finish(ctx, result.TaskID); streamed > 0 {
+			result.NetworkDataGB += float64(streamed) / (1 << 30)
+		}
 	}
 
 	metrics := ports.ResourceMetrics{
@@ -521,6 +718,10 @@ func (s *TaskService) SaveTaskResult(ctx context.Context, result *models.TaskRes
 				Str("task_id", result.TaskID.String()).
 				Msg("Failed to update task status")
 		} else {
+			s.eventBus.Publish(models.NewTaskEvent(string(task.Status), task))
+			if task.Status == models.TaskStatusCompleted {
+				s.notifyCreator(ctx, models.CreatorWebhookTaskCompleted, task, result)
+			}
 			log.Info().
 				Str("task_id", result.TaskID.String()).
 				Str("runner_id", runnerID).
@@ -528,22 +729,39 @@ func (s *TaskService) SaveTaskResult(ctx context.Context, result *models.TaskRes
 		}
 	}
 
+	switch result.VerificationStatus {
+	case "verified":
+		s.notifyCreator(ctx, models.CreatorWebhookTaskVerified, task, result)
+	case "failed":
+		s.notifyCreator(ctx, models.CreatorWebhookTaskNotVerified, task, result)
+	}
+
 	runner, err := s.runnerService.GetRunner(ctx, runnerID)
-	if err == nil && (runner.TaskID != nil || runner.Status != models.RunnerStatusOnline) {
+	if err == nil && (runner.HasAssignment(result.TaskID) || (runner.Status != models.RunnerStatusOnline && !runner.IsDraining() && !runner.ForcedOffline)) {
 		log.Warn().
 			Str("task_id", result.TaskID.String()).
 			Str("runner_id", runnerID).
-			Interface("task_id_after_completion", runner.TaskID).
+			Bool("slot_held_after_completion", runner.HasAssignment(result.TaskID)).
 			Str("status_after_completion", string(runner.Status)).
-			Msg("Runner still has TaskID or incorrect status after completion, attempting to fix")
+			Msg("Runner still holds the task slot or has incorrect status after completion, attempting to fix")
 
-		runner.TaskID = nil
-		runner.Status = models.RunnerStatusOnline
-		if _, err := s.runnerService.UpdateRunner(ctx, runner); err != nil {
+		if err := s.runnerService.ReleaseSlot(ctx, runnerID, result.TaskID); err != nil {
 			log.Error().Err(err).
 				Str("task_id", result.TaskID.String()).
 				Str("runner_id", runnerID).
-				Msg("Failed to fix runner state in final check")
+				Msg("Failed to release runner slot in final check")
+		}
+
+		// A runner an operator forced offline only reports its last result;
+		// it stays offline until it is restored.
+		if !runner.ForcedOffline {
+			runner.Status = models.RunnerStatusOnline
+			if _, err := s.runnerService.UpdateRunner(ctx, runner); err != nil {
+				log.Error().Err(err).
+					Str("task_id", result.TaskID.String()).
+					Str("runner_id", runnerID).
+					Msg("Failed to fix runner state in final check")
+			}
 		}
 	}
 
@@ -554,7 +772,9 @@ func (s *TaskService) SaveTaskResult(ctx context.Context, result *models.TaskRes
 					Str("task_id", result.TaskID.String()).
 					Float64("reward", result.Reward).
 					Msg("Failed to distribute rewards")
+				return
 			}
+			s.notifyCreator(context.Background(), models.CreatorWebhookRewardPaid, task, result)
 		}()
 	}
 
@@ -574,11 +794,11 @@ func determineVerificationStatus(task *models.Task, result *models.TaskResult) s
 		return "pending"
 	}
 
-	if task.ImageHash == "" && task.CommandHash == "" {
+	if task.ImageHash == "" && task.CommandHash == "" && task.InputHash == "" {
 		return "not_requested"
 	}
 
-	if utils.VerifyTaskHashes(task, result.ImageHashVerified, result.CommandHashVerified) {
+	if utils.VerifyTaskHashes(task, result.ImageHashVerified, result.CommandHashVerified, result.InputHashVerified) {
 		return "verified"
 	}
 
@@ -650,7 +870,11 @@ func (s *TaskService) checkPendingAssignments() error {
 		if task.RunnerID == "" {
 			continue
 		}
-		if now.Sub(task.UpdatedAt) < pendingAssignmentTimeout {
+		if task.LeaseExpiresAt != nil {
+			if now.Before(*task.LeaseExpiresAt) {
+				continue
+			}
+		} else if now.Sub(task.UpdatedAt) < pendingAssignmentTimeout {
 			continue
 		}
 		if err := s.handlePendingAssignmentTimeout(task); err != nil {
@@ -679,26 +903,73 @@ func (s *TaskService) handleStalledTask(task *models.Task) error {
 		}
 	} else {
 		runner.Status = models.RunnerStatusOffline
-		runner.TaskID = nil
 		if _, err := s.runnerService.UpdateRunner(context.Background(), runner); err != nil {
 			log.Error().Err(err).
 				Str("runner_id", runner.DeviceID).
 				Msg("Failed to update runner status")
 			return err
 		}
+		if err := s.runnerService.ReleaseSlot(context.Background(), runner.DeviceID, task.ID); err != nil {
+			log.Error().Err(err).
+				Str("runner_id", runner.DeviceID).
+				Msg("Failed to release runner slot")
+			return err
+		}
 	}
 
+	stalledRunnerID := task.RunnerID
 	task.Status = models.TaskStatusPending
 	task.RunnerID = ""
+	task.LeaseExpiresAt = nil
 	task.UpdatedAt = time.Now()
 
 	if err := s.repo.Update(context.Background(), task); err != nil {
 		return fmt.Errorf("failed to reset task status: %w", err)
 	}
+	s.eventBus.Publish(models.NewTaskEvent("requeued", task))
+
+	s.notifyTaskCancelled(stalledRunnerID, task.ID, "task stalled and was returned to the queue")
 
 	return nil
 }
 
+// RequeueTask takes a task back from runnerID and returns it to the pending
+// queue. Tasks that already finished or moved to another runner are left alone
+// and reported as not requeued.
+func (s *TaskService) RequeueTask(ctx context.Context, taskID uuid.UUID, runnerID, reason string) (bool, error) {
+	task, err := s.repo.Get(ctx, taskID)
+	if err != nil {
+		return false, err
+	}
+
+	if task.RunnerID != runnerID ||
+		(task.Status != models.TaskStatusPending && task.Status != models.TaskStatusRunning) {
+		if err := s.runnerService.ReleaseSlot(ctx, runnerID, taskID); err != nil {
+			return false, fmt.Errorf("failed to release runner slot: %w", err)
+		}
+		return false, nil
+	}
+
+	task.Status = models.TaskStatusPending
+	task.RunnerID = ""
+	task.LeaseExpiresAt = nil
+	task.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(ctx, task); err != nil {
+		return false, fmt.Errorf("failed to requeue task: %w", err)
+	}
+	s.eventBus.Publish(models.NewTaskEvent("requeued", task))
+
+	if err := s.runnerService.ReleaseSlot(ctx, runnerID, taskID); err != nil {
+		return false, fmt.Errorf("failed to release runner slot: %w", err)
+	}
+
+	s.notifyTaskCancelled(runnerID, taskID, reason)
+	s.runnerService.TriggerTaskMonitor()
+
+	return true, nil
+}
+
 func (s *TaskService) handlePendingAssignmentTimeout(task *models.Task) error {
 	log := gologger.WithComponent("task_service")
 	assignmentAge := time.Since(task.UpdatedAt)
@@ -710,11 +981,9 @@ func (s *TaskService) handlePendingAssignmentTimeout(task *models.Task) error {
 	runnerID := task.RunnerID
 	runner, err := s.runnerService.GetRunner(context.Background(), runnerID)
 	if err == nil {
-		if runner.TaskID != nil && *runner.TaskID == task.ID {
-			runner.TaskID = nil
-			runner.Status = models.RunnerStatusOnline
-			if _, updateErr := s.runnerService.UpdateRunner(context.Background(), runner); updateErr != nil {
-				return fmt.Errorf("failed to clear stale runner assignment: %w", updateErr)
+		if runner.HasAssignment(task.ID) {
+			if releaseErr := s.runnerService.ReleaseSlot(context.Background(), runnerID, task.ID); releaseErr != nil {
+				return fmt.Errorf("failed to clear stale runner assignment: %w", releaseErr)
 			}
 		}
 	} else if !errors.Is(err, ErrRunnerNotFound) && !strings.Contains(err.Error(), "runner not found") {
@@ -722,11 +991,13 @@ func (s *TaskService) handlePendingAssignmentTimeout(task *models.Task) error {
 	}
 
 	task.RunnerID = ""
+	task.LeaseExpiresAt = nil
 	task.UpdatedAt = time.Now()
 	task.CompletedAt = nil
 	if err := s.repo.Update(context.Background(), task); err != nil {
 		return fmt.Errorf("failed to reset task assignment: %w", err)
 	}
+	s.eventBus.Publish(models.NewTaskEvent("requeued", task))
 
 	log.Warn().
 		Str("task_id", task.ID.String()).
@@ -734,6 +1005,8 @@ func (s *TaskService) handlePendingAssignmentTimeout(task *models.Task) error {
 		Dur("age", assignmentAge).
 		Msg("Reset stale pending task assignment")
 
+	s.notifyTaskCancelled(runnerID, task.ID, "assignment expired before the task was started")
+
 	if s.runnerService != nil {
 		s.runnerService.TriggerTaskMonitor()
 	}
@@ -757,9 +1030,18 @@ func (s *TaskService) checkAndAssignPendingTasksToRunner(ctx context.Context, ru
 	if runner.Status != models.RunnerStatusOnline {
 		return nil
 	}
-	if runner.TaskID != nil {
+	freeSlots := runner.FreeSlots()
+	if freeSlots == 0 {
 		return nil
 	}
+	// Pull-mode runners are only leased work while they are polling for it,
+	// one task per poll.
+	if runner.PullsTasks() {
+		if !s.isPolling(runnerID) {
+			return nil
+		}
+		freeSlots = 1
+	}
 
 	pendingTasks, err := s.repo.ListByStatus(ctx, models.TaskStatusPending)
 	if err != nil {
@@ -771,6 +1053,10 @@ func (s *TaskService) checkAndAssignPendingTasksToRunner(ctx context.Context, ru
 	})
 
 	for _, task := range pendingTasks {
+		if runner.HasAssignment(task.ID) {
+			continue
+		}
+
 		currentRunners, err := s.getAvailableRunners(ctx)
 		if err != nil {
 			log.Error().Err(err).
@@ -784,6 +1070,30 @@ func (s *TaskService) checkAndAssignPendingTasksToRunner(ctx context.Context, ru
 			continue
 		}
 
+		if task.HighValue {
+			previousDraws := len(task.Selections)
+			selection, err := s.ensureRunnerSelection(ctx, task)
+			if err != nil {
+				if !errors.Is(err, ErrRunnerUnavailable) {
+					log.Error().Err(err).
+						Str("task_id", task.ID.String()).
+						Msg("Failed to select runner for high-value task")
+				}
+				continue
+			}
+			if selection.SelectedRunnerID != runnerID {
+				// A fresh draw may have picked a runner this pass already skipped.
+				if len(task.Selections) > previousDraws {
+					s.runnerService.TriggerTaskMonitor()
+				}
+				continue
+			}
+		}
+
+		if !task.HighValue && s.holdForWarmRunner(task, runner, currentRunners) {
+			continue
+		}
+
 		if err := s.assignTaskToRunner(ctx, task, runner); err != nil {
 			if errors.Is(err, ErrRunnerUnavailable) || errors.Is(err, ErrTaskUnavailable) {
 				continue
@@ -800,7 +1110,10 @@ func (s *TaskService) checkAndAssignPendingTasksToRunner(ctx context.Context, ru
 			Str("runner_id", runnerID).
 			Msg("Successfully assigned pending task to runner")
 
-		break
+		freeSlots--
+		if freeSlots == 0 {
+			break
+		}
 	}
 
 	return nil
@@ -816,7 +1129,7 @@ func (s *TaskService) getAvailableRunners(ctx context.Context) ([]*models.Runner
 
 	availableRunners := make([]*models.Runner, 0)
 	for _, runner := range runners {
-		if runner.Status == models.RunnerStatusOnline && runner.TaskID == nil {
+		if runner.Status == models.RunnerStatusOnline && runner.FreeSlots() > 0 {
 			availableRunners = append(availableRunners, runner)
 		}
 	}
@@ -833,13 +1146,54 @@ func (s *TaskService) getAvailableRunners(ctx context.Context) ([]*models.Runner
 func (s *TaskService) countAssignedRunners(task *models.Task, runners []*models.Runner) int {
 	assignedCount := 0
 	for _, r := range runners {
-		if r.TaskID != nil && *r.TaskID == task.ID {
+		if r.HasAssignment(task.ID) {
 			assignedCount++
 		}
 	}
 	return assignedCount
 }
 
+// holdForWarmRunner reports whether a cold runner should leave the task for
+// another free runner that already holds its image or model. A task is only
+// held until it has waited the cache service's max wait, so cold runners are
+// never starved of work; when the hold ends the task monitor runs again.
+func (s *TaskService) holdForWarmRunner(task *models.Task, runner *models.Runner, candidates []*models.Runner) bool {
+	if s.cacheService == nil {
+		return false
+	}
+	warm, cacheable := s.cacheService.Warm(runner.DeviceID, task)
+	if !cacheable || warm {
+		return false
+	}
+	wait := s.cacheService.HoldTime(task)
+	if wait <= 0 {
+		return false
+	}
+
+	for _, candidate := range candidates {
+		if candidate.DeviceID == runner.DeviceID || candidate.FreeSlots() == 0 {
+			continue
+		}
+		if candidate.PullsTasks() && !s.isPolling(candidate.DeviceID) {
+			continue
+		}
+		if warm, _ := s.cacheService.Warm(candidate.DeviceID, task); !warm {
+			continue
+		}
+
+		holdKey := "cache_hold_" + task.ID.String()
+		if _, exists := s.notificationInProgress.LoadOrStore(holdKey, true); !exists {
+			s.cacheService.RecordHeld()
+			time.AfterFunc(wait, func() {
+				s.notificationInProgress.Delete(holdKey)
+				s.runnerService.TriggerTaskMonitor()
+			})
+		}
+		return true
+	}
+	return false
+}
+
 func (s *TaskService) assignTaskToRunner(ctx context.Context, task *models.Task, runner *models.Runner) error {
 	log := gologger.WithComponent("task_service")
 	runnerAssignKey := "runner_assign_" + runner.DeviceID
@@ -855,10 +1209,10 @@ func (s *TaskService) assignTaskToRunner(ctx context.Context, task *models.Task,
 	if currentRunner.Status != models.RunnerStatusOnline {
 		return ErrRunnerUnavailable
 	}
-	if currentRunner.TaskID != nil {
-		if *currentRunner.TaskID == task.ID {
-			return nil
-		}
+	if currentRunner.HasAssignment(task.ID) {
+		return nil
+	}
+	if currentRunner.FreeSlots() == 0 {
 		return ErrRunnerUnavailable
 	}
 
@@ -875,32 +1229,54 @@ func (s *TaskService) assignTaskToRunner(ctx context.Context, task *models.Task,
 
 	previousRunnerID := currentTask.RunnerID
 	previousNonce := currentTask.Nonce
+	previousNonceSource := currentTask.NonceSource
+	previousNonceRound := currentTask.NonceRound
+	previousLease := currentTask.LeaseExpiresAt
+
+	nonce, round, err := s.nonceService.GenerateNonce(ctx, currentTask.ID.String())
+	if err != nil {
+		return fmt.Errorf("failed to generate task nonce: %w", err)
+	}
 
 	currentTask.RunnerID = currentRunner.DeviceID
-	currentTask.Nonce = s.nonceService.GenerateNonce()
+	currentTask.Nonce = nonce
+	currentTask.NonceSource = round.Source
+	currentTask.NonceRound = round.Round
 	currentTask.UpdatedAt = time.Now()
+	if currentRunner.PullsTasks() {
+		leaseExpiresAt := currentTask.UpdatedAt.Add(s.leaseTTL)
+		currentTask.LeaseExpiresAt = &leaseExpiresAt
+	}
 
-	if err := s.repo.Update(ctx, currentTask); err != nil {
-		return fmt.Errorf("failed to update task with runner ID: %w", err)
+	acquired, err := s.runnerService.AcquireSlot(ctx, currentRunner.DeviceID, currentTask.ID, models.AssignmentKindForTask(currentTask))
+	if err != nil {
+		return fmt.Errorf("failed to reserve runner slot: %w", err)
+	}
+	if !acquired {
+		return ErrRunnerUnavailable
 	}
 
-	currentRunner.TaskID = &currentTask.ID
-	if _, err := s.runnerService.UpdateRunner(ctx, currentRunner); err != nil {
-		currentTask.RunnerID = previousRunnerID
-		currentTask.Nonce = previousNonce
-		currentTask.UpdatedAt = time.Now()
-		if revertErr := s.repo.Update(ctx, currentTask); revertErr != nil {
-			log.Error().Err(revertErr).
-				Str("task_id", currentTask.ID.String()).
-				Msg("Failed to revert task runner ID")
+	// The claim only succeeds if nobody leased the task since it was read; the
+	// nonce changes on every claim, so it doubles as the row version.
+	claimed, err := s.repo.ClaimPending(ctx, currentTask, previousRunnerID, previousNonce)
+	if err != nil || !claimed {
+		s.releaseLostClaim(ctx, currentTask.ID, currentRunner.DeviceID)
+		if err != nil {
+			return fmt.Errorf("failed to update task with runner ID: %w", err)
 		}
-		return fmt.Errorf("failed to update runner with task ID: %w", err)
+		return ErrTaskUnavailable
+	}
+
+	// Pull-mode runners pick the lease up from their long poll instead of a webhook.
+	if currentRunner.PullsTasks() {
+		s.wakeLeaseWaiter(currentRunner.DeviceID)
+		s.taskAssigned(currentRunner.DeviceID, currentTask)
+		return nil
 	}
 
 	if err := s.notifyRunnerAboutTask(currentRunner, currentTask); err != nil {
-		currentRunner.TaskID = nil
-		if _, updateErr := s.runnerService.UpdateRunner(ctx, currentRunner); updateErr != nil {
-			log.Error().Err(updateErr).
+		if releaseErr := s.runnerService.ReleaseSlot(ctx, currentRunner.DeviceID, currentTask.ID); releaseErr != nil {
+			log.Error().Err(releaseErr).
 				Str("task_id", currentTask.ID.String()).
 				Str("runner_id", currentRunner.DeviceID).
 				Msg("Failed to revert runner assignment after notification failure")
@@ -908,6 +1284,9 @@ func (s *TaskService) assignTaskToRunner(ctx context.Context, task *models.Task,
 
 		currentTask.RunnerID = previousRunnerID
 		currentTask.Nonce = previousNonce
+		currentTask.NonceSource = previousNonceSource
+		currentTask.NonceRound = previousNonceRound
+		currentTask.LeaseExpiresAt = previousLease
 		currentTask.UpdatedAt = time.Now()
 		if revertErr := s.repo.Update(ctx, currentTask); revertErr != nil {
 			log.Error().Err(revertErr).
@@ -922,13 +1301,47 @@ func (s *TaskService) assignTaskToRunner(ctx context.Context, task *models.Task,
 		return fmt.Errorf("failed to notify runner about task: %w", err)
 	}
 
+	s.taskAssigned(currentRunner.DeviceID, currentTask)
 	return nil
 }
 
-func (s *TaskService) notifyRunnerAboutTask(runner *models.Runner, task *models.Task) error {
-	if runner.Webhook == "" {
-		return fmt.Errorf("runner has no webhook URL")
+// releaseLostClaim frees the slot reserved for a claim that lost the race,
+// unless the winner was the same runner and the slot is now its own.
+func (s *TaskService) releaseLostClaim(ctx context.Context, taskID uuid.UUID, deviceID string) {
+	if task, err := s.repo.Get(ctx, taskID); err == nil && task.RunnerID == deviceID {
+		return
+	}
+	if err := s.runnerService.ReleaseSlot(ctx, deviceID, taskID); err != nil {
+		log := gologger.WithComponent("task_service")
+		log.Error().Err(err).
+			Str("task_id", taskID.String()).
+			Str("runner_id", deviceID).
+			Msg("Failed to release runner slot after losing the task claim")
 	}
+}
+
+func (s *TaskService) taskAssigned(deviceID string, task *models.Task) {
+	if s.cacheService != nil {
+		s.cacheService.RecordAssignment(deviceID, task)
+	}
+	s.eventBus.Publish(models.NewTaskEvent("assigned", task))
+}
+
+func (s *TaskService) notifyCreator(ctx context.Context, event models.CreatorWebhookEvent, task *models.Task, result *models.TaskResult) {
+	if s.creatorWebhooks == nil {
+		return
+	}
+	if err := s.creatorWebhooks.Notify(ctx, event, task, result); err != nil {
+		log := gologger.WithComponent("task_service")
+		log.Error().Err(err).
+			Str("task_id", task.ID.String()).
+			Str("event", string(event)).
+			Msg("Failed to queue creator webhook")
+	}
+}
+
+func (s *TaskService) notifyRunnerAboutTask(runner *models.Runner, task *models.Task) error {
+	log := gologger.WithComponent("task_service")
 
 	if runner.Status != models.RunnerStatusOnline {
 		return fmt.Errorf("runner is not online")
@@ -937,16 +1350,55 @@ func (s *TaskService) notifyRunnerAboutTask(runner *models.Runner, task *models.
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 
+	if s.runnerHub != nil && s.runnerHub.IsConnected(runner.DeviceID) {
+		taskPayload, err := buildTaskPayload(task)
+		if err != nil {
+			return err
+		}
+
+		err = s.runnerHub.Send(ctx, runner.DeviceID, models.RunnerMessageTaskOffer, taskPayload)
+		if !errors.Is(err, ErrRunnerNotConnected) {
+			return err
+		}
+
+		log.Info().
+			Str("runner_id", runner.DeviceID).
+			Str("task_id", task.ID.String()).
+			Msg("Runner connection dropped, falling back to webhook")
+	}
+
+	if runner.Webhook == "" {
+		return fmt.Errorf("runner has no webhook URL")
+	}
+
 	return s.sendWebhookNotification(ctx, runner, task)
 }
 
-func (s *TaskService) sendWebhookNotification(ctx context.Context, runner *models.Runner, task *models.Task) error {
-	log := gologger.WithComponent("task_service")
+// notifyTaskCancelled tells a connected runner to stop working on a task. Runners
+// without a connection find out when they next report on the task.
+func (s *TaskService) notifyTaskCancelled(runnerID string, taskID uuid.UUID, reason string) {
+	if s.runnerHub == nil || runnerID == "" {
+		return
+	}
 
+	err := s.runnerHub.Notify(runnerID, models.RunnerMessageTaskCancel, models.TaskCancelPayload{
+		TaskID: taskID.String(),
+		Reason: reason,
+	})
+	if err != nil && !errors.Is(err, ErrRunnerNotConnected) {
+		log := gologger.WithComponent("task_service")
+		log.Warn().Err(err).
+			Str("runner_id", runnerID).
+			Str("task_id", taskID.String()).
+			Msg("Failed to send task cancellation to runner")
+	}
+}
+
+func buildTaskPayload(task *models.Task) (map[string]interface{}, error) {
 	var taskConfig map[string]interface{}
 	if task.Config != nil {
 		if err := json.Unmarshal(task.Config, &taskConfig); err != nil {
-			return fmt.Errorf("failed to unmarshal task config: %w", err)
+			return nil, fmt.Errorf("failed to unmarshal task config: %w", err)
 		}
 	}
 
@@ -973,9 +1425,31 @@ func (s *TaskService) sendWebhookNotification(ctx context.Context, runner *model
 		taskPayload["completed_at"] = task.CompletedAt.Format(time.RFC3339)
 	}
 
-	payload := map[string]interface{}{
-		"type":    "available_tasks",
-		"payload": taskPayload,
+	return taskPayload, nil
+}
+
+func (s *TaskService) sendWebhookNotification(ctx context.Context, runner *models.Runner, task *models.Task) error {
+	log := gologger.WithComponent("task_service")
+
+	taskPayload, err := buildTaskPayload(task)
+	if err != nil {
+		return err
+	}
+
+	payload, err := newWebhookMessage(runner.ProtocolVersion, models.RunnerMessageTaskOffer, taskPayload)
+	if err != nil {
+		return err
+	}
+
+	if s.webhookService != nil {
+		if _, err := s.webhookService.Enqueue(ctx, runner.DeviceID, runner.Webhook, models.WebhookEventTaskOffer, task.ID.String(), payload); err != nil {
+			return err
+		}
+		log.Debug().
+			Str("runner_id", runner.DeviceID).
+			Str("task_id", task.ID.String()).
+			Msg("Task offer queued for webhook delivery")
+		return nil
 	}
 
 	payloadBytes, err := json.Marshal(payload)
@@ -991,6 +1465,12 @@ func (s *TaskService) sendWebhookNotification(ctx context.Context, runner *model
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Device-ID", runner.DeviceID)
 
+	if s.webhookSigner != nil {
+		if err := s.webhookSigner.SignRequest(ctx, req, runner.DeviceID, payloadBytes); err != nil {
+			return fmt.Errorf("failed to sign webhook request: %w", err)
+		}
+	}
+
 	client := &http.Client{
 		Transport: &http.Transport{
 			MaxIdleConns:       100,
@@ -3,8 +3,11 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -13,6 +16,7 @@ import (
 )
 
 type inMemoryTaskRepo struct {
+	mu      sync.Mutex
 	tasks   map[uuid.UUID]*models.Task
 	results map[uuid.UUID]*models.TaskResult
 }
@@ -25,11 +29,20 @@ func newInMemoryTaskRepo() *inMemoryTaskRepo {
 }
 
 func (r *inMemoryTaskRepo) Create(ctx context.Context, task *models.Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tasks[task.ID]; ok {
+		return errors.New(`duplicate key value violates unique constraint "tasks_pkey"`)
+	}
 	r.tasks[task.ID] = cloneTask(task)
 	return nil
 }
 
 func (r *inMemoryTaskRepo) Get(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	task, ok := r.tasks[id]
 	if !ok {
 		return nil, ErrTaskNotFound
@@ -38,11 +51,29 @@ func (r *inMemoryTaskRepo) Get(ctx context.Context, id uuid.UUID) (*models.Task,
 }
 
 func (r *inMemoryTaskRepo) Update(ctx context.Context, task *models.Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	r.tasks[task.ID] = cloneTask(task)
 	return nil
 }
 
+func (r *inMemoryTaskRepo) ClaimPending(ctx context.Context, task *models.Task, previousRunnerID, previousNonce string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.tasks[task.ID]
+	if !ok || stored.Status != models.TaskStatusPending || stored.RunnerID != previousRunnerID || stored.Nonce != previousNonce {
+		return false, nil
+	}
+	r.tasks[task.ID] = cloneTask(task)
+	return true, nil
+}
+
 func (r *inMemoryTaskRepo) List(ctx context.Context, limit, offset int) ([]*models.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	tasks := make([]*models.Task, 0, len(r.tasks))
 	for _, task := range r.tasks {
 		tasks = append(tasks, cloneTask(task))
@@ -51,6 +82,9 @@ func (r *inMemoryTaskRepo) List(ctx context.Context, limit, offset int) ([]*mode
 }
 
 func (r *inMemoryTaskRepo) ListByStatus(ctx context.Context, status models.TaskStatus) ([]*models.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	tasks := make([]*models.Task, 0)
 	for _, task := range r.tasks {
 		if task.Status == status {
@@ -65,12 +99,18 @@ func (r *inMemoryTaskRepo) GetAll(ctx context.Context) ([]models.Task, error) {
 }
 
 func (r *inMemoryTaskRepo) SaveTaskResult(ctx context.Context, result *models.TaskResult) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	cloned := *result
 	r.results[result.TaskID] = &cloned
 	return nil
 }
 
 func (r *inMemoryTaskRepo) GetTaskResult(ctx context.Context, taskID uuid.UUID) (*models.TaskResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	result, ok := r.results[taskID]
 	if !ok {
 		return nil, nil
@@ -84,6 +124,7 @@ func (r *inMemoryTaskRepo) GetTasksByRunner(ctx context.Context, runnerID string
 }
 
 type inMemoryRunnerRepo struct {
+	mu      sync.Mutex
 	runners map[string]*models.Runner
 }
 
@@ -94,11 +135,17 @@ func newInMemoryRunnerRepo() *inMemoryRunnerRepo {
 }
 
 func (r *inMemoryRunnerRepo) Create(ctx context.Context, runner *models.Runner) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	r.runners[runner.DeviceID] = cloneRunner(runner)
 	return nil
 }
 
 func (r *inMemoryRunnerRepo) Get(ctx context.Context, deviceID string) (*models.Runner, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	runner, ok := r.runners[deviceID]
 	if !ok {
 		return nil, ErrRunnerNotFound
@@ -107,19 +154,114 @@ func (r *inMemoryRunnerRepo) Get(ctx context.Context, deviceID string) (*models.
 }
 
 func (r *inMemoryRunnerRepo) CreateOrUpdate(ctx context.Context, runner *models.Runner) (*models.Runner, error) {
-	r.runners[runner.DeviceID] = cloneRunner(runner)
-	return cloneRunner(runner), nil
+	return r.Update(ctx, runner)
 }
 
 func (r *inMemoryRunnerRepo) Update(ctx context.Context, runner *models.Runner) (*models.Runner, error) {
-	r.runners[runner.DeviceID] = cloneRunner(runner)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := cloneRunner(runner)
+	stored.Assignments = nil
+	if existing, ok := r.runners[runner.DeviceID]; ok {
+		switch {
+		case existing.ForcedOffline:
+			stored.Status = models.RunnerStatusOffline
+		case existing.IsDraining():
+			stored.Status = models.RunnerStatusDraining
+		}
+		stored.ForcedOffline = existing.ForcedOffline
+		stored.MaintenanceUntil = existing.MaintenanceUntil
+		stored.Assignments = existing.Assignments
+	}
+	r.runners[runner.DeviceID] = stored
+	return cloneRunner(stored), nil
+}
+
+func (r *inMemoryRunnerRepo) UpdateDrainState(ctx context.Context, deviceID string, status models.RunnerStatus, maintenanceUntil *time.Time) (*models.Runner, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	runner, ok := r.runners[deviceID]
+	if !ok {
+		return nil, ErrRunnerNotFound
+	}
+	runner.Status = status
+	runner.MaintenanceUntil = maintenanceUntil
 	return cloneRunner(runner), nil
 }
 
+func (r *inMemoryRunnerRepo) SetForcedOffline(ctx context.Context, deviceID string, forced bool) (*models.Runner, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	runner, ok := r.runners[deviceID]
+	if !ok {
+		return nil, ErrRunnerNotFound
+	}
+	runner.ForcedOffline = forced
+	if forced {
+		runner.Status = models.RunnerStatusOffline
+		runner.MaintenanceUntil = nil
+	}
+	return cloneRunner(runner), nil
+}
+
+func (r *inMemoryRunnerRepo) BindWallet(ctx context.Context, deviceID string, walletAddress string) (*models.Runner, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	runner, ok := r.runners[deviceID]
+	if !ok {
+		return nil, ErrRunnerNotFound
+	}
+	runner.WalletAddress = walletAddress
+	return cloneRunner(runner), nil
+}
+
+func (r *inMemoryRunnerRepo) AcquireSlot(ctx context.Context, assignment *models.RunnerAssignment) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	runner, ok := r.runners[assignment.DeviceID]
+	if !ok {
+		return false, ErrRunnerNotFound
+	}
+	if runner.HasAssignment(assignment.WorkID) {
+		return true, nil
+	}
+	if runner.FreeSlots() == 0 {
+		return false, nil
+	}
+	runner.Assignments = append(runner.Assignments, *assignment)
+	return true, nil
+}
+
+func (r *inMemoryRunnerRepo) ReleaseSlot(ctx context.Context, deviceID string, workID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	runner, ok := r.runners[deviceID]
+	if !ok {
+		return nil
+	}
+	kept := runner.Assignments[:0]
+	for _, assignment := range runner.Assignments {
+		if assignment.WorkID != workID {
+			kept = append(kept, assignment)
+		}
+	}
+	runner.Assignments = kept
+	return nil
+}
+
 func (r *inMemoryRunnerRepo) ListByStatus(ctx context.Context, status models.RunnerStatus) ([]*models.Runner, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	runners := make([]*models.Runner, 0)
 	for _, runner := range r.runners {
-		if runner.Status == status {
+		if runner.Status == status && (!runner.ForcedOffline || status == models.RunnerStatusOffline) {
 			runners = append(runners, cloneRunner(runner))
 		}
 	}
@@ -127,17 +269,70 @@ func (r *inMemoryRunnerRepo) ListByStatus(ctx context.Context, status models.Run
 }
 
 func (r *inMemoryRunnerRepo) UpdateRunnersToOffline(ctx context.Context, heartbeatTimeout time.Duration) (int64, []string, error) {
-	return 0, nil, nil
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var deviceIDs []string
+	for _, runner := range r.runners {
+		if !runner.LastHeartbeat.Before(now.Add(-heartbeatTimeout)) {
+			continue
+		}
+		switch {
+		case runner.Status == models.RunnerStatusOnline, runner.Status == models.RunnerStatusBusy:
+		case runner.IsDraining() && !runner.InMaintenance(now):
+		default:
+			continue
+		}
+		runner.Status = models.RunnerStatusOffline
+		runner.MaintenanceUntil = nil
+		deviceIDs = append(deviceIDs, runner.DeviceID)
+	}
+	return int64(len(deviceIDs)), deviceIDs, nil
 }
 
 func (r *inMemoryRunnerRepo) GetOnlineRunners(ctx context.Context) ([]*models.Runner, error) {
-	return nil, nil
+	return r.ListByStatus(ctx, models.RunnerStatusOnline)
 }
 
 func (r *inMemoryRunnerRepo) GetRunnerByDeviceID(ctx context.Context, deviceID string) (*models.Runner, error) {
 	return r.Get(ctx, deviceID)
 }
 
+func (r *inMemoryRunnerRepo) List(ctx context.Context, filter models.RunnerFilter) ([]*models.Runner, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	runners := make([]*models.Runner, 0)
+	for _, runner := range r.runners {
+		if filter.Status != "" && runner.Status != filter.Status {
+			continue
+		}
+		if filter.WalletAddress != "" && !strings.EqualFold(runner.WalletAddress, filter.WalletAddress) {
+			continue
+		}
+		if filter.Model != "" && !hasModelCapability(runner, filter.Model) {
+			continue
+		}
+		if !runner.Labels.Matches(filter.Labels) {
+			continue
+		}
+		runners = append(runners, cloneRunner(runner))
+	}
+	return runners, nil
+}
+
+func (r *inMemoryRunnerRepo) Delete(ctx context.Context, deviceID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.runners[deviceID]; !ok {
+		return ErrRunnerNotFound
+	}
+	delete(r.runners, deviceID)
+	return nil
+}
+
 func (r *inMemoryRunnerRepo) UpdateModelCapabilities(ctx context.Context, runnerID string, capabilities []models.ModelCapability) error {
 	return nil
 }
@@ -160,12 +355,35 @@ func cloneTask(task *models.Task) *models.Task {
 	return &cloned
 }
 
+func (r *inMemoryRunnerRepo) VersionDistribution(ctx context.Context) ([]models.RunnerVersionCount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counts := make(map[models.RunnerVersionCount]int64)
+	for _, runner := range r.runners {
+		counts[models.RunnerVersionCount{ProtocolVersion: runner.ProtocolVersion, SoftwareVersion: runner.SoftwareVersion}]++
+	}
+
+	versions := make([]models.RunnerVersionCount, 0, len(counts))
+	for version, count := range counts {
+		version.Count = count
+		versions = append(versions, version)
+	}
+	return versions, nil
+}
+
+func hasModelCapability(runner *models.Runner, modelName string) bool {
+	for _, capability := range runner.ModelCapabilities {
+		if capability.ModelName == modelName {
+			return true
+		}
+	}
+	return false
+}
+
 func cloneRunner(runner *models.Runner) *models.Runner {
 	cloned := *runner
-	if runner.TaskID != nil {
-		taskID := *runner.TaskID
-		cloned.TaskID = &taskID
-	}
+	cloned.Assignments = append([]models.RunnerAssignment(nil), runner.Assignments...)
 	return &cloned
 }
 
@@ -231,8 +449,8 @@ func TestAssignTaskToRunnerRevertsStateWhenNotificationFails(t *testing.T) {
 		t.Fatalf("failed to get stored runner: %v", getErr)
 	}
 
-	if storedRunner.TaskID != nil {
-		t.Fatalf("expected runner task assignment to be cleared, got %s", storedRunner.TaskID.String())
+	if len(storedRunner.Assignments) != 0 {
+		t.Fatalf("expected runner slot to be released, got %d assignments", len(storedRunner.Assignments))
 	}
 }
 
@@ -263,10 +481,11 @@ func TestAssignTaskToRunnerRejectsBusyRunnerUsingFreshState(t *testing.T) {
 
 	otherTaskID := uuid.New()
 	runnerRepo.runners["runner-1"] = &models.Runner{
-		DeviceID: "runner-1",
-		Status:   models.RunnerStatusOnline,
-		TaskID:   &otherTaskID,
-		Webhook:  "http://runner.invalid/webhook",
+		DeviceID:    "runner-1",
+		Status:      models.RunnerStatusOnline,
+		Slots:       1,
+		Assignments: []models.RunnerAssignment{*models.NewRunnerAssignment("runner-1", otherTaskID, models.AssignmentKindTask)},
+		Webhook:     "http://runner.invalid/webhook",
 	}
 
 	// Simulate a stale runner snapshot captured before another assignment landed.
@@ -274,7 +493,6 @@ func TestAssignTaskToRunnerRejectsBusyRunnerUsingFreshState(t *testing.T) {
 		DeviceID: "runner-1",
 		Status:   models.RunnerStatusOnline,
 		Webhook:  "http://runner.invalid/webhook",
-		TaskID:   nil,
 	}
 
 	err = taskService.assignTaskToRunner(context.Background(), task, staleRunner)
@@ -294,7 +512,7 @@ func TestAssignTaskToRunnerRejectsBusyRunnerUsingFreshState(t *testing.T) {
 	if getErr != nil {
 		t.Fatalf("failed to get stored runner: %v", getErr)
 	}
-	if storedRunner.TaskID == nil || *storedRunner.TaskID != otherTaskID {
+	if len(storedRunner.Assignments) != 1 || !storedRunner.HasAssignment(otherTaskID) {
 		t.Fatalf("expected runner task assignment to remain %s", otherTaskID.String())
 	}
 }
@@ -316,9 +534,9 @@ func TestSaveTaskResultMarksVerifiedWhenHashesMatch(t *testing.T) {
 	taskRepo.tasks[task.ID] = cloneTask(task)
 
 	runnerRepo.runners["runner-1"] = &models.Runner{
-		DeviceID: "runner-1",
-		Status:   models.RunnerStatusBusy,
-		TaskID:   &task.ID,
+		DeviceID:    "runner-1",
+		Status:      models.RunnerStatusOnline,
+		Assignments: []models.RunnerAssignment{*models.NewRunnerAssignment("runner-1", task.ID, models.AssignmentKindTask)},
 	}
 
 	result := models.NewTaskResult()
@@ -367,9 +585,9 @@ func TestSaveTaskResultMarksNotVerifiedWhenHashesMismatch(t *testing.T) {
 	taskRepo.tasks[task.ID] = cloneTask(task)
 
 	runnerRepo.runners["runner-1"] = &models.Runner{
-		DeviceID: "runner-1",
-		Status:   models.RunnerStatusBusy,
-		TaskID:   &task.ID,
+		DeviceID:    "runner-1",
+		Status:      models.RunnerStatusOnline,
+		Assignments: []models.RunnerAssignment{*models.NewRunnerAssignment("runner-1", task.ID, models.AssignmentKindTask)},
 	}
 
 	result := models.NewTaskResult()
@@ -417,9 +635,9 @@ func TestCheckPendingAssignmentsResetsStaleAssignedTask(t *testing.T) {
 	taskRepo.tasks[task.ID] = cloneTask(task)
 
 	runnerRepo.runners["runner-1"] = &models.Runner{
-		DeviceID: "runner-1",
-		Status:   models.RunnerStatusOnline,
-		TaskID:   &task.ID,
+		DeviceID:    "runner-1",
+		Status:      models.RunnerStatusOnline,
+		Assignments: []models.RunnerAssignment{*models.NewRunnerAssignment("runner-1", task.ID, models.AssignmentKindTask)},
 	}
 
 	if err := taskService.checkPendingAssignments(); err != nil {
@@ -441,7 +659,7 @@ func TestCheckPendingAssignmentsResetsStaleAssignedTask(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Get runner error = %v", err)
 	}
-	if storedRunner.TaskID != nil {
-		t.Fatalf("expected runner task ID to be cleared, got %s", storedRunner.TaskID.String())
+	if len(storedRunner.Assignments) != 0 {
+		t.Fatalf("expected runner slot to be released, got %d assignments", len(storedRunner.Assignments))
 	}
 }
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/core/config"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/internal/core/ports"
+)
+
+const telemetryCompactInterval = 10 * time.Minute
+
+// TelemetryService keeps the heartbeat time series for each runner: it stores a
+// raw row per heartbeat, rolls old rows up into coarser buckets, drops rows past
+// retention and computes uptime and jitter from what is left.
+type TelemetryService struct {
+	repo ports.TelemetryRepository
+
+	mu           sync.RWMutex
+	tolerance    time.Duration
+	rawRetention time.Duration
+	resolution   time.Duration
+	retention    time.Duration
+	uptimeWindow time.Duration
+	now          func() time.Time
+}
+
+func NewTelemetryService(repo ports.TelemetryRepository) *TelemetryService {
+	return &TelemetryService{
+		repo:         repo,
+		tolerance:    2 * time.Minute,
+		rawRetention: 24 * time.Hour,
+		resolution:   time.Hour,
+		retention:    30 * 24 * time.Hour,
+		uptimeWindow: 7 * 24 * time.Hour,
+		now:          time.Now,
+	}
+}
+
+func (s *TelemetryService) SetConfig(cfg config.TelemetryConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cfg.RawRetention > 0 {
+		s.rawRetention = time.Duration(cfg.RawRetention) * time.Hour
+	}
+	if cfg.RollupInterval > 0 {
+		s.resolution = time.Duration(cfg.RollupInterval) * time.Minute
+	}
+	if cfg.Retention > 0 {
+		s.retention = time.Duration(cfg.Retention) * 24 * time.Hour
+	}
+	if cfg.UptimeWindow > 0 {
+		s.uptimeWindow = time.Duration(cfg.UptimeWindow) * time.Hour
+	}
+}
+
+// SetGapTolerance sets the longest gap between heartbeats that still counts as
+// online. It follows the runner heartbeat timeout.
+func (s *TelemetryService) SetGapTolerance(tolerance time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tolerance = tolerance
+}
+
+func (s *TelemetryService) gapTolerance() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tolerance
+}
+
+// RecordHeartbeat stores one heartbeat, measuring the gap from the runner's
+// previous raw sample.
+func (s *TelemetryService) RecordHeartbeat(ctx context.Context, deviceID string, at time.Time, telemetry models.HeartbeatTelemetry) error {
+	previous, err := s.repo.LatestSample(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+
+	var gap time.Duration
+	if previous != nil {
+		gap = at.Sub(previous.BucketStart)
+	}
+	sample := models.NewRunnerTelemetrySample(deviceID, at, telemetry, gap, s.gapTolerance())
+	return s.repo.CreateSample(ctx, sample)
+}
+
+// History returns the runner's samples in [since, until), oldest first.
+func (s *TelemetryService) History(ctx context.Context, deviceID string, since, until time.Time) ([]*models.RunnerTelemetrySample, error) {
+	return s.repo.ListSamples(ctx, deviceID, since, until)
+}
+
+// Health summarizes the runner's series over the window ending now. A zero
+// window uses the configured uptime window.
+func (s *TelemetryService) Health(ctx context.Context, deviceID string, window time.Duration) (*models.RunnerHealth, error) {
+	if window <= 0 {
+		s.mu.RLock()
+		window = s.uptimeWindow
+		s.mu.RUnlock()
+	}
+
+	end := s.now()
+	start := end.Add(-window)
+	samples, err := s.repo.ListSamples(ctx, deviceID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.SummarizeTelemetry(deviceID, samples, start, end, s.gapTolerance()), nil
+}
+
+// Compact rolls raw rows older than the raw retention into buckets and deletes
+// rows older than the retention.
+func (s *TelemetryService) Compact(ctx context.Context) error {
+	log := gologger.WithComponent("telemetry")
+
+	s.mu.RLock()
+	rawRetention, resolution, retention := s.rawRetention, s.resolution, s.retention
+	s.mu.RUnlock()
+
+	now := s.now()
+	cutoff := now.Add(-rawRetention).Truncate(resolution)
+
+	folded, err := s.repo.Downsample(ctx, cutoff, resolution)
+	if err != nil {
+		return err
+	}
+
+	expired, err := s.repo.DeleteSamplesBefore(ctx, now.Add(-retention))
+	if err != nil {
+		return err
+	}
+
+	if folded > 0 || expired > 0 {
+		log.Debug().
+			Int64("folded", folded).
+			Int64("expired", expired).
+			Msg("Compacted runner telemetry")
+	}
+
+	return nil
+}
+
+// Start compacts the series periodically until ctx is cancelled.
+func (s *TelemetryService) Start(ctx context.Context) {
+	log := gologger.WithComponent("telemetry")
+	log.Info().Msg("Starting telemetry compaction worker")
+
+	ticker := time.NewTicker(telemetryCompactInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("Telemetry compaction worker stopped")
+			return
+		case <-ticker.C:
+			if err := s.Compact(ctx); err != nil {
+				log.Error().Err(err).Msg("Failed to compact runner telemetry")
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/theblitlabs/parity-server/internal/core/models"
+)
+
+type inMemoryTelemetryRepo struct {
+	mu      sync.Mutex
+	samples []*models.RunnerTelemetrySample
+}
+
+func (r *inMemoryTelemetryRepo) CreateSample(ctx context.Context, sample *models.RunnerTelemetrySample) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cloned := *sample
+	r.samples = append(r.samples, &cloned)
+	return nil
+}
+
+func (r *inMemoryTelemetryRepo) LatestSample(ctx context.Context, deviceID string) (*models.RunnerTelemetrySample, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var latest *models.RunnerTelemetrySample
+	for _, sample := range r.samples {
+		if sample.DeviceID == deviceID && sample.Resolution == 0 &&
+			(latest == nil || sample.BucketStart.After(latest.BucketStart)) {
+			latest = sample
+		}
+	}
+	return latest, nil
+}
+
+func (r *inMemoryTelemetryRepo) ListSamples(ctx context.Context, deviceID string, since, until time.Time) ([]*models.RunnerTelemetrySample, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var samples []*models.RunnerTelemetrySample
+	for _, sample := range r.samples {
+		if sample.DeviceID == deviceID && !sample.BucketStart.Before(since) && sample.BucketStart.Before(until) {
+			cloned := *sample
+			samples = append(samples, &cloned)
+		}
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].BucketStart.Before(samples[j].BucketStart) })
+	return samples, nil
+}
+
+func (r *inMemoryTelemetryRepo) Downsample(ctx context.Context, before time.Time, resolution time.Duration) (int64, error) {
+	return 0, nil
+}
+
+func (r *inMemoryTelemetryRepo) DeleteSamplesBefore(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestRecordHeartbeatStoresTelemetryAndFeedsReputation(t *testing.T) {
+	ctx := context.Background()
+	runnerRepo := newInMemoryRunnerRepo()
+	runnerService := NewRunnerService(runnerRepo)
+	telemetryRepo := &inMemoryTelemetryRepo{}
+	telemetryService := NewTelemetryService(telemetryRepo)
+	runnerService.SetTelemetryService(telemetryService)
+
+	runnerRepo.runners["runner-1"] = &models.Runner{
+		DeviceID: "runner-1",
+		Status:   models.RunnerStatusOffline,
+	}
+
+	heartbeat := &models.Runner{DeviceID: "runner-1", Status: models.RunnerStatusOnline}
+	for _, cpu := range []float64{20, 60} {
+		if _, err := runnerService.RecordHeartbeat(ctx, heartbeat, models.HeartbeatTelemetry{CPU: cpu, MemoryBytes: 512, Reported: true}); err != nil {
+			t.Fatalf("RecordHeartbeat returned error: %v", err)
+		}
+	}
+
+	if runner, _ := runnerRepo.Get(ctx, "runner-1"); runner.Status != models.RunnerStatusOnline {
+		t.Fatalf("expected heartbeat to bring the runner online, got %q", runner.Status)
+	}
+
+	if len(telemetryRepo.samples) != 2 {
+		t.Fatalf("expected two samples, got %d", len(telemetryRepo.samples))
+	}
+	if telemetryRepo.samples[0].Gaps != 0 || telemetryRepo.samples[1].Gaps != 1 {
+		t.Fatalf("expected only the second heartbeat to record a gap, got %d and %d",
+			telemetryRepo.samples[0].Gaps, telemetryRepo.samples[1].Gaps)
+	}
+
+	// Pin the end of the uptime window so both summaries cover the same span.
+	now := time.Now()
+	telemetryService.now = func() time.Time { return now }
+
+	health, err := telemetryService.Health(ctx, "runner-1", time.Hour)
+	if err != nil {
+		t.Fatalf("Health returned error: %v", err)
+	}
+	if health.Heartbeats != 2 || health.CPUAvg != 40 || health.UptimePercentage < 99 {
+		t.Fatalf("unexpected health summary: %+v", health)
+	}
+
+	reputationService := &ReputationService{}
+	reputationService.SetTelemetryService(telemetryService)
+	reputation := &models.RunnerReputation{RunnerID: "runner-1", UptimePercentage: 50}
+	reputationService.refreshUptime(ctx, reputation)
+	if reputation.UptimePercentage != health.UptimePercentage {
+		t.Fatalf("expected reputation uptime %.2f from telemetry, got %.2f", health.UptimePercentage, reputation.UptimePercentage)
+	}
+}
@@ -21,7 +21,7 @@ func NewVerificationService(taskRepo TaskRepository) *VerificationService {
 	}
 }
 
-func (s *VerificationService) VerifyTaskExecution(ctx context.Context, taskID string, imageHashVerified, commandHashVerified string) error {
+func (s *VerificationService) VerifyTaskExecution(ctx context.Context, taskID string, imageHashVerified, commandHashVerified, inputHashVerified string) error {
 	taskUUID, err := uuid.Parse(taskID)
 	if err != nil {
 		return fmt.Errorf("invalid task ID: %w", err)
@@ -32,13 +32,15 @@ func (s *VerificationService) VerifyTaskExecution(ctx context.Context, taskID st
 		return fmt.Errorf("failed to get task: %w", err)
 	}
 
-	if !utils.VerifyTaskHashes(task, imageHashVerified, commandHashVerified) {
+	if !utils.VerifyTaskHashes(task, imageHashVerified, commandHashVerified, inputHashVerified) {
 		log.Error().
 			Str("task_id", taskID).
 			Str("expected_image_hash", task.ImageHash).
 			Str("verified_image_hash", imageHashVerified).
 			Str("expected_command_hash", task.CommandHash).
 			Str("verified_command_hash", commandHashVerified).
+			Str("expected_input_hash", task.InputHash).
+			Str("verified_input_hash", inputHashVerified).
 			Msg("Hash verification failed")
 		return fmt.Errorf("hash verification failed for task %s", taskID)
 	}
@@ -6,21 +6,32 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/core/config"
+	"github.com/theblitlabs/parity-server/internal/core/models"
 	"github.com/theblitlabs/parity-server/internal/core/ports"
 )
 
-type WebhookRegistration struct {
-	ID        string    `json:"id"`
-	URL       string    `json:"url"`
-	DeviceID  string    `json:"-"` // Internal field, not exposed in JSON
-	CreatedAt time.Time `json:"created_at"`
-}
+const (
+	defaultWebhookMaxAttempts      = 8
+	defaultWebhookInitialBackoff   = 2 * time.Second
+	defaultWebhookMaxBackoff       = 5 * time.Minute
+	defaultWebhookBreakerThreshold = 5
+	defaultWebhookBreakerCooldown  = time.Minute
+
+	webhookPollInterval = time.Second
+	// webhookConcurrency is both how many deliveries are claimed per lease and
+	// how many are in flight at once.
+	webhookConcurrency    = 10
+	webhookRequestTimeout = 10 * time.Second
+)
 
 type RegisterWebhookRequest struct {
 	URL           string `json:"url"`
@@ -32,19 +43,76 @@ type WSMessage struct {
 	Payload interface{} `json:"payload"`
 }
 
+// newWebhookMessage shapes a webhook body for the runner's protocol. Legacy
+// runners get the original {"type": "available_tasks"} wrapper for every event;
+// newer runners get the typed RunnerMessage envelope.
+func newWebhookMessage(protocol int, messageType models.RunnerMessageType, payload interface{}) (interface{}, error) {
+	if protocol < models.RunnerProtocolEnvelope {
+		return WSMessage{
+			Type:    string(models.RunnerMessageAvailableTasks),
+			Payload: payload,
+		}, nil
+	}
+	return models.NewRunnerMessage(messageType, payload)
+}
+
+// webhookBreaker tracks consecutive failures for one URL. While open, deliveries
+// to that URL are deferred without spending an attempt.
+type webhookBreaker struct {
+	failures  int
+	openUntil time.Time
+}
+
+// WebhookService persists runner webhook registrations and delivers outbound
+// webhooks through a database outbox. Messages are written to the outbox first
+// and a worker posts them with exponential backoff, dead-lettering them once
+// their attempts run out.
 type WebhookService struct {
-	webhooks     map[string]WebhookRegistration
-	webhookMutex sync.RWMutex
-	taskService  ports.TaskServicer
-	stopCh       chan struct{}
-	taskUpdateCh chan struct{}
+	repo          ports.WebhookRepository
+	taskService   ports.TaskServicer
+	signer        *WebhookSigner
+	runnerService *RunnerService
+	client        *http.Client
+	stopCh        chan struct{}
+	taskUpdateCh  chan struct{}
+	wakeCh        chan struct{}
+	done          chan struct{}
+	mu            sync.Mutex
+	running       bool
+
+	maxAttempts      int
+	initialBackoff   time.Duration
+	maxBackoff       time.Duration
+	breakerThreshold int
+	breakerCooldown  time.Duration
+
+	breakersMu sync.Mutex
+	breakers   map[string]*webhookBreaker
+
+	deadLetterHandlers []func(ctx context.Context, delivery *models.WebhookDelivery)
 }
 
-func NewWebhookService(taskService ports.TaskServicer) *WebhookService {
+func NewWebhookService(repo ports.WebhookRepository, taskService ports.TaskServicer) *WebhookService {
 	return &WebhookService{
-		webhooks:     make(map[string]WebhookRegistration),
-		taskService:  taskService,
-		taskUpdateCh: make(chan struct{}, 100),
+		repo:        repo,
+		taskService: taskService,
+		client: &http.Client{
+			Timeout: webhookRequestTimeout,
+			Transport: &http.Transport{
+				MaxIdleConns:       100,
+				IdleConnTimeout:    90 * time.Second,
+				DisableCompression: true,
+			},
+		},
+		taskUpdateCh:     make(chan struct{}, 100),
+		wakeCh:           make(chan struct{}, 1),
+		done:             make(chan struct{}),
+		maxAttempts:      defaultWebhookMaxAttempts,
+		initialBackoff:   defaultWebhookInitialBackoff,
+		maxBackoff:       defaultWebhookMaxBackoff,
+		breakerThreshold: defaultWebhookBreakerThreshold,
+		breakerCooldown:  defaultWebhookBreakerCooldown,
+		breakers:         make(map[string]*webhookBreaker),
 	}
 }
 
@@ -52,17 +120,62 @@ func (s *WebhookService) SetStopChannel(stopCh chan struct{}) {
 	s.stopCh = stopCh
 }
 
+func (s *WebhookService) SetWebhookSigner(signer *WebhookSigner) {
+	s.signer = signer
+}
+
+// SetRunnerService lets webhook bodies be shaped for each runner's negotiated
+// protocol. Without it every runner gets the legacy format.
+func (s *WebhookService) SetRunnerService(runnerService *RunnerService) {
+	s.runnerService = runnerService
+}
+
+func (s *WebhookService) protocolVersion(ctx context.Context, deviceID string) int {
+	if s.runnerService == nil {
+		return models.RunnerProtocolLegacy
+	}
+	return s.runnerService.ProtocolVersion(ctx, deviceID)
+}
+
+func (s *WebhookService) SetDeliveryConfig(cfg config.WebhookConfig) {
+	if cfg.MaxAttempts > 0 {
+		s.maxAttempts = cfg.MaxAttempts
+	}
+	if cfg.InitialBackoff > 0 {
+		s.initialBackoff = time.Duration(cfg.InitialBackoff) * time.Second
+	}
+	if cfg.MaxBackoff > 0 {
+		s.maxBackoff = time.Duration(cfg.MaxBackoff) * time.Second
+	}
+	if cfg.BreakerThreshold > 0 {
+		s.breakerThreshold = cfg.BreakerThreshold
+	}
+	if cfg.BreakerCooldown > 0 {
+		s.breakerCooldown = time.Duration(cfg.BreakerCooldown) * time.Second
+	}
+}
+
+// OnDeadLetter registers a callback run when a delivery exhausts its attempts.
+func (s *WebhookService) OnDeadLetter(handler func(ctx context.Context, delivery *models.WebhookDelivery)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deadLetterHandlers = append(s.deadLetterHandlers, handler)
+}
+
 func (s *WebhookService) NotifyTaskUpdate() {
 	select {
 	case s.taskUpdateCh <- struct{}{}:
-		go s.notifyWebhooks()
+		go func() {
+			defer func() { <-s.taskUpdateCh }()
+			s.notifyWebhooks()
+		}()
 	case <-s.stopCh:
 		return
 	default:
 	}
 }
 
-func (s *WebhookService) RegisterWebhook(req RegisterWebhookRequest, deviceID string) (string, error) {
+func (s *WebhookService) RegisterWebhook(ctx context.Context, req RegisterWebhookRequest, deviceID string) (string, error) {
 	if req.URL == "" {
 		return "", fmt.Errorf("webhook URL is required")
 	}
@@ -70,204 +183,465 @@ func (s *WebhookService) RegisterWebhook(req RegisterWebhookRequest, deviceID st
 		return "", fmt.Errorf("X-Device-ID header is required")
 	}
 
-	webhookID := uuid.New().String()
-	webhook := WebhookRegistration{
-		ID:        webhookID,
+	now := time.Now()
+	endpoint := &models.WebhookEndpoint{
+		ID:        uuid.New(),
 		URL:       req.URL,
 		DeviceID:  deviceID,
-		CreatedAt: time.Now(),
+		CreatedAt: now,
+		UpdatedAt: now,
 	}
 
-	s.webhookMutex.Lock()
-	s.webhooks[webhookID] = webhook
-	s.webhookMutex.Unlock()
+	if err := s.repo.UpsertEndpoint(ctx, endpoint); err != nil {
+		return "", fmt.Errorf("failed to save webhook: %w", err)
+	}
 
 	log := gologger.WithComponent("webhook")
 	log.Info().
-		Str("webhook_id", webhookID).
+		Str("webhook_id", endpoint.ID.String()).
 		Str("device_id", deviceID).
 		Msg("Webhook registered")
 
-	go s.sendInitialNotification(webhook)
+	go s.sendInitialNotification(endpoint)
 
-	return webhookID, nil
+	return endpoint.ID.String(), nil
 }
 
-func (s *WebhookService) UnregisterWebhook(webhookID string) error {
-	s.webhookMutex.Lock()
-	webhook, exists := s.webhooks[webhookID]
-	if !exists {
-		s.webhookMutex.Unlock()
+func (s *WebhookService) UnregisterWebhook(ctx context.Context, webhookID string) error {
+	id, err := uuid.Parse(webhookID)
+	if err != nil {
 		return fmt.Errorf("webhook not found")
 	}
 
-	delete(s.webhooks, webhookID)
-	s.webhookMutex.Unlock()
+	endpoint, err := s.repo.GetEndpoint(ctx, id)
+	if err != nil {
+		return fmt.Errorf("webhook not found")
+	}
+
+	if err := s.repo.DeleteEndpoint(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
 
 	log := gologger.WithComponent("webhook")
 	log.Info().
 		Str("webhook_id", webhookID).
-		Str("device_id", webhook.DeviceID).
+		Str("device_id", endpoint.DeviceID).
 		Msg("Webhook unregistered")
 
 	return nil
 }
 
-func (s *WebhookService) notifyWebhooks() {
+// UnregisterDeviceWebhooks removes every webhook registered by the device.
+func (s *WebhookService) UnregisterDeviceWebhooks(ctx context.Context, deviceID string) error {
+	if err := s.repo.DeleteEndpointsByDevice(ctx, deviceID); err != nil {
+		return fmt.Errorf("failed to delete webhooks: %w", err)
+	}
+
 	log := gologger.WithComponent("webhook")
+	log.Info().Str("device_id", deviceID).Msg("Device webhooks unregistered")
 
-	select {
-	case <-s.stopCh:
-		return
-	default:
+	return nil
+}
+
+// SigningSecret returns the secret the device's webhooks are signed with,
+// issuing one on first use. issued is only true for that first call, so callers
+// can hand the secret out once instead of on every registration.
+func (s *WebhookService) SigningSecret(ctx context.Context, deviceID string) (secret string, issued bool, err error) {
+	if s.signer == nil {
+		return "", false, fmt.Errorf("webhook signing is not configured")
 	}
+	return s.signer.EnsureSecret(ctx, deviceID)
+}
 
-	tasks, err := s.taskService.ListAvailableTasks(context.Background())
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to list tasks for webhook notification")
-		return
+// RotateSigningSecret replaces the device's signing secret. Webhooks are signed
+// with both the old and new secret until the overlap window ends.
+func (s *WebhookService) RotateSigningSecret(ctx context.Context, deviceID string, overlap time.Duration) (*models.WebhookSecretRotation, error) {
+	if s.signer == nil {
+		return nil, fmt.Errorf("webhook signing is not configured")
 	}
+	return s.signer.RotateSecret(ctx, deviceID, overlap)
+}
 
-	if len(tasks) == 0 {
-		return
+// Enqueue writes a message to the outbox and wakes the delivery worker. The
+// message is delivered to url as JSON; taskID links it to the task it concerns.
+func (s *WebhookService) Enqueue(ctx context.Context, deviceID, url, eventType, taskID string, message interface{}) (*models.WebhookDelivery, error) {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal webhook payload: %w", err)
 	}
 
-	payload := WSMessage{
-		Type:    "available_tasks",
-		Payload: tasks,
+	delivery := models.NewWebhookDelivery(deviceID, url, eventType, body)
+	delivery.TaskID = taskID
+	delivery.MaxAttempts = s.maxAttempts
+
+	if err := s.repo.CreateDelivery(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("failed to write webhook to outbox: %w", err)
 	}
 
-	payloadBytes, err := json.Marshal(payload)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to marshal webhook payload")
-		return
+	select {
+	case s.wakeCh <- struct{}{}:
+	default:
 	}
 
-	s.webhookMutex.RLock()
-	webhooks := make([]WebhookRegistration, 0, len(s.webhooks))
-	for _, webhook := range s.webhooks {
-		webhooks = append(webhooks, webhook)
+	return delivery, nil
+}
+
+// ListDeliveries returns the device's most recent deliveries with their attempts.
+func (s *WebhookService) ListDeliveries(ctx context.Context, deviceID string, status models.WebhookDeliveryStatus, limit int) ([]*models.WebhookDelivery, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 100
 	}
-	s.webhookMutex.RUnlock()
+	return s.repo.ListDeliveriesByDevice(ctx, deviceID, status, limit)
+}
 
-	if len(webhooks) == 0 {
+// Start runs the delivery worker until ctx is cancelled or the service stops.
+func (s *WebhookService) Start(ctx context.Context) {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
 		return
 	}
+	s.running = true
+	s.mu.Unlock()
 
-	client := &http.Client{
-		Timeout: 5 * time.Second,
-		Transport: &http.Transport{
-			MaxIdleConns:       100,
-			IdleConnTimeout:    90 * time.Second,
-			DisableCompression: true,
-		},
-	}
+	log := gologger.WithComponent("webhook")
+	log.Info().Msg("Starting webhook delivery worker")
 
-	sem := make(chan struct{}, 10)
-	var wg sync.WaitGroup
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
 
-	for _, webhook := range webhooks {
+	for {
 		select {
+		case <-ctx.Done():
+			log.Info().Msg("Webhook delivery worker stopped due to context cancellation")
+			return
 		case <-s.stopCh:
+			log.Info().Msg("Webhook delivery worker stopped")
 			return
-		default:
-			sem <- struct{}{}
-			wg.Add(1)
+		case <-s.done:
+			log.Info().Msg("Webhook delivery worker stopped")
+			return
+		case <-ticker.C:
+			s.processDueDeliveries(ctx)
+		case <-s.wakeCh:
+			s.processDueDeliveries(ctx)
+		}
+	}
+}
 
-			go func(webhook WebhookRegistration) {
-				defer func() {
-					<-sem
-					wg.Done()
-				}()
+func (s *WebhookService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-				s.sendWebhookNotification(client, webhook, payloadBytes)
-			}(webhook)
-		}
+	select {
+	case <-s.done:
+	default:
+		close(s.done)
 	}
+	s.running = false
+}
+
+func (s *WebhookService) processDueDeliveries(ctx context.Context) {
+	log := gologger.WithComponent("webhook")
 
-	wg.Wait()
+	for {
+		deliveries, err := s.repo.ClaimDueDeliveries(ctx, time.Now(), 2*webhookRequestTimeout, webhookConcurrency)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to claim webhook deliveries")
+			return
+		}
+		if len(deliveries) == 0 {
+			return
+		}
+
+		// Every claimed delivery is sent at once, so each one goes out well
+		// inside its lock and no other instance can claim it meanwhile.
+		var wg sync.WaitGroup
+		for _, delivery := range deliveries {
+			wg.Add(1)
+			go func(delivery *models.WebhookDelivery) {
+				defer wg.Done()
+				s.deliver(ctx, delivery)
+			}(delivery)
+		}
+		wg.Wait()
+
+		if len(deliveries) < webhookConcurrency {
+			return
+		}
+	}
 }
 
-func (s *WebhookService) sendWebhookNotification(client *http.Client, webhook WebhookRegistration, payloadBytes []byte) {
+func (s *WebhookService) deliver(ctx context.Context, delivery *models.WebhookDelivery) {
 	log := gologger.WithComponent("webhook")
 
-	req, err := http.NewRequest("POST", webhook.URL, bytes.NewReader(payloadBytes))
-	if err != nil {
-		log.Error().Err(err).
-			Str("webhook_id", webhook.ID).
-			Msg("Failed to create webhook request")
+	if openUntil, open := s.breakerOpen(delivery.URL); open {
+		delivery.NextAttemptAt = openUntil
+		delivery.LockedUntil = nil
+		delivery.UpdatedAt = time.Now()
+		if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+			log.Error().Err(err).Str("delivery_id", delivery.ID.String()).Msg("Failed to defer webhook delivery")
+		}
+		return
+	}
+
+	start := time.Now()
+	statusCode, sendErr := s.post(ctx, delivery)
+	now := time.Now()
+
+	delivery.Attempts++
+	attempt := &models.WebhookDeliveryAttempt{
+		ID:         uuid.New(),
+		DeliveryID: delivery.ID,
+		DeviceID:   delivery.DeviceID,
+		Attempt:    delivery.Attempts,
+		StatusCode: statusCode,
+		DurationMs: now.Sub(start).Milliseconds(),
+		CreatedAt:  now,
+	}
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+	}
+	if err := s.repo.CreateAttempt(ctx, attempt); err != nil {
+		log.Error().Err(err).Str("delivery_id", delivery.ID.String()).Msg("Failed to record webhook attempt")
+	}
+
+	delivery.LastStatusCode = statusCode
+	delivery.LockedUntil = nil
+	delivery.UpdatedAt = now
+
+	if sendErr == nil {
+		s.recordBreakerSuccess(delivery.URL)
+		delivery.Status = models.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+		if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+			log.Error().Err(err).Str("delivery_id", delivery.ID.String()).Msg("Failed to mark webhook delivered")
+		}
+		return
+	}
+
+	s.recordBreakerFailure(delivery.URL)
+	delivery.LastError = sendErr.Error()
+
+	maxAttempts := delivery.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = s.maxAttempts
+	}
+
+	if delivery.Attempts >= maxAttempts {
+		delivery.Status = models.WebhookDeliveryDead
+		if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+			log.Error().Err(err).Str("delivery_id", delivery.ID.String()).Msg("Failed to dead-letter webhook")
+		}
+
+		log.Warn().
+			Str("delivery_id", delivery.ID.String()).
+			Str("device_id", delivery.DeviceID).
+			Str("event_type", delivery.EventType).
+			Int("attempts", delivery.Attempts).
+			Str("last_error", delivery.LastError).
+			Msg("Webhook dead-lettered")
+
+		s.mu.Lock()
+		handlers := append([]func(context.Context, *models.WebhookDelivery){}, s.deadLetterHandlers...)
+		s.mu.Unlock()
+		for _, handler := range handlers {
+			handler(ctx, delivery)
+		}
 		return
 	}
 
+	delivery.NextAttemptAt = now.Add(s.backoff(delivery.Attempts))
+	if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+		log.Error().Err(err).Str("delivery_id", delivery.ID.String()).Msg("Failed to reschedule webhook")
+	}
+
+	log.Debug().
+		Str("delivery_id", delivery.ID.String()).
+		Str("device_id", delivery.DeviceID).
+		Int("attempts", delivery.Attempts).
+		Time("next_attempt_at", delivery.NextAttemptAt).
+		Str("error", delivery.LastError).
+		Msg("Webhook delivery failed, will retry")
+}
+
+func (s *WebhookService) post(ctx context.Context, delivery *models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, strings.NewReader(delivery.Body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Webhook-ID", webhook.ID)
+	req.Header.Set("X-Device-ID", delivery.DeviceID)
+	req.Header.Set("X-Webhook-Delivery-ID", delivery.ID.String())
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
 
-	resp, err := client.Do(req)
+	if s.signer != nil {
+		if err := s.signer.SignRequest(ctx, req, delivery.DeviceID, []byte(delivery.Body)); err != nil {
+			return 0, fmt.Errorf("failed to sign webhook: %w", err)
+		}
+	}
+
+	resp, err := s.client.Do(req)
 	if err != nil {
-		log.Error().Err(err).
-			Str("webhook_id", webhook.ID).
-			Msg("Failed to send webhook notification")
-		return
+		return 0, fmt.Errorf("failed to send webhook request: %w", err)
 	}
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil {
+			log := gologger.WithComponent("webhook")
 			log.Error().Err(closeErr).Msg("Failed to close response body")
 		}
 	}()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		log.Error().
-			Str("webhook_id", webhook.ID).
-			Int("status", resp.StatusCode).
-			Str("response", string(body)).
-			Msg("Webhook notification failed")
-		return
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return resp.StatusCode, fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
 	}
+
+	return resp.StatusCode, nil
 }
 
-func (s *WebhookService) sendInitialNotification(webhook WebhookRegistration) {
+func (s *WebhookService) backoff(attempts int) time.Duration {
+	return webhookBackoff(s.initialBackoff, s.maxBackoff, attempts)
+}
+
+// webhookBackoff doubles the delay for every failed attempt, up to maxBackoff,
+// with up to 20% jitter so retries from a batch do not arrive together.
+func webhookBackoff(initialBackoff, maxBackoff time.Duration, attempts int) time.Duration {
+	delay := initialBackoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	if jitter := int64(delay) / 5; jitter > 0 {
+		delay += time.Duration(rand.Int63n(jitter))
+	}
+	return delay
+}
+
+func (s *WebhookService) breakerOpen(url string) (time.Time, bool) {
+	s.breakersMu.Lock()
+	defer s.breakersMu.Unlock()
+
+	breaker, ok := s.breakers[url]
+	if !ok || breaker.openUntil.IsZero() {
+		return time.Time{}, false
+	}
+	if time.Now().Before(breaker.openUntil) {
+		return breaker.openUntil, true
+	}
+
+	// Half-open: let one delivery through; a failure reopens the breaker.
+	breaker.openUntil = time.Time{}
+	breaker.failures = s.breakerThreshold - 1
+	return time.Time{}, false
+}
+
+func (s *WebhookService) recordBreakerSuccess(url string) {
+	s.breakersMu.Lock()
+	defer s.breakersMu.Unlock()
+	delete(s.breakers, url)
+}
+
+func (s *WebhookService) recordBreakerFailure(url string) {
+	s.breakersMu.Lock()
+	defer s.breakersMu.Unlock()
+
+	breaker, ok := s.breakers[url]
+	if !ok {
+		breaker = &webhookBreaker{}
+		s.breakers[url] = breaker
+	}
+	breaker.failures++
+	if breaker.failures >= s.breakerThreshold {
+		breaker.openUntil = time.Now().Add(s.breakerCooldown)
+
+		log := gologger.WithComponent("webhook")
+		log.Warn().
+			Str("url", url).
+			Int("failures", breaker.failures).
+			Time("open_until", breaker.openUntil).
+			Msg("Webhook circuit opened")
+	}
+}
+
+func (s *WebhookService) notifyWebhooks() {
 	log := gologger.WithComponent("webhook")
 
-	tasks, err := s.taskService.ListAvailableTasks(context.Background())
+	select {
+	case <-s.stopCh:
+		return
+	default:
+	}
+
+	ctx := context.Background()
+
+	endpoints, err := s.repo.ListEndpoints(ctx)
 	if err != nil {
-		log.Error().Err(err).
-			Str("webhook_id", webhook.ID).
-			Msg("Failed to list tasks for initial notification")
+		log.Error().Err(err).Msg("Failed to list webhooks for notification")
+		return
+	}
+	if len(endpoints) == 0 {
 		return
 	}
 
+	tasks, err := s.taskService.ListAvailableTasks(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list tasks for webhook notification")
+		return
+	}
 	if len(tasks) == 0 {
 		return
 	}
 
-	payload := WSMessage{
-		Type:    "available_tasks",
-		Payload: tasks,
+	for _, endpoint := range endpoints {
+		message, err := newWebhookMessage(s.protocolVersion(ctx, endpoint.DeviceID), models.RunnerMessageAvailableTasks, tasks)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to build webhook notification")
+			return
+		}
+		if _, err := s.Enqueue(ctx, endpoint.DeviceID, endpoint.URL, models.WebhookEventAvailableTasks, "", message); err != nil {
+			log.Error().Err(err).
+				Str("webhook_id", endpoint.ID.String()).
+				Msg("Failed to enqueue webhook notification")
+		}
 	}
+}
+
+func (s *WebhookService) sendInitialNotification(endpoint *models.WebhookEndpoint) {
+	log := gologger.WithComponent("webhook")
+	ctx := context.Background()
 
-	payloadBytes, err := json.Marshal(payload)
+	tasks, err := s.taskService.ListAvailableTasks(ctx)
 	if err != nil {
 		log.Error().Err(err).
-			Str("webhook_id", webhook.ID).
-			Msg("Failed to marshal initial notification payload")
+			Str("webhook_id", endpoint.ID.String()).
+			Msg("Failed to list tasks for initial notification")
 		return
 	}
 
-	client := &http.Client{
-		Timeout: 5 * time.Second,
-		Transport: &http.Transport{
-			MaxIdleConns:       100,
-			IdleConnTimeout:    90 * time.Second,
-			DisableCompression: true,
-		},
+	if len(tasks) == 0 {
+		return
+	}
+
+	message, err := newWebhookMessage(s.protocolVersion(ctx, endpoint.DeviceID), models.RunnerMessageAvailableTasks, tasks)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to build initial notification")
+		return
 	}
 
-	s.sendWebhookNotification(client, webhook, payloadBytes)
+	if _, err := s.Enqueue(ctx, endpoint.DeviceID, endpoint.URL, models.WebhookEventAvailableTasks, "", message); err != nil {
+		log.Error().Err(err).
+			Str("webhook_id", endpoint.ID.String()).
+			Msg("Failed to enqueue initial notification")
+	}
 }
 
+// CleanupResources stops the delivery worker. Registrations and undelivered
+// messages stay in the database and are picked up again on the next start.
 func (s *WebhookService) CleanupResources() {
-	s.webhookMutex.Lock()
-	defer s.webhookMutex.Unlock()
-	s.webhooks = make(map[string]WebhookRegistration)
+	s.Stop()
 }
@@ -0,0 +1,493 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/parity-server/internal/core/config"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/pkg/webhooksig"
+)
+
+type inMemoryWebhookRepo struct {
+	mu           sync.Mutex
+	endpoints    map[uuid.UUID]*models.WebhookEndpoint
+	deliveries   map[uuid.UUID]*models.WebhookDelivery
+	attempts     []*models.WebhookDeliveryAttempt
+	keys         []*models.WebhookSigningKey
+	largestClaim int
+}
+
+func newInMemoryWebhookRepo() *inMemoryWebhookRepo {
+	return &inMemoryWebhookRepo{
+		endpoints:  make(map[uuid.UUID]*models.WebhookEndpoint),
+		deliveries: make(map[uuid.UUID]*models.WebhookDelivery),
+	}
+}
+
+func (r *inMemoryWebhookRepo) UpsertEndpoint(ctx context.Context, endpoint *models.WebhookEndpoint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.endpoints {
+		if existing.DeviceID == endpoint.DeviceID {
+			endpoint.ID = existing.ID
+			endpoint.CreatedAt = existing.CreatedAt
+		}
+	}
+	stored := *endpoint
+	r.endpoints[endpoint.ID] = &stored
+	return nil
+}
+
+func (r *inMemoryWebhookRepo) GetEndpoint(ctx context.Context, id uuid.UUID) (*models.WebhookEndpoint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	endpoint, ok := r.endpoints[id]
+	if !ok {
+		return nil, errors.New("webhook endpoint not found")
+	}
+	stored := *endpoint
+	return &stored, nil
+}
+
+func (r *inMemoryWebhookRepo) ListEndpoints(ctx context.Context) ([]*models.WebhookEndpoint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	endpoints := make([]*models.WebhookEndpoint, 0, len(r.endpoints))
+	for _, endpoint := range r.endpoints {
+		stored := *endpoint
+		endpoints = append(endpoints, &stored)
+	}
+	return endpoints, nil
+}
+
+func (r *inMemoryWebhookRepo) DeleteEndpoint(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.endpoints, id)
+	return nil
+}
+
+func (r *inMemoryWebhookRepo) DeleteEndpointsByDevice(ctx context.Context, deviceID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, endpoint := range r.endpoints {
+		if endpoint.DeviceID == deviceID {
+			delete(r.endpoints, id)
+		}
+	}
+	return nil
+}
+
+func (r *inMemoryWebhookRepo) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *delivery
+	r.deliveries[delivery.ID] = &stored
+	return nil
+}
+
+func (r *inMemoryWebhookRepo) ClaimDueDeliveries(ctx context.Context, now time.Time, lockFor time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if limit > r.largestClaim {
+		r.largestClaim = limit
+	}
+	var claimed []*models.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if len(claimed) == limit {
+			break
+		}
+		if delivery.Status != models.WebhookDeliveryPending || delivery.NextAttemptAt.After(now) {
+			continue
+		}
+		if delivery.LockedUntil != nil && delivery.LockedUntil.After(now) {
+			continue
+		}
+		lockedUntil := now.Add(lockFor)
+		delivery.LockedUntil = &lockedUntil
+		stored := *delivery
+		claimed = append(claimed, &stored)
+	}
+	return claimed, nil
+}
+
+func (r *inMemoryWebhookRepo) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *delivery
+	r.deliveries[delivery.ID] = &stored
+	return nil
+}
+
+func (r *inMemoryWebhookRepo) CreateAttempt(ctx context.Context, attempt *models.WebhookDeliveryAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *attempt
+	r.attempts = append(r.attempts, &stored)
+	return nil
+}
+
+func (r *inMemoryWebhookRepo) ListDeliveriesByDevice(ctx context.Context, deviceID string, status models.WebhookDeliveryStatus, limit int) ([]*models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deliveries []*models.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if delivery.DeviceID != deviceID || (status != "" && delivery.Status != status) {
+			continue
+		}
+		stored := *delivery
+		for _, attempt := range r.attempts {
+			if attempt.DeliveryID == delivery.ID {
+				stored.AttemptLog = append(stored.AttemptLog, *attempt)
+			}
+		}
+		deliveries = append(deliveries, &stored)
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+	})
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+func (r *inMemoryWebhookRepo) CreateSigningKey(ctx context.Context, key *models.WebhookSigningKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *key
+	r.keys = append(r.keys, &stored)
+	return nil
+}
+
+func (r *inMemoryWebhookRepo) ListActiveSigningKeys(ctx context.Context, deviceID string, now time.Time) ([]*models.WebhookSigningKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var keys []*models.WebhookSigningKey
+	for i := len(r.keys) - 1; i >= 0; i-- {
+		key := r.keys[i]
+		if key.DeviceID != deviceID || (key.ExpiresAt != nil && !key.ExpiresAt.After(now)) {
+			continue
+		}
+		stored := *key
+		keys = append(keys, &stored)
+	}
+	return keys, nil
+}
+
+func (r *inMemoryWebhookRepo) ExpireSigningKeys(ctx context.Context, deviceID string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, key := range r.keys {
+		if key.DeviceID == deviceID && (key.ExpiresAt == nil || key.ExpiresAt.After(expiresAt)) {
+			expiry := expiresAt
+			key.ExpiresAt = &expiry
+		}
+	}
+	return nil
+}
+
+// drainDueDeliveries runs the worker until nothing is left pending, sleeping past
+// each retry delay.
+func drainDueDeliveries(t *testing.T, service *WebhookService, repo *inMemoryWebhookRepo) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		service.processDueDeliveries(context.Background())
+
+		pending := 0
+		repo.mu.Lock()
+		for _, delivery := range repo.deliveries {
+			if delivery.Status == models.WebhookDeliveryPending {
+				pending++
+			}
+		}
+		repo.mu.Unlock()
+
+		if pending == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d deliveries still pending", pending)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWebhookServiceDeliversOutboxMessages(t *testing.T) {
+	ctx := context.Background()
+
+	var received atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Device-ID") != "runner-1" || r.Header.Get("X-Webhook-Delivery-ID") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received.Add(1)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	repo := newInMemoryWebhookRepo()
+	service := NewWebhookService(repo, nil)
+
+	delivery, err := service.Enqueue(ctx, "runner-1", server.URL, models.WebhookEventTaskOffer, "task-1", map[string]string{"type": "available_tasks"})
+	if err != nil {
+		t.Fatalf("Enqueue returned error: %v", err)
+	}
+
+	drainDueDeliveries(t, service, repo)
+
+	if received.Load() != 1 {
+		t.Fatalf("expected one webhook to be received, got %d", received.Load())
+	}
+
+	deliveries, err := service.ListDeliveries(ctx, "runner-1", "", 10)
+	if err != nil {
+		t.Fatalf("ListDeliveries returned error: %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].ID != delivery.ID {
+		t.Fatalf("expected the queued delivery to be listed, got %v", deliveries)
+	}
+	got := deliveries[0]
+	if got.Status != models.WebhookDeliveryDelivered || got.DeliveredAt == nil || got.Attempts != 1 {
+		t.Fatalf("expected a delivered message after one attempt, got status %s attempts %d", got.Status, got.Attempts)
+	}
+	if len(got.AttemptLog) != 1 || got.AttemptLog[0].StatusCode != http.StatusAccepted {
+		t.Fatalf("expected one recorded 202 attempt, got %v", got.AttemptLog)
+	}
+}
+
+func TestWebhookServiceClaimsNoMoreThanItSendsAtOnce(t *testing.T) {
+	ctx := context.Background()
+
+	var received atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	repo := newInMemoryWebhookRepo()
+	service := NewWebhookService(repo, nil)
+
+	const queued = 2*webhookConcurrency + 5
+	for i := 0; i < queued; i++ {
+		if _, err := service.Enqueue(ctx, "runner-1", server.URL, models.WebhookEventTaskOffer, fmt.Sprintf("task-%d", i), map[string]string{"type": "available_tasks"}); err != nil {
+			t.Fatalf("Enqueue returned error: %v", err)
+		}
+	}
+
+	service.processDueDeliveries(ctx)
+
+	if received.Load() != queued {
+		t.Fatalf("expected all %d deliveries in one pass, got %d", queued, received.Load())
+	}
+	if repo.largestClaim > webhookConcurrency {
+		t.Fatalf("expected at most %d deliveries per lease, claimed %d", webhookConcurrency, repo.largestClaim)
+	}
+}
+
+func TestWebhookServiceRetriesThenDeadLetters(t *testing.T) {
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	repo := newInMemoryWebhookRepo()
+	service := NewWebhookService(repo, nil)
+	service.SetDeliveryConfig(config.WebhookConfig{MaxAttempts: 3, BreakerThreshold: 10})
+	service.initialBackoff = time.Millisecond
+	service.maxBackoff = 2 * time.Millisecond
+
+	var deadLettered []*models.WebhookDelivery
+	service.OnDeadLetter(func(ctx context.Context, delivery *models.WebhookDelivery) {
+		deadLettered = append(deadLettered, delivery)
+	})
+
+	if _, err := service.Enqueue(ctx, "runner-1", server.URL, models.WebhookEventPromptForward, "task-1", map[string]string{}); err != nil {
+		t.Fatalf("Enqueue returned error: %v", err)
+	}
+
+	drainDueDeliveries(t, service, repo)
+
+	if calls.Load() != 3 {
+		t.Fatalf("expected 3 attempts before dead-lettering, got %d", calls.Load())
+	}
+	if len(deadLettered) != 1 || deadLettered[0].TaskID != "task-1" {
+		t.Fatalf("expected the dead-letter handler to see task-1, got %v", deadLettered)
+	}
+
+	dead, _ := service.ListDeliveries(ctx, "runner-1", models.WebhookDeliveryDead, 10)
+	if len(dead) != 1 || len(dead[0].AttemptLog) != 3 || dead[0].LastStatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected one dead delivery with 3 recorded attempts, got %v", dead)
+	}
+}
+
+func TestWebhookServiceCircuitBreakerDefersDeliveries(t *testing.T) {
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	repo := newInMemoryWebhookRepo()
+	service := NewWebhookService(repo, nil)
+	service.SetDeliveryConfig(config.WebhookConfig{MaxAttempts: 10, BreakerThreshold: 2, BreakerCooldown: 3600})
+	service.initialBackoff = time.Millisecond
+	service.maxBackoff = time.Millisecond
+
+	for i := 0; i < 2; i++ {
+		if _, err := service.Enqueue(ctx, "runner-1", server.URL, models.WebhookEventAvailableTasks, "", map[string]string{}); err != nil {
+			t.Fatalf("Enqueue returned error: %v", err)
+		}
+		service.processDueDeliveries(ctx)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected 2 failed attempts to open the circuit, got %d", calls.Load())
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	service.processDueDeliveries(ctx)
+
+	if calls.Load() != 2 {
+		t.Fatalf("expected the open circuit to hold back deliveries, got %d calls", calls.Load())
+	}
+
+	pending, _ := service.ListDeliveries(ctx, "runner-1", models.WebhookDeliveryPending, 10)
+	if len(pending) != 2 {
+		t.Fatalf("expected both deliveries to stay pending, got %d", len(pending))
+	}
+	for _, delivery := range pending {
+		if delivery.Attempts != 1 || time.Until(delivery.NextAttemptAt) < 30*time.Minute {
+			t.Fatalf("expected deferral to the end of the cooldown without spending an attempt, got attempts %d next %v", delivery.Attempts, delivery.NextAttemptAt)
+		}
+	}
+}
+
+func TestWebhookServicePersistsRegistrations(t *testing.T) {
+	ctx := context.Background()
+
+	repo := newInMemoryWebhookRepo()
+	service := NewWebhookService(repo, NewTaskService(newInMemoryTaskRepo(), nil, NewRunnerService(newInMemoryRunnerRepo())))
+
+	first, err := service.RegisterWebhook(ctx, RegisterWebhookRequest{URL: "http://runner.invalid/a"}, "runner-1")
+	if err != nil {
+		t.Fatalf("RegisterWebhook returned error: %v", err)
+	}
+	second, err := service.RegisterWebhook(ctx, RegisterWebhookRequest{URL: "http://runner.invalid/b"}, "runner-1")
+	if err != nil {
+		t.Fatalf("RegisterWebhook returned error: %v", err)
+	}
+	if first != second {
+		t.Fatalf("expected re-registration to keep webhook %s, got %s", first, second)
+	}
+
+	// A fresh service over the same store sees the registration.
+	restarted := NewWebhookService(repo, nil)
+	endpoints, _ := restarted.repo.ListEndpoints(ctx)
+	if len(endpoints) != 1 || endpoints[0].URL != "http://runner.invalid/b" {
+		t.Fatalf("expected the updated registration to persist, got %v", endpoints)
+	}
+
+	if err := restarted.UnregisterWebhook(ctx, first); err != nil {
+		t.Fatalf("UnregisterWebhook returned error: %v", err)
+	}
+	if endpoints, _ := repo.ListEndpoints(ctx); len(endpoints) != 0 {
+		t.Fatalf("expected registration to be removed, got %v", endpoints)
+	}
+}
+
+func TestWebhookServiceSignsDeliveriesAcrossRotation(t *testing.T) {
+	ctx := context.Background()
+
+	repo := newInMemoryWebhookRepo()
+	signer := NewWebhookSigner(repo)
+	service := NewWebhookService(repo, nil)
+	service.SetWebhookSigner(signer)
+
+	oldSecret, issued, err := service.SigningSecret(ctx, "runner-1")
+	if err != nil {
+		t.Fatalf("SigningSecret returned error: %v", err)
+	}
+	if !issued {
+		t.Fatal("expected the first call to issue a secret")
+	}
+	if again, issued, _ := service.SigningSecret(ctx, "runner-1"); again != oldSecret || issued {
+		t.Fatal("expected the issued secret to be stable until rotated")
+	}
+
+	var (
+		mu       sync.Mutex
+		accepted []string
+	)
+	verifyWith := func(secret string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if err := webhooksig.VerifyRequest(r, []string{secret}); err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			mu.Lock()
+			accepted = append(accepted, secret)
+			mu.Unlock()
+			w.WriteHeader(http.StatusOK)
+		}
+	}
+
+	oldRunner := httptest.NewServer(verifyWith(oldSecret))
+	defer oldRunner.Close()
+
+	rotation, err := service.RotateSigningSecret(ctx, "runner-1", time.Hour)
+	if err != nil {
+		t.Fatalf("RotateSigningSecret returned error: %v", err)
+	}
+	if rotation.Secret == oldSecret || rotation.PreviousExpiresAt == nil {
+		t.Fatalf("expected a new secret with an overlap window, got %+v", rotation)
+	}
+
+	newRunner := httptest.NewServer(verifyWith(rotation.Secret))
+	defer newRunner.Close()
+
+	for _, url := range []string{oldRunner.URL, newRunner.URL} {
+		if _, err := service.Enqueue(ctx, "runner-1", url, models.WebhookEventTaskOffer, "", map[string]string{"type": "available_tasks"}); err != nil {
+			t.Fatalf("Enqueue returned error: %v", err)
+		}
+	}
+	drainDueDeliveries(t, service, repo)
+
+	if len(accepted) != 2 {
+		t.Fatalf("expected runners holding either secret to verify during the overlap, got %v", accepted)
+	}
+
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"github.com/theblitlabs/parity-server/internal/core/ports"
+	"github.com/theblitlabs/parity-server/pkg/webhooksig"
+)
+
+const (
+	defaultWebhookSecretOverlap = 24 * time.Hour
+	webhookSecretPrefix         = "whsec_"
+)
+
+// WebhookSigner issues per-runner webhook secrets and signs outbound webhooks
+// with every secret the runner currently holds, using pkg/webhooksig.
+type WebhookSigner struct {
+	repo    ports.WebhookRepository
+	overlap time.Duration
+}
+
+func NewWebhookSigner(repo ports.WebhookRepository) *WebhookSigner {
+	return &WebhookSigner{
+		repo:    repo,
+		overlap: defaultWebhookSecretOverlap,
+	}
+}
+
+func (s *WebhookSigner) SetOverlap(overlap time.Duration) {
+	if overlap > 0 {
+		s.overlap = overlap
+	}
+}
+
+// EnsureSecret returns the runner's newest secret, issuing one if it has none.
+// issued reports whether the secret was created by this call.
+func (s *WebhookSigner) EnsureSecret(ctx context.Context, deviceID string) (secret string, issued bool, err error) {
+	keys, err := s.repo.ListActiveSigningKeys(ctx, deviceID, time.Now())
+	if err != nil {
+		return "", false, fmt.Errorf("failed to load webhook secrets: %w", err)
+	}
+	for _, key := range keys {
+		if key.ExpiresAt == nil {
+			return key.Secret, false, nil
+		}
+	}
+
+	key, err := s.issue(ctx, deviceID)
+	if err != nil {
+		return "", false, err
+	}
+	return key.Secret, true, nil
+}
+
+// RotateSecret issues a new secret and keeps the old ones signing for overlap
+// (or the configured default when overlap is zero).
+func (s *WebhookSigner) RotateSecret(ctx context.Context, deviceID string, overlap time.Duration) (*models.WebhookSecretRotation, error) {
+	if overlap <= 0 {
+		overlap = s.overlap
+	}
+
+	previousExpiresAt := time.Now().Add(overlap)
+	if err := s.repo.ExpireSigningKeys(ctx, deviceID, previousExpiresAt); err != nil {
+		return nil, fmt.Errorf("failed to expire webhook secrets: %w", err)
+	}
+
+	key, err := s.issue(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	log := gologger.WithComponent("webhook_signer")
+	log.Info().
+		Str("device_id", deviceID).
+		Time("previous_expires_at", previousExpiresAt).
+		Msg("Webhook secret rotated")
+
+	return &models.WebhookSecretRotation{
+		Secret:            key.Secret,
+		PreviousExpiresAt: &previousExpiresAt,
+	}, nil
+}
+
+// SignRequest adds the signature header for body to req. A runner that has never
+// been issued a secret gets one here so its webhooks are never sent unsigned.
+func (s *WebhookSigner) SignRequest(ctx context.Context, req *http.Request, deviceID string, body []byte) error {
+	keys, err := s.repo.ListActiveSigningKeys(ctx, deviceID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to load webhook secrets: %w", err)
+	}
+
+	secrets := make([]string, 0, len(keys))
+	for _, key := range keys {
+		secrets = append(secrets, key.Secret)
+	}
+	if len(secrets) == 0 {
+		secret, _, err := s.EnsureSecret(ctx, deviceID)
+		if err != nil {
+			return err
+		}
+		secrets = append(secrets, secret)
+	}
+
+	webhooksig.SignRequest(req, secrets, body)
+	return nil
+}
+
+func (s *WebhookSigner) issue(ctx context.Context, deviceID string) (*models.WebhookSigningKey, error) {
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	key := &models.WebhookSigningKey{
+		ID:        uuid.New(),
+		DeviceID:  deviceID,
+		Secret:    webhookSecretPrefix + token,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.CreateSigningKey(ctx, key); err != nil {
+		return nil, fmt.Errorf("failed to save webhook secret: %w", err)
+	}
+	return key, nil
+}
@@ -43,6 +43,7 @@ func Connect(ctx context.Context, dbURL string) (*gorm.DB, error) {
 		&models.Task{},
 		&models.TaskResult{},
 		&models.Runner{},
+		&models.RunnerAssignment{},
 		&models.RunnerReputation{},
 		&models.ReputationEvent{},
 		&models.PromptRequest{},
@@ -57,6 +58,30 @@ func Connect(ctx context.Context, dbURL string) (*gorm.DB, error) {
 		&models.QualityAlert{},
 		&models.QualitySLA{},
 		&models.QualityReport{},
+		&models.RunnerAuthChallenge{},
+		&models.RunnerSession{},
+		&models.Account{},
+		&models.AccountAuthChallenge{},
+		&models.AccountSession{},
+		&models.APIKey{},
+		&models.WebhookEndpoint{},
+		&models.WebhookDelivery{},
+		&models.WebhookDeliveryAttempt{},
+		&models.WebhookSigningKey{},
+		&models.RunnerTelemetrySample{},
+		&models.StoredImage{},
+		&models.ImageReference{},
+		&models.RetentionPolicy{},
+		&models.TaskArtifact{},
+		&models.ArtifactUpload{},
+		&models.TaskLogChunk{},
+		&models.TaskLogUsage{},
+		&models.VerifiedDataset{},
+		&models.RunnerCache{},
+		&models.CreatorWebhook{},
+		&models.CreatorWebhookDelivery{},
+		&models.CreatorWebhookAttempt{},
+		&models.ClientEvent{},
 	}
 
 	for _, model := range modelsList {
@@ -85,6 +110,34 @@ func Connect(ctx context.Context, dbURL string) (*gorm.DB, error) {
 		}
 	}
 
+	if err := migrateRunnerTaskIDs(db); err != nil {
+		return nil, err
+	}
+
 	log.Info().Msg("Database connected successfully")
 	return db, nil
 }
+
+// migrateRunnerTaskIDs moves the single task each runner used to track in
+// runners.task_id into runner_assignments and drops the old column.
+func migrateRunnerTaskIDs(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&models.Runner{}, "task_id") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`
+			INSERT INTO runner_assignments (id, device_id, work_id, kind, created_at)
+			SELECT gen_random_uuid(), device_id, task_id, ?, NOW()
+			FROM runners
+			WHERE task_id IS NOT NULL
+			ON CONFLICT DO NOTHING`, models.AssignmentKindTask).Error; err != nil {
+			return fmt.Errorf("error migrating runner task assignments: %w", err)
+		}
+
+		if err := tx.Migrator().DropColumn(&models.Runner{}, "task_id"); err != nil {
+			return fmt.Errorf("error dropping runners.task_id: %w", err)
+		}
+		return nil
+	})
+}
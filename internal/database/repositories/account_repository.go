@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAccountNotFound        = errors.New("account not found")
+	ErrAccountSessionNotFound = errors.New("account session not found")
+	ErrAPIKeyNotFound         = errors.New("api key not found")
+)
+
+type AccountRepository struct {
+	db *gorm.DB
+}
+
+func NewAccountRepository(db *gorm.DB) *AccountRepository {
+	return &AccountRepository{db: db}
+}
+
+func (r *AccountRepository) CreateChallenge(ctx context.Context, challenge *models.AccountAuthChallenge) error {
+	return r.db.WithContext(ctx).Create(challenge).Error
+}
+
+func (r *AccountRepository) GetChallenge(ctx context.Context, id uuid.UUID) (*models.AccountAuthChallenge, error) {
+	var challenge models.AccountAuthChallenge
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&challenge).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAuthChallengeNotFound
+		}
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+func (r *AccountRepository) MarkChallengeUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.AccountAuthChallenge{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *AccountRepository) GetAccount(ctx context.Context, id uuid.UUID) (*models.Account, error) {
+	var account models.Account
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&account).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAccountNotFound
+		}
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *AccountRepository) GetAccountByWallet(ctx context.Context, walletAddress string) (*models.Account, error) {
+	var account models.Account
+	if err := r.db.WithContext(ctx).Where("LOWER(wallet_address) = LOWER(?)", walletAddress).First(&account).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAccountNotFound
+		}
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *AccountRepository) CreateAccount(ctx context.Context, account *models.Account) error {
+	return r.db.WithContext(ctx).Create(account).Error
+}
+
+func (r *AccountRepository) UpdateAccount(ctx context.Context, account *models.Account) error {
+	return r.db.WithContext(ctx).Save(account).Error
+}
+
+func (r *AccountRepository) CreateSession(ctx context.Context, session *models.AccountSession) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+func (r *AccountRepository) GetSessionByTokenHash(ctx context.Context, tokenHash string) (*models.AccountSession, error) {
+	var session models.AccountSession
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAccountSessionNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *AccountRepository) UpdateSession(ctx context.Context, session *models.AccountSession) error {
+	return r.db.WithContext(ctx).Save(session).Error
+}
+
+func (r *AccountRepository) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *AccountRepository) GetAPIKey(ctx context.Context, id uuid.UUID) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *AccountRepository) GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.db.WithContext(ctx).Where("key_hash = ?", keyHash).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *AccountRepository) ListAPIKeys(ctx context.Context, accountID uuid.UUID) ([]*models.APIKey, error) {
+	var keys []*models.APIKey
+	err := r.db.WithContext(ctx).
+		Where("account_id = ?", accountID).
+		Order("created_at DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+func (r *AccountRepository) UpdateAPIKey(ctx context.Context, key *models.APIKey) error {
+	return r.db.WithContext(ctx).Save(key).Error
+}
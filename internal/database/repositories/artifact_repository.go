@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ArtifactRepository struct {
+	db *gorm.DB
+}
+
+func NewArtifactRepository(db *gorm.DB) *ArtifactRepository {
+	return &ArtifactRepository{db: db}
+}
+
+func (r *ArtifactRepository) CreateUpload(ctx context.Context, upload *models.ArtifactUpload) error {
+	return r.db.WithContext(ctx).Create(upload).Error
+}
+
+func (r *ArtifactRepository) GetUpload(ctx context.Context, id uuid.UUID) (*models.ArtifactUpload, error) {
+	var upload models.ArtifactUpload
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&upload).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &upload, nil
+}
+
+func (r *ArtifactRepository) FindUpload(ctx context.Context, taskID uuid.UUID, name string) (*models.ArtifactUpload, error) {
+	var upload models.ArtifactUpload
+	err := r.db.WithContext(ctx).
+		Where("task_id = ? AND name = ?", taskID, name).
+		Order("created_at DESC").
+		First(&upload).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &upload, nil
+}
+
+func (r *ArtifactRepository) TouchUpload(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&models.ArtifactUpload{}).
+		Where("id = ?", id).
+		Update("updated_at", at).Error
+}
+
+func (r *ArtifactRepository) DeleteUpload(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&models.ArtifactUpload{}).Error
+}
+
+func (r *ArtifactRepository) ListStaleUploads(ctx context.Context, updatedBefore time.Time) ([]*models.ArtifactUpload, error) {
+	var uploads []*models.ArtifactUpload
+	err := r.db.WithContext(ctx).
+		Where("updated_at < ?", updatedBefore).
+		Order("updated_at ASC").
+		Find(&uploads).Error
+	return uploads, err
+}
+
+// SaveArtifact stores artifact, replacing an earlier upload with the same
+// name for the same task.
+func (r *ArtifactRepository) SaveArtifact(ctx context.Context, artifact *models.TaskArtifact) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(artifact).Error
+}
+
+func (r *ArtifactRepository) ListArtifacts(ctx context.Context, taskID uuid.UUID) ([]models.TaskArtifact, error) {
+	var artifacts []models.TaskArtifact
+	err := r.db.WithContext(ctx).Where("task_id = ?", taskID).Order("name ASC").Find(&artifacts).Error
+	return artifacts, err
+}
@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"gorm.io/gorm"
+)
+
+type ClientEventRepository struct {
+	db *gorm.DB
+}
+
+func NewClientEventRepository(db *gorm.DB) *ClientEventRepository {
+	return &ClientEventRepository{db: db}
+}
+
+func (r *ClientEventRepository) Append(ctx context.Context, event *models.ClientEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+func (r *ClientEventRepository) ListAfter(ctx context.Context, afterID int64, limit int) ([]models.ClientEvent, error) {
+	var events []models.ClientEvent
+	err := r.db.WithContext(ctx).
+		Where("id > ?", afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+func (r *ClientEventRepository) LastID(ctx context.Context) (int64, error) {
+	var id int64
+	err := r.db.WithContext(ctx).
+		Model(&models.ClientEvent{}).
+		Select("COALESCE(MAX(id), 0)").
+		Scan(&id).Error
+	return id, err
+}
+
+func (r *ClientEventRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("occurred_at < ?", before).
+		Delete(&models.ClientEvent{})
+	return result.RowsAffected, result.Error
+}
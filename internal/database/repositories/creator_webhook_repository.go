@@ -0,0 +1,158 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrCreatorWebhookNotFound = errors.New("creator webhook not found")
+
+type CreatorWebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewCreatorWebhookRepository(db *gorm.DB) *CreatorWebhookRepository {
+	return &CreatorWebhookRepository{db: db}
+}
+
+func (r *CreatorWebhookRepository) CreateWebhook(ctx context.Context, webhook *models.CreatorWebhook) error {
+	return r.db.WithContext(ctx).Create(webhook).Error
+}
+
+func (r *CreatorWebhookRepository) GetWebhook(ctx context.Context, id uuid.UUID) (*models.CreatorWebhook, error) {
+	var webhook models.CreatorWebhook
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&webhook).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCreatorWebhookNotFound
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// ListWebhooks returns the creator's webhooks, or every creator's when
+// creatorAddress is empty.
+func (r *CreatorWebhookRepository) ListWebhooks(ctx context.Context, creatorAddress string) ([]*models.CreatorWebhook, error) {
+	query := r.db.WithContext(ctx)
+	if creatorAddress != "" {
+		query = query.Where("LOWER(creator_address) = LOWER(?)", creatorAddress)
+	}
+
+	var webhooks []*models.CreatorWebhook
+	if err := query.Order("created_at ASC").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// ListWebhooksForTask returns the creator's account-wide webhooks and those
+// registered for taskID.
+func (r *CreatorWebhookRepository) ListWebhooksForTask(ctx context.Context, creatorAddress string, taskID uuid.UUID) ([]*models.CreatorWebhook, error) {
+	var webhooks []*models.CreatorWebhook
+	if err := r.db.WithContext(ctx).
+		Where("LOWER(creator_address) = LOWER(?)", creatorAddress).
+		Where("task_id IS NULL OR task_id = ?", taskID).
+		Order("created_at ASC").
+		Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *CreatorWebhookRepository) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Where("id = ?", id).Delete(&models.CreatorWebhook{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCreatorWebhookNotFound
+	}
+	return nil
+}
+
+func (r *CreatorWebhookRepository) CreateDelivery(ctx context.Context, delivery *models.CreatorWebhookDelivery) error {
+	return r.db.WithContext(ctx).Omit("AttemptLog").Create(delivery).Error
+}
+
+// ClaimDueDeliveries locks a batch of pending deliveries that are due, so that
+// several server instances can drain the outbox without sending a message twice.
+func (r *CreatorWebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lockFor time.Duration, limit int) ([]*models.CreatorWebhookDelivery, error) {
+	var deliveries []*models.CreatorWebhookDelivery
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+			Where("locked_until IS NULL OR locked_until < ?", now).
+			Order("next_attempt_at ASC").
+			Limit(limit).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, len(deliveries))
+		lockedUntil := now.Add(lockFor)
+		for i, delivery := range deliveries {
+			ids[i] = delivery.ID
+			delivery.LockedUntil = &lockedUntil
+		}
+
+		return tx.Model(&models.CreatorWebhookDelivery{}).
+			Where("id IN ?", ids).
+			Update("locked_until", lockedUntil).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (r *CreatorWebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.CreatorWebhookDelivery) error {
+	return r.db.WithContext(ctx).Model(delivery).Updates(map[string]interface{}{
+		"status":           delivery.Status,
+		"attempts":         delivery.Attempts,
+		"next_attempt_at":  delivery.NextAttemptAt,
+		"locked_until":     delivery.LockedUntil,
+		"last_status_code": delivery.LastStatusCode,
+		"last_error":       delivery.LastError,
+		"delivered_at":     delivery.DeliveredAt,
+		"updated_at":       delivery.UpdatedAt,
+	}).Error
+}
+
+func (r *CreatorWebhookRepository) CreateAttempt(ctx context.Context, attempt *models.CreatorWebhookAttempt) error {
+	return r.db.WithContext(ctx).Create(attempt).Error
+}
+
+// ListDeliveries returns the creator's most recent deliveries with their
+// attempts, optionally for one webhook and in one status.
+func (r *CreatorWebhookRepository) ListDeliveries(ctx context.Context, creatorAddress string, webhookID *uuid.UUID, status models.WebhookDeliveryStatus, limit int) ([]*models.CreatorWebhookDelivery, error) {
+	query := r.db.WithContext(ctx).
+		Preload("AttemptLog", func(db *gorm.DB) *gorm.DB {
+			return db.Order("attempt ASC")
+		})
+	if creatorAddress != "" {
+		query = query.Where("LOWER(creator_address) = LOWER(?)", creatorAddress)
+	}
+	if webhookID != nil {
+		query = query.Where("webhook_id = ?", *webhookID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var deliveries []*models.CreatorWebhookDelivery
+	if err := query.Order("created_at DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
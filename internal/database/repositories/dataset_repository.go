@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DatasetRepository struct {
+	db *gorm.DB
+}
+
+func NewDatasetRepository(db *gorm.DB) *DatasetRepository {
+	return &DatasetRepository{db: db}
+}
+
+func (r *DatasetRepository) GetVerified(ctx context.Context, backend, contentID string) (*models.VerifiedDataset, error) {
+	var dataset models.VerifiedDataset
+	err := r.db.WithContext(ctx).
+		Where("backend = ? AND content_id = ?", backend, contentID).
+		First(&dataset).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &dataset, nil
+}
+
+func (r *DatasetRepository) SaveVerified(ctx context.Context, dataset *models.VerifiedDataset) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(dataset).Error
+}
@@ -0,0 +1,199 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"gorm.io/gorm"
+)
+
+var ErrStoredImageNotFound = errors.New("stored image not found")
+
+// imageOwnerCondition matches images the owner uploaded or has used in a task.
+const imageOwnerCondition = `(LOWER(uploaded_by) = LOWER(?) OR EXISTS (
+	SELECT 1 FROM image_references
+	WHERE image_references.image_digest = stored_images.digest
+	AND LOWER(image_references.creator_address) = LOWER(?)))`
+
+type ImageRepository struct {
+	db *gorm.DB
+}
+
+func NewImageRepository(db *gorm.DB) *ImageRepository {
+	return &ImageRepository{db: db}
+}
+
+// GetImage returns the stored image with digest, or nil if there is none.
+func (r *ImageRepository) GetImage(ctx context.Context, digest string) (*models.StoredImage, error) {
+	var image models.StoredImage
+	err := r.db.WithContext(ctx).Where("digest = ?", digest).First(&image).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+// CreateImage stores image unless its digest is already stored, and returns
+// whichever row ends up in the table. Two uploads of the same image racing
+// each other both get the first one's row.
+func (r *ImageRepository) CreateImage(ctx context.Context, image *models.StoredImage) (*models.StoredImage, error) {
+	if image.CreatedAt.IsZero() {
+		image.CreatedAt = time.Now()
+	}
+
+	err := r.db.WithContext(ctx).Exec(`
+		INSERT INTO stored_images (digest, url, name, size, metadata, uploaded_by, ref_count, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, 0, ?)
+		ON CONFLICT (digest) DO NOTHING`,
+		image.Digest, image.URL, image.Name, image.Size, image.Metadata, image.UploadedBy, image.CreatedAt,
+	).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetImage(ctx, image.Digest)
+}
+
+// ReplaceImageArchive points an existing image at a new archive and metadata,
+// keeping its owner and references.
+func (r *ImageRepository) ReplaceImageArchive(ctx context.Context, image *models.StoredImage) (*models.StoredImage, error) {
+	result := r.db.WithContext(ctx).Model(&models.StoredImage{}).
+		Where("digest = ?", image.Digest).
+		Updates(map[string]interface{}{
+			"url":      image.URL,
+			"size":     image.Size,
+			"metadata": image.Metadata,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrStoredImageNotFound
+	}
+	return r.GetImage(ctx, image.Digest)
+}
+
+// RunnerCanDownload reports whether key is the archive of an image used by a
+// task the runner holds, or the global model of an FL session it trains in.
+// No other stored object is served to runners. Image URLs end in their object
+// key, so the key is compared against the URL's suffix rather than used as a
+// pattern.
+func (r *ImageRepository) RunnerCanDownload(ctx context.Context, deviceID, key string) (bool, error) {
+	var allowed bool
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT EXISTS (
+			SELECT 1 FROM stored_images
+			JOIN image_references ON image_references.image_digest = stored_images.digest
+			JOIN tasks ON tasks.id = image_references.task_id
+			WHERE RIGHT(stored_images.url, LENGTH(?)) = ?
+			AND tasks.runner_id = ?
+			AND tasks.status IN ?
+		) OR EXISTS (
+			SELECT 1 FROM federated_learning_sessions
+			JOIN federated_learning_rounds ON federated_learning_rounds.session_id = federated_learning_sessions.id
+			JOIN fl_round_participants ON fl_round_participants.round_id = federated_learning_rounds.id
+			WHERE federated_learning_sessions.global_model_key = ?
+			AND fl_round_participants.runner_id = ?
+		)`,
+		"/"+key, "/"+key, deviceID, []models.TaskStatus{models.TaskStatusPending, models.TaskStatusRunning},
+		key, deviceID,
+	).Scan(&allowed).Error
+	return allowed, err
+}
+
+// ListImages returns the images owner uploaded or has referenced from a task,
+// newest first. An empty owner lists every image.
+func (r *ImageRepository) ListImages(ctx context.Context, owner string) ([]*models.StoredImage, error) {
+	query := r.db.WithContext(ctx).Model(&models.StoredImage{})
+	if owner != "" {
+		query = query.Where(imageOwnerCondition, owner, owner)
+	}
+
+	var images []*models.StoredImage
+	err := query.Order("created_at DESC").Find(&images).Error
+	return images, err
+}
+
+// HasAccess reports whether owner uploaded the image or has referenced it
+// before, which is what lets them create tasks from its digest alone.
+func (r *ImageRepository) HasAccess(ctx context.Context, digest, owner string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.StoredImage{}).
+		Where("digest = ?", digest).
+		Where(imageOwnerCondition, owner, owner).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// AddReference records that a task uses an image and bumps its reference
+// count. Adding the same reference twice counts once. It fails with
+// ErrStoredImageNotFound if the image is not stored.
+func (r *ImageRepository) AddReference(ctx context.Context, reference *models.ImageReference) error {
+	if reference.CreatedAt.IsZero() {
+		reference.CreatedAt = time.Now()
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		insert := tx.Exec(`
+			INSERT INTO image_references (image_digest, task_id, creator_address, created_at)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT DO NOTHING`,
+			reference.ImageDigest, reference.TaskID, reference.CreatorAddress, reference.CreatedAt,
+		)
+		if insert.Error != nil {
+			return insert.Error
+		}
+		if insert.RowsAffected == 0 {
+			return nil
+		}
+
+		// The image may have been collected since the caller looked it up.
+		update := tx.Model(&models.StoredImage{}).
+			Where("digest = ?", reference.ImageDigest).
+			Updates(map[string]interface{}{
+				"ref_count":          gorm.Expr("ref_count + 1"),
+				"last_referenced_at": reference.CreatedAt,
+			})
+		if update.Error != nil {
+			return update.Error
+		}
+		if update.RowsAffected == 0 {
+			return ErrStoredImageNotFound
+		}
+		return nil
+	})
+}
+
+// ReleaseReferences drops every image reference held by a task and lowers the
+// images' reference counts to match.
+func (r *ImageRepository) ReleaseReferences(ctx context.Context, taskID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var references []models.ImageReference
+		if err := tx.Where("task_id = ?", taskID).Find(&references).Error; err != nil {
+			return err
+		}
+
+		for _, reference := range references {
+			deleted := tx.Where("image_digest = ? AND task_id = ?", reference.ImageDigest, taskID).
+				Delete(&models.ImageReference{})
+			if deleted.Error != nil {
+				return deleted.Error
+			}
+			if deleted.RowsAffected == 0 {
+				continue
+			}
+			if err := tx.Model(&models.StoredImage{}).
+				Where("digest = ? AND ref_count > 0", reference.ImageDigest).
+				Update("ref_count", gorm.Expr("ref_count - 1")).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
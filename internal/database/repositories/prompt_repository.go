@@ -2,10 +2,12 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/theblitlabs/parity-server/internal/core/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PromptRepository struct {
@@ -56,3 +58,49 @@ func (r *PromptRepository) GetPendingPrompts(ctx context.Context) ([]*models.Pro
 		Find(&prompts).Error
 	return prompts, err
 }
+
+// LeaseQueued locks a batch of queued prompts that are due and hides them from
+// other servers for visibility. A server that dies holding a lease loses it
+// when visibility runs out, and the prompts are leased again.
+func (r *PromptRepository) LeaseQueued(ctx context.Context, now time.Time, visibility time.Duration, limit int) ([]*models.PromptRequest, error) {
+	var prompts []*models.PromptRequest
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND available_at <= ?", models.PromptStatusQueued, now).
+			Where("locked_until IS NULL OR locked_until < ?", now).
+			Order("available_at ASC").
+			Limit(limit).
+			Find(&prompts).Error; err != nil {
+			return err
+		}
+		if len(prompts) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, len(prompts))
+		lockedUntil := now.Add(visibility)
+		for i, prompt := range prompts {
+			ids[i] = prompt.ID
+			prompt.LockedUntil = &lockedUntil
+		}
+
+		return tx.Model(&models.PromptRequest{}).
+			Where("id IN ?", ids).
+			Update("locked_until", lockedUntil).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return prompts, nil
+}
+
+func (r *PromptRepository) CountQueued(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.PromptRequest{}).
+		Where("status = ?", models.PromptStatusQueued).
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,182 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// imageInUseCondition matches stored images referenced by a task that has not
+// finished yet.
+const imageInUseCondition = `EXISTS (
+	SELECT 1 FROM image_references
+	JOIN tasks ON tasks.id = image_references.task_id
+	WHERE image_references.image_digest = stored_images.digest
+	AND tasks.status IN ('pending', 'running'))`
+
+type RetentionRepository struct {
+	db *gorm.DB
+}
+
+func NewRetentionRepository(db *gorm.DB) *RetentionRepository {
+	return &RetentionRepository{db: db}
+}
+
+func (r *RetentionRepository) ListPolicies(ctx context.Context) ([]*models.RetentionPolicy, error) {
+	var policies []*models.RetentionPolicy
+	err := r.db.WithContext(ctx).Order("creator_address ASC").Find(&policies).Error
+	return policies, err
+}
+
+func (r *RetentionRepository) SavePolicy(ctx context.Context, policy *models.RetentionPolicy) error {
+	policy.CreatorAddress = strings.ToLower(policy.CreatorAddress)
+	policy.UpdatedAt = time.Now()
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(policy).Error
+}
+
+func (r *RetentionRepository) DeletePolicy(ctx context.Context, creatorAddress string) error {
+	return r.db.WithContext(ctx).
+		Where("creator_address = ?", strings.ToLower(creatorAddress)).
+		Delete(&models.RetentionPolicy{}).Error
+}
+
+// ListIdleImages returns images that no pending or running task uses and that
+// were last used before usedBefore, least recently used first.
+func (r *RetentionRepository) ListIdleImages(ctx context.Context, usedBefore time.Time) ([]*models.ImageRetentionCandidate, error) {
+	var images []*models.StoredImage
+	err := r.db.WithContext(ctx).
+		Where("COALESCE(last_referenced_at, created_at) < ?", usedBefore).
+		Where("NOT " + imageInUseCondition).
+		Order("COALESCE(last_referenced_at, created_at) ASC").
+		Find(&images).Error
+	if err != nil || len(images) == 0 {
+		return nil, err
+	}
+
+	digests := make([]string, 0, len(images))
+	for _, image := range images {
+		digests = append(digests, image.Digest)
+	}
+
+	var references []models.ImageReference
+	if err := r.db.WithContext(ctx).Where("image_digest IN ?", digests).Find(&references).Error; err != nil {
+		return nil, err
+	}
+	referencedBy := make(map[string][]string)
+	for _, reference := range references {
+		referencedBy[reference.ImageDigest] = append(referencedBy[reference.ImageDigest], reference.CreatorAddress)
+	}
+
+	candidates := make([]*models.ImageRetentionCandidate, 0, len(images))
+	for _, image := range images {
+		candidates = append(candidates, &models.ImageRetentionCandidate{
+			Image:  image,
+			Owners: append([]string{image.UploadedBy}, referencedBy[image.Digest]...),
+		})
+	}
+	return candidates, nil
+}
+
+// DeleteIdleImage removes the image and its references unless a pending or
+// running task has started using it since it was listed. It reports whether
+// the image was removed.
+func (r *RetentionRepository) DeleteIdleImage(ctx context.Context, digest string) (bool, error) {
+	deleted := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("digest = ?", digest).
+			Where("NOT " + imageInUseCondition).
+			Delete(&models.StoredImage{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		deleted = true
+		return tx.Where("image_digest = ?", digest).Delete(&models.ImageReference{}).Error
+	})
+	return deleted, err
+}
+
+// ObjectKeyInUse reports whether a stored image, task artifact or archived
+// result still points at the storage object key. Objects are content
+// addressed, so identical bytes stored for different reasons share one key.
+func (r *RetentionRepository) ObjectKeyInUse(ctx context.Context, key string) (bool, error) {
+	var inUse bool
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT EXISTS (SELECT 1 FROM stored_images WHERE RIGHT(url, LENGTH(?)) = ?)
+		OR EXISTS (SELECT 1 FROM task_artifacts WHERE storage_key = ?)
+		OR EXISTS (SELECT 1 FROM task_results WHERE archive_key = ?)
+		OR EXISTS (SELECT 1 FROM federated_learning_sessions WHERE global_model_key = ?)`,
+		"/"+key, "/"+key, key, key, key,
+	).Scan(&inUse).Error
+	return inUse, err
+}
+
+// ListFinishedResults pages through unarchived results of completed, failed
+// or unverified tasks created before createdBefore, oldest first. Pass the
+// last candidate of the previous page as after to continue.
+func (r *RetentionRepository) ListFinishedResults(ctx context.Context, createdBefore time.Time, after *models.ResultRetentionCandidate, limit int) ([]*models.ResultRetentionCandidate, error) {
+	query := r.db.WithContext(ctx).
+		Table("task_results").
+		Select(`task_results.id AS result_id, task_results.task_id, tasks.creator_address,
+			tasks.status AS task_status, task_results.created_at`).
+		Joins("JOIN tasks ON tasks.id = task_results.task_id").
+		Where("task_results.archived_at IS NULL").
+		Where("task_results.created_at < ?", createdBefore).
+		Where("tasks.status IN ?", []models.TaskStatus{
+			models.TaskStatusCompleted, models.TaskStatusFailed, models.TaskStatusNotVerified,
+		})
+	if after != nil {
+		query = query.Where("(task_results.created_at, task_results.id) > (?, ?)", after.CreatedAt, after.ResultID)
+	}
+
+	var candidates []*models.ResultRetentionCandidate
+	err := query.
+		Order("task_results.created_at ASC, task_results.id ASC").
+		Limit(limit).
+		Scan(&candidates).Error
+	return candidates, err
+}
+
+// GetResultArchive returns the output and error that archiving moves out of
+// the result's row.
+func (r *RetentionRepository) GetResultArchive(ctx context.Context, resultID uuid.UUID) (*models.ResultArchive, error) {
+	var archive models.ResultArchive
+	result := r.db.WithContext(ctx).
+		Table("task_results").
+		Select("output, error").
+		Where("id = ?", resultID).
+		Scan(&archive)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("task result %s not found", resultID)
+	}
+	return &archive, nil
+}
+
+// ArchiveResult clears the result's output and error, keeping the row and a
+// pointer to the archived copy.
+func (r *RetentionRepository) ArchiveResult(ctx context.Context, resultID uuid.UUID, at time.Time, key, hash string) error {
+	return r.db.WithContext(ctx).Model(&models.TaskResult{}).
+		Where("id = ? AND archived_at IS NULL", resultID).
+		Updates(map[string]interface{}{
+			"output":       "",
+			"error":        "",
+			"archived_at":  at,
+			"archive_key":  key,
+			"archive_hash": hash,
+		}).Error
+}
+
+func (r *RetentionRepository) DeleteResult(ctx context.Context, resultID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("id = ?", resultID).Delete(&models.TaskResult{}).Error
+}
@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAuthChallengeNotFound = errors.New("auth challenge not found")
+	ErrRunnerSessionNotFound = errors.New("runner session not found")
+)
+
+type RunnerAuthRepository struct {
+	db *gorm.DB
+}
+
+func NewRunnerAuthRepository(db *gorm.DB) *RunnerAuthRepository {
+	return &RunnerAuthRepository{db: db}
+}
+
+func (r *RunnerAuthRepository) CreateChallenge(ctx context.Context, challenge *models.RunnerAuthChallenge) error {
+	return r.db.WithContext(ctx).Create(challenge).Error
+}
+
+func (r *RunnerAuthRepository) GetChallenge(ctx context.Context, id uuid.UUID) (*models.RunnerAuthChallenge, error) {
+	var challenge models.RunnerAuthChallenge
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&challenge).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAuthChallengeNotFound
+		}
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+// MarkChallengeUsed consumes the challenge and reports whether this call was the
+// one that consumed it, so a signature can never be replayed concurrently.
+func (r *RunnerAuthRepository) MarkChallengeUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.RunnerAuthChallenge{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *RunnerAuthRepository) CreateSession(ctx context.Context, session *models.RunnerSession) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+func (r *RunnerAuthRepository) GetSessionByTokenHash(ctx context.Context, tokenHash string) (*models.RunnerSession, error) {
+	var session models.RunnerSession
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRunnerSessionNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *RunnerAuthRepository) GetSessionByRefreshTokenHash(ctx context.Context, refreshTokenHash string) (*models.RunnerSession, error) {
+	var session models.RunnerSession
+	if err := r.db.WithContext(ctx).Where("refresh_token_hash = ?", refreshTokenHash).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRunnerSessionNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *RunnerAuthRepository) UpdateSession(ctx context.Context, session *models.RunnerSession) error {
+	return r.db.WithContext(ctx).Save(session).Error
+}
+
+func (r *RunnerAuthRepository) RevokeSessionsByDevice(ctx context.Context, deviceID string) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.RunnerSession{}).
+		Where("device_id = ? AND revoked_at IS NULL", deviceID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected, result.Error
+}
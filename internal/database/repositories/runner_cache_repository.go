@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RunnerCacheRepository struct {
+	db *gorm.DB
+}
+
+func NewRunnerCacheRepository(db *gorm.DB) *RunnerCacheRepository {
+	return &RunnerCacheRepository{db: db}
+}
+
+func (r *RunnerCacheRepository) Save(ctx context.Context, cache *models.RunnerCache) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(cache).Error
+}
+
+func (r *RunnerCacheRepository) List(ctx context.Context) ([]*models.RunnerCache, error) {
+	var caches []*models.RunnerCache
+	err := r.db.WithContext(ctx).Find(&caches).Error
+	return caches, err
+}
+
+func (r *RunnerCacheRepository) Delete(ctx context.Context, deviceID string) error {
+	return r.db.WithContext(ctx).Where("device_id = ?", deviceID).Delete(&models.RunnerCache{}).Error
+}
+
+func (r *RunnerCacheRepository) DeleteReportedBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("reported_at < ?", before).
+		Delete(&models.RunnerCache{})
+	return result.RowsAffected, result.Error
+}
@@ -5,8 +5,10 @@ import (
 	"errors"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/theblitlabs/parity-server/internal/core/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var ErrRunnerNotFound = errors.New("runner not found")
@@ -21,12 +23,25 @@ func NewRunnerRepository(db *gorm.DB) *RunnerRepository {
 
 func (r *RunnerRepository) Create(ctx context.Context, runner *models.Runner) error {
 	dbRunner := models.Runner{
-		DeviceID:      runner.DeviceID,
-		WalletAddress: runner.WalletAddress,
-		Status:        runner.Status,
-		TaskID:        runner.TaskID,
-		Webhook:       runner.Webhook,
-		LastHeartbeat: time.Now(),
+		DeviceID:        runner.DeviceID,
+		WalletAddress:   runner.WalletAddress,
+		Status:          runner.Status,
+		Webhook:         runner.Webhook,
+		DeliveryMode:    runner.DeliveryMode,
+		Slots:           runner.Slots,
+		Labels:          runner.Labels,
+		ProtocolVersion: runner.ProtocolVersion,
+		SoftwareVersion: runner.SoftwareVersion,
+		LastHeartbeat:   time.Now(),
+	}
+	if dbRunner.Slots < 1 {
+		dbRunner.Slots = 1
+	}
+	if dbRunner.ProtocolVersion < 1 {
+		dbRunner.ProtocolVersion = models.RunnerProtocolLegacy
+	}
+	if dbRunner.DeliveryMode == "" {
+		dbRunner.DeliveryMode = models.DeliveryModePush
 	}
 
 	result := r.db.WithContext(ctx).Create(&dbRunner)
@@ -35,7 +50,7 @@ func (r *RunnerRepository) Create(ctx context.Context, runner *models.Runner) er
 
 func (r *RunnerRepository) Get(ctx context.Context, deviceID string) (*models.Runner, error) {
 	var runner models.Runner
-	result := r.db.WithContext(ctx).First(&runner, "device_id = ?", deviceID)
+	result := r.db.WithContext(ctx).Preload("Assignments").First(&runner, "device_id = ?", deviceID)
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		return nil, ErrRunnerNotFound
 	}
@@ -59,23 +74,56 @@ func (r *RunnerRepository) CreateOrUpdate(ctx context.Context, runner *models.Ru
 		return nil, result.Error
 	}
 
-	existingRunner.Status = runner.Status
-	existingRunner.TaskID = runner.TaskID
+	if !existingRunner.IsDraining() {
+		existingRunner.Status = runner.Status
+	}
 	existingRunner.Webhook = runner.Webhook
+	if runner.DeliveryMode != "" {
+		existingRunner.DeliveryMode = runner.DeliveryMode
+	}
+	if runner.Slots > 0 {
+		existingRunner.Slots = runner.Slots
+	}
+	if runner.Labels != nil {
+		existingRunner.Labels = runner.Labels
+	}
+	if runner.ProtocolVersion > 0 {
+		existingRunner.ProtocolVersion = runner.ProtocolVersion
+	}
+	if runner.SoftwareVersion != "" {
+		existingRunner.SoftwareVersion = runner.SoftwareVersion
+	}
 	existingRunner.LastHeartbeat = time.Now()
 
-	err := r.db.WithContext(ctx).Save(&existingRunner).Error
-	return &existingRunner, err
+	if err := r.db.WithContext(ctx).Save(&existingRunner).Error; err != nil {
+		return nil, err
+	}
+	return r.Get(ctx, runner.DeviceID)
 }
 
 func (r *RunnerRepository) Update(ctx context.Context, runner *models.Runner) (*models.Runner, error) {
 	updateFields := map[string]interface{}{
-		"status":         runner.Status,
-		"task_id":        runner.TaskID,
+		// A draining runner stays draining until it is explicitly undrained,
+		// and a forced-offline runner stays offline until it is restored.
+		"status": gorm.Expr("CASE WHEN forced_offline THEN ? WHEN status = ? THEN status ELSE ? END",
+			models.RunnerStatusOffline, models.RunnerStatusDraining, runner.Status),
 		"webhook":        runner.Webhook,
 		"wallet_address": runner.WalletAddress,
 	}
 
+	if runner.DeliveryMode != "" {
+		updateFields["delivery_mode"] = runner.DeliveryMode
+	}
+	if runner.Slots > 0 {
+		updateFields["slots"] = runner.Slots
+	}
+	if runner.ProtocolVersion > 0 {
+		updateFields["protocol_version"] = runner.ProtocolVersion
+	}
+	if runner.SoftwareVersion != "" {
+		updateFields["software_version"] = runner.SoftwareVersion
+	}
+
 	if runner.Status == models.RunnerStatusOnline {
 		updateFields["last_heartbeat"] = time.Now()
 	}
@@ -90,10 +138,119 @@ func (r *RunnerRepository) Update(ctx context.Context, runner *models.Runner) (*
 	return r.Get(ctx, runner.DeviceID)
 }
 
+// UpdateDrainState sets the runner's status and maintenance window directly,
+// bypassing the draining guard in Update.
+func (r *RunnerRepository) UpdateDrainState(ctx context.Context, deviceID string, status models.RunnerStatus, maintenanceUntil *time.Time) (*models.Runner, error) {
+	result := r.db.WithContext(ctx).Model(&models.Runner{}).
+		Where("device_id = ?", deviceID).
+		Updates(map[string]interface{}{
+			"status":            status,
+			"maintenance_until": maintenanceUntil,
+			"last_heartbeat":    time.Now(),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrRunnerNotFound
+	}
+	return r.Get(ctx, deviceID)
+}
+
+// AcquireSlot records assignment against one of the runner's free slots. It
+// reports false when every slot is taken. Acquiring a slot the work already
+// holds succeeds without adding another.
+func (r *RunnerRepository) AcquireSlot(ctx context.Context, assignment *models.RunnerAssignment) (bool, error) {
+	acquired := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var runner models.Runner
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&runner, "device_id = ?", assignment.DeviceID)
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return ErrRunnerNotFound
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+
+		var held int64
+		if err := tx.Model(&models.RunnerAssignment{}).
+			Where("device_id = ? AND work_id = ?", assignment.DeviceID, assignment.WorkID).
+			Count(&held).Error; err != nil {
+			return err
+		}
+		if held > 0 {
+			acquired = true
+			return nil
+		}
+
+		var active int64
+		if err := tx.Model(&models.RunnerAssignment{}).
+			Where("device_id = ?", assignment.DeviceID).
+			Count(&active).Error; err != nil {
+			return err
+		}
+		if active >= int64(runner.Capacity()) {
+			return nil
+		}
+
+		if err := tx.Create(assignment).Error; err != nil {
+			return err
+		}
+		acquired = true
+		return nil
+	})
+	return acquired, err
+}
+
+func (r *RunnerRepository) ReleaseSlot(ctx context.Context, deviceID string, workID uuid.UUID) error {
+	return r.db.WithContext(ctx).
+		Where("device_id = ? AND work_id = ?", deviceID, workID).
+		Delete(&models.RunnerAssignment{}).Error
+}
+
+// SetForcedOffline sets or clears the operator's offline hold. Setting it also
+// marks the runner offline and ends any maintenance window.
+func (r *RunnerRepository) SetForcedOffline(ctx context.Context, deviceID string, forced bool) (*models.Runner, error) {
+	updates := map[string]interface{}{
+		"forced_offline": forced,
+	}
+	if forced {
+		updates["status"] = models.RunnerStatusOffline
+		updates["maintenance_until"] = nil
+	}
+
+	result := r.db.WithContext(ctx).Model(&models.Runner{}).
+		Where("device_id = ?", deviceID).
+		Updates(updates)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrRunnerNotFound
+	}
+	return r.Get(ctx, deviceID)
+}
+
+func (r *RunnerRepository) BindWallet(ctx context.Context, deviceID string, walletAddress string) (*models.Runner, error) {
+	result := r.db.WithContext(ctx).Model(&models.Runner{}).
+		Where("device_id = ?", deviceID).
+		Update("wallet_address", walletAddress)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrRunnerNotFound
+	}
+	return r.Get(ctx, deviceID)
+}
+
 func (r *RunnerRepository) ListByStatus(ctx context.Context, status models.RunnerStatus) ([]*models.Runner, error) {
 	var runners []*models.Runner
 
-	result := r.db.WithContext(ctx).Where("status = ?", status).Find(&runners)
+	result := r.db.WithContext(ctx).Preload("Assignments").
+		Where("status = ? AND (forced_offline = ? OR status = ?)", status, false, models.RunnerStatusOffline).
+		Find(&runners)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -101,15 +258,98 @@ func (r *RunnerRepository) ListByStatus(ctx context.Context, status models.Runne
 	return runners, nil
 }
 
+// List returns the runners matching filter, most recently seen first.
+func (r *RunnerRepository) List(ctx context.Context, filter models.RunnerFilter) ([]*models.Runner, error) {
+	query := r.db.WithContext(ctx).
+		Preload("ModelCapabilities").
+		Preload("Assignments")
+
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.WalletAddress != "" {
+		query = query.Where("LOWER(wallet_address) = LOWER(?)", filter.WalletAddress)
+	}
+	if filter.Model != "" {
+		query = query.Where("EXISTS (SELECT 1 FROM model_capabilities mc WHERE mc.runner_id = runners.device_id AND mc.model_name = ?)", filter.Model)
+	}
+	if filter.HeartbeatAfter != nil {
+		query = query.Where("last_heartbeat >= ?", *filter.HeartbeatAfter)
+	}
+	if filter.HeartbeatBefore != nil {
+		query = query.Where("last_heartbeat < ?", *filter.HeartbeatBefore)
+	}
+	if len(filter.Labels) > 0 {
+		labels, err := models.RunnerLabels(filter.Labels).Value()
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("labels @> ?::jsonb", labels)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	var runners []*models.Runner
+	if err := query.Order("last_heartbeat DESC").Find(&runners).Error; err != nil {
+		return nil, err
+	}
+	return runners, nil
+}
+
+// VersionDistribution counts runners by protocol and software version.
+func (r *RunnerRepository) VersionDistribution(ctx context.Context) ([]models.RunnerVersionCount, error) {
+	var counts []models.RunnerVersionCount
+	err := r.db.WithContext(ctx).
+		Model(&models.Runner{}).
+		Select("protocol_version, software_version, COUNT(*) AS count").
+		Group("protocol_version, software_version").
+		Order("protocol_version DESC, software_version DESC").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// Delete removes the runner together with its slots and model capabilities.
+func (r *RunnerRepository) Delete(ctx context.Context, deviceID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("device_id = ?", deviceID).Delete(&models.RunnerAssignment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("runner_id = ?", deviceID).Delete(&models.ModelCapability{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("device_id = ?", deviceID).Delete(&models.Runner{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRunnerNotFound
+		}
+		return nil
+	})
+}
+
+// staleRunnersQuery matches runners whose heartbeat is older than the cutoff.
+// Draining runners time out too, unless they are inside a maintenance window.
+const staleRunnersQuery = "last_heartbeat < ? AND (status IN (?, ?) OR (status = ? AND (maintenance_until IS NULL OR maintenance_until < ?)))"
+
 func (r *RunnerRepository) UpdateRunnersToOffline(ctx context.Context, heartbeatTimeout time.Duration) (int64, []string, error) {
-	cutoffTime := time.Now().Add(-heartbeatTimeout)
+	now := time.Now()
+	cutoffTime := now.Add(-heartbeatTimeout)
+	staleArgs := []interface{}{
+		cutoffTime,
+		models.RunnerStatusOnline,
+		models.RunnerStatusBusy,
+		models.RunnerStatusDraining,
+		now,
+	}
 
 	var runners []models.Runner
 	if err := r.db.WithContext(ctx).
-		Where("status IN (?, ?) AND last_heartbeat < ?",
-			models.RunnerStatusOnline,
-			models.RunnerStatusBusy,
-			cutoffTime).
+		Where(staleRunnersQuery, staleArgs...).
 		Find(&runners).Error; err != nil {
 		return 0, nil, err
 	}
@@ -124,12 +364,10 @@ func (r *RunnerRepository) UpdateRunnersToOffline(ctx context.Context, heartbeat
 	}
 
 	result := r.db.WithContext(ctx).Model(&models.Runner{}).
-		Where("status IN (?, ?) AND last_heartbeat < ?",
-			models.RunnerStatusOnline,
-			models.RunnerStatusBusy,
-			cutoffTime).
+		Where(staleRunnersQuery, staleArgs...).
 		Updates(map[string]interface{}{
-			"status": models.RunnerStatusOffline,
+			"status":            models.RunnerStatusOffline,
+			"maintenance_until": nil,
 		})
 
 	if result.Error != nil {
@@ -143,7 +381,8 @@ func (r *RunnerRepository) GetOnlineRunners(ctx context.Context) ([]*models.Runn
 	var runners []*models.Runner
 	err := r.db.WithContext(ctx).
 		Preload("ModelCapabilities").
-		Where("status = ?", models.RunnerStatusOnline).
+		Preload("Assignments").
+		Where("status = ? AND forced_offline = ?", models.RunnerStatusOnline, false).
 		Find(&runners).Error
 	return runners, err
 }
@@ -152,6 +391,7 @@ func (r *RunnerRepository) GetRunnerByDeviceID(ctx context.Context, deviceID str
 	var runner models.Runner
 	err := r.db.WithContext(ctx).
 		Preload("ModelCapabilities").
+		Preload("Assignments").
 		Where("device_id = ?", deviceID).
 		First(&runner).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TaskLogRepository struct {
+	db *gorm.DB
+}
+
+func NewTaskLogRepository(db *gorm.DB) *TaskLogRepository {
+	return &TaskLogRepository{db: db}
+}
+
+func (r *TaskLogRepository) SaveChunks(ctx context.Context, chunks []models.TaskLogChunk) error {
+	if len(chunks) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(chunks, 500).Error
+}
+
+func (r *TaskLogRepository) ListChunks(ctx context.Context, taskID uuid.UUID, afterSeq int64) ([]models.TaskLogChunk, error) {
+	var chunks []models.TaskLogChunk
+	err := r.db.WithContext(ctx).
+		Where("task_id = ? AND seq > ?", taskID, afterSeq).
+		Order("seq ASC").
+		Find(&chunks).Error
+	return chunks, err
+}
+
+func (r *TaskLogRepository) LastSeq(ctx context.Context, taskID uuid.UUID) (int64, error) {
+	var seq int64
+	err := r.db.WithContext(ctx).
+		Model(&models.TaskLogChunk{}).
+		Where("task_id = ?", taskID).
+		Select("COALESCE(MAX(seq), 0)").
+		Scan(&seq).Error
+	return seq, err
+}
+
+func (r *TaskLogRepository) SaveStreamedBytes(ctx context.Context, taskID uuid.UUID, bytes int64) error {
+	usage := models.TaskLogUsage{TaskID: taskID, Bytes: bytes, UpdatedAt: time.Now()}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "task_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"bytes", "updated_at"}),
+	}).Create(&usage).Error
+}
+
+func (r *TaskLogRepository) StreamedBytes(ctx context.Context, taskID uuid.UUID) (int64, error) {
+	var bytes int64
+	err := r.db.WithContext(ctx).
+		Model(&models.TaskLogUsage{}).
+		Where("task_id = ?", taskID).
+		Select("COALESCE(MAX(bytes), 0)").
+		Scan(&bytes).Error
+	return bytes, err
+}
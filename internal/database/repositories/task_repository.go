@@ -38,8 +38,15 @@ func (r *TaskRepository) Create(ctx context.Context, task *models.Task) error {
 		Reward:          task.Reward,
 		RunnerID:        task.RunnerID,
 		Nonce:           task.Nonce,
+		NonceSource:     task.NonceSource,
+		NonceRound:      task.NonceRound,
 		ImageHash:       task.ImageHash,
 		CommandHash:     task.CommandHash,
+		InputHash:       task.InputHash,
+		HighValue:       task.HighValue,
+		Selections:      task.Selections,
+		SelectionRound:  task.SelectionRound,
+		LeaseExpiresAt:  task.LeaseExpiresAt,
 		CreatedAt:       task.CreatedAt,
 		UpdatedAt:       task.UpdatedAt,
 		CompletedAt:     task.CompletedAt,
@@ -72,8 +79,15 @@ func (r *TaskRepository) Get(ctx context.Context, id uuid.UUID) (*models.Task, e
 		Reward:          dbTask.Reward,
 		RunnerID:        dbTask.RunnerID,
 		Nonce:           dbTask.Nonce,
+		NonceSource:     dbTask.NonceSource,
+		NonceRound:      dbTask.NonceRound,
 		ImageHash:       dbTask.ImageHash,
 		CommandHash:     dbTask.CommandHash,
+		InputHash:       dbTask.InputHash,
+		HighValue:       dbTask.HighValue,
+		Selections:      dbTask.Selections,
+		SelectionRound:  dbTask.SelectionRound,
+		LeaseExpiresAt:  dbTask.LeaseExpiresAt,
 		CreatedAt:       dbTask.CreatedAt,
 		UpdatedAt:       dbTask.UpdatedAt,
 		CompletedAt:     dbTask.CompletedAt,
@@ -84,16 +98,23 @@ func (r *TaskRepository) Get(ctx context.Context, id uuid.UUID) (*models.Task, e
 
 func (r *TaskRepository) Update(ctx context.Context, task *models.Task) error {
 	updates := map[string]interface{}{
-		"status":       task.Status,
-		"updated_at":   task.UpdatedAt,
-		"config":       task.Config,
-		"environment":  task.Environment,
-		"reward":       task.Reward,
-		"runner_id":    task.RunnerID,
-		"nonce":        task.Nonce,
-		"image_hash":   task.ImageHash,
-		"command_hash": task.CommandHash,
-		"completed_at": task.CompletedAt,
+		"status":           task.Status,
+		"updated_at":       task.UpdatedAt,
+		"config":           task.Config,
+		"environment":      task.Environment,
+		"reward":           task.Reward,
+		"runner_id":        task.RunnerID,
+		"nonce":            task.Nonce,
+		"nonce_source":     task.NonceSource,
+		"nonce_round":      task.NonceRound,
+		"image_hash":       task.ImageHash,
+		"command_hash":     task.CommandHash,
+		"input_hash":       task.InputHash,
+		"high_value":       task.HighValue,
+		"selections":       task.Selections,
+		"selection_round":  task.SelectionRound,
+		"lease_expires_at": task.LeaseExpiresAt,
+		"completed_at":     task.CompletedAt,
 	}
 
 	result := r.db.WithContext(ctx).Model(&models.Task{}).Where("id = ?", task.ID).Updates(updates)
@@ -108,6 +129,25 @@ func (r *TaskRepository) Update(ctx context.Context, task *models.Task) error {
 	return nil
 }
 
+func (r *TaskRepository) ClaimPending(ctx context.Context, task *models.Task, previousRunnerID, previousNonce string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.Task{}).
+		Where("id = ? AND status = ? AND COALESCE(runner_id, '') = ? AND nonce = ?",
+			task.ID, models.TaskStatusPending, previousRunnerID, previousNonce).
+		Updates(map[string]interface{}{
+			"runner_id":        task.RunnerID,
+			"nonce":            task.Nonce,
+			"nonce_source":     task.NonceSource,
+			"nonce_round":      task.NonceRound,
+			"lease_expires_at": task.LeaseExpiresAt,
+			"updated_at":       task.UpdatedAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
 func (r *TaskRepository) ListByStatus(ctx context.Context, status models.TaskStatus) ([]*models.Task, error) {
 	var dbTasks []models.Task
 	result := r.db.WithContext(ctx).Where("status = ?", status).Order("created_at DESC").Find(&dbTasks)
@@ -133,8 +173,15 @@ func (r *TaskRepository) ListByStatus(ctx context.Context, status models.TaskSta
 			UpdatedAt:       dbTask.UpdatedAt,
 			CompletedAt:     dbTask.CompletedAt,
 			Nonce:           dbTask.Nonce,
+			NonceSource:     dbTask.NonceSource,
+			NonceRound:      dbTask.NonceRound,
 			ImageHash:       dbTask.ImageHash,
 			CommandHash:     dbTask.CommandHash,
+			InputHash:       dbTask.InputHash,
+			HighValue:       dbTask.HighValue,
+			Selections:      dbTask.Selections,
+			SelectionRound:  dbTask.SelectionRound,
+			LeaseExpiresAt:  dbTask.LeaseExpiresAt,
 		}
 	}
 
@@ -163,8 +210,15 @@ func (r *TaskRepository) List(ctx context.Context, limit, offset int) ([]*models
 			Reward:          dbTask.Reward,
 			RunnerID:        dbTask.RunnerID,
 			Nonce:           dbTask.Nonce,
+			NonceSource:     dbTask.NonceSource,
+			NonceRound:      dbTask.NonceRound,
 			ImageHash:       dbTask.ImageHash,
 			CommandHash:     dbTask.CommandHash,
+			InputHash:       dbTask.InputHash,
+			HighValue:       dbTask.HighValue,
+			Selections:      dbTask.Selections,
+			SelectionRound:  dbTask.SelectionRound,
+			LeaseExpiresAt:  dbTask.LeaseExpiresAt,
 			CreatedAt:       dbTask.CreatedAt,
 			UpdatedAt:       dbTask.UpdatedAt,
 			CompletedAt:     dbTask.CompletedAt,
@@ -196,8 +250,15 @@ func (r *TaskRepository) GetAll(ctx context.Context) ([]models.Task, error) {
 			Reward:          dbTask.Reward,
 			RunnerID:        dbTask.RunnerID,
 			Nonce:           dbTask.Nonce,
+			NonceSource:     dbTask.NonceSource,
+			NonceRound:      dbTask.NonceRound,
 			ImageHash:       dbTask.ImageHash,
 			CommandHash:     dbTask.CommandHash,
+			InputHash:       dbTask.InputHash,
+			HighValue:       dbTask.HighValue,
+			Selections:      dbTask.Selections,
+			SelectionRound:  dbTask.SelectionRound,
+			LeaseExpiresAt:  dbTask.LeaseExpiresAt,
 			CreatedAt:       dbTask.CreatedAt,
 			UpdatedAt:       dbTask.UpdatedAt,
 			CompletedAt:     dbTask.CompletedAt,
@@ -227,6 +288,7 @@ func (r *TaskRepository) SaveTaskResult(ctx context.Context, result *models.Task
 		ResultHash:          result.ResultHash,
 		ImageHashVerified:   result.ImageHashVerified,
 		CommandHashVerified: result.CommandHashVerified,
+		InputHashVerified:   result.InputHashVerified,
 		VerificationStatus:  result.VerificationStatus,
 		CreatedAt:           result.CreatedAt,
 		CreatorDeviceID:     result.CreatorDeviceID,
@@ -237,6 +299,7 @@ func (r *TaskRepository) SaveTaskResult(ctx context.Context, result *models.Task
 		MemoryGBHours:       result.MemoryGBHours,
 		StorageGB:           result.StorageGB,
 		NetworkDataGB:       result.NetworkDataGB,
+		Artifacts:           result.Artifacts,
 	}
 
 	var existing models.TaskResult
@@ -279,6 +342,7 @@ func (r *TaskRepository) GetTaskResult(ctx context.Context, taskID uuid.UUID) (*
 		ResultHash:          dbResult.ResultHash,
 		ImageHashVerified:   dbResult.ImageHashVerified,
 		CommandHashVerified: dbResult.CommandHashVerified,
+		InputHashVerified:   dbResult.InputHashVerified,
 		VerificationStatus:  dbResult.VerificationStatus,
 		CreatedAt:           dbResult.CreatedAt,
 		CreatorDeviceID:     dbResult.CreatorDeviceID,
@@ -289,6 +353,10 @@ func (r *TaskRepository) GetTaskResult(ctx context.Context, taskID uuid.UUID) (*
 		MemoryGBHours:       dbResult.MemoryGBHours,
 		StorageGB:           dbResult.StorageGB,
 		NetworkDataGB:       dbResult.NetworkDataGB,
+		ArchivedAt:          dbResult.ArchivedAt,
+		ArchiveKey:          dbResult.ArchiveKey,
+		ArchiveHash:         dbResult.ArchiveHash,
+		Artifacts:           dbResult.Artifacts,
 	}
 
 	return taskResult, nil
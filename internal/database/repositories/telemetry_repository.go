@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"gorm.io/gorm"
+)
+
+type TelemetryRepository struct {
+	db *gorm.DB
+}
+
+func NewTelemetryRepository(db *gorm.DB) *TelemetryRepository {
+	return &TelemetryRepository{db: db}
+}
+
+func (r *TelemetryRepository) CreateSample(ctx context.Context, sample *models.RunnerTelemetrySample) error {
+	return r.db.WithContext(ctx).Create(sample).Error
+}
+
+// LatestSample returns the runner's newest raw sample, or nil if it has none.
+func (r *TelemetryRepository) LatestSample(ctx context.Context, deviceID string) (*models.RunnerTelemetrySample, error) {
+	var sample models.RunnerTelemetrySample
+	err := r.db.WithContext(ctx).
+		Where("device_id = ? AND resolution = 0", deviceID).
+		Order("bucket_start DESC").
+		First(&sample).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sample, nil
+}
+
+// ListSamples returns raw and rolled-up rows whose bucket starts in
+// [since, until), oldest first.
+func (r *TelemetryRepository) ListSamples(ctx context.Context, deviceID string, since, until time.Time) ([]*models.RunnerTelemetrySample, error) {
+	var samples []*models.RunnerTelemetrySample
+	err := r.db.WithContext(ctx).
+		Where("device_id = ? AND bucket_start >= ? AND bucket_start < ?", deviceID, since, until).
+		Order("bucket_start ASC").
+		Find(&samples).Error
+	return samples, err
+}
+
+// Downsample folds raw rows older than before into buckets of resolution and
+// deletes them. before should fall on a bucket boundary so no bucket is split
+// across two runs.
+func (r *TelemetryRepository) Downsample(ctx context.Context, before time.Time, resolution time.Duration) (int64, error) {
+	seconds := int(resolution.Seconds())
+	var folded int64
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		insert := tx.Exec(`
+			INSERT INTO runner_telemetry_samples
+				(device_id, bucket_start, resolution, heartbeats, reports, cpu_avg, cpu_max, memory_avg, memory_max,
+				 uptime_seconds, online_seconds, gaps, gap_sum, gap_squares, public_ip)
+			SELECT device_id,
+				to_timestamp(floor(extract(epoch FROM bucket_start) / ?) * ?) AT TIME ZONE 'UTC',
+				?,
+				SUM(heartbeats),
+				SUM(reports),
+				COALESCE(SUM(cpu_avg * reports) / NULLIF(SUM(reports), 0), 0),
+				MAX(cpu_max),
+				COALESCE(SUM(memory_avg * reports) / NULLIF(SUM(reports), 0), 0)::bigint,
+				MAX(uptime_seconds),
+				SUM(online_seconds),
+				SUM(gaps),
+				SUM(gap_sum),
+				SUM(gap_squares),
+				MAX(public_ip)
+			FROM runner_telemetry_samples
+			WHERE resolution = 0 AND bucket_start < ?
+			GROUP BY device_id, 2`,
+			seconds, seconds, seconds, before)
+		if insert.Error != nil {
+			return insert.Error
+		}
+
+		deleted := tx.Where("resolution = 0 AND bucket_start < ?", before).Delete(&models.RunnerTelemetrySample{})
+		if deleted.Error != nil {
+			return deleted.Error
+		}
+		folded = deleted.RowsAffected
+		return nil
+	})
+
+	return folded, err
+}
+
+func (r *TelemetryRepository) DeleteSamplesBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("bucket_start < ?", before).
+		Delete(&models.RunnerTelemetrySample{})
+	return result.RowsAffected, result.Error
+}
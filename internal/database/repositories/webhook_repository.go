@@ -0,0 +1,180 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theblitlabs/parity-server/internal/core/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrWebhookEndpointNotFound = errors.New("webhook endpoint not found")
+
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// UpsertEndpoint stores the device's webhook, keeping the existing ID when the
+// device re-registers.
+func (r *WebhookRepository) UpsertEndpoint(ctx context.Context, endpoint *models.WebhookEndpoint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing models.WebhookEndpoint
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("device_id = ?", endpoint.DeviceID).
+			First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tx.Create(endpoint).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		endpoint.ID = existing.ID
+		endpoint.CreatedAt = existing.CreatedAt
+		return tx.Model(&existing).Updates(map[string]interface{}{
+			"url":        endpoint.URL,
+			"updated_at": endpoint.UpdatedAt,
+		}).Error
+	})
+}
+
+func (r *WebhookRepository) GetEndpoint(ctx context.Context, id uuid.UUID) (*models.WebhookEndpoint, error) {
+	var endpoint models.WebhookEndpoint
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&endpoint).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookEndpointNotFound
+		}
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+func (r *WebhookRepository) ListEndpoints(ctx context.Context) ([]*models.WebhookEndpoint, error) {
+	var endpoints []*models.WebhookEndpoint
+	if err := r.db.WithContext(ctx).Order("created_at ASC").Find(&endpoints).Error; err != nil {
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+func (r *WebhookRepository) DeleteEndpoint(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Where("id = ?", id).Delete(&models.WebhookEndpoint{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrWebhookEndpointNotFound
+	}
+	return nil
+}
+
+func (r *WebhookRepository) DeleteEndpointsByDevice(ctx context.Context, deviceID string) error {
+	return r.db.WithContext(ctx).Where("device_id = ?", deviceID).Delete(&models.WebhookEndpoint{}).Error
+}
+
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Omit("AttemptLog").Create(delivery).Error
+}
+
+// ClaimDueDeliveries locks a batch of pending deliveries that are due, so that
+// several server instances can drain the outbox without sending a message twice.
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lockFor time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+			Where("locked_until IS NULL OR locked_until < ?", now).
+			Order("next_attempt_at ASC").
+			Limit(limit).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, len(deliveries))
+		lockedUntil := now.Add(lockFor)
+		for i, delivery := range deliveries {
+			ids[i] = delivery.ID
+			delivery.LockedUntil = &lockedUntil
+		}
+
+		return tx.Model(&models.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Update("locked_until", lockedUntil).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Model(delivery).Updates(map[string]interface{}{
+		"url":              delivery.URL,
+		"status":           delivery.Status,
+		"attempts":         delivery.Attempts,
+		"next_attempt_at":  delivery.NextAttemptAt,
+		"locked_until":     delivery.LockedUntil,
+		"last_status_code": delivery.LastStatusCode,
+		"last_error":       delivery.LastError,
+		"delivered_at":     delivery.DeliveredAt,
+		"updated_at":       delivery.UpdatedAt,
+	}).Error
+}
+
+func (r *WebhookRepository) CreateAttempt(ctx context.Context, attempt *models.WebhookDeliveryAttempt) error {
+	return r.db.WithContext(ctx).Create(attempt).Error
+}
+
+func (r *WebhookRepository) ListDeliveriesByDevice(ctx context.Context, deviceID string, status models.WebhookDeliveryStatus, limit int) ([]*models.WebhookDelivery, error) {
+	query := r.db.WithContext(ctx).
+		Preload("AttemptLog", func(db *gorm.DB) *gorm.DB {
+			return db.Order("attempt ASC")
+		}).
+		Where("device_id = ?", deviceID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var deliveries []*models.WebhookDelivery
+	if err := query.Order("created_at DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *WebhookRepository) CreateSigningKey(ctx context.Context, key *models.WebhookSigningKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+// ListActiveSigningKeys returns the device's unexpired keys, newest first.
+func (r *WebhookRepository) ListActiveSigningKeys(ctx context.Context, deviceID string, now time.Time) ([]*models.WebhookSigningKey, error) {
+	var keys []*models.WebhookSigningKey
+	if err := r.db.WithContext(ctx).
+		Where("device_id = ? AND (expires_at IS NULL OR expires_at > ?)", deviceID, now).
+		Order("created_at DESC").
+		Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// ExpireSigningKeys brings forward the expiry of every key the device holds to
+// expiresAt; keys that already expire sooner are left alone.
+func (r *WebhookRepository) ExpireSigningKeys(ctx context.Context, deviceID string, expiresAt time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&models.WebhookSigningKey{}).
+		Where("device_id = ? AND (expires_at IS NULL OR expires_at > ?)", deviceID, expiresAt).
+		Update("expires_at", expiresAt).Error
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	shell "github.com/ipfs/go-ipfs-api"
+	"github.com/theblitlabs/gologger"
+	"github.com/theblitlabs/parity-server/internal/core/config"
+	"github.com/theblitlabs/parity-server/internal/core/ports"
+)
+
+// IPFSStorage pins objects on an IPFS node and serves them through a gateway.
+// Keys are CIDs.
+type IPFSStorage struct {
+	client       *shell.Shell
+	gatewayURL   string
+	storageDeals bool
+}
+
+func NewIPFSStorage(cfg config.BlockchainConfig) (*IPFSStorage, error) {
+	if cfg.IPFSEndpoint == "" {
+		return nil, fmt.Errorf("ipfs endpoint must be specified")
+	}
+
+	if cfg.GatewayURL == "" {
+		return nil, fmt.Errorf("blockchain gateway URL must be specified")
+	}
+
+	return &IPFSStorage{
+		client:       shell.NewShell(cfg.IPFSEndpoint),
+		gatewayURL:   strings.TrimRight(cfg.GatewayURL, "/"),
+		storageDeals: cfg.CreateStorageDeals,
+	}, nil
+}
+
+func (s *IPFSStorage) Backend() string {
+	return BackendIPFS
+}
+
+func (s *IPFSStorage) Put(ctx context.Context, content io.Reader, name string) (*ports.StoredObject, error) {
+	log := gologger.WithComponent("storage")
+
+	counted := &countingReader{r: content}
+	cid, err := s.client.Add(counted, shell.Pin(true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to add %s to IPFS: %w", name, err)
+	}
+
+	if s.storageDeals {
+		log.Info().
+			Str("cid", cid).
+			Msg("Creating storage deal")
+	}
+
+	return &ports.StoredObject{Key: cid, URL: s.URL(cid), Size: counted.n}, nil
+}
+
+func (s *IPFSStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	// Cat ignores the context, so send the request directly.
+	response, err := s.client.Request("cat", key).Send(ctx)
+	if err == nil && response.Error != nil {
+		response.Close()
+		err = response.Error
+	}
+	if err != nil {
+		// The IPFS API reports unknown and malformed CIDs only in the message.
+		if message := err.Error(); strings.Contains(message, "not found") || strings.Contains(message, "invalid") {
+			return nil, ports.ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("failed to read %s from IPFS: %w", key, err)
+	}
+	return response.Output, nil
+}
+
+func (s *IPFSStorage) Delete(ctx context.Context, key string) error {
+	if err := s.client.Unpin(key); err != nil && !strings.Contains(err.Error(), "not pinned") {
+		return fmt.Errorf("failed to unpin %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *IPFSStorage) URL(key string) string {
+	return fmt.Sprintf("%s/ipfs/%s", s.gatewayURL, key)
+}
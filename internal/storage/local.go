@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/theblitlabs/parity-server/internal/core/ports"
+)
+
+// LocalStorage keeps objects on the local filesystem under
+// <root>/sha256/<aa>/<bb>/<hex>, where aa and bb are the first two byte pairs
+// of the content hash. Partial uploads are written to <root>/tmp and renamed
+// into place, so a reader never sees a half-written object. Runners download
+// objects from baseURL, which should point at the server's storage download
+// endpoint.
+type LocalStorage struct {
+	root    string
+	baseURL string
+}
+
+func NewLocalStorage(root, baseURL string) (*LocalStorage, error) {
+	if root == "" || baseURL == "" {
+		return nil, fmt.Errorf("local storage path and base URL must be specified")
+	}
+	if err := os.MkdirAll(filepath.Join(root, "tmp"), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create local storage directory: %w", err)
+	}
+
+	return &LocalStorage{root: root, baseURL: strings.TrimRight(baseURL, "/")}, nil
+}
+
+func (s *LocalStorage) Backend() string {
+	return BackendLocal
+}
+
+func (s *LocalStorage) path(key string) string {
+	return filepath.Join(s.root, "sha256", key[:2], key[2:4], key)
+}
+
+func (s *LocalStorage) Put(ctx context.Context, content io.Reader, name string) (*ports.StoredObject, error) {
+	file, key, size, err := spool(filepath.Join(s.root, "tmp"), content)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	target := s.path(key)
+	if _, err := os.Stat(target); errors.Is(err, fs.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create object directory: %w", err)
+		}
+		if err := os.Rename(file.Name(), target); err != nil {
+			return nil, fmt.Errorf("failed to store %s: %w", name, err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to check for %s: %w", key, err)
+	}
+
+	return &ports.StoredObject{Key: key, URL: s.URL(key), Size: size}, nil
+}
+
+func (s *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if !isContentKey(key) {
+		return nil, ports.ErrObjectNotFound
+	}
+
+	file, err := os.Open(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ports.ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", key, err)
+	}
+	return file, nil
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	if !isContentKey(key) {
+		return nil
+	}
+
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *LocalStorage) URL(key string) string {
+	return s.baseURL + "/" + key
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/theblitlabs/parity-server/internal/core/ports"
+)
+
+func TestLocalStorageIsContentAddressed(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	store, err := NewLocalStorage(root, "https://parity.example/api/v1/storage/download/")
+	if err != nil {
+		t.Fatalf("NewLocalStorage returned error: %v", err)
+	}
+
+	first, err := store.Put(ctx, strings.NewReader("model weights"), "model.json")
+	if err != nil {
+		t.Fatalf("Put returned error: %v", err)
+	}
+	second, err := store.Put(ctx, strings.NewReader("model weights"), "copy.json")
+	if err != nil {
+		t.Fatalf("Put returned error: %v", err)
+	}
+	if first.Key != second.Key || first.Size != int64(len("model weights")) {
+		t.Fatalf("expected identical content to share a key, got %+v and %+v", first, second)
+	}
+	if first.URL != "https://parity.example/api/v1/storage/download/"+first.Key {
+		t.Fatalf("unexpected URL %q", first.URL)
+	}
+	if _, err := os.Stat(filepath.Join(root, "sha256", first.Key[:2], first.Key[2:4], first.Key)); err != nil {
+		t.Fatalf("expected object in the sharded layout: %v", err)
+	}
+	if leftovers, _ := os.ReadDir(filepath.Join(root, "tmp")); len(leftovers) != 0 {
+		t.Fatalf("expected no temporary files, found %d", len(leftovers))
+	}
+
+	reader, err := store.Get(ctx, first.Key)
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	content, _ := io.ReadAll(reader)
+	reader.Close()
+	if string(content) != "model weights" {
+		t.Fatalf("unexpected content %q", content)
+	}
+
+	if err := store.Delete(ctx, first.Key); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if err := store.Delete(ctx, first.Key); err != nil {
+		t.Fatalf("expected deleting a missing object to succeed, got %v", err)
+	}
+	if _, err := store.Get(ctx, first.Key); !errors.Is(err, ports.ErrObjectNotFound) {
+		t.Fatalf("expected ErrObjectNotFound after delete, got %v", err)
+	}
+}
+
+func TestLocalStorageRejectsKeysOutsideTheStore(t *testing.T) {
+	store, err := NewLocalStorage(t.TempDir(), "http://localhost")
+	if err != nil {
+		t.Fatalf("NewLocalStorage returned error: %v", err)
+	}
+
+	for _, key := range []string{"../../etc/passwd", "", strings.Repeat("G", 64)} {
+		if _, err := store.Get(context.Background(), key); !errors.Is(err, ports.ErrObjectNotFound) {
+			t.Fatalf("expected ErrObjectNotFound for %q, got %v", key, err)
+		}
+	}
+}
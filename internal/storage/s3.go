@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/theblitlabs/parity-server/internal/core/config"
+	"github.com/theblitlabs/parity-server/internal/core/ports"
+)
+
+// S3Storage keeps objects in a bucket of any S3-compatible API, named by the
+// hex sha256 of their content under an optional prefix.
+type S3Storage struct {
+	client    *http.Client
+	signer    *s3Signer
+	endpoint  *url.URL
+	bucket    string
+	prefix    string
+	pathStyle bool
+	publicURL string
+}
+
+func NewS3Storage(cfg config.StorageConfig) (*S3Storage, error) {
+	if cfg.S3Endpoint == "" || cfg.S3Bucket == "" {
+		return nil, fmt.Errorf("s3 endpoint and bucket must be specified")
+	}
+	if cfg.S3AccessKey == "" || cfg.S3SecretKey == "" {
+		return nil, fmt.Errorf("s3 access key and secret key must be specified")
+	}
+
+	endpoint, err := url.Parse(strings.TrimRight(cfg.S3Endpoint, "/"))
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", cfg.S3Endpoint)
+	}
+
+	region := cfg.S3Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	prefix := strings.Trim(cfg.S3Prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	return &S3Storage{
+		client: &http.Client{Timeout: 30 * time.Minute},
+		signer: &s3Signer{
+			accessKey: cfg.S3AccessKey,
+			secretKey: cfg.S3SecretKey,
+			region:    region,
+		},
+		endpoint:  endpoint,
+		bucket:    cfg.S3Bucket,
+		prefix:    prefix,
+		pathStyle: cfg.S3PathStyle,
+		publicURL: strings.TrimRight(cfg.S3PublicURL, "/"),
+	}, nil
+}
+
+func (s *S3Storage) Backend() string {
+	return BackendS3
+}
+
+func (s *S3Storage) objectURL(key string) *url.URL {
+	objectURL := *s.endpoint
+	if s.pathStyle {
+		objectURL.Path = s.endpoint.Path + "/" + s.bucket + "/" + s.prefix + key
+	} else {
+		objectURL.Host = s.bucket + "." + s.endpoint.Host
+		objectURL.Path = s.endpoint.Path + "/" + s.prefix + key
+	}
+	return &objectURL
+}
+
+func (s *S3Storage) do(ctx context.Context, method, key string, body io.Reader, size int64, payloadHash string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key).String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+	s.signer.sign(req, payloadHash, time.Now())
+	return s.client.Do(req)
+}
+
+func (s *S3Storage) Put(ctx context.Context, content io.Reader, name string) (*ports.StoredObject, error) {
+	file, key, size, err := spool("", content)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	// The key is the content hash, so an object that already exists holds
+	// exactly these bytes.
+	head, err := s.do(ctx, http.MethodHead, key, nil, 0, emptyPayloadHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for %s in s3: %w", key, err)
+	}
+	head.Body.Close()
+	if head.StatusCode == http.StatusOK {
+		return &ports.StoredObject{Key: key, URL: s.URL(key), Size: size}, nil
+	}
+
+	resp, err := s.do(ctx, http.MethodPut, key, file, size, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload %s to s3: %w", name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to upload %s to s3: %w", name, s3Error(resp))
+	}
+
+	return &ports.StoredObject{Key: key, URL: s.URL(key), Size: size}, nil
+}
+
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if !isContentKey(key) {
+		return nil, ports.ErrObjectNotFound
+	}
+
+	resp, err := s.do(ctx, http.MethodGet, key, nil, 0, emptyPayloadHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s from s3: %w", key, err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ports.ErrObjectNotFound
+	default:
+		defer resp.Body.Close()
+		return nil, fmt.Errorf("failed to read %s from s3: %w", key, s3Error(resp))
+	}
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	if !isContentKey(key) {
+		return nil
+	}
+
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, 0, emptyPayloadHash)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s from s3: %w", key, err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return fmt.Errorf("failed to delete %s from s3: %w", key, s3Error(resp))
+	}
+}
+
+// URL is where runners download the object: the configured public URL, or
+// the object's address on the S3 endpoint.
+func (s *S3Storage) URL(key string) string {
+	if s.publicURL != "" {
+		return s.publicURL + "/" + s.prefix + key
+	}
+	return s.objectURL(key).String()
+}
+
+func s3Error(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("s3 returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/theblitlabs/parity-server/internal/core/config"
+	"github.com/theblitlabs/parity-server/internal/core/ports"
+)
+
+const (
+	BackendIPFS  = "ipfs"
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+// New returns the backend selected by cfg.Storage.Backend, IPFS by default.
+func New(cfg *config.Config) (ports.Storage, error) {
+	var (
+		store ports.Storage
+		err   error
+	)
+	switch backend := strings.ToLower(cfg.Storage.Backend); backend {
+	case "", BackendIPFS:
+		store, err = NewIPFSStorage(cfg.Blockchain)
+	case BackendLocal:
+		store, err = NewLocalStorage(cfg.Storage.LocalPath, cfg.Storage.LocalBaseURL)
+	case BackendS3:
+		store, err = NewS3Storage(cfg.Storage)
+	default:
+		err = fmt.Errorf("unknown storage backend %q", backend)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// isContentKey reports whether key is a hex sha256, the key format of the
+// local and S3 backends.
+func isContentKey(key string) bool {
+	if len(key) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(key)
+	return err == nil && strings.ToLower(key) == key
+}
+
+// spool copies content to a temporary file in dir while hashing it, and
+// returns the file rewound to the start with its sha256 key. The caller
+// removes the file.
+func spool(dir string, content io.Reader) (*os.File, string, int64, error) {
+	file, err := os.CreateTemp(dir, "upload-*")
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hasher), content)
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, "", 0, fmt.Errorf("failed to buffer upload: %w", err)
+	}
+
+	return file, hex.EncodeToString(hasher.Sum(nil)), size, nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"crypto/sha256"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/theblitlabs/parity-server/internal/core/models"
@@ -14,19 +15,50 @@ func ComputeCommandHash(command []string) string {
 	return fmt.Sprintf("%x", hash)
 }
 
-func ComputeResultHash(stdout, stderr string, exitCode int) string {
+// ComputeResultHash hashes a task's output, exit code and artifacts. Each
+// artifact adds "\x00<name>\x00<digest>" in name order, so a result without
+// artifacts hashes the same as before artifacts existed.
+func ComputeResultHash(stdout, stderr string, exitCode int, artifacts []models.TaskArtifact) string {
 	combined := fmt.Sprintf("%s%s%d", stdout, stderr, exitCode)
+
+	sorted := append([]models.TaskArtifact(nil), artifacts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	for _, artifact := range sorted {
+		combined += "\x00" + artifact.Name + "\x00" + artifact.Digest
+	}
+
 	hash := sha256.Sum256([]byte(combined))
 	return fmt.Sprintf("%x", hash)
 }
 
-func VerifyTaskHashes(task *models.Task, imageHashVerified, commandHashVerified string) bool {
+// ComputeInputHash hashes a task's input datasets as "<name>\x00<digest>\n"
+// in name order. A task without inputs has no input hash.
+func ComputeInputHash(inputs []models.InputDataset) string {
+	if len(inputs) == 0 {
+		return ""
+	}
+
+	sorted := append([]models.InputDataset(nil), inputs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	var combined strings.Builder
+	for _, input := range sorted {
+		combined.WriteString(input.Name + "\x00" + input.Digest + "\n")
+	}
+
+	hash := sha256.Sum256([]byte(combined.String()))
+	return fmt.Sprintf("%x", hash)
+}
+
+func VerifyTaskHashes(task *models.Task, imageHashVerified, commandHashVerified, inputHashVerified string) bool {
 	if task.ImageHash != "" && task.ImageHash != imageHashVerified {
 		return false
 	}
 	if task.CommandHash != "" && task.CommandHash != commandHashVerified {
 		return false
 	}
+	if task.InputHash != "" && task.InputHash != inputHashVerified {
+		return false
+	}
 	return true
 }
 
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// RecoverPersonalSignAddress recovers the wallet address that produced an EIP-191
+// personal_sign signature over message.
+func RecoverPersonalSignAddress(message string, signatureHex string) (common.Address, error) {
+	signature, err := hexutil.Decode(ensureHexPrefix(signatureHex))
+	if err != nil {
+		return common.Address{}, fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	if len(signature) != crypto.SignatureLength {
+		return common.Address{}, errors.New("invalid signature length")
+	}
+
+	// Wallets return V as 27/28, crypto.SigToPub expects 0/1.
+	if signature[crypto.RecoveryIDOffset] >= 27 {
+		signature[crypto.RecoveryIDOffset] -= 27
+	}
+
+	publicKey, err := crypto.SigToPub(accounts.TextHash([]byte(message)), signature)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to recover signer: %w", err)
+	}
+
+	return crypto.PubkeyToAddress(*publicKey), nil
+}
+
+// VerifyPersonalSignature reports whether signatureHex is a personal_sign signature
+// over message by walletAddress.
+func VerifyPersonalSignature(walletAddress string, message string, signatureHex string) error {
+	if !common.IsHexAddress(walletAddress) {
+		return errors.New("invalid wallet address")
+	}
+
+	signer, err := RecoverPersonalSignAddress(message, signatureHex)
+	if err != nil {
+		return err
+	}
+
+	if signer != common.HexToAddress(walletAddress) {
+		return errors.New("signature does not match wallet address")
+	}
+
+	return nil
+}
+
+func ensureHexPrefix(value string) string {
+	if strings.HasPrefix(value, "0x") || strings.HasPrefix(value, "0X") {
+		return value
+	}
+	return "0x" + value
+}
@@ -0,0 +1,162 @@
+// Package webhooksig signs and verifies the webhooks parity-server sends to
+// runners. The server and runners both use it so they agree on how a payload is
+// canonicalized.
+//
+// A signed request carries a header of the form
+//
+//	X-Parity-Signature: t=1700000000,v1=5257a869...,v1=9f86d081...
+//
+// where t is the Unix time the request was signed and each v1 is the hex
+// HMAC-SHA256 of "<t>.<raw body>" under one of the runner's active secrets.
+// During a key rotation the server signs with both the new and the previous
+// secret, so a runner holding either one can verify the request.
+package webhooksig
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// SignatureHeader is the request header that carries the signature.
+	SignatureHeader = "X-Parity-Signature"
+	// DefaultTolerance is how far a signature's timestamp may drift from the
+	// verifier's clock before the request is treated as a replay.
+	DefaultTolerance = 5 * time.Minute
+
+	schemeV1 = "v1"
+)
+
+var (
+	ErrMissingSignature    = errors.New("webhook signature header is missing")
+	ErrInvalidHeader       = errors.New("webhook signature header is malformed")
+	ErrTimestampTooOld     = errors.New("webhook signature timestamp is outside the tolerance window")
+	ErrSignatureMismatch   = errors.New("webhook signature does not match any secret")
+	ErrNoSecretsConfigured = errors.New("no webhook secrets configured")
+)
+
+// Canonicalize returns the bytes that are signed for a body sent at timestamp.
+func Canonicalize(timestamp time.Time, body []byte) []byte {
+	prefix := strconv.FormatInt(timestamp.Unix(), 10) + "."
+	canonical := make([]byte, 0, len(prefix)+len(body))
+	canonical = append(canonical, prefix...)
+	return append(canonical, body...)
+}
+
+// Sign returns the hex HMAC-SHA256 of the canonical payload under secret.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(Canonicalize(timestamp, body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Header builds the signature header value, with one v1 entry per secret.
+func Header(secrets []string, timestamp time.Time, body []byte) string {
+	parts := make([]string, 0, len(secrets)+1)
+	parts = append(parts, "t="+strconv.FormatInt(timestamp.Unix(), 10))
+	for _, secret := range secrets {
+		parts = append(parts, schemeV1+"="+Sign(secret, timestamp, body))
+	}
+	return strings.Join(parts, ",")
+}
+
+// SignRequest sets the signature header on req for body, signed now.
+func SignRequest(req *http.Request, secrets []string, body []byte) {
+	req.Header.Set(SignatureHeader, Header(secrets, time.Now(), body))
+}
+
+// Verify checks header against body. It succeeds when the timestamp is within
+// tolerance of now and any v1 signature matches any of secrets, so runners can
+// keep accepting the old secret while a rotation is in progress.
+func Verify(header string, body []byte, secrets []string, tolerance time.Duration, now time.Time) error {
+	if len(secrets) == 0 {
+		return ErrNoSecretsConfigured
+	}
+	if header == "" {
+		return ErrMissingSignature
+	}
+
+	timestamp, signatures, err := parseHeader(header)
+	if err != nil {
+		return err
+	}
+
+	if tolerance > 0 {
+		drift := now.Sub(timestamp)
+		if drift < 0 {
+			drift = -drift
+		}
+		if drift > tolerance {
+			return ErrTimestampTooOld
+		}
+	}
+
+	for _, secret := range secrets {
+		expected, _ := hex.DecodeString(Sign(secret, timestamp, body))
+		for _, signature := range signatures {
+			if hmac.Equal(expected, signature) {
+				return nil
+			}
+		}
+	}
+
+	return ErrSignatureMismatch
+}
+
+// VerifyRequest verifies an incoming webhook request using DefaultTolerance. It
+// reads the body and replaces it so handlers can still decode it.
+func VerifyRequest(req *http.Request, secrets []string) error {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read webhook body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	return Verify(req.Header.Get(SignatureHeader), body, secrets, DefaultTolerance, time.Now())
+}
+
+func parseHeader(header string) (time.Time, [][]byte, error) {
+	var (
+		timestamp  time.Time
+		haveTime   bool
+		signatures [][]byte
+	)
+
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return time.Time{}, nil, ErrInvalidHeader
+		}
+
+		switch key {
+		case "t":
+			seconds, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return time.Time{}, nil, ErrInvalidHeader
+			}
+			timestamp = time.Unix(seconds, 0)
+			haveTime = true
+		case schemeV1:
+			signature, err := hex.DecodeString(value)
+			if err != nil {
+				return time.Time{}, nil, ErrInvalidHeader
+			}
+			signatures = append(signatures, signature)
+		}
+	}
+
+	if !haveTime || len(signatures) == 0 {
+		return time.Time{}, nil, ErrInvalidHeader
+	}
+
+	return timestamp, signatures, nil
+}
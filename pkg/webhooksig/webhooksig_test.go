@@ -0,0 +1,61 @@
+package webhooksig
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyAcceptsAnyActiveSecret(t *testing.T) {
+	body := []byte(`{"type":"available_tasks"}`)
+	now := time.Unix(1700000000, 0)
+
+	header := Header([]string{"new-secret", "old-secret"}, now, body)
+
+	if err := Verify(header, body, []string{"old-secret"}, DefaultTolerance, now); err != nil {
+		t.Fatalf("expected runner holding the old secret to verify, got %v", err)
+	}
+	if err := Verify(header, body, []string{"new-secret"}, DefaultTolerance, now); err != nil {
+		t.Fatalf("expected runner holding the new secret to verify, got %v", err)
+	}
+	if err := Verify(header, body, []string{"other"}, DefaultTolerance, now); !errors.Is(err, ErrSignatureMismatch) {
+		t.Fatalf("expected ErrSignatureMismatch for an unknown secret, got %v", err)
+	}
+}
+
+func TestVerifyRejectsTamperingAndReplays(t *testing.T) {
+	body := []byte(`{"type":"available_tasks"}`)
+	now := time.Unix(1700000000, 0)
+	header := Header([]string{"secret"}, now, body)
+
+	if err := Verify(header, []byte(`{"type":"fake"}`), []string{"secret"}, DefaultTolerance, now); !errors.Is(err, ErrSignatureMismatch) {
+		t.Fatalf("expected a modified body to fail, got %v", err)
+	}
+	if err := Verify(header, body, []string{"secret"}, DefaultTolerance, now.Add(10*time.Minute)); !errors.Is(err, ErrTimestampTooOld) {
+		t.Fatalf("expected a stale signature to fail, got %v", err)
+	}
+	if err := Verify("", body, []string{"secret"}, DefaultTolerance, now); !errors.Is(err, ErrMissingSignature) {
+		t.Fatalf("expected ErrMissingSignature, got %v", err)
+	}
+	if err := Verify("t=abc,v1=zz", body, []string{"secret"}, DefaultTolerance, now); !errors.Is(err, ErrInvalidHeader) {
+		t.Fatalf("expected ErrInvalidHeader, got %v", err)
+	}
+}
+
+func TestVerifyRequestRestoresBody(t *testing.T) {
+	body := `{"type":"available_tasks"}`
+	req, _ := http.NewRequest(http.MethodPost, "http://runner.invalid/webhook", strings.NewReader(body))
+	SignRequest(req, []string{"secret"}, []byte(body))
+
+	if err := VerifyRequest(req, []string{"secret"}); err != nil {
+		t.Fatalf("VerifyRequest returned error: %v", err)
+	}
+
+	restored, _ := io.ReadAll(req.Body)
+	if string(restored) != body {
+		t.Fatalf("expected body to be readable after verification, got %q", restored)
+	}
+}